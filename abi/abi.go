@@ -8,6 +8,7 @@ import (
 	"github.com/filecoin-project/go-leb128"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -67,6 +68,15 @@ const (
 	MinerPoStStates
 	// FaultSet is the faults generated during PoSt generation
 	FaultSet
+	// VoucherMerges is the lanes merged by a payment voucher
+	VoucherMerges
+	// Addresses is a []address.Address
+	Addresses
+	// Multiaddrs is a []ma.Multiaddr
+	Multiaddrs
+	// Uint64 is a uint64 that is not a sector ID, such as a count or an
+	// identifier. It is encoded like a SectorID.
+	Uint64
 )
 
 func (t Type) String() string {
@@ -117,6 +127,14 @@ func (t Type) String() string {
 		return "*map[string]uint64"
 	case FaultSet:
 		return "types.FaultSet"
+	case VoucherMerges:
+		return "[]types.VoucherMerge"
+	case Addresses:
+		return "[]address.Address"
+	case Multiaddrs:
+		return "[]ma.Multiaddr"
+	case Uint64:
+		return "uint64"
 	default:
 		return "<unknown type>"
 	}
@@ -176,6 +194,14 @@ func (av *Value) String() string {
 		return fmt.Sprint(av.Val.(*map[address.Address]uint8))
 	case FaultSet:
 		return av.Val.(types.FaultSet).String()
+	case VoucherMerges:
+		return fmt.Sprint(av.Val.([]types.VoucherMerge))
+	case Addresses:
+		return fmt.Sprint(av.Val.([]address.Address))
+	case Multiaddrs:
+		return fmt.Sprint(av.Val.([]ma.Multiaddr))
+	case Uint64:
+		return fmt.Sprint(av.Val.(uint64))
 	default:
 		return "<unknown type>"
 	}
@@ -261,7 +287,7 @@ func (av *Value) Serialize() ([]byte, error) {
 		}
 
 		return []byte(pid), nil
-	case SectorID:
+	case SectorID, Uint64:
 		n, ok := av.Val.(uint64)
 		if !ok {
 			return nil, &typeError{0, av.Val}
@@ -345,6 +371,29 @@ func (av *Value) Serialize() ([]byte, error) {
 			return nil, &typeError{types.FaultSet{}, av.Val}
 		}
 		return cbor.DumpObject(fs)
+	case VoucherMerges:
+		merges, ok := av.Val.([]types.VoucherMerge)
+		if !ok {
+			return nil, &typeError{[]types.VoucherMerge{}, av.Val}
+		}
+		return cbor.DumpObject(merges)
+	case Addresses:
+		addrs, ok := av.Val.([]address.Address)
+		if !ok {
+			return nil, &typeError{[]address.Address{}, av.Val}
+		}
+		return cbor.DumpObject(addrs)
+	case Multiaddrs:
+		addrs, ok := av.Val.([]ma.Multiaddr)
+		if !ok {
+			return nil, &typeError{[]ma.Multiaddr{}, av.Val}
+		}
+		// multiaddrs are encoded in their binary form
+		raw := make([][]byte, len(addrs))
+		for i, addr := range addrs {
+			raw[i] = addr.Bytes()
+		}
+		return cbor.DumpObject(raw)
 	default:
 		return nil, fmt.Errorf("unrecognized Type: %d", av.Type)
 	}
@@ -404,6 +453,12 @@ func ToValues(i []interface{}) ([]*Value, error) {
 			out = append(out, &Value{Type: MinerPoStStates, Val: v})
 		case types.FaultSet:
 			out = append(out, &Value{Type: FaultSet, Val: v})
+		case []types.VoucherMerge:
+			out = append(out, &Value{Type: VoucherMerges, Val: v})
+		case []address.Address:
+			out = append(out, &Value{Type: Addresses, Val: v})
+		case []ma.Multiaddr:
+			out = append(out, &Value{Type: Multiaddrs, Val: v})
 		default:
 			return nil, fmt.Errorf("unsupported type: %T", v)
 		}
@@ -491,7 +546,7 @@ func Deserialize(data []byte, t Type) (*Value, error) {
 			Type: t,
 			Val:  id,
 		}, nil
-	case SectorID:
+	case SectorID, Uint64:
 		return &Value{
 			Type: t,
 			Val:  leb128.ToUInt64(data),
@@ -584,6 +639,41 @@ func Deserialize(data []byte, t Type) (*Value, error) {
 			Type: t,
 			Val:  fs,
 		}, nil
+	case VoucherMerges:
+		var merges []types.VoucherMerge
+		if err := cbor.DecodeInto(data, &merges); err != nil {
+			return nil, err
+		}
+		return &Value{
+			Type: t,
+			Val:  merges,
+		}, nil
+	case Addresses:
+		var addrs []address.Address
+		if err := cbor.DecodeInto(data, &addrs); err != nil {
+			return nil, err
+		}
+		return &Value{
+			Type: t,
+			Val:  addrs,
+		}, nil
+	case Multiaddrs:
+		var raw [][]byte
+		if err := cbor.DecodeInto(data, &raw); err != nil {
+			return nil, err
+		}
+		addrs := make([]ma.Multiaddr, len(raw))
+		for i, b := range raw {
+			addr, err := ma.NewMultiaddrBytes(b)
+			if err != nil {
+				return nil, err
+			}
+			addrs[i] = addr
+		}
+		return &Value{
+			Type: t,
+			Val:  addrs,
+		}, nil
 	case Invalid:
 		return nil, ErrInvalidType
 	default:
@@ -614,6 +704,10 @@ var typeTable = map[Type]reflect.Type{
 	IntSet:          reflect.TypeOf(types.IntSet{}),
 	MinerPoStStates: reflect.TypeOf(&map[string]uint64{}),
 	FaultSet:        reflect.TypeOf(types.FaultSet{}),
+	VoucherMerges:   reflect.TypeOf([]types.VoucherMerge{}),
+	Addresses:       reflect.TypeOf([]address.Address{}),
+	Multiaddrs:      reflect.TypeOf([]ma.Multiaddr{}),
+	Uint64:          reflect.TypeOf(uint64(0)),
 }
 
 // TypeMatches returns whether or not 'val' is the go type expected for the given ABI type
@@ -4,7 +4,9 @@ import (
 	"math/big"
 	"testing"
 
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/address"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
@@ -19,6 +21,10 @@ func TestBasicEncodingRoundTrip(t *testing.T) {
 	tf.UnitTest(t)
 
 	addrGetter := address.NewForTestGetter()
+	tcpAddr, err := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/6000")
+	require.NoError(t, err)
+	dnsAddr, err := ma.NewMultiaddr("/dns4/example.com/tcp/443")
+	require.NoError(t, err)
 
 	cases := map[string][]interface{}{
 		"empty":      nil,
@@ -40,6 +46,15 @@ func TestBasicEncodingRoundTrip(t *testing.T) {
 		"miner post states": {
 			&map[string]uint64{address.TestAddress.String(): 1, address.TestAddress2.String(): 2},
 		},
+		"voucher merges": {
+			[]types.VoucherMerge{{Lane: 1, Nonce: 3}, {Lane: 2, Nonce: 0}},
+		},
+		"addresses": {
+			[]address.Address{address.TestAddress, address.TestAddress2},
+		},
+		"multiaddrs": {
+			[]ma.Multiaddr{tcpAddr, dnsAddr},
+		},
 	}
 
 	for tname, tcase := range cases {
@@ -0,0 +1,234 @@
+// Package initactor implements the actor assigning ID addresses to actors.
+package initactor
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-leb128"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-hamt-ipld"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	xerrors "github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+const (
+	// ErrUnknownAddress indicates an address was not assigned an ID address.
+	ErrUnknownAddress = 33
+)
+
+// Errors map error codes to revert errors this actor may return.
+var Errors = map[uint8]error{
+	ErrUnknownAddress: errors.NewCodedRevertErrorf(ErrUnknownAddress, "address was not assigned an ID"),
+}
+
+// FirstActorID is the ID of the first ID address the actor assigns. Lower
+// IDs are reserved for the singleton actors.
+const FirstActorID = 100
+
+func init() {
+	cbor.RegisterCborType(State{})
+}
+
+// Actor is the singleton actor assigning sequential ID addresses to the
+// actors created at other addresses, so they can be referenced compactly.
+// Actors stay stored in the state tree under the address they were created
+// at; ID addresses resolve to it through the actor.
+type Actor struct{}
+
+// State is the init actor's storage.
+type State struct {
+	// NextID is the ID of the next ID address assigned.
+	NextID uint64
+
+	// AddressMap maps the addresses of actors to the bytes of their ID
+	// address.
+	AddressMap cid.Cid `refmt:",omitempty"`
+
+	// IDMap maps ID addresses to the bytes of the address their actor was
+	// created at.
+	IDMap cid.Cid `refmt:",omitempty"`
+}
+
+// NewActor returns a new init actor.
+func NewActor() *actor.Actor {
+	return actor.NewActor(types.InitActorCodeCid, types.ZeroAttoFIL)
+}
+
+// IsAssignable is true of the ID addresses the init actor assigns, as
+// opposed to those reserved for singleton actors.
+func IsAssignable(addr address.Address) bool {
+	return !addr.Empty() && addr.Protocol() == address.ID && leb128.ToUInt64(addr.Payload()) >= FirstActorID
+}
+
+// InitializeState stores the actor's initial state.
+func (a *Actor) InitializeState(storage exec.Storage, _ interface{}) error {
+	stateBytes, err := cbor.DumpObject(&State{NextID: FirstActorID})
+	if err != nil {
+		return err
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*Actor)(nil)
+
+// Exports returns the actor's exports.
+func (a *Actor) Exports() exec.Exports {
+	return initExports
+}
+
+var initExports = exec.Exports{
+	"getIDAddress": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: []abi.Type{abi.Address},
+	},
+	"getRobustAddress": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: []abi.Type{abi.Address},
+	},
+}
+
+// GetIDAddress returns the ID address assigned to the actor created at addr.
+func (a *Actor) GetIDAddress(ctx exec.VMContext, addr address.Address) (address.Address, uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return address.Undef, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	id, err := LookupIDAddress(context.Background(), ctx.Storage(), addr)
+	if err != nil {
+		return address.Undef, errors.CodeError(err), err
+	}
+
+	return id, 0, nil
+}
+
+// GetRobustAddress returns the address the actor assigned an ID address was
+// created at.
+func (a *Actor) GetRobustAddress(ctx exec.VMContext, id address.Address) (address.Address, uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return address.Undef, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	addr, err := LookupRobustAddress(context.Background(), ctx.Storage(), id)
+	if err != nil {
+		return address.Undef, errors.CodeError(err), err
+	}
+
+	return addr, 0, nil
+}
+
+// LookupIDAddress returns the ID address assigned to the actor created at
+// addr, from the storage of the init actor. ID addresses are returned
+// unchanged.
+func LookupIDAddress(ctx context.Context, storage exec.Storage, addr address.Address) (address.Address, error) {
+	if !addr.Empty() && addr.Protocol() == address.ID {
+		return addr, nil
+	}
+
+	state, err := readState(storage)
+	if err != nil {
+		return address.Undef, err
+	}
+	return find(ctx, storage, state.AddressMap, addr)
+}
+
+// LookupRobustAddress returns the address the actor assigned the ID address
+// id was created at, from the storage of the init actor.
+func LookupRobustAddress(ctx context.Context, storage exec.Storage, id address.Address) (address.Address, error) {
+	state, err := readState(storage)
+	if err != nil {
+		return address.Undef, err
+	}
+	return find(ctx, storage, state.IDMap, id)
+}
+
+// RegisterAddress assigns the next ID address to the actor created at addr,
+// and commits the storage of the init actor. Addresses are only assigned an
+// ID address once, so the ID address of registered addresses is returned.
+func RegisterAddress(ctx context.Context, storage exec.Storage, addr address.Address) (address.Address, error) {
+	state, err := readState(storage)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	id, err := find(ctx, storage, state.AddressMap, addr)
+	if err == nil {
+		return id, nil
+	} else if err != Errors[ErrUnknownAddress] {
+		return address.Undef, err
+	}
+
+	id, err = address.NewIDAddress(state.NextID)
+	if err != nil {
+		return address.Undef, err
+	}
+	state.NextID++
+
+	state.AddressMap, err = actor.SetKeyValue(ctx, storage, state.AddressMap, addr.String(), id.Bytes())
+	if err != nil {
+		return address.Undef, xerrors.Wrapf(err, "could not set ID address of %s", addr)
+	}
+	state.IDMap, err = actor.SetKeyValue(ctx, storage, state.IDMap, id.String(), addr.Bytes())
+	if err != nil {
+		return address.Undef, xerrors.Wrapf(err, "could not set address of %s", id)
+	}
+
+	head, err := storage.Put(state)
+	if err != nil {
+		return address.Undef, err
+	}
+	if err := storage.Commit(head, storage.Head()); err != nil {
+		return address.Undef, err
+	}
+
+	return id, nil
+}
+
+// readState reads the state of the init actor from its storage.
+func readState(storage exec.Storage) (*State, error) {
+	memory, err := storage.Get(storage.Head())
+	if err != nil {
+		return nil, errors.FaultErrorWrap(err, "could not read init actor storage")
+	}
+
+	var state State
+	if err := actor.UnmarshalStorage(memory, &state); err != nil {
+		return nil, errors.FaultErrorWrap(err, "could not unmarshal init actor storage")
+	}
+	return &state, nil
+}
+
+// find returns the address stored under the key addr in the lookup rooted
+// at root.
+func find(ctx context.Context, storage exec.Storage, root cid.Cid, addr address.Address) (address.Address, error) {
+	var found address.Address
+	err := actor.WithLookupForReading(ctx, storage, root, func(lookup exec.Lookup) error {
+		value, err := lookup.Find(ctx, addr.String())
+		if err != nil {
+			if err == hamt.ErrNotFound {
+				return Errors[ErrUnknownAddress]
+			}
+			return errors.FaultErrorWrapf(err, "could not find %s", addr)
+		}
+
+		raw, ok := value.([]byte)
+		if !ok {
+			return errors.NewFaultErrorf("expected address bytes for %s, got %T", addr, value)
+		}
+		found, err = address.NewFromBytes(raw)
+		return err
+	})
+	return found, err
+}
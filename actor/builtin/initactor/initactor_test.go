@@ -0,0 +1,108 @@
+package initactor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-leb128"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+func TestInitActorAssignsIDAddresses(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	lookup := func(t *testing.T, st state.Tree, vms vm.StorageMap, method string, addr address.Address) (address.Address, uint8) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, address.InitAddress, 0, 0, method, nil, addr)
+		require.NoError(t, err)
+		if res.Receipt.ExitCode != 0 {
+			return address.Undef, res.Receipt.ExitCode
+		}
+		found, err := address.NewFromBytes(res.Receipt.Return[0])
+		require.NoError(t, err)
+		return found, 0
+	}
+
+	t.Run("genesis actors are assigned ID addresses in both directions", func(t *testing.T) {
+		st, vms := th.RequireCreateStorages(ctx, t)
+
+		id, code := lookup(t, st, vms, "getIDAddress", address.TestAddress2)
+		require.Equal(t, uint8(0), code)
+		assert.True(t, IsAssignable(id))
+
+		robust, code := lookup(t, st, vms, "getRobustAddress", id)
+		require.Equal(t, uint8(0), code)
+		assert.Equal(t, address.TestAddress2, robust)
+
+		// singletons keep their ID address
+		id, code = lookup(t, st, vms, "getIDAddress", address.StorageMarketAddress)
+		require.Equal(t, uint8(0), code)
+		assert.Equal(t, address.StorageMarketAddress, id)
+	})
+
+	t.Run("new actors are assigned sequential ID addresses", func(t *testing.T) {
+		st, vms := th.RequireCreateStorages(ctx, t)
+		addrGetter := address.NewForTestGetter()
+		addr1, addr2 := addrGetter(), addrGetter()
+
+		for _, addr := range []address.Address{addr1, addr2} {
+			res, err := th.CreateAndApplyTestMessage(t, st, vms, addr, 1, 0, "", nil)
+			require.NoError(t, err)
+			require.Equal(t, uint8(0), res.Receipt.ExitCode)
+		}
+
+		id1, code := lookup(t, st, vms, "getIDAddress", addr1)
+		require.Equal(t, uint8(0), code)
+		id2, code := lookup(t, st, vms, "getIDAddress", addr2)
+		require.Equal(t, uint8(0), code)
+
+		next, err := address.NewIDAddress(leb128.ToUInt64(id1.Payload()) + 1)
+		require.NoError(t, err)
+		assert.Equal(t, next, id2)
+
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+		_, code = lookup(t, st, vms, "getIDAddress", minerAddr)
+		assert.Equal(t, uint8(0), code)
+	})
+
+	t.Run("messages are delivered to the actor of an ID address", func(t *testing.T) {
+		st, vms := th.RequireCreateStorages(ctx, t)
+
+		id, code := lookup(t, st, vms, "getIDAddress", address.TestAddress2)
+		require.Equal(t, uint8(0), code)
+		before, err := st.GetActor(ctx, address.TestAddress2)
+		require.NoError(t, err)
+
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, id, 5, 0, "", nil)
+		require.NoError(t, err)
+		require.Equal(t, uint8(0), res.Receipt.ExitCode)
+
+		after, err := st.GetActor(ctx, address.TestAddress2)
+		require.NoError(t, err)
+		assert.Equal(t, before.Balance.Add(types.NewAttoFILFromFIL(5)), after.Balance)
+	})
+
+	t.Run("unassigned ID addresses are unknown", func(t *testing.T) {
+		st, vms := th.RequireCreateStorages(ctx, t)
+
+		unassigned, err := address.NewIDAddress(FirstActorID + 1000)
+		require.NoError(t, err)
+
+		_, code := lookup(t, st, vms, "getRobustAddress", unassigned)
+		assert.Equal(t, uint8(ErrUnknownAddress), code)
+
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, unassigned, 1, 0, "", nil)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrUnknownAddress), res.Receipt.ExitCode)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
 	xerrors "github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/abi"
@@ -22,6 +23,7 @@ import (
 func init() {
 	cbor.RegisterCborType(State{})
 	cbor.RegisterCborType(Ask{})
+	cbor.RegisterCborType(PriorWorker{})
 }
 
 // LargestSectorSizeProvingPeriodBlocks defines the number of blocks in a
@@ -82,6 +84,21 @@ const (
 	// ErrInvalidPieceInclusionProof indicates that the piece inclusion proof was
 	// malformed or did not succesfully verify.
 	ErrInvalidPieceInclusionProof = 46
+	// ErrInvalidConsensusFault indicates that the evidence of a consensus
+	// fault was malformed, did not conflict or was not signed by the worker.
+	ErrInvalidConsensusFault = 47
+	// ErrInvalidSectorExpiration indicates that a sector expiration height is
+	// not after the current block height or the current expiration height.
+	ErrInvalidSectorExpiration = 48
+	// ErrInvalidFaultDeclaration indicates that sectors declared faulty were
+	// already declared faulty, or that sectors declared recovered were not.
+	ErrInvalidFaultDeclaration = 49
+	// ErrTooManyMultiaddrs indicates that a miner advertised more than
+	// MaxMultiaddrs multiaddrs.
+	ErrTooManyMultiaddrs = 50
+	// ErrMultiaddrTooLarge indicates that a miner advertised a multiaddr of
+	// more than MaxMultiaddrSize bytes.
+	ErrMultiaddrTooLarge = 51
 )
 
 // Errors map error codes to revert errors this actor may return.
@@ -97,8 +114,30 @@ var Errors = map[uint8]error{
 	ErrGetProofsModeFailed:        errors.NewCodedRevertErrorf(ErrGetProofsModeFailed, "failed to get proofs mode"),
 	ErrInsufficientCollateral:     errors.NewCodedRevertErrorf(ErrInsufficientCollateral, "insufficient collateral"),
 	ErrInvalidPieceInclusionProof: errors.NewCodedRevertErrorf(ErrInvalidPieceInclusionProof, "piece inclusion proof did not validate"),
+	ErrInvalidConsensusFault:      errors.NewCodedRevertErrorf(ErrInvalidConsensusFault, "invalid consensus fault evidence"),
+	ErrInvalidSectorExpiration:    errors.NewCodedRevertErrorf(ErrInvalidSectorExpiration, "invalid sector expiration"),
+	ErrInvalidFaultDeclaration:    errors.NewCodedRevertErrorf(ErrInvalidFaultDeclaration, "invalid fault declaration"),
+	ErrTooManyMultiaddrs:          errors.NewCodedRevertErrorf(ErrTooManyMultiaddrs, "too many multiaddrs"),
+	ErrMultiaddrTooLarge:          errors.NewCodedRevertErrorf(ErrMultiaddrTooLarge, "multiaddr too large"),
 }
 
+// ConsensusFaultReporterRewardDivisor divides the collateral slashed for a
+// consensus fault into the reward of the reporter of the fault. The rest is
+// burnt.
+const ConsensusFaultReporterRewardDivisor = 10
+
+// DeclaredFaultPenaltyDivisor divides the collateral of a sector into the
+// penalty for declaring the sector faulty ahead of a PoSt.
+const DeclaredFaultPenaltyDivisor = 10
+
+// MaxMultiaddrs is the largest number of multiaddrs a miner may advertise on
+// chain.
+const MaxMultiaddrs = 16
+
+// MaxMultiaddrSize is the largest size in bytes of a multiaddr a miner may
+// advertise on chain.
+const MaxMultiaddrSize = 256
+
 const (
 	PoStStateNoStorage = iota
 	PoStStateWithinProvingPeriod
@@ -134,15 +173,29 @@ type State struct {
 	// worker address for the miner.
 	Owner address.Address
 
+	// PendingOwner is the new owner the owner proposed, which becomes the
+	// owner once it accepts. It is empty if no owner change is pending.
+	PendingOwner address.Address
+
 	// Worker is the address of the worker account for this miner.
 	// This will be the key that is used to sign blocks created by this miner, and
 	// sign messages sent on behalf of this miner to commit sectors, submit PoSts, and
 	// other day to day miner activities.
 	Worker address.Address
 
+	// PriorWorkers are the workers this miner replaced, in the order they
+	// were replaced. Blocks are signed by the worker in their parent state,
+	// so the evidence of a consensus fault is checked against the worker of
+	// the heights of its blocks.
+	PriorWorkers []PriorWorker
+
 	// PeerID references the libp2p identity that the miner is operating.
 	PeerID peer.ID
 
+	// Multiaddrs are the binary multiaddrs the miner can be dialed at, for
+	// clients that cannot find the miner's peer through the DHT.
+	Multiaddrs [][]byte
+
 	// ActiveCollateral is the amount of collateral currently committed to live
 	// storage.
 	ActiveCollateral types.AttoFIL
@@ -160,6 +213,12 @@ type State struct {
 	// See also: https://github.com/polydawn/refmt/issues/35
 	SectorCommitments SectorSet
 
+	// SectorExpirations maps sector id to the block height at which the
+	// sector expires, for the sectors in SectorCommitments. Expired sectors
+	// are removed by the next submitPoSt. Sectors committed before sectors
+	// carried an expiration never expire.
+	SectorExpirations SectorExpirations
+
 	// NextDoneSet is a set of sector ids reported during the last PoSt
 	// submission as being 'done'.  The collateral for them is still being
 	// held until the next PoSt submission in case early sector removal
@@ -170,6 +229,13 @@ type State struct {
 	// currently required to prove.
 	ProvingSet types.IntSet
 
+	// DeclaredFaults is the set of sector ids of committed sectors the
+	// miner declared faulty. They are left out of the ProvingSet until the
+	// miner declares them recovered, and pay the declared fault penalty for
+	// every proving period they are not proven. Declarations the collateral
+	// no longer covers expire, and their sectors are dropped.
+	DeclaredFaults types.IntSet
+
 	LastUsedSectorID uint64
 
 	// ProvingPeriodEnd is the block height at the end of the current proving period.
@@ -194,6 +260,17 @@ type State struct {
 	// OwedStorageCollateral is the collateral for sectors that have been slashed.
 	// This collateral can be collected from arbitrated deals, but not de-pledged.
 	OwedStorageCollateral types.AttoFIL
+
+	// ConsensusFaultAt is the height at which this miner was slashed for a
+	// consensus fault.
+	ConsensusFaultAt *types.BlockHeight
+}
+
+// PriorWorker is a worker a miner replaced, with the height of the block that
+// replaced it. It signed the blocks of the miner up to that height.
+type PriorWorker struct {
+	Worker     address.Address
+	ReplacedAt *types.BlockHeight
 }
 
 // NewActor returns a new miner actor with the provided balance.
@@ -208,8 +285,10 @@ func NewState(owner, worker address.Address, pid peer.ID, sectorSize *types.Byte
 		Worker:            worker,
 		PeerID:            pid,
 		SectorCommitments: NewSectorSet(),
+		SectorExpirations: NewSectorExpirations(),
 		NextDoneSet:       types.EmptyIntSet(),
 		ProvingSet:        types.EmptyIntSet(),
+		DeclaredFaults:    types.EmptyIntSet(),
 		Power:             types.NewBytesAmount(0),
 		NextAskID:         big.NewInt(0),
 		SectorSize:        sectorSize,
@@ -246,12 +325,28 @@ var minerExports = exec.Exports{
 		Params: []abi.Type{abi.AttoFIL, abi.Integer},
 		Return: []abi.Type{abi.Integer},
 	},
+	"cancelAsk": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Integer},
+		Return: []abi.Type{},
+	},
+	"removeExpiredAsks": &exec.FunctionSignature{
+		Params: nil,
+		Return: []abi.Type{},
+	},
 	"getOwner": &exec.FunctionSignature{
 		Params: nil,
 		Return: []abi.Type{abi.Address},
 	},
 	"commitSector": &exec.FunctionSignature{
-		Params: []abi.Type{abi.SectorID, abi.Bytes, abi.Bytes, abi.Bytes, abi.PoRepProof},
+		Params: []abi.Type{abi.SectorID, abi.Bytes, abi.Bytes, abi.Bytes, abi.PoRepProof, abi.BlockHeight},
+		Return: []abi.Type{},
+	},
+	"extendSector": &exec.FunctionSignature{
+		Params: []abi.Type{abi.SectorID, abi.BlockHeight},
+		Return: []abi.Type{},
+	},
+	"terminateSectors": &exec.FunctionSignature{
+		Params: []abi.Type{abi.IntSet},
 		Return: []abi.Type{},
 	},
 	"getWorker": &exec.FunctionSignature{
@@ -266,6 +361,14 @@ var minerExports = exec.Exports{
 		Params: []abi.Type{abi.PeerID},
 		Return: []abi.Type{},
 	},
+	"getMultiaddrs": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.Multiaddrs},
+	},
+	"updateMultiaddrs": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Multiaddrs},
+		Return: []abi.Type{},
+	},
 	"getPower": &exec.FunctionSignature{
 		Params: []abi.Type{},
 		Return: []abi.Type{abi.BytesAmount},
@@ -278,10 +381,34 @@ var minerExports = exec.Exports{
 		Params: []abi.Type{},
 		Return: []abi.Type{},
 	},
+	"reportConsensusFault": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Bytes, abi.Bytes},
+		Return: []abi.Type{},
+	},
 	"changeWorker": &exec.FunctionSignature{
 		Params: []abi.Type{abi.Address},
 		Return: []abi.Type{},
 	},
+	"changeOwner": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address},
+		Return: []abi.Type{},
+	},
+	"declareFaults": &exec.FunctionSignature{
+		Params: []abi.Type{abi.IntSet},
+		Return: []abi.Type{},
+	},
+	"declareRecovery": &exec.FunctionSignature{
+		Params: []abi.Type{abi.IntSet},
+		Return: []abi.Type{},
+	},
+	"addCollateral": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{},
+	},
+	"withdrawCollateral": &exec.FunctionSignature{
+		Params: []abi.Type{abi.AttoFIL},
+		Return: []abi.Type{},
+	},
 	// verifyPieceInclusion is not in spec, but should be.
 	"verifyPieceInclusion": &exec.FunctionSignature{
 		Params: []abi.Type{abi.Bytes, abi.BytesAmount, abi.SectorID, abi.Bytes},
@@ -333,6 +460,22 @@ var minerExports = exec.Exports{
 		Params: []abi.Type{},
 		Return: []abi.Type{abi.AttoFIL},
 	},
+	"getAvailableCollateral": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.AttoFIL},
+	},
+	"getSectorExpiration": &exec.FunctionSignature{
+		Params: []abi.Type{abi.SectorID},
+		Return: []abi.Type{abi.BlockHeight},
+	},
+	"getDeclaredFaults": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.IntSet},
+	},
+	"getPendingOwner": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.Address},
+	},
 }
 
 // Exports returns the miner actors exported functions.
@@ -360,14 +503,7 @@ func (ma *Actor) AddAsk(ctx exec.VMContext, price types.AttoFIL, expiry *big.Int
 		id := big.NewInt(0).Set(state.NextAskID)
 		state.NextAskID = state.NextAskID.Add(state.NextAskID, big.NewInt(1))
 
-		// filter out expired asks
-		asks := state.Asks
-		state.Asks = state.Asks[:0]
-		for _, a := range asks {
-			if ctx.BlockHeight().LessThan(a.Expiry) {
-				state.Asks = append(state.Asks, a)
-			}
-		}
+		removeExpiredAsks(&state, ctx.BlockHeight())
 
 		if !expiry.IsUint64() {
 			return nil, errors.NewRevertError("expiry was invalid")
@@ -394,6 +530,61 @@ func (ma *Actor) AddAsk(ctx exec.VMContext, price types.AttoFIL, expiry *big.Int
 	return askID, 0, nil
 }
 
+// CancelAsk removes an ask before it expires. Expired asks are removed as well.
+func (ma *Actor) CancelAsk(ctx exec.VMContext, askid *big.Int) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Worker {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		found := false
+		asks := state.Asks
+		state.Asks = state.Asks[:0]
+		for _, a := range asks {
+			if a.ID.Cmp(askid) == 0 {
+				found = true
+				continue
+			}
+			state.Asks = append(state.Asks, a)
+		}
+		if !found {
+			return nil, Errors[ErrAskNotFound]
+		}
+
+		removeExpiredAsks(&state, ctx.BlockHeight())
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// RemoveExpiredAsks removes all asks that have expired from the miner's state.
+// Anyone may call it.
+func (ma *Actor) RemoveExpiredAsks(ctx exec.VMContext) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		removeExpiredAsks(&state, ctx.BlockHeight())
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
 // GetAsks returns all the asks for this miner. (TODO: this isnt a great function signature, it returns the asks in a
 // serialized array. Consider doing this some other way)
 func (ma *Actor) GetAsks(ctx exec.VMContext) ([]uint64, uint8, error) {
@@ -584,8 +775,9 @@ func (ma *Actor) GetSectorSize(ctx exec.VMContext) (*types.BytesAmount, uint8, e
 }
 
 // CommitSector adds a commitment to the specified sector. The sector must not
-// already be committed.
-func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR, commRStar []byte, proof types.PoRepProof) (uint8, error) {
+// already be committed. The sector expires at the given height, which is
+// typically the end of the longest deal with a piece in it.
+func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR, commRStar []byte, proof types.PoRepProof, expiration *types.BlockHeight) (uint8, error) {
 	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
@@ -598,6 +790,9 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 	if len(commRStar) != int(types.CommitmentBytesLen) {
 		return 1, errors.NewRevertError("invalid sized commRStar")
 	}
+	if expiration.LessEqual(ctx.BlockHeight()) {
+		return ErrInvalidSectorExpiration, Errors[ErrInvalidSectorExpiration]
+	}
 
 	var state State
 	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
@@ -606,6 +801,10 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 		//
 		// This switching will be removed when issue #2270 is completed.
 		if !ma.Bootstrap {
+			if err := ctx.Charge(ctx.GasSchedule().VerifySeal); err != nil {
+				return nil, errors.RevertErrorWrap(err, "Insufficient gas")
+			}
+
 			req := verification.VerifySealRequest{}
 			copy(req.CommD[:], commD)
 			copy(req.CommR[:], commR)
@@ -664,6 +863,220 @@ func (ma *Actor) CommitSector(ctx exec.VMContext, sectorID uint64, commD, commR,
 
 		state.LastUsedSectorID = sectorID
 		state.SectorCommitments.Add(sectorID, comms)
+		if state.SectorExpirations == nil {
+			state.SectorExpirations = NewSectorExpirations()
+		}
+		state.SectorExpirations.Set(sectorID, expiration)
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// ExtendSector moves the expiration of a committed sector to a later height,
+// typically when the sector takes on a longer deal.
+func (ma *Actor) ExtendSector(ctx exec.VMContext, sectorID uint64, expiration *types.BlockHeight) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Worker {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		if !state.SectorCommitments.Has(sectorID) {
+			return nil, Errors[ErrInvalidSector]
+		}
+
+		if expiration.LessEqual(ctx.BlockHeight()) {
+			return nil, Errors[ErrInvalidSectorExpiration]
+		}
+		if state.SectorExpirations == nil {
+			state.SectorExpirations = NewSectorExpirations()
+		}
+		if current, ok := state.SectorExpirations.Get(sectorID); ok && expiration.LessEqual(current) {
+			return nil, Errors[ErrInvalidSectorExpiration]
+		}
+
+		state.SectorExpirations.Set(sectorID, expiration)
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// TerminateSectors removes committed sectors before they expire. The miner
+// forfeits the collateral of the sectors, which is burnt, and loses the power
+// of the sectors it is proving.
+func (ma *Actor) TerminateSectors(ctx exec.VMContext, sectorIDs types.IntSet) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Worker {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		for _, id := range sectorIDs.Values() {
+			if !state.SectorCommitments.Has(id) {
+				return nil, Errors[ErrInvalidSector]
+			}
+		}
+
+		// Remove the power of the terminated sectors that are being proven.
+		proven := state.ProvingSet.Intersection(sectorIDs)
+		powerLoss := types.NewBytesAmount(uint64(proven.Size())).Mul(state.SectorSize)
+		if powerLoss.GreaterThan(state.Power) {
+			powerLoss = state.Power
+		}
+		if !powerLoss.IsZero() {
+			_, ret, err := ctx.Send(address.StorageMarketAddress, "updateStorage", types.ZeroAttoFIL, []interface{}{types.ZeroBytes.Sub(powerLoss)})
+			if err != nil {
+				return nil, err
+			}
+			if ret != 0 {
+				return nil, Errors[ErrStoragemarketCallFailed]
+			}
+			state.Power = state.Power.Sub(powerLoss)
+		}
+
+		// The collateral of the sectors is no longer active, and is burnt as
+		// the penalty for terminating them.
+		penalty := EarlyTerminationPenalty(state.SectorSize).MulBigInt(big.NewInt(int64(sectorIDs.Size())))
+		if penalty.GreaterThan(ctx.MyBalance()) {
+			return nil, Errors[ErrInsufficientCollateral]
+		}
+		if err := ma.burnFunds(ctx, penalty); err != nil {
+			return nil, errors.RevertErrorWrapf(err, "failed to burn penalty %s", penalty)
+		}
+		released := CollateralForSector(state.SectorSize).MulBigInt(big.NewInt(int64(sectorIDs.Size())))
+		if released.GreaterThan(state.ActiveCollateral) {
+			released = state.ActiveCollateral
+		}
+		state.ActiveCollateral = state.ActiveCollateral.Sub(released)
+
+		if err := state.SectorCommitments.Drop(sectorIDs.Values()); err != nil {
+			return nil, err
+		}
+		state.SectorExpirations.Drop(sectorIDs.Values())
+		state.ProvingSet = state.ProvingSet.Difference(sectorIDs)
+		state.DeclaredFaults = declaredFaults(state).Difference(sectorIDs)
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// DeclareFaults declares committed sectors faulty ahead of a PoSt, for a
+// penalty lower than reporting them as faults of the PoSt. The sectors leave
+// the ProvingSet, and the miner loses their power, until it declares them
+// recovered. The penalty is charged again by every PoSt after which the
+// sectors are still declared faulty.
+func (ma *Actor) DeclareFaults(ctx exec.VMContext, sectorIDs types.IntSet) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Worker {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		if sectorIDs.Size() == 0 {
+			return nil, Errors[ErrInvalidFaultDeclaration]
+		}
+		declared := declaredFaults(state)
+		for _, id := range sectorIDs.Values() {
+			if !state.SectorCommitments.Has(id) {
+				return nil, Errors[ErrInvalidSector]
+			}
+			if declared.Has(id) {
+				return nil, Errors[ErrInvalidFaultDeclaration]
+			}
+		}
+
+		// The penalty is paid from the collateral in excess of the
+		// requirement, which the miner may top up.
+		penalty := DeclaredFaultPenalty(state.SectorSize).MulBigInt(big.NewInt(int64(sectorIDs.Size())))
+		if penalty.GreaterThan(ma.getAvailableCollateral(ctx, state)) {
+			return nil, Errors[ErrInsufficientCollateral]
+		}
+		if err := ma.burnFunds(ctx, penalty); err != nil {
+			return nil, errors.RevertErrorWrapf(err, "failed to burn penalty %s", penalty)
+		}
+
+		// Remove the power of the faulty sectors that are being proven.
+		proven := state.ProvingSet.Intersection(sectorIDs)
+		powerLoss := types.NewBytesAmount(uint64(proven.Size())).Mul(state.SectorSize)
+		if powerLoss.GreaterThan(state.Power) {
+			powerLoss = state.Power
+		}
+		if !powerLoss.IsZero() {
+			_, ret, err := ctx.Send(address.StorageMarketAddress, "updateStorage", types.ZeroAttoFIL, []interface{}{types.ZeroBytes.Sub(powerLoss)})
+			if err != nil {
+				return nil, err
+			}
+			if ret != 0 {
+				return nil, Errors[ErrStoragemarketCallFailed]
+			}
+			state.Power = state.Power.Sub(powerLoss)
+		}
+
+		state.DeclaredFaults = declared.Union(sectorIDs)
+		state.ProvingSet = state.ProvingSet.Difference(sectorIDs)
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// DeclareRecovery declares sectors that were declared faulty recovered. The
+// sectors rejoin the ProvingSet at the next PoSt, and regain their power once
+// a PoSt proves them.
+func (ma *Actor) DeclareRecovery(ctx exec.VMContext, sectorIDs types.IntSet) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Worker {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		declared := declaredFaults(state)
+		if sectorIDs.Size() == 0 || !declared.HasSubset(sectorIDs) {
+			return nil, Errors[ErrInvalidFaultDeclaration]
+		}
+		state.DeclaredFaults = declared.Difference(sectorIDs)
+
+		// If the miner is not currently proving any sectors, no PoSt would
+		// bring the recovered sectors back, so start proving them
+		// immediately, as when committing a sector.
+		if state.ProvingSet.Size() == 0 {
+			state.ProvingSet = types.NewIntSet(sectorIDs.Values()...)
+			state.ProvingPeriodEnd = ctx.BlockHeight().Add(types.NewBlockHeight(ProvingPeriodDuration(state.SectorSize)))
+		}
+
 		return nil, nil
 	})
 	if err != nil {
@@ -723,7 +1136,8 @@ func (ma *Actor) VerifyPieceInclusion(ctx exec.VMContext, commP []byte, pieceSiz
 	return errors.CodeError(err), err
 }
 
-// ChangeWorker alters the worker address in state
+// ChangeWorker alters the worker address in state, recording the replaced
+// worker as a prior worker.
 func (ma *Actor) ChangeWorker(ctx exec.VMContext, worker address.Address) (uint8, error) {
 	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
@@ -735,6 +1149,10 @@ func (ma *Actor) ChangeWorker(ctx exec.VMContext, worker address.Address) (uint8
 			return nil, Errors[ErrCallerUnauthorized]
 		}
 
+		state.PriorWorkers = append(state.PriorWorkers, PriorWorker{
+			Worker:     state.Worker,
+			ReplacedAt: ctx.BlockHeight(),
+		})
 		state.Worker = worker
 
 		return nil, nil
@@ -746,6 +1164,55 @@ func (ma *Actor) ChangeWorker(ctx exec.VMContext, worker address.Address) (uint8
 	return 0, nil
 }
 
+// ChangeOwner transfers the miner to a new owner. The transfer needs the
+// approval of both owners: the owner first proposes the new owner, which then
+// accepts by calling ChangeOwner with its own address. The owner may replace
+// or, by proposing itself, cancel a pending proposal.
+func (ma *Actor) ChangeOwner(ctx exec.VMContext, owner address.Address) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		from := ctx.Message().From
+		switch {
+		case from == state.Owner && owner == state.Owner:
+			state.PendingOwner = address.Undef
+		case from == state.Owner:
+			state.PendingOwner = owner
+		case !state.PendingOwner.Empty() && from == state.PendingOwner && owner == state.PendingOwner:
+			state.Owner = owner
+			state.PendingOwner = address.Undef
+		default:
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// GetPendingOwner returns the new owner proposed for this miner, or an empty
+// address if no owner change is pending.
+func (ma *Actor) GetPendingOwner(ctx exec.VMContext) (address.Address, uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return address.Undef, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	err := actor.ReadState(ctx, &state)
+	if err != nil {
+		return address.Undef, errors.CodeError(err), err
+	}
+
+	return state.PendingOwner, 0, nil
+}
+
 // GetWorker returns the worker address for this miner.
 func (ma *Actor) GetWorker(ctx exec.VMContext) (address.Address, uint8, error) {
 	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
@@ -808,6 +1275,66 @@ func (ma *Actor) UpdatePeerID(ctx exec.VMContext, pid peer.ID) (uint8, error) {
 	return 0, nil
 }
 
+// GetMultiaddrs returns the multiaddrs this miner advertises.
+func (ma *Actor) GetMultiaddrs(ctx exec.VMContext) ([]multiaddr.Multiaddr, uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	err := actor.ReadState(ctx, &state)
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	addrs := make([]multiaddr.Multiaddr, len(state.Multiaddrs))
+	for i, raw := range state.Multiaddrs {
+		addr, err := multiaddr.NewMultiaddrBytes(raw)
+		if err != nil {
+			return nil, 1, errors.NewFaultErrorf("invalid multiaddr in miner state: %s", err)
+		}
+		addrs[i] = addr
+	}
+
+	return addrs, 0, nil
+}
+
+// UpdateMultiaddrs replaces the multiaddrs this miner advertises. A miner
+// advertises at most MaxMultiaddrs multiaddrs of at most MaxMultiaddrSize
+// bytes each.
+func (ma *Actor) UpdateMultiaddrs(ctx exec.VMContext, addrs []multiaddr.Multiaddr) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if ctx.Message().From != state.Worker {
+			return nil, Errors[ErrCallerUnauthorized]
+		}
+
+		if len(addrs) > MaxMultiaddrs {
+			return nil, Errors[ErrTooManyMultiaddrs]
+		}
+
+		multiaddrs := make([][]byte, len(addrs))
+		for i, addr := range addrs {
+			multiaddrs[i] = addr.Bytes()
+			if len(multiaddrs[i]) > MaxMultiaddrSize {
+				return nil, Errors[ErrMultiaddrTooLarge]
+			}
+		}
+		state.Multiaddrs = multiaddrs
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
 // GetPower returns the amount of proven sectors for this miner.
 func (ma *Actor) GetPower(ctx exec.VMContext) (*types.BytesAmount, uint8, error) {
 	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
@@ -852,6 +1379,110 @@ func (ma *Actor) GetActiveCollateral(ctx exec.VMContext) (types.AttoFIL, uint8,
 	return collateral, 0, nil
 }
 
+// GetAvailableCollateral returns the balance of a miner in excess of the
+// collateral it is required to hold, which its owner may withdraw.
+func (ma *Actor) GetAvailableCollateral(ctx exec.VMContext) (types.AttoFIL, uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return types.ZeroAttoFIL, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	err := actor.ReadState(ctx, &state)
+	if err != nil {
+		return types.ZeroAttoFIL, errors.CodeError(err), err
+	}
+
+	return ma.getAvailableCollateral(ctx, state), 0, nil
+}
+
+// AddCollateral adds the value of the message to the collateral of the
+// miner. Only the owner or the worker may add collateral.
+func (ma *Actor) AddCollateral(ctx exec.VMContext) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	err := actor.ReadState(ctx, &state)
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	// the value of the message is already part of the balance of the miner,
+	// and is returned to the sender if the caller is rejected
+	from := ctx.Message().From
+	if from != state.Owner && from != state.Worker {
+		return ErrCallerUnauthorized, Errors[ErrCallerUnauthorized]
+	}
+
+	return 0, nil
+}
+
+// WithdrawCollateral sends amount of the available collateral of the miner
+// to its owner. Only the owner may withdraw collateral.
+func (ma *Actor) WithdrawCollateral(ctx exec.VMContext, amount types.AttoFIL) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	err := actor.ReadState(ctx, &state)
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	if ctx.Message().From != state.Owner {
+		return ErrCallerUnauthorized, Errors[ErrCallerUnauthorized]
+	}
+
+	if amount.IsNegative() || amount.GreaterThan(ma.getAvailableCollateral(ctx, state)) {
+		return ErrInsufficientCollateral, Errors[ErrInsufficientCollateral]
+	}
+
+	_, _, err = ctx.Send(state.Owner, "", amount, nil)
+	if err != nil {
+		return errors.CodeError(err), errors.RevertErrorWrapf(err, "failed to send %s to the owner", amount)
+	}
+
+	return 0, nil
+}
+
+// GetSectorExpiration returns the block height at which a committed sector
+// expires.
+func (ma *Actor) GetSectorExpiration(ctx exec.VMContext, sectorID uint64) (*types.BlockHeight, uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	err := actor.ReadState(ctx, &state)
+	if err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	expiration, ok := state.SectorExpirations.Get(sectorID)
+	if !ok || !state.SectorCommitments.Has(sectorID) {
+		return nil, ErrInvalidSector, Errors[ErrInvalidSector]
+	}
+	return expiration, 0, nil
+}
+
+// GetDeclaredFaults returns the sectors the miner declared faulty and has
+// not declared recovered.
+func (ma *Actor) GetDeclaredFaults(ctx exec.VMContext) (types.IntSet, uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost); err != nil {
+		return types.EmptyIntSet(), exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	err := actor.ReadState(ctx, &state)
+	if err != nil {
+		return types.EmptyIntSet(), errors.CodeError(err), err
+	}
+
+	return declaredFaults(state), 0, nil
+}
+
 // SubmitPoSt is used to submit a coalesced PoST to the chain to convince the chain
 // that you have been actually storing the files you claim to be.
 func (ma *Actor) SubmitPoSt(ctx exec.VMContext, poStProofs []types.PoStProof, faults types.FaultSet, done types.IntSet) (uint8, error) {
@@ -910,6 +1541,10 @@ func (ma *Actor) SubmitPoSt(ctx exec.VMContext, poStProofs []types.PoStProof, fa
 		//
 		// This switching will be removed when issue #2270 is completed.
 		if !ma.Bootstrap {
+			if err := ctx.Charge(ctx.GasSchedule().VerifyPoSt); err != nil {
+				return nil, errors.RevertErrorWrap(err, "Insufficient gas")
+			}
+
 			seed, err := getPoStChallengeSeed(ctx, state)
 			if err != nil {
 				return nil, errors.RevertErrorWrap(err, "failed to sample chain for challenge seed")
@@ -973,11 +1608,49 @@ func (ma *Actor) SubmitPoSt(ctx exec.VMContext, poStProofs []types.PoStProof, fa
 			return nil, err
 		}
 
+		state.SectorExpirations.Drop(done.Values())
+		state.SectorExpirations.Drop(faults.SectorIds.Values())
+
+		// Drop the sectors that have expired, which were proven for the
+		// last time by this PoSt, and release their collateral.
+		expired, err := state.SectorExpirations.Expired(chainHeight)
+		if err != nil {
+			return nil, err
+		}
+		if err = state.SectorCommitments.Drop(expired); err != nil {
+			return nil, err
+		}
+		state.SectorExpirations.Drop(expired)
+
+		released := CollateralForSector(state.SectorSize).MulBigInt(big.NewInt(int64(len(expired))))
+		if released.GreaterThan(state.ActiveCollateral) {
+			released = state.ActiveCollateral
+		}
+		state.ActiveCollateral = state.ActiveCollateral.Sub(released)
+
+		// Sectors that stay declared faulty are not proven in the next
+		// proving period either, and pay the declared fault penalty again.
+		// The declarations the available collateral does not cover expire,
+		// and their sectors are dropped as the faults of the PoSt are.
+		dropped := done.Union(faults.SectorIds).Union(types.NewIntSet(expired...))
+		unpaid, err := ma.chargeDeclaredFaults(ctx, state, declaredFaults(state).Difference(dropped))
+		if err != nil {
+			return nil, err
+		}
+		if err = state.SectorCommitments.Drop(unpaid.Values()); err != nil {
+			return nil, err
+		}
+		state.SectorExpirations.Drop(unpaid.Values())
+		dropped = dropped.Union(unpaid)
+
 		sectorIDsToProve, err := state.SectorCommitments.IDs()
 		if err != nil {
 			return nil, err
 		}
-		state.ProvingSet = types.NewIntSet(sectorIDsToProve...)
+
+		// Sectors declared faulty are not proven until they recover.
+		state.DeclaredFaults = declaredFaults(state).Difference(dropped)
+		state.ProvingSet = types.NewIntSet(sectorIDsToProve...).Difference(state.DeclaredFaults)
 		state.NextDoneSet = done
 
 		return nil, nil
@@ -1036,6 +1709,7 @@ func (ma *Actor) SlashStorageFault(ctx exec.VMContext) (uint8, error) {
 
 		// remove proving set from our sectors
 		state.SectorCommitments.Drop(state.SlashedSet.Values())
+		state.SectorExpirations.Drop(state.SlashedSet.Values())
 
 		// clear proving set
 		state.ProvingSet = types.NewIntSet()
@@ -1053,6 +1727,88 @@ func (ma *Actor) SlashStorageFault(ctx exec.VMContext) (uint8, error) {
 	return 0, nil
 }
 
+// ReportConsensusFault is called by an independent actor with evidence that
+// this miner signed two conflicting blocks, as serialized block headers. It
+// strips the miner of its power and sectors, and slashes its collateral,
+// rewarding the reporter with a share of it and burning the rest.
+func (ma *Actor) ReportConsensusFault(ctx exec.VMContext, block1, block2 []byte) (uint8, error) {
+	if err := ctx.Charge(actor.DefaultGasCost + 2*ctx.GasSchedule().VerifySignature); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	minerAddr := ctx.Message().To
+	reporter := ctx.Message().From
+	var state State
+	_, err := actor.WithState(ctx, &state, func() (interface{}, error) {
+		if state.ConsensusFaultAt != nil {
+			return nil, errors.NewCodedRevertError(ErrMinerAlreadySlashed, "miner already slashed for a consensus fault")
+		}
+
+		blk1, err := types.DecodeBlock(block1)
+		if err != nil {
+			return nil, errors.NewCodedRevertErrorf(ErrInvalidConsensusFault, "malformed block: %s", err)
+		}
+		blk2, err := types.DecodeBlock(block2)
+		if err != nil {
+			return nil, errors.NewCodedRevertErrorf(ErrInvalidConsensusFault, "malformed block: %s", err)
+		}
+		if blk1.Miner != minerAddr || !blk1.ConflictsWith(blk2) {
+			return nil, errors.NewCodedRevertError(ErrInvalidConsensusFault, "blocks are not conflicting blocks of the miner")
+		}
+		if !blk1.VerifySignature(ma.workerAt(state, blk1.Height)) || !blk2.VerifySignature(ma.workerAt(state, blk2.Height)) {
+			return nil, errors.NewCodedRevertError(ErrInvalidConsensusFault, "blocks are not signed by the worker")
+		}
+
+		// Strip the miner of their power.
+		if !state.Power.IsZero() {
+			powerDelta := types.ZeroBytes.Sub(state.Power) // negate bytes amount
+			_, ret, err := ctx.Send(address.StorageMarketAddress, "updateStorage", types.ZeroAttoFIL, []interface{}{powerDelta})
+			if err != nil {
+				return nil, err
+			}
+			if ret != 0 {
+				return nil, Errors[ErrStoragemarketCallFailed]
+			}
+		}
+		state.Power = types.NewBytesAmount(0)
+
+		// Remove all sectors, so that the miner cannot prove them again.
+		sectorIDs, err := state.SectorCommitments.IDs()
+		if err != nil {
+			return nil, err
+		}
+		state.SlashedSet = state.SlashedSet.Union(types.NewIntSet(sectorIDs...))
+		if err := state.SectorCommitments.Drop(sectorIDs); err != nil {
+			return nil, err
+		}
+		state.SectorExpirations.Drop(sectorIDs)
+		state.ProvingSet = types.NewIntSet()
+		state.DeclaredFaults = types.NewIntSet()
+
+		// Slash all collateral, which excludes the value of this message.
+		collateral := ctx.MyBalance().Sub(ctx.Message().Value)
+		reward := types.NewAttoFIL(big.NewInt(0).Div(collateral.AsBigInt(), big.NewInt(ConsensusFaultReporterRewardDivisor)))
+		if reward.IsPositive() {
+			if _, _, err := ctx.Send(reporter, "", reward, []interface{}{}); err != nil {
+				return nil, errors.RevertErrorWrapf(err, "failed to reward reporter %s", reporter)
+			}
+		}
+		if err := ma.burnFunds(ctx, collateral.Sub(reward)); err != nil {
+			return nil, errors.RevertErrorWrap(err, "failed to burn slashed collateral")
+		}
+		state.ActiveCollateral = types.ZeroAttoFIL
+
+		state.ConsensusFaultAt = ctx.BlockHeight()
+
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
 // GetProvingPeriod returns the proving period start and proving period end
 func (ma *Actor) GetProvingPeriod(ctx exec.VMContext) (*types.BlockHeight, *types.BlockHeight, uint8, error) {
 	var state State
@@ -1085,6 +1841,18 @@ func (ma *Actor) CalculateLateFee(ctx exec.VMContext, height *types.BlockHeight)
 // expectation of this being important for future protocol upgrade mechanisms.
 //
 
+// workerAt returns the worker that signed the blocks of the miner at a height:
+// the worker in the state of the parent of blocks at that height.
+func (ma *Actor) workerAt(state State, height types.Uint64) address.Address {
+	h := types.NewBlockHeight(uint64(height))
+	for _, prior := range state.PriorWorkers {
+		if h.LessEqual(prior.ReplacedAt) {
+			return prior.Worker
+		}
+	}
+	return state.Worker
+}
+
 func (ma *Actor) burnFunds(ctx exec.VMContext, amount types.AttoFIL) error {
 	_, _, err := ctx.Send(address.BurntFundsAddress, "", amount, []interface{}{})
 	return err
@@ -1096,6 +1864,17 @@ func (ma *Actor) getPledgeCollateralRequirement(state State, height *types.Block
 	return state.ActiveCollateral
 }
 
+// getAvailableCollateral returns the balance of the miner in excess of its
+// pledge collateral requirement and the collateral owed for slashed sectors.
+func (ma *Actor) getAvailableCollateral(ctx exec.VMContext, state State) types.AttoFIL {
+	locked := ma.getPledgeCollateralRequirement(state, ctx.BlockHeight()).Add(state.OwedStorageCollateral)
+	balance := ctx.MyBalance()
+	if locked.GreaterThan(balance) {
+		return types.ZeroAttoFIL
+	}
+	return balance.Sub(locked)
+}
+
 // getPoStChallengeSeed returns some chain randomness
 func getPoStChallengeSeed(ctx exec.VMContext, state State) (types.PoStChallengeSeed, error) {
 	randomness, err := ctx.SampleChainRandomness(provingPeriodStart(state))
@@ -1134,6 +1913,18 @@ func CollateralForSector(sectorSize *types.BytesAmount) types.AttoFIL {
 	return MinimumCollateralPerSector
 }
 
+// DeclaredFaultPenalty returns the penalty for declaring a sector of the
+// given size faulty ahead of a PoSt.
+func DeclaredFaultPenalty(sectorSize *types.BytesAmount) types.AttoFIL {
+	return types.NewAttoFIL(big.NewInt(0).Div(CollateralForSector(sectorSize).AsBigInt(), big.NewInt(DeclaredFaultPenaltyDivisor)))
+}
+
+// EarlyTerminationPenalty returns the penalty for terminating a sector of the
+// given size before it expires, which is the collateral of the sector.
+func EarlyTerminationPenalty(sectorSize *types.BytesAmount) types.AttoFIL {
+	return CollateralForSector(sectorSize)
+}
+
 // GenerationAttackTime is the number of blocks after a proving period ends
 // after which a storage miner will be subject to storage fault slashing.
 //
@@ -1178,6 +1969,38 @@ func latePoStFee(pledgeCollateral types.AttoFIL, provingPeriodEnd *types.BlockHe
 // Internal functions
 //
 
+// declaredFaults returns the sectors declared faulty, which are unset in
+// states from before faults could be declared.
+func declaredFaults(state State) types.IntSet {
+	if state.DeclaredFaults == (types.IntSet{}) {
+		return types.EmptyIntSet()
+	}
+	return state.DeclaredFaults
+}
+
+// chargeDeclaredFaults burns the declared fault penalty of the sectors, as far
+// as the available collateral covers it, and returns the sectors it does not
+// cover.
+func (ma *Actor) chargeDeclaredFaults(ctx exec.VMContext, state State, sectorIDs types.IntSet) (types.IntSet, error) {
+	perSector := DeclaredFaultPenalty(state.SectorSize)
+	ids := sectorIDs.Values()
+	paid := len(ids)
+	if perSector.IsPositive() {
+		affordable := big.NewInt(0).Div(ma.getAvailableCollateral(ctx, state).AsBigInt(), perSector.AsBigInt())
+		if affordable.Cmp(big.NewInt(int64(paid))) < 0 {
+			paid = int(affordable.Int64())
+		}
+	}
+
+	penalty := perSector.MulBigInt(big.NewInt(int64(paid)))
+	if penalty.IsPositive() {
+		if err := ma.burnFunds(ctx, penalty); err != nil {
+			return types.IntSet{}, errors.RevertErrorWrapf(err, "failed to burn penalty %s", penalty)
+		}
+	}
+	return types.NewIntSet(ids[paid:]...), nil
+}
+
 // calculates proving period start from the proving period end and the proving period duration
 func provingPeriodStart(state State) *types.BlockHeight {
 	if state.ProvingPeriodEnd == nil {
@@ -1197,3 +2020,14 @@ func lateState(provingPeriodEnd *types.BlockHeight, chainHeight *types.BlockHeig
 	}
 	return PoStStateWithinProvingPeriod, roundsLate
 }
+
+// removeExpiredAsks drops the asks that have expired at the given height.
+func removeExpiredAsks(state *State, height *types.BlockHeight) {
+	asks := state.Asks
+	state.Asks = state.Asks[:0]
+	for _, a := range asks {
+		if height.LessThan(a.Expiry) {
+			state.Asks = append(state.Asks, a)
+		}
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"strings"
 	"testing"
 
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -15,6 +17,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	. "github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/consensus"
@@ -29,6 +32,10 @@ import (
 	vmerrors "github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
+// testSectorExpiration is the expiration height of the sectors committed by
+// tests that do not exercise sector expiration.
+var testSectorExpiration = types.NewBlockHeight(100000)
+
 func TestAskFunctions(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -99,6 +106,80 @@ func TestAskFunctions(t *testing.T) {
 	assert.Len(t, askids, 2)
 }
 
+func TestCancelAsk(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := th.RequireCreateStorages(ctx, t)
+	minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+
+	// one long lived ask and one that expires at height 11
+	pdata := actor.MustConvertParams(types.NewAttoFILFromFIL(5), big.NewInt(1500))
+	msg := types.NewMessage(address.TestAddress, minerAddr, 1, types.ZeroAttoFIL, "addAsk", pdata)
+	_, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(1))
+	require.NoError(t, err)
+
+	pdata = actor.MustConvertParams(types.NewAttoFILFromFIL(6), big.NewInt(10))
+	msg = types.NewMessage(address.TestAddress, minerAddr, 2, types.ZeroAttoFIL, "addAsk", pdata)
+	_, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(1))
+	require.NoError(t, err)
+
+	t.Run("fails when called by someone other than the worker", func(t *testing.T) {
+		pdata := actor.MustConvertParams(big.NewInt(0))
+		msg := types.NewMessage(address.TestAddress2, minerAddr, 0, types.ZeroAttoFIL, "cancelAsk", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(2))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrCallerUnauthorized], result.ExecutionError)
+	})
+
+	t.Run("fails for an unknown ask", func(t *testing.T) {
+		pdata := actor.MustConvertParams(big.NewInt(42))
+		msg := types.NewMessage(address.TestAddress, minerAddr, 3, types.ZeroAttoFIL, "cancelAsk", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(2))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrAskNotFound], result.ExecutionError)
+	})
+
+	t.Run("removes the ask and expired asks", func(t *testing.T) {
+		pdata := actor.MustConvertParams(big.NewInt(0))
+		msg := types.NewMessage(address.TestAddress, minerAddr, 4, types.ZeroAttoFIL, "cancelAsk", pdata)
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(20))
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		minerState := mustGetMinerState(st, vms, minerAddr)
+		assert.Len(t, minerState.Asks, 0)
+	})
+}
+
+func TestRemoveExpiredAsks(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := th.RequireCreateStorages(ctx, t)
+	minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+
+	pdata := actor.MustConvertParams(types.NewAttoFILFromFIL(5), big.NewInt(1500))
+	msg := types.NewMessage(address.TestAddress, minerAddr, 1, types.ZeroAttoFIL, "addAsk", pdata)
+	_, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(1))
+	require.NoError(t, err)
+
+	pdata = actor.MustConvertParams(types.NewAttoFILFromFIL(6), big.NewInt(10))
+	msg = types.NewMessage(address.TestAddress, minerAddr, 2, types.ZeroAttoFIL, "addAsk", pdata)
+	_, err = th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(1))
+	require.NoError(t, err)
+
+	// anyone may remove expired asks
+	msg = types.NewMessage(address.TestAddress2, minerAddr, 0, types.ZeroAttoFIL, "removeExpiredAsks", nil)
+	result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(20))
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+
+	minerState := mustGetMinerState(st, vms, minerAddr)
+	require.Len(t, minerState.Asks, 1)
+	assert.Equal(t, uint64(0), minerState.Asks[0].ID.Uint64())
+}
+
 func TestChangeWorker(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -214,6 +295,74 @@ func TestGetOwner(t *testing.T) {
 	assert.Equal(t, address.TestAddress, addr)
 }
 
+func TestChangeOwner(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st, vms := th.RequireCreateStorages(ctx, t)
+
+	changeOwner := func(t *testing.T, from, minerAddr, owner address.Address) *consensus.ApplicationResult {
+		msg := types.NewMessage(from, minerAddr, 0, types.ZeroAttoFIL, "changeOwner", actor.MustConvertParams(owner))
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(1))
+		require.NoError(t, err)
+		return result
+	}
+	getOwner := func(t *testing.T, minerAddr address.Address) address.Address {
+		return mustDeserializeAddress(t, callQueryMethodSuccess("getOwner", ctx, t, st, vms, address.TestAddress, minerAddr))
+	}
+	getPendingOwner := func(t *testing.T, minerAddr address.Address) address.Address {
+		return mustDeserializeAddress(t, callQueryMethodSuccess("getPendingOwner", ctx, t, st, vms, address.TestAddress, minerAddr))
+	}
+
+	t.Run("the new owner accepts the change the owner proposed", func(t *testing.T) {
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+
+		result := changeOwner(t, address.TestAddress, minerAddr, address.TestAddress2)
+		require.NoError(t, result.ExecutionError)
+		assert.Equal(t, address.TestAddress, getOwner(t, minerAddr))
+		assert.Equal(t, address.TestAddress2, getPendingOwner(t, minerAddr))
+
+		result = changeOwner(t, address.TestAddress2, minerAddr, address.TestAddress2)
+		require.NoError(t, result.ExecutionError)
+		assert.Equal(t, address.TestAddress2, getOwner(t, minerAddr))
+		assert.Equal(t, address.Undef, getPendingOwner(t, minerAddr))
+
+		// the old owner no longer controls the miner
+		result = changeOwner(t, address.TestAddress, minerAddr, address.TestAddress)
+		assert.Equal(t, uint8(ErrCallerUnauthorized), result.Receipt.ExitCode)
+	})
+
+	t.Run("the owner can cancel a proposed change", func(t *testing.T) {
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+
+		require.NoError(t, changeOwner(t, address.TestAddress, minerAddr, address.TestAddress2).ExecutionError)
+		require.NoError(t, changeOwner(t, address.TestAddress, minerAddr, address.TestAddress).ExecutionError)
+		assert.Equal(t, address.Undef, getPendingOwner(t, minerAddr))
+
+		result := changeOwner(t, address.TestAddress2, minerAddr, address.TestAddress2)
+		assert.Equal(t, uint8(ErrCallerUnauthorized), result.Receipt.ExitCode)
+		assert.Equal(t, address.TestAddress, getOwner(t, minerAddr))
+	})
+
+	t.Run("only the owner proposes and only the proposed owner accepts", func(t *testing.T) {
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+		other := address.NewForTestGetter()()
+
+		result := changeOwner(t, address.TestAddress2, minerAddr, address.TestAddress2)
+		assert.Equal(t, uint8(ErrCallerUnauthorized), result.Receipt.ExitCode)
+
+		require.NoError(t, changeOwner(t, address.TestAddress, minerAddr, address.TestAddress2).ExecutionError)
+
+		result = changeOwner(t, other, minerAddr, other)
+		assert.Equal(t, uint8(ErrCallerUnauthorized), result.Receipt.ExitCode)
+		result = changeOwner(t, address.TestAddress2, minerAddr, other)
+		assert.Equal(t, uint8(ErrCallerUnauthorized), result.Receipt.ExitCode)
+		assert.Equal(t, address.TestAddress, getOwner(t, minerAddr))
+	})
+}
+
 func TestGetActiveCollateral(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -239,7 +388,7 @@ func TestGetActiveCollateral(t *testing.T) {
 	commRStar := th.MakeCommitment()
 	commD := th.MakeCommitment()
 
-	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", nil, uint64(0), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", nil, uint64(0), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 	require.NoError(t, err)
 	require.NoError(t, res.ExecutionError)
 	require.Equal(t, uint8(0), res.Receipt.ExitCode)
@@ -255,6 +404,69 @@ func TestGetActiveCollateral(t *testing.T) {
 	assert.Equal(t, MinimumCollateralPerSector, coll)
 }
 
+func TestMinerCollateral(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st, vms := th.RequireCreateStorages(ctx, t)
+
+	minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+
+	availableCollateral := func(t *testing.T) types.AttoFIL {
+		result := callQueryMethodSuccess("getAvailableCollateral", ctx, t, st, vms, address.TestAddress, minerAddr)
+		value, err := abi.Deserialize(result[0], abi.AttoFIL)
+		require.NoError(t, err)
+		return value.Val.(types.AttoFIL)
+	}
+	minerBalance := func() types.AttoFIL {
+		return state.MustGetActor(st, minerAddr).Balance
+	}
+
+	t.Run("owner adds collateral", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 10, 1, "addCollateral", nil)
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+
+		assert.Equal(t, types.NewAttoFILFromFIL(110), minerBalance())
+		assert.Equal(t, types.NewAttoFILFromFIL(110), availableCollateral(t))
+	})
+
+	t.Run("only the owner or worker adds collateral", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress2, minerAddr, 0, 1, "addCollateral", nil)
+		require.NoError(t, err)
+		require.Error(t, res.ExecutionError)
+		assert.Equal(t, uint8(ErrCallerUnauthorized), res.Receipt.ExitCode)
+	})
+
+	t.Run("owner withdraws the collateral in excess of the requirement", func(t *testing.T) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", nil, uint64(0), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+
+		available := types.NewAttoFILFromFIL(110).Sub(MinimumCollateralPerSector)
+		assert.Equal(t, available, availableCollateral(t))
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "withdrawCollateral", nil, available.Add(types.NewAttoFILFromFIL(1)))
+		require.NoError(t, err)
+		require.Error(t, res.ExecutionError)
+		assert.Equal(t, uint8(ErrInsufficientCollateral), res.Receipt.ExitCode)
+
+		res, err = th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress2, minerAddr, 0, 4, "withdrawCollateral", nil, available)
+		require.NoError(t, err)
+		require.Error(t, res.ExecutionError)
+		assert.Equal(t, uint8(ErrCallerUnauthorized), res.Receipt.ExitCode)
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "withdrawCollateral", nil, available)
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+
+		assert.Equal(t, MinimumCollateralPerSector, minerBalance())
+		assert.True(t, availableCollateral(t).IsZero())
+	})
+}
+
 func TestCBOREncodeState(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -328,6 +540,75 @@ func TestPeerIdGetterAndSetter(t *testing.T) {
 	})
 }
 
+func TestMultiaddrsGetterAndSetter(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st, vms := th.RequireCreateStorages(ctx, t)
+
+	getMultiaddrs := func(t *testing.T, minerAddr address.Address) []multiaddr.Multiaddr {
+		result := callQueryMethodSuccess("getMultiaddrs", ctx, t, st, vms, address.TestAddress, minerAddr)
+		value, err := abi.Deserialize(result[0], abi.Multiaddrs)
+		require.NoError(t, err)
+		return value.Val.([]multiaddr.Multiaddr)
+	}
+	updateMultiaddrs := func(t *testing.T, from, minerAddr address.Address, addrs []multiaddr.Multiaddr) *consensus.ApplicationResult {
+		msg := types.NewMessage(from, minerAddr, 0, types.ZeroAttoFIL, "updateMultiaddrs", actor.MustConvertParams(addrs))
+		result, err := th.ApplyTestMessage(st, vms, msg, types.NewBlockHeight(0))
+		require.NoError(t, err)
+		return result
+	}
+
+	addr1, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/6000")
+	require.NoError(t, err)
+	addr2, err := multiaddr.NewMultiaddr("/dns4/example.com/tcp/443")
+	require.NoError(t, err)
+
+	t.Run("the worker updates the multiaddrs", func(t *testing.T) {
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+		assert.Empty(t, getMultiaddrs(t, minerAddr))
+
+		require.NoError(t, updateMultiaddrs(t, address.TestAddress, minerAddr, []multiaddr.Multiaddr{addr1, addr2}).ExecutionError)
+		assert.Equal(t, []multiaddr.Multiaddr{addr1, addr2}, getMultiaddrs(t, minerAddr))
+
+		require.NoError(t, updateMultiaddrs(t, address.TestAddress, minerAddr, []multiaddr.Multiaddr{addr2}).ExecutionError)
+		assert.Equal(t, []multiaddr.Multiaddr{addr2}, getMultiaddrs(t, minerAddr))
+	})
+
+	t.Run("only the worker updates the multiaddrs", func(t *testing.T) {
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+
+		result := updateMultiaddrs(t, address.TestAddress2, minerAddr, []multiaddr.Multiaddr{addr1})
+		assert.Equal(t, uint8(ErrCallerUnauthorized), result.Receipt.ExitCode)
+		assert.Empty(t, getMultiaddrs(t, minerAddr))
+	})
+
+	t.Run("a miner advertises at most MaxMultiaddrs multiaddrs", func(t *testing.T) {
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+
+		addrs := make([]multiaddr.Multiaddr, MaxMultiaddrs+1)
+		for i := range addrs {
+			addrs[i] = addr1
+		}
+		result := updateMultiaddrs(t, address.TestAddress, minerAddr, addrs)
+		assert.Equal(t, uint8(ErrTooManyMultiaddrs), result.Receipt.ExitCode)
+	})
+
+	t.Run("a miner advertises multiaddrs of at most MaxMultiaddrSize bytes", func(t *testing.T) {
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+
+		large, err := multiaddr.NewMultiaddr("/dns4/" + strings.Repeat("a", MaxMultiaddrSize) + "/tcp/443")
+		require.NoError(t, err)
+		require.True(t, len(large.Bytes()) > MaxMultiaddrSize)
+
+		result := updateMultiaddrs(t, address.TestAddress, minerAddr, []multiaddr.Multiaddr{addr1, large})
+		assert.Equal(t, uint8(ErrMultiaddrTooLarge), result.Receipt.ExitCode)
+		assert.Empty(t, getMultiaddrs(t, minerAddr))
+	})
+}
+
 func TestMinerGetPower(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -387,7 +668,7 @@ func TestMinerGetProvingPeriod(t *testing.T) {
 		commD := th.MakeCommitment()
 
 		blockHeight := uint64(42)
-		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, blockHeight, "commitSector", nil, uint64(1), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, blockHeight, "commitSector", nil, uint64(1), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 		require.NoError(t, err)
 		require.NoError(t, res.ExecutionError)
 		require.Equal(t, uint8(0), res.Receipt.ExitCode)
@@ -455,7 +736,7 @@ func TestMinerCommitSector(t *testing.T) {
 		commD := th.MakeCommitment()
 
 		f := func(sectorId uint64) (*consensus.ApplicationResult, error) {
-			return th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", nil, uint64(sectorId), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+			return th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", nil, uint64(sectorId), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 		}
 
 		// these commitments should exhaust miner's FIL
@@ -484,7 +765,7 @@ func TestMinerCommitSector(t *testing.T) {
 		commRStar := th.MakeCommitment()
 		commD := th.MakeCommitment()
 
-		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", nil, uint64(1), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", nil, uint64(1), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 		require.NoError(t, err)
 		require.NoError(t, res.ExecutionError)
 		require.Equal(t, uint8(0), res.Receipt.ExitCode)
@@ -501,13 +782,129 @@ func TestMinerCommitSector(t *testing.T) {
 		require.Equal(t, types.NewBlockHeight(3+provingPeriod), types.NewBlockHeightFromBytes(res.Receipt.Return[1]))
 
 		// fail because commR already exists
-		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", nil, uint64(1), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 4, "commitSector", nil, uint64(1), commD, commR, commRStar, th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 		require.NoError(t, err)
 		require.EqualError(t, res.ExecutionError, "sector already committed at this ID")
 		require.Equal(t, uint8(0x23), res.Receipt.ExitCode)
 	})
 }
 
+func TestMinerSectorLifecycle(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	commitHeight := uint64(3)
+	postHeight := commitHeight + ProvingPeriodDuration(types.OneKiBSectorSize)
+
+	setup := func(t *testing.T) (state.Tree, vm.StorageMap, []types.TipSet, address.Address) {
+		st, vms := th.RequireCreateStorages(ctx, t)
+		builder := chain.NewBuilder(t, address.Undef)
+		head := builder.AppendManyOn(10, types.UndefTipSet)
+		ancestors := builder.RequireTipSets(head.Key(), 10)
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+		return st, vms, ancestors, minerAddr
+	}
+
+	commit := func(t *testing.T, st state.Tree, vms vm.StorageMap, ancestors []types.TipSet, minerAddr address.Address, height, sectorID uint64, expiration *types.BlockHeight) *consensus.ApplicationResult {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, height, "commitSector", ancestors, sectorID, th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), expiration)
+		require.NoError(t, err)
+		return res
+	}
+
+	post := func(t *testing.T, st state.Tree, vms vm.StorageMap, ancestors []types.TipSet, minerAddr address.Address) {
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, postHeight, "submitPoSt", ancestors, []types.PoStProof{th.MakeRandomPoStProofForTest()}, types.EmptyFaultSet(), types.EmptyIntSet())
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+	}
+
+	t.Run("a committed sector carries its expiration", func(t *testing.T) {
+		st, vms, ancestors, minerAddr := setup(t)
+
+		res := commit(t, st, vms, ancestors, minerAddr, commitHeight, 1, types.NewBlockHeight(commitHeight))
+		assert.Equal(t, Errors[ErrInvalidSectorExpiration], res.ExecutionError)
+		assert.Equal(t, uint8(ErrInvalidSectorExpiration), res.Receipt.ExitCode)
+
+		res = commit(t, st, vms, ancestors, minerAddr, commitHeight, 1, types.NewBlockHeight(500))
+		require.NoError(t, res.ExecutionError)
+
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, commitHeight, "getSectorExpiration", nil, uint64(1))
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, types.NewBlockHeight(500), types.NewBlockHeightFromBytes(res.Receipt.Return[0]))
+
+		res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, commitHeight, "getSectorExpiration", nil, uint64(2))
+		require.NoError(t, err)
+		assert.Equal(t, Errors[ErrInvalidSector], res.ExecutionError)
+	})
+
+	t.Run("the worker extends the expiration of a sector", func(t *testing.T) {
+		st, vms, ancestors, minerAddr := setup(t)
+		require.NoError(t, commit(t, st, vms, ancestors, minerAddr, commitHeight, 1, types.NewBlockHeight(500)).ExecutionError)
+
+		extend := func(from address.Address, sectorID uint64, expiration uint64) *consensus.ApplicationResult {
+			res, err := th.CreateAndApplyTestMessageFrom(t, st, vms, from, minerAddr, 0, 10, "extendSector", nil, sectorID, types.NewBlockHeight(expiration))
+			require.NoError(t, err)
+			return res
+		}
+
+		assert.Equal(t, Errors[ErrInvalidSectorExpiration], extend(address.TestAddress, 1, 500).ExecutionError)
+		assert.Equal(t, Errors[ErrInvalidSector], extend(address.TestAddress, 2, 1000).ExecutionError)
+		assert.Equal(t, Errors[ErrCallerUnauthorized], extend(address.TestAddress2, 1, 1000).ExecutionError)
+
+		require.NoError(t, extend(address.TestAddress, 1, 1000).ExecutionError)
+		expiration, ok := mustGetMinerState(st, vms, minerAddr).SectorExpirations.Get(1)
+		require.True(t, ok)
+		assert.Equal(t, types.NewBlockHeight(1000), expiration)
+	})
+
+	t.Run("a PoSt drops the expired sectors and releases their collateral", func(t *testing.T) {
+		st, vms, ancestors, minerAddr := setup(t)
+		require.NoError(t, commit(t, st, vms, ancestors, minerAddr, commitHeight, 1, types.NewBlockHeight(postHeight)).ExecutionError)
+		require.NoError(t, commit(t, st, vms, ancestors, minerAddr, commitHeight+1, 2, testSectorExpiration).ExecutionError)
+
+		post(t, st, vms, ancestors, minerAddr)
+
+		minerState := mustGetMinerState(st, vms, minerAddr)
+		// the expired sector was proven by the PoSt
+		assert.Equal(t, types.OneKiBSectorSize, minerState.Power)
+		assert.False(t, minerState.SectorCommitments.Has(1))
+		assert.True(t, minerState.SectorCommitments.Has(2))
+		_, ok := minerState.SectorExpirations.Get(1)
+		assert.False(t, ok)
+		assert.Equal(t, []uint64{2}, minerState.ProvingSet.Values())
+		assert.Equal(t, MinimumCollateralPerSector, minerState.ActiveCollateral)
+	})
+
+	t.Run("terminating sectors burns their collateral and removes their power", func(t *testing.T) {
+		st, vms, ancestors, minerAddr := setup(t)
+		require.NoError(t, commit(t, st, vms, ancestors, minerAddr, commitHeight, 1, testSectorExpiration).ExecutionError)
+		require.NoError(t, commit(t, st, vms, ancestors, minerAddr, commitHeight+1, 2, testSectorExpiration).ExecutionError)
+		post(t, st, vms, ancestors, minerAddr)
+		require.Equal(t, types.OneKiBSectorSize, mustGetMinerState(st, vms, minerAddr).Power)
+
+		terminate := func(from address.Address, sectorIDs ...uint64) *consensus.ApplicationResult {
+			res, err := th.CreateAndApplyTestMessageFrom(t, st, vms, from, minerAddr, 0, postHeight+1, "terminateSectors", nil, types.NewIntSet(sectorIDs...))
+			require.NoError(t, err)
+			return res
+		}
+
+		assert.Equal(t, Errors[ErrInvalidSector], terminate(address.TestAddress, 1, 5).ExecutionError)
+		assert.Equal(t, Errors[ErrCallerUnauthorized], terminate(address.TestAddress2, 1).ExecutionError)
+
+		oldTotalStoragePower := th.GetTotalPower(t, st, vms)
+		balance := state.MustGetActor(st, minerAddr).Balance
+		require.NoError(t, terminate(address.TestAddress, 1).ExecutionError)
+
+		minerState := mustGetMinerState(st, vms, minerAddr)
+		assert.Equal(t, types.NewBytesAmount(0), minerState.Power)
+		assert.Equal(t, oldTotalStoragePower.Sub(types.OneKiBSectorSize), th.GetTotalPower(t, st, vms))
+		assert.False(t, minerState.SectorCommitments.Has(1))
+		assert.Equal(t, []uint64{2}, minerState.ProvingSet.Values())
+		assert.Equal(t, MinimumCollateralPerSector, minerState.ActiveCollateral)
+		assert.Equal(t, balance.Sub(EarlyTerminationPenalty(types.OneKiBSectorSize)), state.MustGetActor(st, minerAddr).Balance)
+	})
+}
+
 // minerActorLiason provides a set of test friendly calls for setting up, reading
 // internals, and transitioning the portion of the filecoin state machine
 // related to a particular miner actor.
@@ -527,7 +924,7 @@ func (mal *minerActorLiason) requireHeightNotPast(blockHeight uint64) {
 
 func (mal *minerActorLiason) requireCommit(blockHeight, sectorID uint64) {
 	mal.requireHeightNotPast(blockHeight)
-	res, err := th.CreateAndApplyTestMessage(mal.t, mal.st, mal.vms, mal.minerAddr, 0, blockHeight, "commitSector", mal.ancestors, sectorID, th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+	res, err := th.CreateAndApplyTestMessage(mal.t, mal.st, mal.vms, mal.minerAddr, 0, blockHeight, "commitSector", mal.ancestors, sectorID, th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 	require.NoError(mal.t, err)
 	require.NoError(mal.t, res.ExecutionError)
 	require.Equal(mal.t, uint8(0), res.Receipt.ExitCode)
@@ -733,6 +1130,118 @@ func TestMinerSubmitPoStPowerUpdates(t *testing.T) {
 	})
 }
 
+func TestMinerFaultDeclarations(t *testing.T) {
+	tf.UnitTest(t)
+
+	firstCommitBlockHeight := uint64(3)
+	secondProvingPeriodStart := LargestSectorSizeProvingPeriodBlocks + firstCommitBlockHeight
+	thirdProvingPeriodStart := 2*LargestSectorSizeProvingPeriodBlocks + firstCommitBlockHeight
+	fourthProvingPeriodStart := 3*LargestSectorSizeProvingPeriodBlocks + firstCommitBlockHeight
+
+	declare := func(mal *minerActorLiason, method string, from address.Address, height uint64, sectorIDs ...uint64) *consensus.ApplicationResult {
+		mal.requireHeightNotPast(height)
+		res, err := th.CreateAndApplyTestMessageFrom(mal.t, mal.st, mal.vms, from, mal.minerAddr, 0, height, method, mal.ancestors, types.NewIntSet(sectorIDs...))
+		require.NoError(mal.t, err)
+		return res
+	}
+
+	t.Run("declared faults leave the proving set until they recover", func(t *testing.T) {
+		mal := setupMinerActorLiason(t)
+		mal.requireCommit(firstCommitBlockHeight, uint64(1))
+		mal.requireCommit(firstCommitBlockHeight+1, uint64(2))
+		mal.requirePoSt(secondProvingPeriodStart, types.EmptyIntSet(), types.EmptyFaultSet())
+		require.Equal(t, []uint64{1, 2}, mal.requireReadState().ProvingSet.Values())
+
+		height := secondProvingPeriodStart + 1
+		assert.Equal(t, Errors[ErrCallerUnauthorized], declare(mal, "declareFaults", address.TestAddress2, height, 1).ExecutionError)
+		assert.Equal(t, Errors[ErrInvalidSector], declare(mal, "declareFaults", address.TestAddress, height, 1, 5).ExecutionError)
+
+		balance := state.MustGetActor(mal.st, mal.minerAddr).Balance
+		require.NoError(t, declare(mal, "declareFaults", address.TestAddress, height, 1).ExecutionError)
+		assert.Equal(t, Errors[ErrInvalidFaultDeclaration], declare(mal, "declareFaults", address.TestAddress, height, 1).ExecutionError)
+
+		minerState := mal.requireReadState()
+		assert.Equal(t, []uint64{1}, minerState.DeclaredFaults.Values())
+		assert.Equal(t, []uint64{2}, minerState.ProvingSet.Values())
+		assert.True(t, minerState.SectorCommitments.Has(1))
+		assert.Equal(t, types.NewBytesAmount(0), minerState.Power)
+		assert.Equal(t, balance.Sub(DeclaredFaultPenalty(types.OneKiBSectorSize)), state.MustGetActor(mal.st, mal.minerAddr).Balance)
+		assert.True(t, DeclaredFaultPenalty(types.OneKiBSectorSize).LessThan(CollateralForSector(types.OneKiBSectorSize)))
+
+		// the faulty sector stays out of the proving set of the next period
+		mal.requirePoSt(thirdProvingPeriodStart, types.EmptyIntSet(), types.EmptyFaultSet())
+		assert.Equal(t, []uint64{2}, mal.requireReadState().ProvingSet.Values())
+
+		height = thirdProvingPeriodStart + 1
+		assert.Equal(t, Errors[ErrInvalidFaultDeclaration], declare(mal, "declareRecovery", address.TestAddress, height, 2).ExecutionError)
+		assert.Equal(t, Errors[ErrCallerUnauthorized], declare(mal, "declareRecovery", address.TestAddress2, height, 1).ExecutionError)
+		require.NoError(t, declare(mal, "declareRecovery", address.TestAddress, height, 1).ExecutionError)
+
+		minerState = mal.requireReadState()
+		assert.Equal(t, 0, minerState.DeclaredFaults.Size())
+		assert.Equal(t, []uint64{2}, minerState.ProvingSet.Values())
+
+		// the recovered sector rejoins the proving set at the next PoSt
+		mal.requirePoSt(fourthProvingPeriodStart, types.EmptyIntSet(), types.EmptyFaultSet())
+		assert.Equal(t, []uint64{1, 2}, mal.requireReadState().ProvingSet.Values())
+	})
+
+	t.Run("declared faults pay the penalty every proving period", func(t *testing.T) {
+		mal := setupMinerActorLiason(t)
+		mal.requireCommit(firstCommitBlockHeight, uint64(1))
+		mal.requireCommit(firstCommitBlockHeight+1, uint64(2))
+		mal.requirePoSt(secondProvingPeriodStart, types.EmptyIntSet(), types.EmptyFaultSet())
+		require.NoError(t, declare(mal, "declareFaults", address.TestAddress, secondProvingPeriodStart+1, 1).ExecutionError)
+
+		balance := state.MustGetActor(mal.st, mal.minerAddr).Balance
+		mal.requirePoSt(thirdProvingPeriodStart, types.EmptyIntSet(), types.EmptyFaultSet())
+		assert.Equal(t, balance.Sub(DeclaredFaultPenalty(types.OneKiBSectorSize)), state.MustGetActor(mal.st, mal.minerAddr).Balance)
+
+		minerState := mal.requireReadState()
+		assert.Equal(t, []uint64{1}, minerState.DeclaredFaults.Values())
+		assert.True(t, minerState.SectorCommitments.Has(1))
+	})
+
+	t.Run("declarations the collateral does not cover expire", func(t *testing.T) {
+		mal := setupMinerActorLiason(t)
+		mal.requireCommit(firstCommitBlockHeight, uint64(1))
+		mal.requireCommit(firstCommitBlockHeight+1, uint64(2))
+		mal.requirePoSt(secondProvingPeriodStart, types.EmptyIntSet(), types.EmptyFaultSet())
+		require.NoError(t, declare(mal, "declareFaults", address.TestAddress, secondProvingPeriodStart+1, 1).ExecutionError)
+
+		// withdraw all the collateral in excess of the requirement
+		result := callQueryMethodSuccess("getAvailableCollateral", context.Background(), t, mal.st, mal.vms, address.TestAddress, mal.minerAddr)
+		available, err := abi.Deserialize(result[0], abi.AttoFIL)
+		require.NoError(t, err)
+		mal.requireHeightNotPast(secondProvingPeriodStart + 2)
+		res, err := th.CreateAndApplyTestMessage(t, mal.st, mal.vms, mal.minerAddr, 0, secondProvingPeriodStart+2, "withdrawCollateral", mal.ancestors, available.Val)
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+
+		balance := state.MustGetActor(mal.st, mal.minerAddr).Balance
+		mal.requirePoSt(thirdProvingPeriodStart, types.EmptyIntSet(), types.EmptyFaultSet())
+		assert.Equal(t, balance, state.MustGetActor(mal.st, mal.minerAddr).Balance)
+
+		minerState := mal.requireReadState()
+		assert.Equal(t, 0, minerState.DeclaredFaults.Size())
+		assert.False(t, minerState.SectorCommitments.Has(1))
+		assert.Equal(t, []uint64{2}, minerState.ProvingSet.Values())
+	})
+
+	t.Run("recovered sectors are proven immediately when no sector is", func(t *testing.T) {
+		mal := setupMinerActorLiason(t)
+		mal.requireCommit(firstCommitBlockHeight, uint64(1))
+		require.NoError(t, declare(mal, "declareFaults", address.TestAddress, firstCommitBlockHeight+1, 1).ExecutionError)
+		require.Equal(t, 0, mal.requireReadState().ProvingSet.Size())
+
+		require.NoError(t, declare(mal, "declareRecovery", address.TestAddress, secondProvingPeriodStart, 1).ExecutionError)
+
+		minerState := mal.requireReadState()
+		assert.Equal(t, []uint64{1}, minerState.ProvingSet.Values())
+		assert.Equal(t, types.NewBlockHeight(secondProvingPeriodStart+LargestSectorSizeProvingPeriodBlocks), minerState.ProvingPeriodEnd)
+	})
+}
+
 func TestMinerSubmitPoStVerification(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -1032,13 +1541,13 @@ func TestMinerSubmitPoSt(t *testing.T) {
 	lastPossibleSubmission := secondProvingPeriodStart + LargestSectorSizeProvingPeriodBlocks + LargestSectorGenerationAttackThresholdBlocks
 
 	// add a sector
-	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, firstCommitBlockHeight, "commitSector", ancestors, uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, firstCommitBlockHeight, "commitSector", ancestors, uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 	require.NoError(t, err)
 	require.NoError(t, res.ExecutionError)
 	require.Equal(t, uint8(0), res.Receipt.ExitCode)
 
 	// add another sector
-	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, firstCommitBlockHeight+1, "commitSector", ancestors, uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+	res, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, firstCommitBlockHeight+1, "commitSector", ancestors, uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 	require.NoError(t, err)
 	require.NoError(t, res.ExecutionError)
 	require.Equal(t, uint8(0), res.Receipt.ExitCode)
@@ -1116,11 +1625,11 @@ func TestActorSlashStorageFault(t *testing.T) {
 		faultsDefault := types.EmptyFaultSet()
 
 		// add a sector
-		_, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, firstCommitBlockHeight, "commitSector", ancestors, uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+		_, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, firstCommitBlockHeight, "commitSector", ancestors, uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 		require.NoError(t, err)
 
 		// add another sector (not in proving set yet)
-		_, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, firstCommitBlockHeight+1, "commitSector", ancestors, uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+		_, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, firstCommitBlockHeight+1, "commitSector", ancestors, uint64(2), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
 		require.NoError(t, err)
 
 		// submit post (first sector only)
@@ -1227,6 +1736,175 @@ func assertSlashStatus(t *testing.T, st state.Tree, vms vm.StorageMap, minerAddr
 
 }
 
+func TestReportConsensusFault(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	signer, _ := types.NewMockSignersAndKeyInfo(3)
+	worker, reporter, newWorker := signer.Addresses[0], signer.Addresses[1], signer.Addresses[2]
+
+	createMinerWithPower := func(t *testing.T) (state.Tree, vm.StorageMap, address.Address) {
+		st, vms := th.RequireCreateStorages(ctx, t)
+		minerAddr := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+
+		builder := chain.NewBuilder(t, address.Undef)
+		head := builder.AppendManyOn(10, types.UndefTipSet)
+		ancestors := builder.RequireTipSets(head.Key(), 10)
+
+		commitHeight := uint64(3)
+		_, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, commitHeight, "commitSector", ancestors, uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), testSectorExpiration)
+		require.NoError(t, err)
+		_, err = th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, commitHeight+ProvingPeriodDuration(types.OneKiBSectorSize), "submitPoSt", ancestors, []types.PoStProof{th.MakeRandomPoStProofForTest()}, types.EmptyFaultSet(), types.EmptyIntSet())
+		require.NoError(t, err)
+
+		// the worker signs the blocks of the miner
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 50, "changeWorker", nil, worker)
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+
+		require.NoError(t, st.SetActor(ctx, reporter, th.RequireNewAccountActor(t, types.ZeroAttoFIL)))
+		return st, vms, minerAddr
+	}
+
+	sign := func(t *testing.T, blk *types.Block, by address.Address) []byte {
+		sig, err := signer.SignBytes(blk.SignatureData(), by)
+		require.NoError(t, err)
+		blk.BlockSig = sig
+		return blk.ToNode().RawData()
+	}
+
+	report := func(t *testing.T, st state.Tree, vms vm.StorageMap, minerAddr address.Address, block1, block2 []byte) *consensus.ApplicationResult {
+		res, err := th.CreateAndApplyTestMessageFrom(t, st, vms, reporter, minerAddr, 0, 100, "reportConsensusFault", nil, block1, block2)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("reporting two blocks at the same height slashes the miner and rewards the reporter", func(t *testing.T) {
+		st, vms, minerAddr := createMinerWithPower(t)
+		minerPower := mustGetMinerState(st, vms, minerAddr).Power
+		require.False(t, minerPower.IsZero())
+		oldTotalStoragePower := th.GetTotalPower(t, st, vms)
+		collateral := state.MustGetActor(st, minerAddr).Balance
+
+		block1 := sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 1}, worker)
+		block2 := sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 2}, worker)
+		res := report(t, st, vms, minerAddr, block1, block2)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, uint8(0), res.Receipt.ExitCode)
+
+		minerState := mustGetMinerState(st, vms, minerAddr)
+		assert.Equal(t, types.NewBytesAmount(0), minerState.Power)
+		assert.Equal(t, types.NewBlockHeight(100), minerState.ConsensusFaultAt)
+		assert.Equal(t, 0, minerState.SectorCommitments.Size())
+		assert.Equal(t, 0, minerState.ProvingSet.Size())
+		assert.Equal(t, []uint64{1}, minerState.SlashedSet.Values())
+		assert.Equal(t, minerPower, oldTotalStoragePower.Sub(th.GetTotalPower(t, st, vms)))
+
+		reward := types.NewAttoFIL(big.NewInt(0).Div(collateral.AsBigInt(), big.NewInt(ConsensusFaultReporterRewardDivisor)))
+		assert.Equal(t, types.ZeroAttoFIL, state.MustGetActor(st, minerAddr).Balance)
+		assert.Equal(t, reward, state.MustGetActor(st, reporter).Balance)
+	})
+
+	t.Run("reporting two blocks with the same parents slashes the miner", func(t *testing.T) {
+		st, vms, minerAddr := createMinerWithPower(t)
+		parents := types.NewTipSetKey(types.SomeCid())
+
+		block1 := sign(t, &types.Block{Miner: minerAddr, Height: 60, Parents: parents}, worker)
+		block2 := sign(t, &types.Block{Miner: minerAddr, Height: 61, Parents: parents}, worker)
+		res := report(t, st, vms, minerAddr, block1, block2)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, uint8(0), res.Receipt.ExitCode)
+	})
+
+	t.Run("blocks that do not conflict are not evidence", func(t *testing.T) {
+		st, vms, minerAddr := createMinerWithPower(t)
+
+		block := sign(t, &types.Block{Miner: minerAddr, Height: 60}, worker)
+		res := report(t, st, vms, minerAddr, block, block)
+		assert.Equal(t, uint8(ErrInvalidConsensusFault), res.Receipt.ExitCode)
+
+		child := sign(t, &types.Block{Miner: minerAddr, Height: 61, Parents: types.NewTipSetKey(types.SomeCid())}, worker)
+		res = report(t, st, vms, minerAddr, block, child)
+		assert.Equal(t, uint8(ErrInvalidConsensusFault), res.Receipt.ExitCode)
+
+		otherMiner1 := sign(t, &types.Block{Miner: address.TestAddress2, Height: 60, Nonce: 1}, worker)
+		otherMiner2 := sign(t, &types.Block{Miner: address.TestAddress2, Height: 60, Nonce: 2}, worker)
+		res = report(t, st, vms, minerAddr, otherMiner1, otherMiner2)
+		assert.Equal(t, uint8(ErrInvalidConsensusFault), res.Receipt.ExitCode)
+
+		res = report(t, st, vms, minerAddr, []byte{0x01}, block)
+		assert.Equal(t, uint8(ErrInvalidConsensusFault), res.Receipt.ExitCode)
+
+		assert.Nil(t, mustGetMinerState(st, vms, minerAddr).ConsensusFaultAt)
+	})
+
+	t.Run("blocks not signed by the worker are not evidence", func(t *testing.T) {
+		st, vms, minerAddr := createMinerWithPower(t)
+
+		block1 := sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 1}, worker)
+		block2 := sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 2}, reporter)
+		res := report(t, st, vms, minerAddr, block1, block2)
+		require.Error(t, res.ExecutionError)
+		assert.Contains(t, res.ExecutionError.Error(), "not signed by the worker")
+		assert.Equal(t, uint8(ErrInvalidConsensusFault), res.Receipt.ExitCode)
+	})
+
+	t.Run("blocks are checked against the worker at their height", func(t *testing.T) {
+		st, vms, minerAddr := createMinerWithPower(t)
+
+		// the owner replaces the worker after it signed conflicting blocks
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 70, "changeWorker", nil, newWorker)
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, []PriorWorker{
+			{Worker: address.TestAddress, ReplacedAt: types.NewBlockHeight(50)},
+			{Worker: worker, ReplacedAt: types.NewBlockHeight(70)},
+		}, mustGetMinerState(st, vms, minerAddr).PriorWorkers)
+
+		block1 := sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 1}, newWorker)
+		block2 := sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 2}, newWorker)
+		res = report(t, st, vms, minerAddr, block1, block2)
+		assert.Equal(t, uint8(ErrInvalidConsensusFault), res.Receipt.ExitCode)
+
+		block1 = sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 1}, worker)
+		block2 = sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 2}, worker)
+		res = report(t, st, vms, minerAddr, block1, block2)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, uint8(0), res.Receipt.ExitCode)
+	})
+
+	t.Run("blocks after a worker change are checked against the new worker", func(t *testing.T) {
+		st, vms, minerAddr := createMinerWithPower(t)
+
+		res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 70, "changeWorker", nil, newWorker)
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+
+		block1 := sign(t, &types.Block{Miner: minerAddr, Height: 71, Nonce: 1}, worker)
+		block2 := sign(t, &types.Block{Miner: minerAddr, Height: 71, Nonce: 2}, worker)
+		res = report(t, st, vms, minerAddr, block1, block2)
+		assert.Equal(t, uint8(ErrInvalidConsensusFault), res.Receipt.ExitCode)
+
+		block1 = sign(t, &types.Block{Miner: minerAddr, Height: 71, Nonce: 1}, newWorker)
+		block2 = sign(t, &types.Block{Miner: minerAddr, Height: 71, Nonce: 2}, newWorker)
+		res = report(t, st, vms, minerAddr, block1, block2)
+		require.NoError(t, res.ExecutionError)
+		assert.Equal(t, uint8(0), res.Receipt.ExitCode)
+	})
+
+	t.Run("a miner is slashed for a consensus fault once", func(t *testing.T) {
+		st, vms, minerAddr := createMinerWithPower(t)
+
+		block1 := sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 1}, worker)
+		block2 := sign(t, &types.Block{Miner: minerAddr, Height: 60, Nonce: 2}, worker)
+		res := report(t, st, vms, minerAddr, block1, block2)
+		require.Equal(t, uint8(0), res.Receipt.ExitCode)
+
+		res = report(t, st, vms, minerAddr, block1, block2)
+		assert.Equal(t, uint8(ErrMinerAlreadySlashed), res.Receipt.ExitCode)
+	})
+}
+
 func TestVerifyPIP(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -1392,7 +2070,7 @@ func TestGetProofsMode(t *testing.T) {
 			Ancestors:   []types.TipSet{},
 		})
 
-		require.NoError(t, consensus.SetupDefaultActors(ctx, st, vms, types.TestProofsMode))
+		require.NoError(t, consensus.SetupDefaultActors(ctx, st, vms, storagemarket.NetworkParams{ProofsMode: types.TestProofsMode}))
 
 		mode, err := GetProofsMode(vmCtx)
 		require.NoError(t, err)
@@ -1411,7 +2089,7 @@ func TestGetProofsMode(t *testing.T) {
 			Ancestors:   []types.TipSet{},
 		})
 
-		require.NoError(t, consensus.SetupDefaultActors(ctx, st, vms, types.LiveProofsMode))
+		require.NoError(t, consensus.SetupDefaultActors(ctx, st, vms, storagemarket.NetworkParams{ProofsMode: types.LiveProofsMode}))
 
 		mode, err := GetProofsMode(vmCtx)
 		require.NoError(t, err)
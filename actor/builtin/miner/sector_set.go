@@ -1,6 +1,7 @@
 package miner
 
 import (
+	"sort"
 	"strconv"
 
 	"github.com/filecoin-project/go-filecoin/types"
@@ -68,6 +69,53 @@ func (ss SectorSet) Size() int {
 	return len(ss)
 }
 
+// SectorExpirations maps sector ids to the block heights at which the sectors
+// expire. Like SectorSet, its sector id-keys are stringified.
+type SectorExpirations map[string]*types.BlockHeight
+
+// NewSectorExpirations initializes a SectorExpirations with no entries.
+func NewSectorExpirations() SectorExpirations {
+	return make(map[string]*types.BlockHeight)
+}
+
+// Get returns the expiration height of the sector at the given id and a bool
+// indicating success.
+func (se SectorExpirations) Get(id uint64) (*types.BlockHeight, bool) {
+	height, ok := se[idStr(id)]
+	return height, ok
+}
+
+// Set sets the expiration height of the sector at the given id.
+func (se SectorExpirations) Set(id uint64, height *types.BlockHeight) {
+	se[idStr(id)] = height
+}
+
+// Drop removes the expiration heights of the provided sectorIDs. Sectors
+// without an expiration height are ignored.
+func (se SectorExpirations) Drop(ids []uint64) {
+	for _, id := range ids {
+		delete(se, idStr(id))
+	}
+}
+
+// Expired returns the sorted ids of the sectors that have expired at the
+// given height.
+func (se SectorExpirations) Expired(height *types.BlockHeight) ([]uint64, error) {
+	var ids []uint64
+	for idStr, expiration := range se {
+		if expiration.GreaterThan(height) {
+			continue
+		}
+		id, err := str2ID(idStr)
+		if err != nil {
+			return nil, errors.RevertErrorWrap(err, "corrupt sector expirations id")
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
 // TODO: use uint64 as map keys instead of this abomination, once refmt is fixed.
 // https://github.com/polydawn/refmt/issues/35
 func idStr(sectorID uint64) string {
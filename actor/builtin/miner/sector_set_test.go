@@ -8,6 +8,7 @@ import (
 	. "github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 func TestSectorSet(t *testing.T) {
@@ -98,3 +99,46 @@ func TestSectorSet(t *testing.T) {
 		assert.Contains(t, ids, uint64(8))
 	})
 }
+
+func TestSectorExpirations(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("Set and Get", func(t *testing.T) {
+		se := NewSectorExpirations()
+		se.Set(1, types.NewBlockHeight(10))
+		height, ok := se.Get(1)
+		assert.True(t, ok)
+		assert.Equal(t, types.NewBlockHeight(10), height)
+
+		_, ok = se.Get(2)
+		assert.False(t, ok)
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		se := NewSectorExpirations()
+		se.Set(1, types.NewBlockHeight(10))
+		se.Set(2, types.NewBlockHeight(20))
+
+		se.Drop([]uint64{1, 3})
+		_, ok := se.Get(1)
+		assert.False(t, ok)
+		_, ok = se.Get(2)
+		assert.True(t, ok)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		se := NewSectorExpirations()
+		se.Set(8, types.NewBlockHeight(10))
+		se.Set(3, types.NewBlockHeight(20))
+		se.Set(1, types.NewBlockHeight(10))
+		se.Set(5, types.NewBlockHeight(30))
+
+		ids, err := se.Expired(types.NewBlockHeight(9))
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+
+		ids, err = se.Expired(types.NewBlockHeight(20))
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{1, 3, 8}, ids)
+	})
+}
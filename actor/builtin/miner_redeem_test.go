@@ -69,7 +69,7 @@ func TestVerifyPieceInclusionInRedeem(t *testing.T) {
 	}
 
 	makeAndSignVoucher := func(condition *types.Predicate) []byte {
-		sig, err := paymentbroker.SignVoucher(channelID, amt, defaultValidAt, payer, condition, mockSigner)
+		sig, err := paymentbroker.SignVoucher(channelID, amt, defaultValidAt, 0, 0, nil, payer, condition, mockSigner)
 		require.NoError(t, err)
 		signature := ([]byte)(sig)
 
@@ -78,7 +78,8 @@ func TestVerifyPieceInclusionInRedeem(t *testing.T) {
 
 	makeRedeemMsg := func(condition *types.Predicate, sectorID uint64, pip []byte, signature []byte) *types.Message {
 		suppliedParams := []interface{}{sectorID, pip}
-		pdata := core.MustConvertParams(payer, channelID, amt, types.NewBlockHeight(0), condition, signature, suppliedParams)
+		var merges []types.VoucherMerge
+		pdata := core.MustConvertParams(payer, channelID, amt, types.NewBlockHeight(0), uint64(0), uint64(0), merges, condition, signature, suppliedParams)
 		return types.NewMessage(target, address.PaymentBrokerAddress, 0, types.NewAttoFILFromFIL(0), "redeem", pdata)
 	}
 
@@ -0,0 +1,108 @@
+package multisig
+
+import (
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+func init() {
+	cbor.RegisterCborType(FactoryState{})
+}
+
+// FactoryActor is the singleton actor creating multisig actors.
+type FactoryActor struct{}
+
+// FactoryState is the factory's storage.
+type FactoryState struct {
+	// Count is the number of multisigs created.
+	Count uint64
+}
+
+// NewFactoryActor returns a new multisig factory actor.
+func NewFactoryActor() *actor.Actor {
+	return actor.NewActor(types.MultisigFactoryActorCodeCid, types.ZeroAttoFIL)
+}
+
+// InitializeState stores the factory's initial state.
+func (fa *FactoryActor) InitializeState(storage exec.Storage, _ interface{}) error {
+	stateBytes, err := cbor.DumpObject(&FactoryState{})
+	if err != nil {
+		return err
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*FactoryActor)(nil)
+
+// Exports returns the actor's exports.
+func (fa *FactoryActor) Exports() exec.Exports {
+	return factoryExports
+}
+
+var factoryExports = exec.Exports{
+	"createMultisig": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Addresses, abi.Uint64, abi.BlockHeight},
+		Return: []abi.Type{abi.Address},
+	},
+}
+
+// CreateMultisig creates a multisig of the signers, requiring threshold of
+// them to approve a transaction. The value of the message funds it and vests
+// over unlockDuration blocks, none if zero. It returns the address of the
+// new multisig.
+func (fa *FactoryActor) CreateMultisig(vmctx exec.VMContext, signers []address.Address, threshold uint64, unlockDuration *types.BlockHeight) (address.Address, uint8, error) {
+	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+		return address.Undef, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if threshold < 1 || threshold > uint64(len(signers)) {
+		return address.Undef, ErrInvalidThreshold, Errors[ErrInvalidThreshold]
+	}
+	seen := make(map[address.Address]bool, len(signers))
+	for _, signer := range signers {
+		if seen[signer] {
+			return address.Undef, ErrDuplicateSigner, Errors[ErrDuplicateSigner]
+		}
+		seen[signer] = true
+	}
+
+	var state FactoryState
+	out, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		addr, err := vmctx.AddressForNewActor()
+		if err != nil {
+			return nil, errors.FaultErrorWrap(err, "could not get address for new actor")
+		}
+
+		value := vmctx.Message().Value
+		initState := NewState(signers, threshold, value, vmctx.BlockHeight(), unlockDuration)
+		if err := vmctx.CreateNewActor(addr, types.MultisigActorCodeCid, initState); err != nil {
+			return nil, err
+		}
+
+		_, _, err = vmctx.Send(addr, "", value, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		state.Count++
+		return addr, nil
+	})
+	if err != nil {
+		return address.Undef, errors.CodeError(err), err
+	}
+
+	return out.(address.Address), 0, nil
+}
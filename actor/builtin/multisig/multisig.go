@@ -0,0 +1,512 @@
+package multisig
+
+import (
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+const (
+	// ErrNotSigner indicates the caller is not a signer of the multisig.
+	ErrNotSigner = 33
+	// ErrInvalidThreshold indicates a threshold of zero or above the number of signers.
+	ErrInvalidThreshold = 34
+	// ErrUnknownTransaction indicates an invalid transaction id.
+	ErrUnknownTransaction = 35
+	// ErrAlreadyApproved indicates a signer approving the same transaction twice.
+	ErrAlreadyApproved = 36
+	// ErrNotProposer indicates an attempt to cancel a transaction proposed by another signer.
+	ErrNotProposer = 37
+	// ErrFundsLocked indicates a transaction spending funds that have not vested yet.
+	ErrFundsLocked = 38
+	// ErrNotSelf indicates a call to a method only the multisig itself may call.
+	ErrNotSelf = 39
+	// ErrDuplicateSigner indicates adding a signer that already is one.
+	ErrDuplicateSigner = 40
+)
+
+// Errors map error codes to revert errors this actor may return.
+var Errors = map[uint8]error{
+	ErrNotSigner:          errors.NewCodedRevertError(ErrNotSigner, "caller is not a signer of the multisig"),
+	ErrInvalidThreshold:   errors.NewCodedRevertError(ErrInvalidThreshold, "threshold must be between 1 and the number of signers"),
+	ErrUnknownTransaction: errors.NewCodedRevertError(ErrUnknownTransaction, "transaction is unknown"),
+	ErrAlreadyApproved:    errors.NewCodedRevertError(ErrAlreadyApproved, "transaction already approved by signer"),
+	ErrNotProposer:        errors.NewCodedRevertError(ErrNotProposer, "only the proposer may cancel a transaction"),
+	ErrFundsLocked:        errors.NewCodedRevertError(ErrFundsLocked, "transaction spends funds that have not vested"),
+	ErrNotSelf:            errors.NewCodedRevertError(ErrNotSelf, "method may only be called through a transaction of the multisig"),
+	ErrDuplicateSigner:    errors.NewCodedRevertError(ErrDuplicateSigner, "address already is a signer"),
+}
+
+func init() {
+	cbor.RegisterCborType(State{})
+	cbor.RegisterCborType(Transaction{})
+}
+
+// Actor is the builtin actor of accounts controlled by several signers. A
+// transaction proposed by one of them is sent once Threshold of them approve
+// it. The balance of the multisig may vest over a number of blocks.
+type Actor struct{}
+
+// Transaction is a message the multisig sends once enough signers approve it.
+type Transaction struct {
+	// ID is the transaction number.
+	ID uint64 `json:"id"`
+
+	// To is the recipient of the message.
+	To address.Address `json:"to"`
+
+	// Value is the amount sent with the message.
+	Value types.AttoFIL `json:"value"`
+
+	// Method is the method the message calls.
+	Method string `json:"method"`
+
+	// Params are the abi encoded parameters of the method.
+	Params []byte `json:"params"`
+
+	// Approved are the signers that approved the transaction, its proposer first.
+	Approved []address.Address `json:"approved"`
+}
+
+// State is the multisig's storage.
+type State struct {
+	// Signers are the addresses that may propose and approve transactions.
+	Signers []address.Address `json:"signers"`
+
+	// Threshold is the number of signers that have to approve a transaction.
+	Threshold uint64 `json:"threshold"`
+
+	// NextTxID is the id of the next proposed transaction.
+	NextTxID uint64 `json:"nextTxID"`
+
+	// Transactions are the transactions pending approval.
+	Transactions []*Transaction `json:"transactions"`
+
+	// InitialBalance is the balance the multisig was created with, which
+	// vests over UnlockDuration blocks from StartHeight.
+	InitialBalance types.AttoFIL `json:"initialBalance"`
+
+	// StartHeight is the block height the multisig was created at.
+	StartHeight *types.BlockHeight `json:"startHeight"`
+
+	// UnlockDuration is the number of blocks the initial balance vests over,
+	// zero for none.
+	UnlockDuration *types.BlockHeight `json:"unlockDuration"`
+}
+
+// NewState returns the state of a multisig created at startHeight with the
+// initial balance, vesting over unlockDuration blocks.
+func NewState(signers []address.Address, threshold uint64, initialBalance types.AttoFIL, startHeight, unlockDuration *types.BlockHeight) *State {
+	return &State{
+		Signers:        signers,
+		Threshold:      threshold,
+		Transactions:   []*Transaction{},
+		InitialBalance: initialBalance,
+		StartHeight:    startHeight,
+		UnlockDuration: unlockDuration,
+	}
+}
+
+// IsSigner returns whether the address is a signer of the multisig.
+func (st *State) IsSigner(addr address.Address) bool {
+	for _, signer := range st.Signers {
+		if signer == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Transaction returns the pending transaction with the given id, or nil.
+func (st *State) Transaction(id uint64) *Transaction {
+	for _, tx := range st.Transactions {
+		if tx.ID == id {
+			return tx
+		}
+	}
+	return nil
+}
+
+// LockedBalance returns the part of the initial balance that has not vested
+// at the given block height.
+func (st *State) LockedBalance(height *types.BlockHeight) types.AttoFIL {
+	if st.UnlockDuration == nil || st.UnlockDuration.Equal(types.NewBlockHeight(0)) {
+		return types.ZeroAttoFIL
+	}
+
+	elapsed := height.Sub(st.StartHeight)
+	if elapsed.GreaterEqual(st.UnlockDuration) {
+		return types.ZeroAttoFIL
+	}
+
+	remaining := st.UnlockDuration.Sub(elapsed)
+	return st.InitialBalance.MulBigInt(remaining.AsBigInt()).DivCeil(types.NewAttoFIL(st.UnlockDuration.AsBigInt()))
+}
+
+func (st *State) removeTransaction(id uint64) {
+	for i, tx := range st.Transactions {
+		if tx.ID == id {
+			st.Transactions = append(st.Transactions[:i], st.Transactions[i+1:]...)
+			return
+		}
+	}
+}
+
+// NewActor returns a new multisig actor.
+func NewActor() *actor.Actor {
+	return actor.NewActor(types.MultisigActorCodeCid, types.ZeroAttoFIL)
+}
+
+// InitializeState stores the multisig's initial state.
+func (msa *Actor) InitializeState(storage exec.Storage, initializerData interface{}) error {
+	multisigState, ok := initializerData.(*State)
+	if !ok {
+		return errors.NewFaultError("Initial state to multisig actor is not a multisig.State struct")
+	}
+
+	stateBytes, err := cbor.DumpObject(multisigState)
+	if err != nil {
+		return err
+	}
+
+	id, err := storage.Put(stateBytes)
+	if err != nil {
+		return err
+	}
+
+	return storage.Commit(id, cid.Undef)
+}
+
+var _ exec.ExecutableActor = (*Actor)(nil)
+
+// Exports returns the actor's exports.
+func (msa *Actor) Exports() exec.Exports {
+	return multisigExports
+}
+
+var multisigExports = exec.Exports{
+	"propose": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.AttoFIL, abi.String, abi.Bytes},
+		Return: []abi.Type{abi.Uint64},
+	},
+	"approve": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Uint64},
+		Return: nil,
+	},
+	"cancel": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Uint64},
+		Return: nil,
+	},
+	"addSigner": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.Boolean},
+		Return: nil,
+	},
+	"removeSigner": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Address, abi.Boolean},
+		Return: nil,
+	},
+	"changeThreshold": &exec.FunctionSignature{
+		Params: []abi.Type{abi.Uint64},
+		Return: nil,
+	},
+	"getState": &exec.FunctionSignature{
+		Params: nil,
+		Return: []abi.Type{abi.Bytes},
+	},
+}
+
+// Propose proposes a transaction, approved by its proposer, and sends it
+// right away if that is enough. It returns the id of the transaction.
+func (msa *Actor) Propose(vmctx exec.VMContext, to address.Address, value types.AttoFIL, method string, params []byte) (uint64, uint8, error) {
+	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+		return 0, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	out, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		proposer := vmctx.Message().From
+		if !state.IsSigner(proposer) {
+			return nil, Errors[ErrNotSigner]
+		}
+
+		tx := &Transaction{
+			ID:       state.NextTxID,
+			To:       to,
+			Value:    value,
+			Method:   method,
+			Params:   params,
+			Approved: []address.Address{proposer},
+		}
+		state.NextTxID++
+		state.Transactions = append(state.Transactions, tx)
+
+		if err := executeIfApproved(vmctx, &state, tx); err != nil {
+			return nil, err
+		}
+		return tx.ID, nil
+	})
+	if err != nil {
+		return 0, errors.CodeError(err), err
+	}
+
+	return out.(uint64), 0, nil
+}
+
+// Approve approves a pending transaction, sending it once Threshold signers
+// approved it.
+func (msa *Actor) Approve(vmctx exec.VMContext, id uint64) (uint8, error) {
+	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		signer := vmctx.Message().From
+		if !state.IsSigner(signer) {
+			return nil, Errors[ErrNotSigner]
+		}
+
+		tx := state.Transaction(id)
+		if tx == nil {
+			return nil, Errors[ErrUnknownTransaction]
+		}
+		for _, approver := range tx.Approved {
+			if approver == signer {
+				return nil, Errors[ErrAlreadyApproved]
+			}
+		}
+
+		tx.Approved = append(tx.Approved, signer)
+		return nil, executeIfApproved(vmctx, &state, tx)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// Cancel drops a pending transaction. Only its proposer may cancel it.
+func (msa *Actor) Cancel(vmctx exec.VMContext, id uint64) (uint8, error) {
+	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	_, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		tx := state.Transaction(id)
+		if tx == nil {
+			return nil, Errors[ErrUnknownTransaction]
+		}
+		if tx.Approved[0] != vmctx.Message().From {
+			return nil, Errors[ErrNotProposer]
+		}
+
+		state.removeTransaction(id)
+		return nil, nil
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// AddSigner adds a signer, raising the threshold by one if increase is set.
+// It may only be called through a transaction of the multisig.
+func (msa *Actor) AddSigner(vmctx exec.VMContext, signer address.Address, increase bool) (uint8, error) {
+	return msa.updateSelf(vmctx, func(state *State) error {
+		return addSigner(state, signer, increase)
+	})
+}
+
+// RemoveSigner removes a signer, lowering the threshold by one if decrease is
+// set. It may only be called through a transaction of the multisig.
+func (msa *Actor) RemoveSigner(vmctx exec.VMContext, signer address.Address, decrease bool) (uint8, error) {
+	return msa.updateSelf(vmctx, func(state *State) error {
+		return removeSigner(state, signer, decrease)
+	})
+}
+
+// ChangeThreshold sets the number of signers that have to approve a
+// transaction. It may only be called through a transaction of the multisig.
+func (msa *Actor) ChangeThreshold(vmctx exec.VMContext, threshold uint64) (uint8, error) {
+	return msa.updateSelf(vmctx, func(state *State) error {
+		return changeThreshold(state, threshold)
+	})
+}
+
+// GetState returns the cbor encoded state of the multisig.
+func (msa *Actor) GetState(vmctx exec.VMContext) ([]byte, uint8, error) {
+	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	if err := actor.ReadState(vmctx, &state); err != nil {
+		return nil, errors.CodeError(err), err
+	}
+
+	out, err := cbor.DumpObject(state)
+	if err != nil {
+		return nil, 1, errors.FaultErrorWrap(err, "failed to encode multisig state")
+	}
+	return out, 0, nil
+}
+
+// updateSelf applies a change only the multisig itself may make. The VM does
+// not let actors send messages to themselves, so approved transactions of the
+// multisig to itself are applied without going through here; a message
+// calling these methods is therefore always rejected, unless its sender is
+// the multisig.
+func (msa *Actor) updateSelf(vmctx exec.VMContext, update func(*State) error) (uint8, error) {
+	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	if vmctx.Message().From != vmctx.Message().To {
+		return errors.CodeError(Errors[ErrNotSelf]), Errors[ErrNotSelf]
+	}
+
+	var state State
+	_, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		return nil, update(&state)
+	})
+	if err != nil {
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
+// executeIfApproved sends the transaction and drops it once Threshold signers
+// approved it.
+func executeIfApproved(vmctx exec.VMContext, state *State, tx *Transaction) error {
+	if uint64(len(tx.Approved)) < state.Threshold {
+		return nil
+	}
+
+	if tx.Value.GreaterThan(types.ZeroAttoFIL) {
+		spendable := vmctx.MyBalance().Sub(state.LockedBalance(vmctx.BlockHeight()))
+		if tx.Value.GreaterThan(spendable) {
+			return Errors[ErrFundsLocked]
+		}
+	}
+
+	state.removeTransaction(tx.ID)
+
+	if tx.To == vmctx.Message().To {
+		return applySelf(state, tx)
+	}
+
+	// The params are forwarded as the raw bytes they were encoded to, which
+	// the abi encodes unchanged, so that the recipient decodes them as the
+	// proposer encoded them.
+	var encoded [][]byte
+	if len(tx.Params) > 0 {
+		if err := cbor.DecodeInto(tx.Params, &encoded); err != nil {
+			return errors.NewRevertErrorf("invalid transaction params: %s", err)
+		}
+	}
+	params := make([]interface{}, len(encoded))
+	for i, param := range encoded {
+		params[i] = param
+	}
+
+	_, _, err := vmctx.Send(tx.To, tx.Method, tx.Value, params)
+	return err
+}
+
+// applySelf applies an approved transaction of the multisig to itself.
+func applySelf(state *State, tx *Transaction) error {
+	signature, ok := multisigExports[tx.Method]
+	if !ok || tx.Method == "propose" || tx.Method == "approve" || tx.Method == "cancel" || tx.Method == "getState" {
+		if tx.Method == "" {
+			// plain transfer to itself
+			return nil
+		}
+		return errors.NewRevertErrorf("multisig cannot call %q on itself", tx.Method)
+	}
+
+	values, err := abi.DecodeValues(tx.Params, signature.Params)
+	if err != nil {
+		return errors.NewRevertErrorf("invalid %s params: %s", tx.Method, err)
+	}
+
+	switch tx.Method {
+	case "addSigner":
+		return addSigner(state, values[0].Val.(address.Address), values[1].Val.(bool))
+	case "removeSigner":
+		return removeSigner(state, values[0].Val.(address.Address), values[1].Val.(bool))
+	default: // changeThreshold
+		return changeThreshold(state, values[0].Val.(uint64))
+	}
+}
+
+func addSigner(state *State, signer address.Address, increase bool) error {
+	if state.IsSigner(signer) {
+		return Errors[ErrDuplicateSigner]
+	}
+
+	state.Signers = append(state.Signers, signer)
+	if increase {
+		state.Threshold++
+	}
+	return nil
+}
+
+func removeSigner(state *State, signer address.Address, decrease bool) error {
+	if !state.IsSigner(signer) {
+		return Errors[ErrNotSigner]
+	}
+
+	threshold := state.Threshold
+	if decrease {
+		threshold--
+	}
+	if threshold < 1 || threshold > uint64(len(state.Signers)-1) {
+		return Errors[ErrInvalidThreshold]
+	}
+
+	for i, s := range state.Signers {
+		if s == signer {
+			state.Signers = append(state.Signers[:i], state.Signers[i+1:]...)
+			break
+		}
+	}
+	state.Threshold = threshold
+
+	// Approvals of a removed signer no longer count. Its proposals are
+	// dropped, since no one else may cancel them.
+	var pending []*Transaction
+	for _, tx := range state.Transactions {
+		if tx.Approved[0] == signer {
+			continue
+		}
+		var approved []address.Address
+		for _, approver := range tx.Approved {
+			if approver != signer {
+				approved = append(approved, approver)
+			}
+		}
+		tx.Approved = approved
+		pending = append(pending, tx)
+	}
+	state.Transactions = pending
+	return nil
+}
+
+func changeThreshold(state *State, threshold uint64) error {
+	if threshold < 1 || threshold > uint64(len(state.Signers)) {
+		return Errors[ErrInvalidThreshold]
+	}
+
+	state.Threshold = threshold
+	return nil
+}
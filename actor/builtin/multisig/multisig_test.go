@@ -0,0 +1,283 @@
+package multisig_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	. "github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+func TestMultisigCreate(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := th.RequireCreateStorages(ctx, t)
+	signers := []address.Address{address.TestAddress, address.TestAddress2, address.NewForTestGetter()()}
+
+	msAddr := requireCreateMultisig(t, st, vms, signers, 2, 0)
+
+	ms, err := st.GetActor(ctx, msAddr)
+	require.NoError(t, err)
+	assert.Equal(t, types.NewAttoFILFromFIL(100), ms.Balance)
+
+	state := requireMultisigState(t, st, vms, msAddr)
+	assert.Equal(t, signers, state.Signers)
+	assert.Equal(t, uint64(2), state.Threshold)
+	assert.Empty(t, state.Transactions)
+
+	t.Run("rejects invalid thresholds", func(t *testing.T) {
+		for _, threshold := range []uint64{0, 4} {
+			result, err := th.CreateAndApplyTestMessage(t, st, vms, address.MultisigFactoryAddress, 100, 0, "createMultisig", nil, signers, threshold, types.NewBlockHeight(0))
+			require.NoError(t, err)
+			assert.Equal(t, uint8(ErrInvalidThreshold), result.Receipt.ExitCode)
+		}
+	})
+
+	t.Run("rejects duplicate signers", func(t *testing.T) {
+		dup := []address.Address{address.TestAddress, address.TestAddress}
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, address.MultisigFactoryAddress, 100, 0, "createMultisig", nil, dup, uint64(1), types.NewBlockHeight(0))
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrDuplicateSigner), result.Receipt.ExitCode)
+	})
+}
+
+func TestMultisigProposeApprove(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := th.RequireCreateStorages(ctx, t)
+	newAddr := address.NewForTestGetter()
+	recipient := newAddr()
+	signers := []address.Address{address.TestAddress, address.TestAddress2, newAddr()}
+	msAddr := requireCreateMultisig(t, st, vms, signers, 2, 0)
+
+	// the proposal alone does not meet the threshold
+	result, err := th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress, msAddr, 0, 0, "propose", nil, recipient, types.NewAttoFILFromFIL(10), "", []byte{})
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+	txID, err := abi.Deserialize(result.Receipt.Return[0], abi.Uint64)
+	require.NoError(t, err)
+
+	state := requireMultisigState(t, st, vms, msAddr)
+	require.Len(t, state.Transactions, 1)
+	assert.Equal(t, []address.Address{address.TestAddress}, state.Transactions[0].Approved)
+
+	t.Run("only signers approve", func(t *testing.T) {
+		result, err := th.CreateAndApplyTestMessageFrom(t, st, vms, address.NetworkAddress, msAddr, 0, 0, "approve", nil, txID.Val)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrNotSigner), result.Receipt.ExitCode)
+	})
+
+	t.Run("signers approve once", func(t *testing.T) {
+		result, err := th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress, msAddr, 0, 0, "approve", nil, txID.Val)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrAlreadyApproved), result.Receipt.ExitCode)
+	})
+
+	t.Run("approval meeting the threshold sends the transaction", func(t *testing.T) {
+		result, err := th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress2, msAddr, 0, 0, "approve", nil, txID.Val)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		to, err := st.GetActor(ctx, recipient)
+		require.NoError(t, err)
+		assert.Equal(t, types.NewAttoFILFromFIL(10), to.Balance)
+
+		ms, err := st.GetActor(ctx, msAddr)
+		require.NoError(t, err)
+		assert.Equal(t, types.NewAttoFILFromFIL(90), ms.Balance)
+
+		assert.Empty(t, requireMultisigState(t, st, vms, msAddr).Transactions)
+	})
+
+	t.Run("unknown transactions are rejected", func(t *testing.T) {
+		result, err := th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress2, msAddr, 0, 0, "approve", nil, txID.Val)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrUnknownTransaction), result.Receipt.ExitCode)
+	})
+}
+
+func TestMultisigCancel(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := th.RequireCreateStorages(ctx, t)
+	signers := []address.Address{address.TestAddress, address.TestAddress2}
+	msAddr := requireCreateMultisig(t, st, vms, signers, 2, 0)
+
+	result, err := th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress, msAddr, 0, 0, "propose", nil, address.NetworkAddress, types.NewAttoFILFromFIL(10), "", []byte{})
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+	txID, err := abi.Deserialize(result.Receipt.Return[0], abi.Uint64)
+	require.NoError(t, err)
+
+	result, err = th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress2, msAddr, 0, 0, "cancel", nil, txID.Val)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(ErrNotProposer), result.Receipt.ExitCode)
+
+	result, err = th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress, msAddr, 0, 0, "cancel", nil, txID.Val)
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+	assert.Empty(t, requireMultisigState(t, st, vms, msAddr).Transactions)
+}
+
+func TestMultisigManagesSigners(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := th.RequireCreateStorages(ctx, t)
+	// the new signer has to be funded to send its approval
+	newSigner := address.TestAddress2
+	msAddr := requireCreateMultisig(t, st, vms, []address.Address{address.TestAddress}, 1, 0)
+
+	t.Run("signers cannot change the multisig directly", func(t *testing.T) {
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, msAddr, 0, 0, "addSigner", nil, newSigner, true)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrNotSelf), result.Receipt.ExitCode)
+	})
+
+	t.Run("transactions of the multisig to itself change it", func(t *testing.T) {
+		params, err := abi.ToEncodedValues(newSigner, true)
+		require.NoError(t, err)
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, msAddr, 0, 0, "propose", nil, msAddr, types.ZeroAttoFIL, "addSigner", params)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		state := requireMultisigState(t, st, vms, msAddr)
+		assert.Equal(t, []address.Address{address.TestAddress, newSigner}, state.Signers)
+		assert.Equal(t, uint64(2), state.Threshold)
+		assert.Empty(t, state.Transactions)
+	})
+
+	t.Run("the threshold cannot exceed the signers", func(t *testing.T) {
+		params, err := abi.ToEncodedValues(uint64(3))
+		require.NoError(t, err)
+		result, err := th.CreateAndApplyTestMessage(t, st, vms, msAddr, 0, 0, "propose", nil, msAddr, types.ZeroAttoFIL, "changeThreshold", params)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+		txID, err := abi.Deserialize(result.Receipt.Return[0], abi.Uint64)
+		require.NoError(t, err)
+
+		result, err = th.CreateAndApplyTestMessageFrom(t, st, vms, newSigner, msAddr, 0, 0, "approve", nil, txID.Val)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(ErrInvalidThreshold), result.Receipt.ExitCode)
+	})
+}
+
+func TestMultisigRemoveSignerPrunesApprovals(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := th.RequireCreateStorages(ctx, t)
+	removed := address.TestAddress2
+	// the network actor stands in for a third signer able to send approvals
+	signers := []address.Address{address.TestAddress, removed, address.NetworkAddress}
+	msAddr := requireCreateMultisig(t, st, vms, signers, 3, 0)
+
+	propose := func(from address.Address, method string, params []byte) uint64 {
+		result, err := th.CreateAndApplyTestMessageFrom(t, st, vms, from, msAddr, 0, 0, "propose", nil, msAddr, types.ZeroAttoFIL, method, params)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+		txID, err := abi.Deserialize(result.Receipt.Return[0], abi.Uint64)
+		require.NoError(t, err)
+		return txID.Val.(uint64)
+	}
+	approve := func(from address.Address, txID uint64) {
+		result, err := th.CreateAndApplyTestMessageFrom(t, st, vms, from, msAddr, 0, 0, "approve", nil, txID)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+	}
+
+	// one transaction proposed by the removed signer, one it approved
+	proposedByRemoved := propose(removed, "", []byte{})
+	approve(address.TestAddress, proposedByRemoved)
+	approvedByRemoved := propose(address.TestAddress, "", []byte{})
+	approve(removed, approvedByRemoved)
+
+	params, err := abi.ToEncodedValues(removed, true)
+	require.NoError(t, err)
+	removal := propose(address.TestAddress, "removeSigner", params)
+	approve(address.NetworkAddress, removal)
+	approve(removed, removal)
+
+	state := requireMultisigState(t, st, vms, msAddr)
+	assert.Equal(t, []address.Address{address.TestAddress, address.NetworkAddress}, state.Signers)
+	assert.Equal(t, uint64(2), state.Threshold)
+
+	// the proposal of the removed signer is dropped, and its approval of
+	// the other transaction no longer counts
+	require.Len(t, state.Transactions, 1)
+	assert.Equal(t, approvedByRemoved, state.Transactions[0].ID)
+	assert.Equal(t, []address.Address{address.TestAddress}, state.Transactions[0].Approved)
+}
+
+func TestMultisigVesting(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	st, vms := th.RequireCreateStorages(ctx, t)
+	recipient := address.NewForTestGetter()()
+	msAddr := requireCreateMultisig(t, st, vms, []address.Address{address.TestAddress}, 1, 10)
+
+	// half of the balance vested at height 5
+	result, err := th.CreateAndApplyTestMessage(t, st, vms, msAddr, 0, 5, "propose", nil, recipient, types.NewAttoFILFromFIL(51), "", []byte{})
+	require.NoError(t, err)
+	assert.Equal(t, uint8(ErrFundsLocked), result.Receipt.ExitCode)
+
+	result, err = th.CreateAndApplyTestMessage(t, st, vms, msAddr, 0, 5, "propose", nil, recipient, types.NewAttoFILFromFIL(50), "", []byte{})
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+
+	// all of it vested at height 10
+	result, err = th.CreateAndApplyTestMessage(t, st, vms, msAddr, 0, 10, "propose", nil, recipient, types.NewAttoFILFromFIL(50), "", []byte{})
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+
+	to, err := st.GetActor(ctx, recipient)
+	require.NoError(t, err)
+	assert.Equal(t, types.NewAttoFILFromFIL(100), to.Balance)
+}
+
+func TestStateLockedBalance(t *testing.T) {
+	tf.UnitTest(t)
+
+	state := NewState(nil, 1, types.NewAttoFILFromFIL(100), types.NewBlockHeight(10), types.NewBlockHeight(4))
+	assert.Equal(t, types.NewAttoFILFromFIL(100), state.LockedBalance(types.NewBlockHeight(10)))
+	assert.Equal(t, types.NewAttoFILFromFIL(75), state.LockedBalance(types.NewBlockHeight(11)))
+	assert.Equal(t, types.NewAttoFILFromFIL(25), state.LockedBalance(types.NewBlockHeight(13)))
+	assert.Equal(t, types.ZeroAttoFIL, state.LockedBalance(types.NewBlockHeight(14)))
+	assert.Equal(t, types.ZeroAttoFIL, state.LockedBalance(types.NewBlockHeight(100)))
+
+	state = NewState(nil, 1, types.NewAttoFILFromFIL(100), types.NewBlockHeight(10), types.NewBlockHeight(0))
+	assert.Equal(t, types.ZeroAttoFIL, state.LockedBalance(types.NewBlockHeight(10)))
+}
+
+func requireCreateMultisig(t *testing.T, st state.Tree, vms vm.StorageMap, signers []address.Address, threshold, unlockDuration uint64) address.Address {
+	result, err := th.CreateAndApplyTestMessageFrom(t, st, vms, address.TestAddress2, address.MultisigFactoryAddress, 100, 0, "createMultisig", nil, signers, threshold, types.NewBlockHeight(unlockDuration))
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+
+	msAddr, err := address.NewFromBytes(result.Receipt.Return[0])
+	require.NoError(t, err)
+	return msAddr
+}
+
+func requireMultisigState(t *testing.T, st state.Tree, vms vm.StorageMap, msAddr address.Address) *State {
+	ms, err := st.GetActor(context.Background(), msAddr)
+	require.NoError(t, err)
+
+	var state State
+	builtin.RequireReadState(t, vms, msAddr, ms, &state)
+	return &state
+}
@@ -3,6 +3,7 @@ package paymentbroker
 import (
 	"context"
 
+	"github.com/filecoin-project/go-leb128"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-hamt-ipld"
 	cbor "github.com/ipfs/go-ipld-cbor"
@@ -42,6 +43,10 @@ const (
 	ErrConditionInvalid = 44
 	//ErrInvalidCancel indicates that the condition attached to a voucher did execute successfully and therefore can't be cancelled
 	ErrInvalidCancel = 45
+	// ErrStaleNonce indicates a voucher nonce is lower than the nonce of its lane.
+	ErrStaleNonce = 46
+	// ErrInvalidMerge indicates a voucher merges its own lane or the same lane twice.
+	ErrInvalidMerge = 47
 )
 
 // CancelDelayBlockTime is the number of rounds given to the target to respond after the channel
@@ -63,10 +68,27 @@ var Errors = map[uint8]error{
 	ErrExpired:                  errors.NewCodedRevertError(ErrExpired, "block height has exceeded channel's end of life"),
 	ErrAlreadyWithdrawn:         errors.NewCodedRevertError(ErrAlreadyWithdrawn, "update amount has already been redeemed"),
 	ErrInvalidSignature:         errors.NewCodedRevertErrorf(ErrInvalidSignature, "signature failed to validate"),
+	ErrStaleNonce:               errors.NewCodedRevertError(ErrStaleNonce, "voucher nonce is lower than the nonce of its lane"),
+	ErrInvalidMerge:             errors.NewCodedRevertError(ErrInvalidMerge, "voucher merges are invalid"),
 }
 
 func init() {
 	cbor.RegisterCborType(PaymentChannel{})
+	cbor.RegisterCborType(LaneState{})
+}
+
+// LaneState is the redemption state of one lane of a payment channel.
+type LaneState struct {
+	// ID is the lane number.
+	ID uint64 `json:"id"`
+
+	// Nonce is the lowest nonce of the vouchers that can still be redeemed on
+	// the lane.
+	Nonce uint64 `json:"nonce"`
+
+	// AmountRedeemed is the amount of FIL transferred to the target through
+	// vouchers of this lane, including the funds of lanes merged into it.
+	AmountRedeemed types.AttoFIL `json:"amount_redeemed"`
 }
 
 // PaymentChannel records the intent to pay funds to a target account.
@@ -78,8 +100,12 @@ type PaymentChannel struct {
 	Amount types.AttoFIL `json:"amount"`
 
 	// AmountRedeemed is the amount of FIL already transferred to the target
+	// across all lanes
 	AmountRedeemed types.AttoFIL `json:"amount_redeemed"`
 
+	// Lanes are the states of the lanes vouchers have been redeemed on
+	Lanes []*LaneState `json:"lanes"`
+
 	// AgreedEol is the expiration for the payment channel agreed upon by the
 	// payer and payee upon initialization or extension
 	AgreedEol *types.BlockHeight `json:"agreed_eol"`
@@ -98,6 +124,36 @@ type PaymentChannel struct {
 	Redeemed bool `json:"redeemed"`
 }
 
+// Lane returns the state of the given lane, or nil if no voucher has been
+// redeemed on it.
+func (pc *PaymentChannel) Lane(id uint64) *LaneState {
+	for _, lane := range pc.Lanes {
+		if lane.ID == id {
+			return lane
+		}
+	}
+	return nil
+}
+
+// LaneRedeemed returns the amount redeemed through the given lane.
+func (pc *PaymentChannel) LaneRedeemed(id uint64) types.AttoFIL {
+	if lane := pc.Lane(id); lane != nil {
+		return lane.AmountRedeemed
+	}
+	return types.ZeroAttoFIL
+}
+
+// laneForUpdate returns the state of the given lane, adding it to the
+// channel if no voucher has been redeemed on it yet.
+func (pc *PaymentChannel) laneForUpdate(id uint64) *LaneState {
+	if lane := pc.Lane(id); lane != nil {
+		return lane
+	}
+	lane := &LaneState{ID: id, AmountRedeemed: types.ZeroAttoFIL}
+	pc.Lanes = append(pc.Lanes, lane)
+	return lane
+}
+
 // Actor provides a mechanism for off chain payments.
 // It allows the creation of payment channels that hold funds for a target account
 // and permits that account to withdraw funds only with a voucher signed by the
@@ -123,12 +179,16 @@ func (pb *Actor) Exports() exec.Exports {
 var _ exec.ExecutableActor = (*Actor)(nil)
 
 var paymentBrokerExports = exec.Exports{
+	"addFunds": &exec.FunctionSignature{
+		Params: []abi.Type{abi.ChannelID},
+		Return: nil,
+	},
 	"cancel": &exec.FunctionSignature{
 		Params: []abi.Type{abi.ChannelID},
 		Return: nil,
 	},
 	"close": &exec.FunctionSignature{
-		Params: []abi.Type{abi.Address, abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Predicate, abi.Bytes, abi.Parameters},
+		Params: []abi.Type{abi.Address, abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Uint64, abi.Uint64, abi.VoucherMerges, abi.Predicate, abi.Bytes, abi.Parameters},
 		Return: nil,
 	},
 	"createChannel": &exec.FunctionSignature{
@@ -148,7 +208,7 @@ var paymentBrokerExports = exec.Exports{
 		Return: nil,
 	},
 	"redeem": &exec.FunctionSignature{
-		Params: []abi.Type{abi.Address, abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Predicate, abi.Bytes, abi.Parameters},
+		Params: []abi.Type{abi.Address, abi.ChannelID, abi.AttoFIL, abi.BlockHeight, abi.Uint64, abi.Uint64, abi.VoucherMerges, abi.Predicate, abi.Bytes, abi.Parameters},
 		Return: nil,
 	},
 	"voucher": &exec.FunctionSignature{
@@ -223,18 +283,25 @@ func (pb *Actor) CreateChannel(vmctx exec.VMContext, target address.Address, eol
 // target Redeem(200)          -> Payer: 1000, Target: 200, Channel: 800
 // target Close(500)           -> Payer: 1500, Target: 500, Channel: 0
 //
+// The amt is tracked per lane: a channel can carry several independent series of
+// vouchers, one per lane, whose amounts add up. A voucher may not have a lower
+// nonce than the last voucher redeemed on its lane, and may merge other lanes
+// into its own, in which case amt includes the funds already redeemed through
+// them.
+//
 // If a condition is provided in the voucher:
 // - The parameters provided in the condition will be combined with redeemerConditionParams
 // - A message will be sent to the the condition.To address using the condition.Method with the combined params
 // - If the message returns an error the condition is considered to be false and the redeem will fail
 func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types.ChannelID, amt types.AttoFIL,
-	validAt *types.BlockHeight, condition *types.Predicate, sig []byte, redeemerConditionParams []interface{}) (uint8, error) {
+	validAt *types.BlockHeight, lane uint64, nonce uint64, merges []types.VoucherMerge, condition *types.Predicate,
+	sig []byte, redeemerConditionParams []interface{}) (uint8, error) {
 
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(actor.DefaultGasCost + vmctx.GasSchedule().VerifySignature); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	if !VerifyVoucherSignature(payer, chid, amt, validAt, condition, sig) {
+	if !VerifyVoucherSignature(payer, chid, amt, validAt, lane, nonce, merges, condition, sig) {
 		return errors.CodeError(Errors[ErrInvalidSignature]), Errors[ErrInvalidSignature]
 	}
 
@@ -256,7 +323,7 @@ func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types
 		}
 
 		// validate the amount can be sent to the target and send payment to that address.
-		err = validateAndUpdateChannel(vmctx, vmctx.Message().From, channel, amt, validAt, condition, redeemerConditionParams)
+		err = validateAndUpdateChannel(vmctx, vmctx.Message().From, channel, amt, validAt, lane, nonce, merges, condition, redeemerConditionParams)
 		if err != nil {
 			return err
 		}
@@ -290,13 +357,14 @@ func (pb *Actor) Redeem(vmctx exec.VMContext, payer address.Address, chid *types
 // - A message will be sent to the the condition.To address using the condition.Method with the combined params
 // - If the message returns an error the condition is considered to be false and the redeem will fail
 func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.ChannelID, amt types.AttoFIL,
-	validAt *types.BlockHeight, condition *types.Predicate, sig []byte, redeemerConditionParams []interface{}) (uint8, error) {
+	validAt *types.BlockHeight, lane uint64, nonce uint64, merges []types.VoucherMerge, condition *types.Predicate,
+	sig []byte, redeemerConditionParams []interface{}) (uint8, error) {
 
-	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+	if err := vmctx.Charge(actor.DefaultGasCost + vmctx.GasSchedule().VerifySignature); err != nil {
 		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
 	}
 
-	if !VerifyVoucherSignature(payer, chid, amt, validAt, condition, sig) {
+	if !VerifyVoucherSignature(payer, chid, amt, validAt, lane, nonce, merges, condition, sig) {
 		return errors.CodeError(Errors[ErrInvalidSignature]), Errors[ErrInvalidSignature]
 	}
 
@@ -318,7 +386,7 @@ func (pb *Actor) Close(vmctx exec.VMContext, payer address.Address, chid *types.
 		}
 
 		// validate the amount can be sent to the target and send payment to that address.
-		err = validateAndUpdateChannel(vmctx, vmctx.Message().From, channel, amt, validAt, condition, redeemerConditionParams)
+		err = validateAndUpdateChannel(vmctx, vmctx.Message().From, channel, amt, validAt, lane, nonce, merges, condition, redeemerConditionParams)
 		if err != nil {
 			return err
 		}
@@ -394,6 +462,53 @@ func (pb *Actor) Extend(vmctx exec.VMContext, chid *types.ChannelID, eol *types.
 	return 0, nil
 }
 
+// AddFunds can be used by the owner of a channel to add the value of the
+// message to the channel's funds without changing its lifespan. Vouchers
+// may be issued and redeemed up to the new total.
+func (pb *Actor) AddFunds(vmctx exec.VMContext, chid *types.ChannelID) (uint8, error) {
+	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+		return exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	ctx := context.Background()
+	storage := vmctx.Storage()
+	payerAddress := vmctx.Message().From
+
+	err := withPayerChannels(ctx, storage, payerAddress, func(byChannelID exec.Lookup) error {
+		chInt, err := byChannelID.Find(ctx, chid.KeyString())
+		if err != nil {
+			if err == hamt.ErrNotFound {
+				return Errors[ErrUnknownChannel]
+			}
+			return errors.FaultErrorWrapf(err, "Could not retrieve payment channel with ID: %s", chid)
+		}
+
+		channel, ok := chInt.(*PaymentChannel)
+		if !ok {
+			return errors.NewFaultError("Expected PaymentChannel from channels lookup")
+		}
+
+		// funds added to an expired channel could never be redeemed
+		if vmctx.BlockHeight().GreaterEqual(channel.Eol) {
+			return Errors[ErrExpired]
+		}
+
+		channel.Amount = channel.Amount.Add(vmctx.Message().Value)
+
+		return byChannelID.Set(ctx, chid.KeyString(), channel)
+	})
+
+	if err != nil {
+		// ensure error is properly wrapped
+		if !errors.IsFault(err) && !errors.ShouldRevert(err) {
+			return 1, errors.FaultErrorWrap(err, "Error adding funds to channel")
+		}
+		return errors.CodeError(err), err
+	}
+
+	return 0, nil
+}
+
 // Cancel can be used to end an off chain payment early. It lowers the EOL of
 // the payment channel to 1 blocktime from now and allows a caller to reclaim
 // their payments. In the time before the channel is closed, a target can
@@ -621,7 +736,7 @@ func (pb *Actor) Ls(vmctx exec.VMContext, payer address.Address) ([]byte, uint8,
 	return channelsBytes, 0, nil
 }
 
-func validateAndUpdateChannel(ctx exec.VMContext, target address.Address, channel *PaymentChannel, amt types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate, redeemerSuppliedParams []interface{}) error {
+func validateAndUpdateChannel(ctx exec.VMContext, target address.Address, channel *PaymentChannel, amt types.AttoFIL, validAt *types.BlockHeight, lane uint64, nonce uint64, merges []types.VoucherMerge, condition *types.Predicate, redeemerSuppliedParams []interface{}) error {
 	cacheCondition(channel, condition, redeemerSuppliedParams)
 
 	if err := checkCondition(ctx, channel); err != nil {
@@ -640,23 +755,58 @@ func validateAndUpdateChannel(ctx exec.VMContext, target address.Address, channe
 		return Errors[ErrExpired]
 	}
 
-	if amt.GreaterThan(channel.Amount) {
-		return Errors[ErrInsufficientChannelFunds]
+	laneState := channel.laneForUpdate(lane)
+	if nonce < laneState.Nonce {
+		return Errors[ErrStaleNonce]
 	}
 
-	if amt.LessEqual(channel.AmountRedeemed) {
+	// the voucher amount covers the funds already redeemed through its lane
+	// and the lanes it merges
+	alreadyRedeemed := laneState.AmountRedeemed
+	mergedLanes := make([]*LaneState, 0, len(merges))
+	for _, merge := range merges {
+		if merge.Lane == lane {
+			return Errors[ErrInvalidMerge]
+		}
+		mergedLane := channel.laneForUpdate(merge.Lane)
+		for _, merged := range mergedLanes {
+			if merged == mergedLane {
+				return Errors[ErrInvalidMerge]
+			}
+		}
+		if merge.Nonce < mergedLane.Nonce {
+			return Errors[ErrStaleNonce]
+		}
+		alreadyRedeemed = alreadyRedeemed.Add(mergedLane.AmountRedeemed)
+		mergedLanes = append(mergedLanes, mergedLane)
+	}
+
+	if amt.LessEqual(alreadyRedeemed) {
 		return Errors[ErrAlreadyWithdrawn]
 	}
 
+	updateAmount := amt.Sub(alreadyRedeemed)
+	if channel.AmountRedeemed.Add(updateAmount).GreaterThan(channel.Amount) {
+		return Errors[ErrInsufficientChannelFunds]
+	}
+
 	// transfer funds to sender
-	updateAmount := amt.Sub(channel.AmountRedeemed)
 	_, _, err := ctx.Send(ctx.Message().From, "", updateAmount, nil)
 	if err != nil {
 		return err
 	}
 
-	// update amount redeemed from this channel
-	channel.AmountRedeemed = amt
+	// funds of merged lanes now belong to the voucher's lane, and the
+	// vouchers the merges supersede can no longer be redeemed
+	for i, mergedLane := range mergedLanes {
+		mergedLane.AmountRedeemed = types.ZeroAttoFIL
+		mergedLane.Nonce = merges[i].Nonce + 1
+	}
+
+	// update amounts redeemed from this lane and channel
+	laneState.Nonce = nonce
+	laneState.AmountRedeemed = amt
+	channel.AmountRedeemed = channel.AmountRedeemed.Add(updateAmount)
 
 	return nil
 }
@@ -687,10 +837,12 @@ func reclaim(ctx context.Context, vmctx exec.VMContext, byChannelID exec.Lookup,
 const separator = 0x0
 
 // SignVoucher creates the signature for the given combination of
-// channel, amount, validAt (earliest block height for redeem) and from address.
-// It does so by signing the following bytes: (channelID | 0x0 | amount | 0x0 | validAt)
-func SignVoucher(channelID *types.ChannelID, amount types.AttoFIL, validAt *types.BlockHeight, addr address.Address, condition *types.Predicate, signer types.Signer) (types.Signature, error) {
-	data, err := createVoucherSignatureData(channelID, amount, validAt, condition)
+// channel, amount, validAt (earliest block height for redeem), lane, nonce,
+// merged lanes and from address.
+// It does so by signing the following bytes:
+// (channelID | 0x0 | amount | 0x0 | condition | validAt | lane | nonce | merges)
+func SignVoucher(channelID *types.ChannelID, amount types.AttoFIL, validAt *types.BlockHeight, lane uint64, nonce uint64, merges []types.VoucherMerge, addr address.Address, condition *types.Predicate, signer types.Signer) (types.Signature, error) {
+	data, err := createVoucherSignatureData(channelID, amount, validAt, lane, nonce, merges, condition)
 	if err != nil {
 		return nil, err
 	}
@@ -698,8 +850,8 @@ func SignVoucher(channelID *types.ChannelID, amount types.AttoFIL, validAt *type
 }
 
 // VerifyVoucherSignature returns whether the voucher's signature is valid
-func VerifyVoucherSignature(payer address.Address, chid *types.ChannelID, amt types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate, sig []byte) bool {
-	data, err := createVoucherSignatureData(chid, amt, validAt, condition)
+func VerifyVoucherSignature(payer address.Address, chid *types.ChannelID, amt types.AttoFIL, validAt *types.BlockHeight, lane uint64, nonce uint64, merges []types.VoucherMerge, condition *types.Predicate, sig []byte) bool {
+	data, err := createVoucherSignatureData(chid, amt, validAt, lane, nonce, merges, condition)
 	// the only error is failure to encode the values
 	if err != nil {
 		return false
@@ -707,7 +859,7 @@ func VerifyVoucherSignature(payer address.Address, chid *types.ChannelID, amt ty
 	return types.IsValidSignature(data, payer, sig)
 }
 
-func createVoucherSignatureData(channelID *types.ChannelID, amount types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) ([]byte, error) {
+func createVoucherSignatureData(channelID *types.ChannelID, amount types.AttoFIL, validAt *types.BlockHeight, lane uint64, nonce uint64, merges []types.VoucherMerge, condition *types.Predicate) ([]byte, error) {
 	data := append(channelID.Bytes(), separator)
 	data = append(data, amount.Bytes()...)
 	data = append(data, separator)
@@ -720,7 +872,17 @@ func createVoucherSignatureData(channelID *types.ChannelID, amount types.AttoFIL
 		}
 		data = append(data, encodedParams...)
 	}
-	return append(data, validAt.Bytes()...), nil
+	data = append(data, validAt.Bytes()...)
+
+	// leb128 encoded values are self delimiting
+	data = append(data, leb128.FromUInt64(lane)...)
+	data = append(data, leb128.FromUInt64(nonce)...)
+	data = append(data, leb128.FromUInt64(uint64(len(merges)))...)
+	for _, merge := range merges {
+		data = append(data, leb128.FromUInt64(merge.Lane)...)
+		data = append(data, leb128.FromUInt64(merge.Nonce)...)
+	}
+	return data, nil
 }
 
 func withPayerChannels(ctx context.Context, storage exec.Storage, payer address.Address, f func(exec.Lookup) error) error {
@@ -105,6 +105,97 @@ func TestPaymentBrokerUpdate(t *testing.T) {
 	assert.Equal(t, sys.target, channel.Target)
 }
 
+func TestPaymentBrokerRedeemLanes(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("amounts of different lanes add up", func(t *testing.T) {
+		sys := setup(t)
+
+		result, err := sys.applyLaneRedeemMessage(100, 0, 0, nil, 0)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		result, err = sys.applyLaneRedeemMessage(300, 1, 0, nil, 1)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		payee := state.MustGetActor(sys.st, sys.target)
+		assert.Equal(t, types.NewAttoFILFromFIL(400), payee.Balance)
+
+		channel := sys.retrieveChannel(state.MustGetActor(sys.st, address.PaymentBrokerAddress))
+		assert.Equal(t, types.NewAttoFILFromFIL(400), channel.AmountRedeemed)
+		assert.Equal(t, types.NewAttoFILFromFIL(100), channel.LaneRedeemed(0))
+		assert.Equal(t, types.NewAttoFILFromFIL(300), channel.LaneRedeemed(1))
+	})
+
+	t.Run("refuses vouchers with a nonce lower than their lane", func(t *testing.T) {
+		sys := setup(t)
+
+		result, err := sys.applyLaneRedeemMessage(100, 0, 2, nil, 0)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		result, err = sys.applyLaneRedeemMessage(200, 0, 1, nil, 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, ErrStaleNonce, result.Receipt.ExitCode)
+	})
+
+	t.Run("refuses vouchers exceeding the channel funds across lanes", func(t *testing.T) {
+		sys := setup(t)
+
+		result, err := sys.applyLaneRedeemMessage(600, 0, 0, nil, 0)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		result, err = sys.applyLaneRedeemMessage(500, 1, 0, nil, 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, ErrInsufficientChannelFunds, result.Receipt.ExitCode)
+	})
+
+	t.Run("merges lanes", func(t *testing.T) {
+		sys := setup(t)
+
+		result, err := sys.applyLaneRedeemMessage(100, 0, 0, nil, 0)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		result, err = sys.applyLaneRedeemMessage(300, 1, 0, nil, 1)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		merges := []types.VoucherMerge{{Lane: 0, Nonce: 0}, {Lane: 1, Nonce: 0}}
+		result, err = sys.applyLaneRedeemMessage(500, 2, 0, merges, 2)
+		require.NoError(t, err)
+		require.NoError(t, result.ExecutionError)
+
+		payee := state.MustGetActor(sys.st, sys.target)
+		assert.Equal(t, types.NewAttoFILFromFIL(500), payee.Balance)
+
+		channel := sys.retrieveChannel(state.MustGetActor(sys.st, address.PaymentBrokerAddress))
+		assert.Equal(t, types.NewAttoFILFromFIL(500), channel.AmountRedeemed)
+		assert.Equal(t, types.NewAttoFILFromFIL(500), channel.LaneRedeemed(2))
+		assert.Equal(t, types.ZeroAttoFIL, channel.LaneRedeemed(1))
+		assert.Equal(t, uint64(1), channel.Lane(1).Nonce)
+
+		// vouchers superseded by the merge can no longer be redeemed
+		result, err = sys.applyLaneRedeemMessage(400, 1, 0, nil, 3)
+		require.NoError(t, err)
+		assert.EqualValues(t, ErrStaleNonce, result.Receipt.ExitCode)
+	})
+
+	t.Run("refuses invalid merges", func(t *testing.T) {
+		sys := setup(t)
+
+		result, err := sys.applyLaneRedeemMessage(100, 0, 0, []types.VoucherMerge{{Lane: 0, Nonce: 0}}, 0)
+		require.NoError(t, err)
+		assert.EqualValues(t, ErrInvalidMerge, result.Receipt.ExitCode)
+
+		result, err = sys.applyLaneRedeemMessage(100, 0, 0, []types.VoucherMerge{{Lane: 1, Nonce: 0}, {Lane: 1, Nonce: 1}}, 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, ErrInvalidMerge, result.Receipt.ExitCode)
+	})
+}
+
 func TestPaymentBrokerRedeemWithCondition(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -606,7 +697,8 @@ func TestPaymentBrokerCloseInvalidSig(t *testing.T) {
 	signature[1] = 1
 
 	var condition *types.Predicate
-	pdata := core.MustConvertParams(sys.payer, sys.channelID, amt, sys.defaultValidAt, condition, signature, []interface{}{})
+	var merges []types.VoucherMerge
+	pdata := core.MustConvertParams(sys.payer, sys.channelID, amt, sys.defaultValidAt, uint64(0), uint64(0), merges, condition, signature, []interface{}{})
 	msg := types.NewMessage(sys.target, address.PaymentBrokerAddress, 0, types.NewAttoFILFromFIL(0), "close", pdata)
 	res, err := sys.ApplyMessage(msg, 0)
 	require.EqualError(t, res.ExecutionError, Errors[ErrInvalidSignature].Error())
@@ -690,7 +782,8 @@ func TestPaymentBrokerRedeemInvalidSig(t *testing.T) {
 	signature[1] = 1
 
 	var condition *types.Predicate
-	pdata := core.MustConvertParams(sys.payer, sys.channelID, amt, sys.defaultValidAt, condition, signature, []interface{}{})
+	var merges []types.VoucherMerge
+	pdata := core.MustConvertParams(sys.payer, sys.channelID, amt, sys.defaultValidAt, uint64(0), uint64(0), merges, condition, signature, []interface{}{})
 	msg := types.NewMessage(sys.target, address.PaymentBrokerAddress, 0, types.NewAttoFILFromFIL(0), "redeem", pdata)
 	res, err := sys.ApplyMessage(msg, 0)
 	require.EqualError(t, res.ExecutionError, Errors[ErrInvalidSignature].Error())
@@ -805,6 +898,74 @@ func TestPaymentBrokerExtendRefusesToShortenTheEol(t *testing.T) {
 	assert.Contains(t, result.ExecutionError.Error(), "payment channel eol may not be decreased")
 }
 
+func TestPaymentBrokerAddFunds(t *testing.T) {
+	tf.UnitTest(t)
+
+	sys := setup(t)
+	var nilCondition *types.Predicate
+
+	// vouchers for more than the channel holds are refused
+	_, exitCode, err := sys.CallQueryMethod("voucher", 9, sys.channelID, types.NewAttoFILFromFIL(1500), sys.defaultValidAt, nilCondition)
+	assert.NotEqual(t, uint8(0), exitCode)
+	assert.Contains(t, fmt.Sprintf("%v", err), "exceeds amount")
+
+	// add funds
+	pdata := core.MustConvertParams(sys.channelID)
+	msg := types.NewMessage(sys.payer, address.PaymentBrokerAddress, 1, types.NewAttoFILFromFIL(500), "addFunds", pdata)
+
+	result, err := sys.ApplyMessage(msg, 9)
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+	assert.Equal(t, uint8(0), result.Receipt.ExitCode)
+
+	// vouchers up to the new total can be created and redeemed
+	_, exitCode, err = sys.CallQueryMethod("voucher", 9, sys.channelID, types.NewAttoFILFromFIL(1500), sys.defaultValidAt, nilCondition)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0), exitCode)
+
+	result, err = sys.ApplyRedeemMessageWithBlockHeight(sys.target, 1100, 0, 12)
+	require.NoError(t, err)
+	require.NoError(t, result.ExecutionError)
+	assert.Equal(t, uint8(0), result.Receipt.ExitCode)
+
+	paymentBroker := state.MustGetActor(sys.st, address.PaymentBrokerAddress)
+	assert.Equal(t, types.NewAttoFILFromFIL(400), paymentBroker.Balance) // 1000 + 500 - 1100
+
+	// the lifespan of the channel is unchanged
+	channel := sys.retrieveChannel(paymentBroker)
+	assert.Equal(t, types.NewAttoFILFromFIL(1500), channel.Amount)
+	assert.Equal(t, types.NewAttoFILFromFIL(1100), channel.AmountRedeemed)
+	assert.Equal(t, types.NewBlockHeight(20000), channel.Eol)
+}
+
+func TestPaymentBrokerAddFundsFailsWithNonExistentChannel(t *testing.T) {
+	tf.UnitTest(t)
+
+	sys := setup(t)
+
+	pdata := core.MustConvertParams(types.NewChannelID(383))
+	msg := types.NewMessage(sys.payer, address.PaymentBrokerAddress, 1, types.NewAttoFILFromFIL(500), "addFunds", pdata)
+
+	result, err := sys.ApplyMessage(msg, 9)
+	require.NoError(t, err)
+	require.EqualError(t, result.ExecutionError, "payment channel is unknown")
+	assert.NotEqual(t, uint8(0), result.Receipt.ExitCode)
+}
+
+func TestPaymentBrokerAddFundsFailsAfterEol(t *testing.T) {
+	tf.UnitTest(t)
+
+	sys := setup(t)
+
+	pdata := core.MustConvertParams(sys.channelID)
+	msg := types.NewMessage(sys.payer, address.PaymentBrokerAddress, 1, types.NewAttoFILFromFIL(500), "addFunds", pdata)
+
+	result, err := sys.ApplyMessage(msg, 20000)
+	require.NoError(t, err)
+	require.Error(t, result.ExecutionError)
+	assert.Contains(t, result.ExecutionError.Error(), "exceeded channel's end of life")
+}
+
 func TestPaymentBrokerCancel(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -1084,22 +1245,37 @@ func TestSignVoucher(t *testing.T) {
 		require := require.New(t)
 		assert := assert.New(t)
 
-		sig, err := SignVoucher(channelId, value, blockHeight, payer, nilCondition, mockSigner)
+		sig, err := SignVoucher(channelId, value, blockHeight, 0, 0, nil, payer, nilCondition, mockSigner)
 		require.NoError(err)
 
-		assert.True(VerifyVoucherSignature(payer, channelId, value, blockHeight, nilCondition, sig))
-		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, condition, sig))
+		assert.True(VerifyVoucherSignature(payer, channelId, value, blockHeight, 0, 0, nil, nilCondition, sig))
+		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, 0, 0, nil, condition, sig))
 	})
 
 	t.Run("validates signatures with condition", func(t *testing.T) {
 		require := require.New(t)
 		assert := assert.New(t)
 
-		sig, err := SignVoucher(channelId, value, blockHeight, payer, condition, mockSigner)
+		sig, err := SignVoucher(channelId, value, blockHeight, 0, 0, nil, payer, condition, mockSigner)
 		require.NoError(err)
 
-		assert.True(VerifyVoucherSignature(payer, channelId, value, blockHeight, condition, sig))
-		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, nilCondition, sig))
+		assert.True(VerifyVoucherSignature(payer, channelId, value, blockHeight, 0, 0, nil, condition, sig))
+		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, 0, 0, nil, nilCondition, sig))
+	})
+
+	t.Run("validates signatures with lanes and merges", func(t *testing.T) {
+		require := require.New(t)
+		assert := assert.New(t)
+
+		merges := []types.VoucherMerge{{Lane: 1, Nonce: 4}}
+		sig, err := SignVoucher(channelId, value, blockHeight, 2, 7, merges, payer, nilCondition, mockSigner)
+		require.NoError(err)
+
+		assert.True(VerifyVoucherSignature(payer, channelId, value, blockHeight, 2, 7, merges, nilCondition, sig))
+		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, 0, 7, merges, nilCondition, sig))
+		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, 2, 8, merges, nilCondition, sig))
+		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, 2, 7, nil, nilCondition, sig))
+		assert.False(VerifyVoucherSignature(payer, channelId, value, blockHeight, 2, 7, []types.VoucherMerge{{Lane: 1, Nonce: 5}}, nilCondition, sig))
 	})
 }
 
@@ -1187,7 +1363,7 @@ func setup(t *testing.T) system {
 }
 
 func (sys *system) Signature(amt types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) ([]byte, error) {
-	sig, err := SignVoucher(sys.channelID, amt, validAt, sys.payer, condition, mockSigner)
+	sig, err := SignVoucher(sys.channelID, amt, validAt, 0, 0, nil, sys.payer, condition, mockSigner)
 	if err != nil {
 		return nil, err
 	}
@@ -1257,12 +1433,28 @@ func (sys *system) applySignatureMessage(target address.Address, amtInt uint64,
 	signature, err := sys.Signature(amt, validAt, condition)
 	require.NoError(sys.t, err)
 
-	pdata := core.MustConvertParams(sys.payer, sys.channelID, amt, validAt, condition, signature, suppliedParams)
+	var merges []types.VoucherMerge
+	pdata := core.MustConvertParams(sys.payer, sys.channelID, amt, validAt, uint64(0), uint64(0), merges, condition, signature, suppliedParams)
 	msg := types.NewMessage(target, address.PaymentBrokerAddress, nonce, types.NewAttoFILFromFIL(0), method, pdata)
 
 	return sys.ApplyMessage(msg, height)
 }
 
+// applyLaneRedeemMessage signs an unconditional voucher on the given lane and redeems it
+func (sys *system) applyLaneRedeemMessage(amtInt uint64, lane uint64, laneNonce uint64, merges []types.VoucherMerge, nonce uint64) (*consensus.ApplicationResult, error) {
+	sys.t.Helper()
+
+	amt := types.NewAttoFILFromFIL(amtInt)
+	signature, err := SignVoucher(sys.channelID, amt, sys.defaultValidAt, lane, laneNonce, merges, sys.payer, nil, mockSigner)
+	require.NoError(sys.t, err)
+
+	var condition *types.Predicate
+	pdata := core.MustConvertParams(sys.payer, sys.channelID, amt, sys.defaultValidAt, lane, laneNonce, merges, condition, ([]byte)(signature), []interface{}{})
+	msg := types.NewMessage(sys.target, address.PaymentBrokerAddress, nonce, types.NewAttoFILFromFIL(0), "redeem", pdata)
+
+	return sys.ApplyMessage(msg, 0)
+}
+
 func (sys *system) ApplyMessage(msg *types.Message, height uint64) (*consensus.ApplicationResult, error) {
 	return th.ApplyTestMessage(sys.st, sys.vms, msg, types.NewBlockHeight(height))
 }
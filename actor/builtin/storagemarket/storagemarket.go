@@ -34,6 +34,7 @@ var Errors = map[uint8]error{
 
 func init() {
 	cbor.RegisterCborType(State{})
+	cbor.RegisterCborType(ProtocolUpgrade{})
 	cbor.RegisterCborType(struct{}{})
 }
 
@@ -53,6 +54,44 @@ type State struct {
 	TotalCommittedStorage *types.BytesAmount
 
 	ProofsMode types.ProofsMode
+
+	// GasSchedule is the version of the gas schedule of the network.
+	GasSchedule uint64
+
+	// ProtocolUpgrades are the protocol versions the network upgrades to,
+	// in order of height.
+	ProtocolUpgrades []ProtocolUpgrade
+
+	// ProtocolVersion is the protocol version the state was last processed
+	// with.
+	ProtocolVersion uint64
+}
+
+// ProtocolUpgrade schedules the activation of a protocol version.
+type ProtocolUpgrade struct {
+	Version uint64
+	Height  *types.BlockHeight
+}
+
+// NetworkParams are the network parameters set in the storage market state
+// at genesis.
+type NetworkParams struct {
+	ProofsMode       types.ProofsMode
+	GasSchedule      uint64
+	ProtocolUpgrades []ProtocolUpgrade
+}
+
+// ProtocolVersionAt returns the protocol version scheduled at the given
+// height. Networks start at version 0.
+func (state *State) ProtocolVersionAt(height *types.BlockHeight) uint64 {
+	version := uint64(0)
+	for _, upgrade := range state.ProtocolUpgrades {
+		if upgrade.Height.GreaterThan(height) {
+			break
+		}
+		version = upgrade.Version
+	}
+	return version
 }
 
 // NewActor returns a new storage market actor.
@@ -61,12 +100,14 @@ func NewActor() *actor.Actor {
 }
 
 // InitializeState stores the actor's initial data structure.
-func (sma *Actor) InitializeState(storage exec.Storage, proofsModeInterface interface{}) error {
-	proofsMode := proofsModeInterface.(types.ProofsMode)
+func (sma *Actor) InitializeState(storage exec.Storage, networkParamsInterface interface{}) error {
+	networkParams := networkParamsInterface.(NetworkParams)
 
 	initStorage := &State{
 		TotalCommittedStorage: types.NewBytesAmount(0),
-		ProofsMode:            proofsMode,
+		ProofsMode:            networkParams.ProofsMode,
+		GasSchedule:           networkParams.GasSchedule,
+		ProtocolUpgrades:      networkParams.ProtocolUpgrades,
 	}
 	stateBytes, err := cbor.DumpObject(initStorage)
 	if err != nil {
@@ -105,6 +146,10 @@ var storageMarketExports = exec.Exports{
 		Params: []abi.Type{},
 		Return: []abi.Type{abi.ProofsMode},
 	},
+	"getGasSchedule": &exec.FunctionSignature{
+		Params: []abi.Type{},
+		Return: []abi.Type{abi.Uint64},
+	},
 	"getLateMiners": &exec.FunctionSignature{
 		Params: nil,
 		Return: []abi.Type{abi.MinerPoStStates},
@@ -292,6 +337,28 @@ func (sma *Actor) GetProofsMode(vmctx exec.VMContext) (types.ProofsMode, uint8,
 	return size, 0, nil
 }
 
+// GetGasSchedule returns the version of the gas schedule of the network.
+func (sma *Actor) GetGasSchedule(vmctx exec.VMContext) (uint64, uint8, error) {
+	if err := vmctx.Charge(actor.DefaultGasCost); err != nil {
+		return 0, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	var state State
+	ret, err := actor.WithState(vmctx, &state, func() (interface{}, error) {
+		return state.GasSchedule, nil
+	})
+	if err != nil {
+		return 0, errors.CodeError(err), err
+	}
+
+	version, ok := ret.(uint64)
+	if !ok {
+		return 0, 1, fmt.Errorf("expected uint64 to be returned, but got %T instead", ret)
+	}
+
+	return version, 0, nil
+}
+
 func (sma *Actor) getMinerPoStState(vmctx exec.VMContext, minerAddr address.Address) (uint64, error) {
 	msgResult, _, err := vmctx.Send(minerAddr, "getPoStState", types.ZeroAttoFIL, nil)
 	if err != nil {
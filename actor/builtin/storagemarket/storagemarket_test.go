@@ -205,7 +205,7 @@ func requireMakeCommitment(t *testing.T, st state.Tree, vms vm.StorageMap, miner
 	builder := chain.NewBuilder(t, address.Undef)
 	head := builder.AppendManyOn(blockHeight, types.UndefTipSet)
 	ancestors := builder.RequireTipSets(head.Key(), blockHeight)
-	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", ancestors, sectorID, th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()))
+	res, err := th.CreateAndApplyTestMessage(t, st, vms, minerAddr, 0, 3, "commitSector", ancestors, sectorID, th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), types.NewBlockHeight(100000))
 	require.NoError(t, err)
 	require.NoError(t, res.ExecutionError)
 	require.Equal(t, uint8(0), res.Receipt.ExitCode)
@@ -67,7 +67,10 @@ func ReadState(ctx exec.VMContext, st interface{}) error {
 	storage := ctx.Storage()
 
 	memory, err := storage.Get(storage.Head())
-	if err != nil {
+	if vmerrors.ShouldRevert(err) {
+		// running out of gas for the read
+		return err
+	} else if err != nil {
 		return vmerrors.FaultErrorWrap(err, "Could not read actor storage")
 	}
 
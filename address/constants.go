@@ -21,6 +21,11 @@ func init() {
 		panic(err)
 	}
 
+	InitAddress, err = NewIDAddress(0)
+	if err != nil {
+		panic(err)
+	}
+
 	NetworkAddress, err = NewIDAddress(1)
 	if err != nil {
 		panic(err)
@@ -36,6 +41,11 @@ func init() {
 		panic(err)
 	}
 
+	MultisigFactoryAddress, err = NewIDAddress(4)
+	if err != nil {
+		panic(err)
+	}
+
 	BurntFundsAddress, err = NewIDAddress(99)
 	if err != nil {
 		panic(err)
@@ -48,12 +58,16 @@ var (
 	// TestAddress2 is an account with some initial funds in it.
 	TestAddress2 Address
 
+	// InitAddress is the hard-coded address of the actor assigning ID addresses to actors.
+	InitAddress Address
 	// NetworkAddress is the filecoin network treasury.
 	NetworkAddress Address
 	// StorageMarketAddress is the hard-coded address of the filecoin storage market actor.
 	StorageMarketAddress Address
 	// PaymentBrokerAddress is the hard-coded address of the filecoin payment broker actor.
 	PaymentBrokerAddress Address
+	// MultisigFactoryAddress is the hard-coded address of the actor creating multisig actors.
+	MultisigFactoryAddress Address
 	// BurntFundsAddress is the hard-coded address of the burnt funds account actor.
 	BurntFundsAddress Address
 )
@@ -149,6 +149,7 @@ func build() {
 	buildGengen()
 	buildFaucet()
 	buildGenesisFileServer()
+	buildRemoteSigner()
 	generateGenesis()
 	buildMigrations()
 	buildPrereleaseTool()
@@ -159,6 +160,7 @@ func forcebuild() {
 	buildGengen()
 	buildFaucet()
 	buildGenesisFileServer()
+	buildRemoteSigner()
 	generateGenesis()
 	buildMigrations()
 	buildPrereleaseTool()
@@ -244,6 +246,28 @@ func generateGenesis() {
 	}...))
 }
 
+// generateVectors records the conformance vectors of the state transitions
+// the actor tests apply.
+func generateVectors() {
+	log.Println("Generating conformance vectors...")
+
+	vectorFixtures, err := filepath.Abs("./fixtures/vectors")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := cleanDirectory(vectorFixtures, true); err != nil {
+		panic(err)
+	}
+
+	// the message application helpers of the tests record vectors to the
+	// directory named by FIL_RECORD_VECTORS
+	if err := os.Setenv("FIL_RECORD_VECTORS", vectorFixtures); err != nil {
+		panic(err)
+	}
+	runCmd(cmd("go test -count=1 ./actor/..."))
+}
+
 func buildFilecoin() {
 	log.Println("Building go-filecoin...")
 
@@ -272,6 +296,12 @@ func buildGenesisFileServer() {
 	runCmd(cmd([]string{"go", "build", "-o", "./tools/genesis-file-server/genesis-file-server", "./tools/genesis-file-server/"}...))
 }
 
+func buildRemoteSigner() {
+	log.Println("Building remote signer...")
+
+	runCmd(cmd([]string{"go", "build", "-o", "./tools/remote-signer/remote-signer", "./tools/remote-signer/"}...))
+}
+
 func buildMigrations() {
 	log.Println("Building migrations...")
 	runCmd(cmd([]string{
@@ -331,6 +361,8 @@ func main() {
 		buildGengen()
 	case "generate-genesis":
 		generateGenesis()
+	case "generate-vectors":
+		generateVectors()
 	case "build-migrations":
 		buildMigrations()
 	case "build":
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-hamt-ipld"
 	bstore "github.com/ipfs/go-ipfs-blockstore"
 
@@ -26,9 +27,9 @@ func TestTotal(t *testing.T) {
 	ctx := context.Background()
 
 	numCommittedSectors := uint64(19)
-	bs, _, st := requireMinerWithNumCommittedSectors(ctx, t, numCommittedSectors)
+	bs, _, st, stateID := requireMinerWithNumCommittedSectors(ctx, t, numCommittedSectors)
 
-	actual, err := (&consensus.MarketView{}).Total(ctx, st, bs)
+	actual, err := (&consensus.MarketView{}).Total(ctx, st, stateID, bs)
 	require.NoError(t, err)
 
 	expected := types.NewBytesAmount(types.OneKiBSectorSize.Uint64() * numCommittedSectors)
@@ -42,9 +43,9 @@ func TestMiner(t *testing.T) {
 	ctx := context.Background()
 
 	numCommittedSectors := uint64(12)
-	bs, addr, st := requireMinerWithNumCommittedSectors(ctx, t, numCommittedSectors)
+	bs, addr, st, stateID := requireMinerWithNumCommittedSectors(ctx, t, numCommittedSectors)
 
-	actual, err := (&consensus.MarketView{}).Miner(ctx, st, bs, addr)
+	actual, err := (&consensus.MarketView{}).Miner(ctx, st, stateID, bs, addr)
 	require.NoError(t, err)
 
 	expected := types.NewBytesAmount(types.OneKiBSectorSize.Uint64() * numCommittedSectors)
@@ -52,7 +53,7 @@ func TestMiner(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
-func requireMinerWithNumCommittedSectors(ctx context.Context, t *testing.T, numCommittedSectors uint64) (bstore.Blockstore, address.Address, state.Tree) {
+func requireMinerWithNumCommittedSectors(ctx context.Context, t *testing.T, numCommittedSectors uint64) (bstore.Blockstore, address.Address, state.Tree, cid.Cid) {
 	r := repo.NewInMemoryRepo()
 	bs := bstore.NewBlockstore(r.Datastore())
 	cst := hamt.NewCborStore()
@@ -78,5 +79,5 @@ func requireMinerWithNumCommittedSectors(ctx context.Context, t *testing.T, numC
 	stateTree, err := state.LoadStateTree(ctx, cst, calcGenBlk.StateRoot, builtin.Actors)
 	require.NoError(t, err)
 
-	return bs, info.Miners[0].Address, stateTree
+	return bs, info.Miners[0].Address, stateTree, calcGenBlk.StateRoot
 }
@@ -99,6 +99,8 @@ type Syncer struct {
 	chainStore syncerChainReaderWriter
 	// Provides message collections given cids
 	messageProvider MessageProvider
+	// Detects consensus faults in the blocks the syncer validates.
+	faultDetector *consensus.FaultDetector
 }
 
 // NewSyncer constructs a Syncer ready for use.
@@ -111,9 +113,16 @@ func NewSyncer(e syncStateEvaluator, s syncerChainReaderWriter, m MessageProvide
 		stateEvaluator:  e,
 		chainStore:      s,
 		messageProvider: m,
+		faultDetector:   consensus.NewFaultDetector(uint64(FinalityLimit)),
 	}
 }
 
+// FaultDetector returns the detector of consensus faults in the blocks the
+// syncer validates.
+func (syncer *Syncer) FaultDetector() *consensus.FaultDetector {
+	return syncer.faultDetector
+}
+
 // syncOne syncs a single tipset with the chain store. syncOne calculates the
 // parent state of the tipset and calls into consensus to run a state transition
 // in order to validate the tipset.  In the case the input tipset is valid,
@@ -182,6 +191,13 @@ func (syncer *Syncer) syncOne(ctx context.Context, parent, next types.TipSet) er
 	}
 	logSyncer.Debugf("Successfully updated store with %s", next.String())
 
+	// The blocks of a valid tipset are signed by the workers of their miners
+	// and have plausible heights, so they may be held as evidence of
+	// consensus faults.
+	for i := 0; i < next.Len(); i++ {
+		syncer.faultDetector.Observe(ctx, next.At(i))
+	}
+
 	// TipSet is validated and added to store, now check if it is the heaviest.
 	nextParentStateID, err := syncer.chainStore.GetTipSetStateRoot(parent.Key())
 	if err != nil {
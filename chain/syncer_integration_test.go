@@ -11,7 +11,6 @@ import (
 	"github.com/ipfs/go-ipfs-exchange-offline"
 	"github.com/libp2p/go-libp2p-core/peer"
 
-	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/consensus"
@@ -199,8 +198,6 @@ func TestTipSetWeightDeep(t *testing.T) {
 	baseTS := requireHeadTipset(t, chainStore) // this is the last block of the bootstrapping chain creating miners
 	require.Equal(t, 1, baseTS.Len())
 	bootstrapStateRoot := baseTS.ToSlice()[0].StateRoot
-	pSt, err := state.LoadStateTree(ctx, cst, baseTS.ToSlice()[0].StateRoot, builtin.Actors)
-	require.NoError(t, err)
 	/* Test chain diagram and weight calcs */
 	// (Note f1b1 = fork 1 block 1)
 	//
@@ -214,14 +211,14 @@ func TestTipSetWeightDeep(t *testing.T) {
 	//  w({f1b1, f2b1})   = sw + 0   + 11 * 2  = sw + 22
 	//  w({f1b2a, f1b2b}) = sw + 11  + 11 * 2  = sw + 33
 	//  w({f2b2})         = sw + 11  + 108 	   = sw + 119
-	startingWeight, err := con.Weight(ctx, baseTS, pSt)
+	startingWeight, err := con.Weight(ctx, baseTS, bootstrapStateRoot)
 	require.NoError(t, err)
 
 	wFun := func(ts types.TipSet) (uint64, error) {
 		// No power-altering messages processed from here on out.
 		// And so bootstrapSt correctly retrives power table for all
 		// test blocks.
-		return con.Weight(ctx, ts, pSt)
+		return con.Weight(ctx, ts, bootstrapStateRoot)
 	}
 
 	fakeChildParams := th.FakeChildParams{
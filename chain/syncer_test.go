@@ -2,6 +2,7 @@ package chain_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/ipfs/go-cid"
@@ -386,6 +387,55 @@ func TestBlockNotLinkedRejected(t *testing.T) {
 	assert.NoError(t, syncer.HandleNewTipSet(ctx, types.NewChainInfo(peer.ID(""), b1.Key(), heightFromTip(t, b1)), true))
 }
 
+func TestFaultsDetectedInValidatedBlocks(t *testing.T) {
+	tf.UnitTest(t)
+	ctx := context.Background()
+
+	signed := func(b *chain.BlockBuilder) {
+		b.SetBlockSig(types.Signature{0x01})
+	}
+
+	t.Run("conflicting blocks of valid tipsets are a fault", func(t *testing.T) {
+		builder, store, syncer := setup(ctx, t)
+		genesis := builder.RequireTipSet(store.GetHead())
+
+		// the blocks of both forks are of the same miner at the same height
+		fork1 := builder.BuildOn(genesis, signed)
+		fork2 := builder.BuildOn(genesis, signed)
+
+		require.NoError(t, syncer.HandleNewTipSet(ctx, types.NewChainInfo(peer.ID(""), fork1.Key(), heightFromTip(t, fork1)), true))
+		assert.Empty(t, syncer.FaultDetector().Faults())
+		require.NoError(t, syncer.HandleNewTipSet(ctx, types.NewChainInfo(peer.ID(""), fork2.Key(), heightFromTip(t, fork2)), true))
+
+		faults := syncer.FaultDetector().Faults()
+		require.Len(t, faults, 1)
+		assert.Equal(t, fork1.At(0).Cid(), faults[0].Block1.Cid())
+		assert.Equal(t, fork2.At(0).Cid(), faults[0].Block2.Cid())
+	})
+
+	t.Run("blocks of invalid tipsets are not observed", func(t *testing.T) {
+		builder, store, _ := setup(ctx, t)
+		genesis := builder.RequireTipSet(store.GetHead())
+		syncer := chain.NewSyncer(&rejectingStateEvaluator{}, store, builder, builder)
+
+		forged1 := builder.BuildOn(genesis, signed)
+		forged2 := builder.BuildOn(genesis, signed)
+
+		assert.Error(t, syncer.HandleNewTipSet(ctx, types.NewChainInfo(peer.ID(""), forged1.Key(), heightFromTip(t, forged1)), true))
+		assert.Error(t, syncer.HandleNewTipSet(ctx, types.NewChainInfo(peer.ID(""), forged2.Key(), heightFromTip(t, forged2)), true))
+		assert.Empty(t, syncer.FaultDetector().Faults())
+	})
+}
+
+// rejectingStateEvaluator rejects every tipset as invalid.
+type rejectingStateEvaluator struct {
+	chain.FakeStateEvaluator
+}
+
+func (e *rejectingStateEvaluator) RunStateTransition(ctx context.Context, tip types.TipSet, messages [][]*types.SignedMessage, receipts [][]*types.MessageReceipt, ancestors []types.TipSet, stateID cid.Cid) (cid.Cid, error) {
+	return cid.Undef, errors.New("invalid tipset")
+}
+
 ///// Set-up /////
 
 // Initializes a chain builder, store and syncer.
@@ -258,6 +258,11 @@ func (bb *BlockBuilder) SetTicket(ticket []byte) {
 	bb.block.Ticket = ticket
 }
 
+// SetBlockSig sets the block's signature.
+func (bb *BlockBuilder) SetBlockSig(sig types.Signature) {
+	bb.block.BlockSig = sig
+}
+
 // SetTimestamp sets the block's timestamp.
 func (bb *BlockBuilder) SetTimestamp(timestamp types.Uint64) {
 	bb.block.Timestamp = timestamp
@@ -1,10 +1,13 @@
 package commands
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"time"
 
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
@@ -20,9 +23,94 @@ var walletCmd = &cmds.Command{
 		Tagline: "Manage your filecoin wallets",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"balance": balanceCmd,
-		"import":  walletImportCmd,
-		"export":  walletExportCmd,
+		"balance":  balanceCmd,
+		"import":   walletImportCmd,
+		"export":   walletExportCmd,
+		"unlock":   walletUnlockCmd,
+		"lock":     walletLockCmd,
+		"mnemonic": walletMnemonicCmd,
+	},
+}
+
+type mnemonicResult struct {
+	Mnemonic string
+}
+
+var walletMnemonicCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the wallet recovery phrase",
+		ShortDescription: `
+Shows the BIP-39 recovery phrase the addresses created with 'address derive'
+are derived from. Anyone knowing it can restore those addresses, keep it secret.
+`,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		mnemonic, err := GetPorcelainAPI(env).WalletMnemonic()
+		if err != nil {
+			return err
+		}
+		return re.Emit(&mnemonicResult{mnemonic})
+	},
+	Type: &mnemonicResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, m *mnemonicResult) error {
+			_, err := fmt.Fprintln(w, m.Mnemonic)
+			return err
+		}),
+	},
+}
+
+var walletUnlockCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Unlock the wallet private keys",
+		ShortDescription: `
+Decrypts the wallet private keys with the passphrase read from the file, or
+from stdin, so that messages can be signed. The wallet stays unlocked for the
+given timeout, or until it is locked or the daemon stops if no timeout is given.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.FileArg("passphraseFile", true, false, "File containing the passphrase the wallet keys are encrypted with").EnableStdin(),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("timeout", "How long the wallet stays unlocked, e.g. 10m"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		var timeout time.Duration
+		if timeoutStr, ok := req.Options["timeout"].(string); ok {
+			var err error
+			timeout, err = time.ParseDuration(timeoutStr)
+			if err != nil {
+				return errors.Wrap(err, "invalid timeout")
+			}
+		}
+
+		iter := req.Files.Entries()
+		if !iter.Next() {
+			return fmt.Errorf("no passphrase given: %s", iter.Err())
+		}
+
+		fi, ok := iter.Node().(files.File)
+		if !ok {
+			return fmt.Errorf("given file was not a files.File")
+		}
+
+		passphrase, err := ioutil.ReadAll(fi)
+		if err != nil {
+			return errors.Wrap(err, "failed to read passphrase")
+		}
+
+		return GetPorcelainAPI(env).WalletUnlock(bytes.TrimRight(passphrase, "\r\n"), timeout)
+	},
+}
+
+var walletLockCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Lock the wallet private keys",
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		GetPorcelainAPI(env).WalletLock()
+		return nil
 	},
 }
 
@@ -33,7 +121,9 @@ var addrsCmd = &cmds.Command{
 	Subcommands: map[string]*cmds.Command{
 		"ls":      addrsLsCmd,
 		"new":     addrsNewCmd,
+		"derive":  addrsDeriveCmd,
 		"lookup":  addrsLookupCmd,
+		"resolve": addrsResolveCmd,
 		"default": defaultAddressCmd,
 	},
 }
@@ -48,8 +138,50 @@ type AddressLsResult struct {
 }
 
 var addrsNewCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Create a new wallet address",
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("type", "Type of the address key, secp256k1 or bls. Defaults to secp256k1"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		protocol := address.SECP256K1
+		if keyType, ok := req.Options["type"].(string); ok {
+			switch keyType {
+			case types.SECP256K1:
+			case types.BLS:
+				protocol = address.BLS
+			default:
+				return fmt.Errorf("unsupported address type %q", keyType)
+			}
+		}
+
+		addr, err := GetPorcelainAPI(env).WalletNewAddress(protocol)
+		if err != nil {
+			return err
+		}
+		return re.Emit(&addressResult{addr.String()})
+	},
+	Type: &addressResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, a *addressResult) error {
+			_, err := fmt.Fprintln(w, a.Address)
+			return err
+		}),
+	},
+}
+
+var addrsDeriveCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Derive the next wallet address from the recovery phrase",
+		ShortDescription: `
+Derives the next secp256k1 address from the wallet recovery phrase. Unlike
+addresses created with 'address new', these are restored with the recovery
+phrase and need no separate backup.
+`,
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
-		addr, err := GetPorcelainAPI(env).WalletNewAddress()
+		addr, err := GetPorcelainAPI(env).WalletDeriveAddress()
 		if err != nil {
 			return err
 		}
@@ -114,6 +246,53 @@ var addrsLookupCmd = &cmds.Command{
 	},
 }
 
+// AddressResolveResult is the result of running the address resolve command.
+type AddressResolveResult struct {
+	// Address is the address the actor was created at.
+	Address address.Address
+	// ID is the ID address assigned to the actor, if any.
+	ID address.Address
+}
+
+var addrsResolveCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Resolve an address to the ID address of its actor and back",
+		ShortDescription: `
+Given the address an actor was created at or the ID address assigned to it,
+shows both.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("address", true, false, "Address or ID address to resolve"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		addr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		robust, id, err := GetPorcelainAPI(env).AddressLookup(req.Context, addr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find address %s", addr)
+		}
+		return re.Emit(&AddressResolveResult{Address: robust, ID: id})
+	},
+	Type: &AddressResolveResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *AddressResolveResult) error {
+			if _, err := fmt.Fprintf(w, "Address: %s\n", res.Address); err != nil {
+				return err
+			}
+			if !res.ID.Empty() {
+				if _, err := fmt.Fprintf(w, "ID:      %s\n", res.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
 var defaultAddressCmd = &cmds.Command{
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		addr, err := GetPorcelainAPI(env).WalletDefaultAddress()
@@ -100,6 +100,25 @@ func TestAddrLookupAndUpdate(t *testing.T) {
 	assert.NotEqual(t, lookupOutA, lookupOutB)
 }
 
+func TestAddrResolve(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(t, th.KeyFile(fixtures.KeyFilePaths()[0])).Start()
+	defer d.ShutdownSuccess()
+
+	addr := fixtures.TestAddresses[0]
+	out := d.RunSuccess("address", "resolve", addr).ReadStdoutTrimNewlines()
+	assert.Contains(t, out, "Address: "+addr)
+
+	// singleton actors are created at their ID address
+	marketAddr := address.StorageMarketAddress.String()
+	out = d.RunSuccess("address", "resolve", marketAddr).ReadStdoutTrimNewlines()
+	assert.Contains(t, out, "Address: "+marketAddr)
+	assert.Contains(t, out, "ID:      "+marketAddr)
+
+	d.RunFail("unknown address network", "address", "resolve", "not-an-address")
+}
+
 func TestWalletLoadFromFile(t *testing.T) {
 	tf.IntegrationTest(t)
 
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
@@ -25,8 +26,11 @@ var clientCmd = &cmds.Command{
 		"import":               clientImportDataCmd,
 		"propose-storage-deal": clientProposeStorageDealCmd,
 		"query-storage-deal":   clientQueryStorageDealCmd,
+		"find-asks":            clientFindAsksCmd,
 		"list-asks":            clientListAsksCmd,
 		"payments":             paymentsCmd,
+		"renew-deals":          clientRenewDealsCmd,
+		"store":                clientStoreCmd,
 	},
 }
 
@@ -163,6 +167,94 @@ be 2, 1 hour would be 120, and 1 day would be 2880.
 	},
 }
 
+// ClientStoreResult is the output of the client store command. Deals and
+// failures are keyed by miner address.
+type ClientStoreResult struct {
+	PieceRef cid.Cid                          `json:"pieceRef"`
+	Deals    map[string]*storagedeal.Response `json:"deals"`
+	Failures map[string]string                `json:"failures,omitempty"`
+}
+
+var clientStoreCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Store data with several storage miners",
+		ShortDescription: `Proposes storage deals for data to several distinct miners`,
+		LongDescription: `
+Store data with the given number of distinct miners. Miners are chosen from the
+current asks in the storage market: the cheapest ask of each miner is used, and
+only miners whose sector size fits the data and that respond to a ping are
+considered. If a miner rejects the deal, it is proposed to the next cheapest
+miner instead.
+
+Duration should be specified with the number of blocks for which to store the
+data. New blocks are generated about every 30 seconds.
+
+The result maps each miner that accepted the data to its deal. The command fails
+if fewer miners than requested accepted the data; deals that were made are still
+reported.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("data", true, false, "CID of the data to be stored"),
+		cmdkit.StringArg("duration", true, false, "Time in blocks (about 30 seconds per block) to store data"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.UintOption("replicas", "Number of distinct miners to store the data with").WithDefault(uint(1)),
+		cmdkit.BoolOption("allow-duplicates", "Allows duplicate proposals to be created."),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		allowDuplicates, _ := req.Options["allow-duplicates"].(bool)
+		replicas, _ := req.Options["replicas"].(uint)
+
+		data, err := cid.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		duration, err := strconv.ParseUint(req.Arguments[1], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		result, storeErr := GetStorageAPI(env).StoreWithReplicas(req.Context, data, duration, int(replicas), allowDuplicates)
+		if result == nil {
+			return storeErr
+		}
+
+		out := ClientStoreResult{
+			PieceRef: result.PieceRef,
+			Deals:    make(map[string]*storagedeal.Response),
+			Failures: make(map[string]string),
+		}
+		for minerAddr, resp := range result.Deals {
+			out.Deals[minerAddr.String()] = resp
+		}
+		for minerAddr, err := range result.Failures {
+			out.Failures[minerAddr.String()] = err.Error()
+		}
+		if err := re.Emit(&out); err != nil {
+			return err
+		}
+		return storeErr
+	},
+	Type: ClientStoreResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *ClientStoreResult) error {
+			for minerAddr, resp := range res.Deals {
+				if _, err := fmt.Fprintf(w, "%s %s %s\n", minerAddr, resp.ProposalCid, resp.State); err != nil {
+					return err
+				}
+			}
+			for minerAddr, msg := range res.Failures {
+				if _, err := fmt.Fprintf(w, "%s failed: %s\n", minerAddr, msg); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
 var clientQueryStorageDealCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Query a storage deal's status",
@@ -229,6 +321,151 @@ respectively.
 	},
 }
 
+// RenewDealResult is the output of the client renew-deals command for a
+// single deal.
+type RenewDealResult struct {
+	DealCid    cid.Cid           `json:"dealCid"`
+	PieceRef   cid.Cid           `json:"pieceRef"`
+	OldMiner   address.Address   `json:"oldMiner"`
+	Miner      address.Address   `json:"miner,omitempty"`
+	Repaired   bool              `json:"repaired"`
+	NewDealCid *cid.Cid          `json:"newDealCid,omitempty"`
+	State      storagedeal.State `json:"state,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+var clientRenewDealsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Renew storage deals that are about to end",
+		ShortDescription: `
+Renews the storage deals made by this node that end within the given number of
+blocks. Each deal is proposed again to the miner storing it, using that miner's
+cheapest current ask. If the miner does not accept the deal it is proposed to
+the miner with the best current ask instead. New payments are created for every
+renewed deal.
+
+Deals whose miner was slashed, is offline, or failed verification are
+repaired: the piece is retrieved from another miner storing it, or from the
+original miner, if it is not available locally, and stored with another miner.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("within", true, false, "Renew deals ending within this many blocks"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.Uint64Option("duration", "Time in blocks to store the data for. Defaults to the duration of the original deal."),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		within, err := strconv.ParseUint(req.Arguments[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		duration, _ := req.Options["duration"].(uint64)
+
+		results, err := GetStorageAPI(env).RenewStorageDeals(req.Context, within, duration)
+		if err != nil {
+			return err
+		}
+
+		for result := range results {
+			out := RenewDealResult{
+				DealCid:  result.ProposalCid,
+				PieceRef: result.PieceRef,
+				OldMiner: result.OldMiner,
+				Miner:    result.Miner,
+				Repaired: result.Repaired,
+			}
+			if result.Response != nil {
+				out.NewDealCid = &result.Response.ProposalCid
+				out.State = result.Response.State
+			}
+			if result.Error != nil {
+				out.Error = result.Error.Error()
+			}
+			if err := re.Emit(out); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: RenewDealResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *RenewDealResult) error {
+			if res.Error != "" {
+				_, err := fmt.Fprintf(w, "%s failed: %s\n", res.DealCid, res.Error)
+				return err
+			}
+			action := "renewed"
+			if res.Repaired {
+				action = "repaired"
+			}
+			_, err := fmt.Fprintf(w, "%s %s with %s as %s (%s)\n", res.DealCid, action, res.Miner, res.NewDealCid, res.State)
+			return err
+		}),
+	},
+}
+
+var clientFindAsksCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Find the best asks in the storage market",
+		ShortDescription: `
+Lists the unexpired asks in the storage market that match the given filters,
+together with the sector size and power of each miner. Asks are ordered by the
+total cost of a deal for the given piece size and duration, cheapest first.
+Results are written as one JSON object per line.
+`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("max-price", "Highest price in FIL per byte per block to accept"),
+		cmdkit.Uint64Option("min-sector-size", "Smallest miner sector size in bytes to accept"),
+		cmdkit.Uint64Option("min-power", "Least storage power in bytes a miner must have"),
+		cmdkit.Uint64Option("size", "Size in bytes of the piece to store, used to compute the total cost"),
+		cmdkit.Uint64Option("duration", "Time in blocks to store the piece, used to compute the total cost"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		var filter porcelain.AskFilter
+
+		if maxPrice, ok := req.Options["max-price"].(string); ok {
+			price, ok := types.NewAttoFILFromFILString(maxPrice)
+			if !ok {
+				return ErrInvalidPrice
+			}
+			filter.MaxPrice = &price
+		}
+		if minSectorSize, ok := req.Options["min-sector-size"].(uint64); ok {
+			filter.MinSectorSize = types.NewBytesAmount(minSectorSize)
+		}
+		if minPower, ok := req.Options["min-power"].(uint64); ok {
+			filter.MinPower = types.NewBytesAmount(minPower)
+		}
+		filter.PieceSize, _ = req.Options["size"].(uint64)
+		filter.Duration, _ = req.Options["duration"].(uint64)
+
+		asks, err := GetPorcelainAPI(env).ClientFindAsks(req.Context, filter)
+		if err != nil {
+			return err
+		}
+
+		for _, ask := range asks {
+			if err := re.Emit(ask); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: porcelain.AskInfo{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ask *porcelain.AskInfo) error {
+			marshaled, err := json.Marshal(ask)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(marshaled, '\n'))
+			return err
+		}),
+	},
+}
+
 var paymentsCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline:          "List payments for a given deal",
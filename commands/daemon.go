@@ -23,6 +23,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/node"
 	"github.com/filecoin-project/go-filecoin/paths"
 	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/wallet"
 )
 
 // exposed here, to be available during testing
@@ -31,6 +32,10 @@ var sigCh = make(chan os.Signal, 1)
 var daemonCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline: "Start a long-running daemon process",
+		ShortDescription: `
+Starts the filecoin node. The wallet starts locked, unless the ` + wallet.PassphraseEnvVar + `
+environment variable is set, in which case it is unlocked with its value.
+`,
 	},
 	Options: []cmdkit.Option{
 		cmdkit.StringOption(SwarmAddress, "multiaddress to listen on for filecoin network connections"),
@@ -86,6 +91,12 @@ func daemonRun(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment)
 		opts = append(opts, node.IsRelay())
 	}
 
+	// The wallet starts locked unless the passphrase is given in the
+	// environment, otherwise it is unlocked with 'wallet unlock'.
+	if passphrase, ok := os.LookupEnv(wallet.PassphraseEnvVar); ok {
+		opts = append(opts, node.UnlockWallet([]byte(passphrase)))
+	}
+
 	durStr, ok := req.Options[BlockTime].(string)
 	if !ok {
 		return errors.New("Bad block time passed")
@@ -33,10 +33,11 @@ var dealsCmd = &cmds.Command{
 
 // DealsListResult represents the subset of deal data returned by deals list
 type DealsListResult struct {
-	Miner       address.Address `json:"minerAddress"`
-	PieceCid    cid.Cid         `json:"pieceCid"`
-	ProposalCid cid.Cid         `json:"proposalCid"`
-	State       string          `json:"state"`
+	Miner        address.Address `json:"minerAddress"`
+	PieceCid     cid.Cid         `json:"pieceCid"`
+	ProposalCid  cid.Cid         `json:"proposalCid"`
+	State        string          `json:"state"`
+	Verification string          `json:"verification"`
 }
 
 var dealsListCmd = &cmds.Command{
@@ -72,10 +73,11 @@ deals, active deals, finished deals and cancelled deals.
 				continue
 			}
 			out := &DealsListResult{
-				Miner:       deal.Deal.Miner,
-				PieceCid:    deal.Deal.Proposal.PieceRef,
-				ProposalCid: deal.Deal.Response.ProposalCid,
-				State:       deal.Deal.Response.State.String(),
+				Miner:        deal.Deal.Miner,
+				PieceCid:     deal.Deal.Proposal.PieceRef,
+				ProposalCid:  deal.Deal.Response.ProposalCid,
+				State:        deal.Deal.Response.State.String(),
+				Verification: deal.Deal.Verification.String(),
 			}
 			if err = re.Emit(out); err != nil {
 				return err
@@ -172,6 +174,8 @@ type DealsShowResult struct {
 	Size            *types.BytesAmount     `json:"deal_size"`
 	TotalPrice      *types.AttoFIL         `json:"total_price"`
 	PaymentVouchers []*PaymenVoucherResult `json:"payment_vouchers"`
+	Verification    string                 `json:"verification"`
+	VerificationMsg string                 `json:"verification_message,omitempty"`
 }
 
 // PaymenVoucherResult is selected PaymentVoucher fields,
@@ -218,6 +222,8 @@ var dealsShowCmd = &cmds.Command{
 			Size:            deal.Proposal.Size,
 			TotalPrice:      &deal.Proposal.TotalPrice,
 			PaymentVouchers: vouchers,
+			Verification:    deal.Verification.String(),
+			VerificationMsg: deal.VerificationMessage,
 		}
 
 		if err := re.Emit(out); err != nil {
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/ipfs/go-car"
 	"github.com/ipfs/go-hamt-ipld"
@@ -15,6 +17,8 @@ import (
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
 	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/config"
@@ -24,6 +28,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/paths"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/wallet"
 )
 
 var initCmd = &cmds.Command{
@@ -36,6 +41,9 @@ var initCmd = &cmds.Command{
 		cmdkit.StringOption(WithMiner, "when set, creates a custom genesis block with a pre generated miner account, requires running the daemon using dev mode (--dev)"),
 		cmdkit.StringOption(OptionSectorDir, "path of directory into which staged and sealed sectors will be written"),
 		cmdkit.StringOption(DefaultAddress, "when set, sets the daemons's default address to the provided address"),
+		cmdkit.StringOption(WalletMnemonicFile, "path of file containing the BIP-39 recovery phrase to restore the wallet from, instead of generating a new one"),
+		cmdkit.UintOption(WalletRestoreCount, "number of addresses to restore from the recovery phrase").WithDefault(uint(1)),
+		cmdkit.BoolOption(EncryptWallet, "when set, encrypts the wallet keys with a passphrase read from stdin, or prompted for; the daemon then starts with the wallet locked unless "+wallet.PassphraseEnvVar+" is set"),
 		cmdkit.UintOption(AutoSealIntervalSeconds, "when set to a number > 0, configures the daemon to check for and seal any staged sectors on an interval.").WithDefault(uint(120)),
 		cmdkit.BoolOption(DevnetStaging, "when set, populates config bootstrap addrs with the dns multiaddrs of the staging devnet and other staging devnet specific bootstrap parameters."),
 		cmdkit.BoolOption(DevnetNightly, "when set, populates config bootstrap addrs with the dns multiaddrs of the nightly devnet and other nightly devnet specific bootstrap parameters"),
@@ -80,6 +88,23 @@ var initCmd = &cmds.Command{
 			return err
 		}
 
+		if encryptWallet, _ := req.Options[EncryptWallet].(bool); encryptWallet {
+			passphrase, err := readWalletPassphrase()
+			if err != nil {
+				return err
+			}
+			initopts = append(initopts, node.WalletPassphraseOpt(passphrase))
+		}
+
+		if mnemonicFile, ok := req.Options[WalletMnemonicFile].(string); ok {
+			mnemonic, err := readMnemonicFile(mnemonicFile)
+			if err != nil {
+				return err
+			}
+			restoreCount, _ := req.Options[WalletRestoreCount].(uint)
+			initopts = append(initopts, node.WalletMnemonicOpt(mnemonic, restoreCount))
+		}
+
 		return node.Init(req.Context, rep, genesisFile, initopts...)
 	},
 	Encoders: cmds.EncoderMap{
@@ -220,3 +245,59 @@ func getNodeInitOpts(autoSealIntervalSeconds uint, peerKeyFile string) ([]node.I
 
 	return initOpts, nil
 }
+
+// readMnemonicFile reads the recovery phrase from the file, so that it is not
+// given on the command line.
+func readMnemonicFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read recovery phrase file")
+	}
+	return strings.Join(strings.Fields(string(data)), " "), nil
+}
+
+// readWalletPassphrase reads the passphrase to encrypt the wallet keys with
+// from stdin, prompting for it twice without echo if stdin is a terminal, so
+// that it ends up neither in the shell history nor in the process list.
+func readWalletPassphrase() ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read wallet passphrase")
+		}
+		passphrase := bytes.TrimRight(data, "\r\n")
+		if len(passphrase) == 0 {
+			return nil, errors.New("wallet passphrase must not be empty")
+		}
+		return passphrase, nil
+	}
+
+	passphrase, err := promptPassword(fd, "Wallet passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("wallet passphrase must not be empty")
+	}
+
+	confirmation, err := promptPassword(fd, "Repeat wallet passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(passphrase, confirmation) {
+		return nil, errors.New("wallet passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func promptPassword(fd int, prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt) // nolint: errcheck
+	defer fmt.Fprintln(os.Stderr) // nolint: errcheck
+
+	password, err := terminal.ReadPassword(fd)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read from terminal")
+	}
+	return password, nil
+}
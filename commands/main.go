@@ -60,6 +60,15 @@ const (
 	// PeerKeyFile is the path of file containing key to use for new nodes libp2p identity
 	PeerKeyFile = "peerkeyfile"
 
+	// EncryptWallet when set, encrypts the wallet keys with a passphrase read from stdin
+	EncryptWallet = "encrypt-wallet"
+
+	// WalletMnemonicFile is the path of file containing the recovery phrase to restore the wallet from
+	WalletMnemonicFile = "wallet-mnemonic-file"
+
+	// WalletRestoreCount is the number of addresses to restore from the recovery phrase
+	WalletRestoreCount = "wallet-restore-count"
+
 	// WithMiner when set, creates a custom genesis block with a pre generated miner account, requires to run the daemon using dev mode (--dev)
 	WithMiner = "with-miner"
 
@@ -120,6 +129,7 @@ NETWORK COMMANDS
 
 ACTOR COMMANDS
   go-filecoin actor                  - Interact with actors. Actors are built-in smart contracts
+  go-filecoin multisig               - Manage accounts controlled by several signers
   go-filecoin paych                  - Payment channel operations
 
 MESSAGE COMMANDS
@@ -174,6 +184,7 @@ var rootSubcmdsDaemon = map[string]*cmds.Command{
 	"miner":            minerCmd,
 	"mining":           miningCmd,
 	"mpool":            mpoolCmd,
+	"multisig":         multisigCmd,
 	"outbox":           outboxCmd,
 	"paych":            paymentChannelCmd,
 	"ping":             pingCmd,
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ipfs/go-cid"
@@ -20,6 +21,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/plumbing/cst"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
 )
 
 var msgCmd = &cmds.Command{
@@ -27,6 +29,7 @@ var msgCmd = &cmds.Command{
 		Tagline: "Send and monitor messages",
 	},
 	Subcommands: map[string]*cmds.Command{
+		"replay": msgReplayCmd,
 		"send":   msgSendCmd,
 		"status": msgStatusCmd,
 		"wait":   msgWaitCmd,
@@ -234,6 +237,80 @@ var msgWaitCmd = &cmds.Command{
 	},
 }
 
+var msgReplayCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Re-execute an on-chain message",
+		ShortDescription: `Re-executes a message against the state it was applied to and prints its receipt.
+With --trace, also prints the messages it sent to other actors, with the exit
+code, error and gas charged of each.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("cid", true, false, "CID of the message to replay"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.BoolOption("trace", "Record the internal sends of the message"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		msgCid, err := cid.Parse(req.Arguments[0])
+		if err != nil {
+			return errors.Wrap(err, "invalid cid "+req.Arguments[0])
+		}
+
+		trace, _ := req.Options["trace"].(bool)
+
+		res, err := GetPorcelainAPI(env).MessageReplay(req.Context, msgCid, trace)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(res)
+	},
+	Type: msg.ReplayResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *msg.ReplayResult) error {
+			if _, err := fmt.Fprintf(w, "exit code: %d\ngas cost: %s\n", res.Receipt.ExitCode, res.Receipt.GasAttoFIL); err != nil {
+				return err
+			}
+			if res.ExecutionError != "" {
+				if _, err := fmt.Fprintf(w, "error: %s\n", res.ExecutionError); err != nil {
+					return err
+				}
+			}
+			if res.Trace != nil {
+				return printTrace(w, res.Trace, 0)
+			}
+			return nil
+		}),
+	},
+}
+
+// printTrace prints a message trace as a tree, one send per line.
+func printTrace(w io.Writer, trace *vm.Trace, depth int) error {
+	method := trace.Method
+	if method == "" {
+		method = "<transfer>"
+	}
+	_, err := fmt.Fprintf(w, "%s%s -> %s %s value: %s, exit code: %d, gas: %d", strings.Repeat("  ", depth), trace.From, trace.To, method, trace.Value, trace.ExitCode, trace.GasCharged)
+	if err != nil {
+		return err
+	}
+	if trace.Error != "" {
+		if _, err := fmt.Fprintf(w, ", error: %s", trace.Error); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+
+	for _, sub := range trace.Subcalls {
+		if err := printTrace(w, sub, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // MessageStatusResult is the status of a message on chain or in the message queue/pool
 type MessageStatusResult struct {
 	InPool    bool // Whether the message is found in the mpool
@@ -188,3 +188,38 @@ func TestMessageStatus(t *testing.T) {
 		assert.NotContains(t, status, "On chain")
 	})
 }
+
+func TestMessageReplay(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := makeTestDaemonWithMinerAndStart(t)
+	defer d.ShutdownSuccess()
+
+	from, to := fixtures.TestAddresses[0], fixtures.TestAddresses[1]
+	msg := d.RunSuccess(
+		"message", "send",
+		"--from", from,
+		"--gas-price", "1", "--gas-limit", "300",
+		"--value=1234",
+		to,
+	)
+	msgcid := msg.ReadStdoutTrimNewlines()
+
+	t.Log("[failure] message not on chain")
+	d.RunFail("not found on chain", "message", "replay", msgcid)
+
+	d.RunSuccess("mining once")
+
+	t.Log("[success] prints the receipt")
+	out := d.RunSuccess("message", "replay", msgcid).ReadStdout()
+	assert.Contains(t, out, "exit code: 0")
+	assert.NotContains(t, out, "<transfer>")
+
+	t.Log("[success] prints the trace")
+	out = d.RunSuccess("message", "replay", "--trace", msgcid).ReadStdout()
+	assert.Contains(t, out, "exit code: 0")
+	assert.Contains(t, out, from+" -> "+to+" <transfer> value: 1234, exit code: 0")
+
+	t.Log("[failure] invalid cid")
+	d.RunFail("invalid cid", "message", "replay", "not-a-cid")
+}
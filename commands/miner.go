@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/ipfs/go-ipfs-cmdkit"
 	"github.com/ipfs/go-ipfs-cmds"
 	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
@@ -23,13 +25,22 @@ var minerCmd = &cmds.Command{
 		Tagline: "Manage a single miner actor",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"create":         minerCreateCmd,
-		"owner":          minerOwnerCmd,
-		"power":          minerPowerCmd,
-		"set-price":      minerSetPriceCmd,
-		"update-peerid":  minerUpdatePeerIDCmd,
-		"collateral":     minerCollateralCmd,
-		"proving-period": minerProvingPeriodCmd,
+		"create":              minerCreateCmd,
+		"owner":               minerOwnerCmd,
+		"power":               minerPowerCmd,
+		"power-table":         minerPowerTableCmd,
+		"set-price":           minerSetPriceCmd,
+		"update-peerid":       minerUpdatePeerIDCmd,
+		"addrs":               minerAddrsCmd,
+		"update-addrs":        minerUpdateAddrsCmd,
+		"change-owner":        minerChangeOwnerCmd,
+		"asks":                minerAsksCmd,
+		"collateral":          minerCollateralCmd,
+		"collateral-status":   minerCollateralStatusCmd,
+		"add-collateral":      minerCollateralAddCmd,
+		"withdraw-collateral": minerCollateralWithdrawCmd,
+		"faults":              minerFaultsCmd,
+		"proving-period":      minerProvingPeriodCmd,
 	},
 }
 
@@ -367,6 +378,229 @@ var minerUpdatePeerIDCmd = &cmds.Command{
 	},
 }
 
+var minerAddrsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Show the multiaddrs a miner advertises",
+		ShortDescription: `Given <miner> miner address, output the multiaddrs the miner advertises on chain.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "The address of the miner"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		addrs, err := GetPorcelainAPI(env).MinerGetMultiaddrs(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+
+		out := make([]string, len(addrs))
+		for i, addr := range addrs {
+			out[i] = addr.String()
+		}
+		return re.Emit(out)
+	},
+	Type: []string{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, addrs []string) error {
+			for _, addr := range addrs {
+				if _, err := fmt.Fprintln(w, addr); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+// MinerUpdateAddrsResult is the type returned when updating the multiaddrs of
+// a miner.
+type MinerUpdateAddrsResult struct {
+	Cid     cid.Cid
+	GasUsed types.GasUnits
+	Preview bool
+}
+
+var minerUpdateAddrsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Change the multiaddrs a miner advertises",
+		ShortDescription: `Issues a message from the worker of the miner replacing the multiaddrs the miner
+advertises on chain, then waits for the message to be mined. Clients dial these
+addresses when they cannot find the miner's peer through the DHT. Giving no
+multiaddrs clears them.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "Miner address to update multiaddrs for"),
+		cmdkit.StringArg("multiaddrs", false, true, "The multiaddrs the miner can be dialed at, without a peer ID"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		addrs := []ma.Multiaddr{}
+		for _, arg := range req.Arguments[1:] {
+			addr, err := ma.NewMultiaddr(arg)
+			if err != nil {
+				return errors.Wrapf(err, "invalid multiaddr %s", arg)
+			}
+			addrs = append(addrs, addr)
+		}
+
+		fromAddr, err := fromAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		if preview {
+			usedGas, err := GetPorcelainAPI(env).MessagePreview(
+				req.Context,
+				fromAddr,
+				minerAddr,
+				"updateMultiaddrs",
+				addrs,
+			)
+			if err != nil {
+				return err
+			}
+
+			return re.Emit(&MinerUpdateAddrsResult{
+				Cid:     cid.Cid{},
+				GasUsed: usedGas,
+				Preview: true,
+			})
+		}
+
+		c, err := GetPorcelainAPI(env).MinerUpdateMultiaddrs(req.Context, fromAddr, minerAddr, addrs, gasPrice, gasLimit)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&MinerUpdateAddrsResult{
+			Cid:     c,
+			GasUsed: types.NewGasUnits(0),
+			Preview: false,
+		})
+	},
+	Type: &MinerUpdateAddrsResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *MinerUpdateAddrsResult) error {
+			if res.Preview {
+				output := strconv.FormatUint(uint64(res.GasUsed), 10)
+				_, err := w.Write([]byte(output))
+				return err
+			}
+			return PrintString(w, res.Cid)
+		}),
+	},
+}
+
+// MinerChangeOwnerResult is the type returned when proposing or accepting a
+// new owner of a miner.
+type MinerChangeOwnerResult struct {
+	Cid     cid.Cid
+	GasUsed types.GasUnits
+	Preview bool
+}
+
+var minerChangeOwnerCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Transfer a miner to a new owner",
+		ShortDescription: `Changing the owner of a miner needs the approval of both owners. The owner first
+proposes the new owner, sending this command from the owner address. The new
+owner then accepts, sending this command with its own address as <owner> from
+that address. The owner cancels a proposal by proposing itself. The command
+waits for the message to be mined.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", true, false, "The address of the miner"),
+		cmdkit.StringArg("owner", true, false, "The address of the new owner"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		ownerAddr, err := address.NewFromString(req.Arguments[1])
+		if err != nil {
+			return err
+		}
+
+		fromAddr, err := fromAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		if preview {
+			usedGas, err := GetPorcelainAPI(env).MessagePreview(
+				req.Context,
+				fromAddr,
+				minerAddr,
+				"changeOwner",
+				ownerAddr,
+			)
+			if err != nil {
+				return err
+			}
+
+			return re.Emit(&MinerChangeOwnerResult{
+				Cid:     cid.Cid{},
+				GasUsed: usedGas,
+				Preview: true,
+			})
+		}
+
+		c, err := GetPorcelainAPI(env).MinerChangeOwner(req.Context, fromAddr, minerAddr, ownerAddr, gasPrice, gasLimit)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&MinerChangeOwnerResult{
+			Cid:     c,
+			GasUsed: types.NewGasUnits(0),
+			Preview: false,
+		})
+	},
+	Type: &MinerChangeOwnerResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *MinerChangeOwnerResult) error {
+			if res.Preview {
+				output := strconv.FormatUint(uint64(res.GasUsed), 10)
+				_, err := w.Write([]byte(output))
+				return err
+			}
+			return PrintString(w, res.Cid)
+		}),
+	},
+}
+
 var minerOwnerCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline:          "Show the actor address of <miner>",
@@ -426,6 +660,67 @@ Values will be output as a ratio where the first number is the miner power and s
 	},
 }
 
+// MinerPowerTableEntry is the power of a single miner listed by the miner
+// power-table command.
+type MinerPowerTableEntry struct {
+	Miner address.Address   `json:"miner"`
+	Power types.BytesAmount `json:"power"`
+}
+
+var minerPowerTableCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Get the power of every miner",
+		ShortDescription: `Lists the power of every miner of the storage market at the head of the chain,
+or at the tipset given by a comma separated list of block CIDs.`,
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("tipset", "comma separated CIDs of the blocks of the tipset to get the power table of"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		var tsKey types.TipSetKey
+		if tipset, ok := req.Options["tipset"].(string); ok {
+			var ids []cid.Cid
+			for _, s := range strings.Split(tipset, ",") {
+				id, err := cid.Decode(strings.TrimSpace(s))
+				if err != nil {
+					return errors.Wrapf(err, "invalid block cid %s", s)
+				}
+				ids = append(ids, id)
+			}
+			key, err := types.NewTipSetKeyFromUnique(ids...)
+			if err != nil {
+				return err
+			}
+			tsKey = key
+		}
+
+		table, err := GetPorcelainAPI(env).MinerGetPowerTable(req.Context, tsKey)
+		if err != nil {
+			return err
+		}
+
+		var entries []MinerPowerTableEntry
+		for miner, power := range table {
+			entries = append(entries, MinerPowerTableEntry{Miner: miner, Power: *power})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Miner.String() < entries[j].Miner.String()
+		})
+		return re.Emit(entries)
+	},
+	Type: []MinerPowerTableEntry{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, entries []MinerPowerTableEntry) error {
+			for _, entry := range entries {
+				if _, err := fmt.Fprintf(w, "%s %s\n", entry.Miner, entry.Power.String()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
 var minerCollateralCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline:          "Get the active collateral of a miner",
@@ -453,6 +748,362 @@ var minerCollateralCmd = &cmds.Command{
 	},
 }
 
+var minerCollateralStatusCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Get the required and available collateral of a miner",
+		ShortDescription: `Check the collateral a given miner is required to hold for its sectors, and the
+balance in excess of it that the owner may withdraw. Values reported in FIL.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", false, false, "The address of the miner"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := minerAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+		collateral, err := GetPorcelainAPI(env).MinerGetCollateralStatus(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+		return re.Emit(&collateral)
+	},
+	Type: porcelain.MinerCollateral{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *porcelain.MinerCollateral) error {
+			_, err := fmt.Fprintf(w, `Required:  %s
+Available: %s
+`,
+				res.Required.String(),
+				res.Available.String(),
+			)
+			return err
+		}),
+	},
+}
+
+// MinerCollateralResult is the type returned when adding or withdrawing
+// collateral.
+type MinerCollateralResult struct {
+	Cid     cid.Cid
+	GasUsed types.GasUnits
+	Preview bool
+}
+
+var minerCollateralAddCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Add collateral to a miner",
+		ShortDescription: `Issues a message from the owner or worker of the miner sending <amount> FIL to the
+miner's collateral, then waits for the message to be mined.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("amount", true, false, "The amount of collateral to add, in FIL"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("miner", "The address of the miner"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		return runMinerCollateralCmd(req, re, env, "addCollateral")
+	},
+	Type: &MinerCollateralResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(encodeMinerCollateralResult),
+	},
+}
+
+var minerCollateralWithdrawCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Withdraw available collateral of a miner",
+		ShortDescription: `Issues a message from the owner of the miner withdrawing <amount> FIL of the
+miner's available collateral to the owner, then waits for the message to be
+mined. Only the collateral in excess of what the miner is required to hold for
+its sectors is available.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("amount", true, false, "The amount of collateral to withdraw, in FIL"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("miner", "The address of the miner"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		return runMinerCollateralCmd(req, re, env, "withdrawCollateral")
+	},
+	Type: &MinerCollateralResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(encodeMinerCollateralResult),
+	},
+}
+
+// runMinerCollateralCmd adds collateral to or withdraws collateral from a
+// miner with the given miner actor method.
+func runMinerCollateralCmd(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment, method string) error {
+	amount, ok := types.NewAttoFILFromFILString(req.Arguments[0])
+	if !ok {
+		return ErrInvalidAmount
+	}
+
+	minerAddr, err := optionalAddr(req.Options["miner"])
+	if err != nil {
+		return err
+	}
+	if minerAddr.Empty() {
+		if minerAddr, err = configuredMinerAddr(env); err != nil {
+			return err
+		}
+	}
+
+	fromAddr, err := fromAddrOrDefault(req, env)
+	if err != nil {
+		return err
+	}
+
+	gasPrice, gasLimit, preview, err := parseGasOptions(req)
+	if err != nil {
+		return err
+	}
+
+	var params []interface{}
+	if method == "withdrawCollateral" {
+		params = append(params, amount)
+	}
+
+	if preview {
+		usedGas, err := GetPorcelainAPI(env).MessagePreview(
+			req.Context,
+			fromAddr,
+			minerAddr,
+			method,
+			params...,
+		)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&MinerCollateralResult{
+			Cid:     cid.Cid{},
+			GasUsed: usedGas,
+			Preview: true,
+		})
+	}
+
+	var c cid.Cid
+	if method == "withdrawCollateral" {
+		c, err = GetPorcelainAPI(env).MinerWithdrawCollateral(req.Context, fromAddr, minerAddr, amount, gasPrice, gasLimit)
+	} else {
+		c, err = GetPorcelainAPI(env).MinerAddCollateral(req.Context, fromAddr, minerAddr, amount, gasPrice, gasLimit)
+	}
+	if err != nil {
+		return err
+	}
+
+	return re.Emit(&MinerCollateralResult{
+		Cid:     c,
+		GasUsed: types.NewGasUnits(0),
+		Preview: false,
+	})
+}
+
+func encodeMinerCollateralResult(req *cmds.Request, w io.Writer, res *MinerCollateralResult) error {
+	if res.Preview {
+		output := strconv.FormatUint(uint64(res.GasUsed), 10)
+		_, err := w.Write([]byte(output))
+		return err
+	}
+	return PrintString(w, res.Cid)
+}
+
+var minerFaultsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the declared faults of a miner",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":      minerFaultsLsCmd,
+		"declare": minerFaultsDeclareCmd,
+		"recover": minerFaultsRecoverCmd,
+	},
+}
+
+var minerFaultsLsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "List the sectors a miner declared faulty",
+		ShortDescription: `Lists the sectors of the given miner, or of the node's miner if no miner is given, that were declared faulty and not declared recovered.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", false, false, "The address of the miner"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := minerAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+		faults, err := GetPorcelainAPI(env).MinerGetDeclaredFaults(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+		return re.Emit(faults.Values())
+	},
+	Type: []uint64{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, sectorIDs []uint64) error {
+			for _, id := range sectorIDs {
+				if _, err := fmt.Fprintln(w, id); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+// MinerFaultsResult is the type returned when declaring sectors faulty or
+// recovered.
+type MinerFaultsResult struct {
+	Cid     cid.Cid
+	GasUsed types.GasUnits
+	Preview bool
+}
+
+var minerFaultsDeclareCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Declare sectors of a miner faulty",
+		ShortDescription: `Issues a message from the worker of the miner declaring the given sectors faulty
+ahead of the next PoSt, then waits for the message to be mined. The penalty for
+declared faults is lower than for faults reported with a PoSt, and is paid from
+the miner's available collateral for every proving period the sectors are not
+proven. The sectors are not proven until they are declared recovered, and are
+dropped once the available collateral no longer covers the penalty.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("sectors", true, true, "The ids of the faulty sectors"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("miner", "The address of the miner"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		return runMinerFaultsCmd(req, re, env, "declareFaults")
+	},
+	Type: &MinerFaultsResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(encodeMinerFaultsResult),
+	},
+}
+
+var minerFaultsRecoverCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Declare faulty sectors of a miner recovered",
+		ShortDescription: `Issues a message from the worker of the miner declaring the given sectors, which
+were declared faulty, recovered, then waits for the message to be mined. The
+sectors rejoin the proving set at the next PoSt.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("sectors", true, true, "The ids of the recovered sectors"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("miner", "The address of the miner"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		return runMinerFaultsCmd(req, re, env, "declareRecovery")
+	},
+	Type: &MinerFaultsResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(encodeMinerFaultsResult),
+	},
+}
+
+// runMinerFaultsCmd declares the sectors given as arguments faulty or
+// recovered with the given miner actor method.
+func runMinerFaultsCmd(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment, method string) error {
+	var ids []uint64
+	for _, arg := range req.Arguments {
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return errors.Wrapf(err, "invalid sector id %s", arg)
+		}
+		ids = append(ids, id)
+	}
+	sectorIDs := types.NewIntSet(ids...)
+
+	minerAddr, err := optionalAddr(req.Options["miner"])
+	if err != nil {
+		return err
+	}
+	if minerAddr.Empty() {
+		if minerAddr, err = configuredMinerAddr(env); err != nil {
+			return err
+		}
+	}
+
+	fromAddr, err := fromAddrOrDefault(req, env)
+	if err != nil {
+		return err
+	}
+
+	gasPrice, gasLimit, preview, err := parseGasOptions(req)
+	if err != nil {
+		return err
+	}
+
+	if preview {
+		usedGas, err := GetPorcelainAPI(env).MessagePreview(
+			req.Context,
+			fromAddr,
+			minerAddr,
+			method,
+			sectorIDs,
+		)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&MinerFaultsResult{
+			Cid:     cid.Cid{},
+			GasUsed: usedGas,
+			Preview: true,
+		})
+	}
+
+	var c cid.Cid
+	if method == "declareRecovery" {
+		c, err = GetPorcelainAPI(env).MinerDeclareRecovery(req.Context, fromAddr, minerAddr, sectorIDs, gasPrice, gasLimit)
+	} else {
+		c, err = GetPorcelainAPI(env).MinerDeclareFaults(req.Context, fromAddr, minerAddr, sectorIDs, gasPrice, gasLimit)
+	}
+	if err != nil {
+		return err
+	}
+
+	return re.Emit(&MinerFaultsResult{
+		Cid:     c,
+		GasUsed: types.NewGasUnits(0),
+		Preview: false,
+	})
+}
+
+func encodeMinerFaultsResult(req *cmds.Request, w io.Writer, res *MinerFaultsResult) error {
+	if res.Preview {
+		output := strconv.FormatUint(uint64(res.GasUsed), 10)
+		_, err := w.Write([]byte(output))
+		return err
+	}
+	return PrintString(w, res.Cid)
+}
+
 var minerProvingPeriodCmd = &cmds.Command{
 	Arguments: []cmdkit.Argument{
 		cmdkit.StringArg("miner", true, false, "Miner address to get proving period for"),
@@ -490,3 +1141,196 @@ ProvingSet: %s
 		}),
 	},
 }
+
+var minerAsksCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage the asks of a miner",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"ls":     minerAsksLsCmd,
+		"cancel": minerAsksCancelCmd,
+	},
+}
+
+// MinerAskResult is a single ask listed by the miner asks ls command.
+type MinerAskResult struct {
+	ID      uint64             `json:"id"`
+	Price   types.AttoFIL      `json:"price"`
+	Expiry  *types.BlockHeight `json:"expiry"`
+	Expired bool               `json:"expired"`
+}
+
+var minerAsksLsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "List the asks of a miner",
+		ShortDescription: `
+Lists the asks of the given miner, or of the node's miner if no miner is given.
+Asks that have expired but have not been removed from the miner's state yet are
+marked as expired.
+`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("miner", false, false, "The address of the miner"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		minerAddr, err := minerAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		asks, err := GetPorcelainAPI(env).MinerGetAsks(req.Context, minerAddr)
+		if err != nil {
+			return err
+		}
+
+		height, err := GetPorcelainAPI(env).ChainBlockHeight()
+		if err != nil {
+			return err
+		}
+
+		for _, ask := range asks {
+			if err := re.Emit(&MinerAskResult{
+				ID:      ask.ID.Uint64(),
+				Price:   ask.Price,
+				Expiry:  ask.Expiry,
+				Expired: ask.Expiry.LessEqual(height),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Type: &MinerAskResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, ask *MinerAskResult) error {
+			status := ""
+			if ask.Expired {
+				status = " (expired)"
+			}
+			_, err := fmt.Fprintf(w, "%.3d %s %s%s\n", ask.ID, ask.Price, ask.Expiry, status)
+			return err
+		}),
+	},
+}
+
+// MinerAsksCancelResult is the type returned when canceling an ask.
+type MinerAsksCancelResult struct {
+	Cid     cid.Cid
+	GasUsed types.GasUnits
+	Preview bool
+}
+
+var minerAsksCancelCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Cancel an ask before it expires",
+		ShortDescription: `Issues a message that removes the ask with the given id from the miner.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("id", true, false, "The id of the ask to cancel"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address to send from"),
+		cmdkit.StringOption("miner", "The address of the miner owning the ask"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		askID, err := strconv.ParseUint(req.Arguments[0], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid ask id")
+		}
+
+		minerAddr, err := optionalAddr(req.Options["miner"])
+		if err != nil {
+			return err
+		}
+		if minerAddr.Empty() {
+			if minerAddr, err = configuredMinerAddr(env); err != nil {
+				return err
+			}
+		}
+
+		fromAddr, err := fromAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		id := big.NewInt(0).SetUint64(askID)
+		if preview {
+			usedGas, err := GetPorcelainAPI(env).MessagePreview(
+				req.Context,
+				fromAddr,
+				minerAddr,
+				"cancelAsk",
+				id,
+			)
+			if err != nil {
+				return err
+			}
+
+			return re.Emit(&MinerAsksCancelResult{
+				Cid:     cid.Cid{},
+				GasUsed: usedGas,
+				Preview: true,
+			})
+		}
+
+		c, err := GetPorcelainAPI(env).MessageSend(
+			req.Context,
+			fromAddr,
+			minerAddr,
+			types.ZeroAttoFIL,
+			gasPrice,
+			gasLimit,
+			"cancelAsk",
+			id,
+		)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&MinerAsksCancelResult{
+			Cid:     c,
+			GasUsed: types.NewGasUnits(0),
+			Preview: false,
+		})
+	},
+	Type: &MinerAsksCancelResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *MinerAsksCancelResult) error {
+			if res.Preview {
+				output := strconv.FormatUint(uint64(res.GasUsed), 10)
+				_, err := w.Write([]byte(output))
+				return err
+			}
+			return PrintString(w, res.Cid)
+		}),
+	},
+}
+
+// minerAddrOrDefault returns the miner address given as the first argument of
+// the request, or the node's configured miner address.
+func minerAddrOrDefault(req *cmds.Request, env cmds.Environment) (address.Address, error) {
+	if len(req.Arguments) > 0 {
+		return address.NewFromString(req.Arguments[0])
+	}
+	return configuredMinerAddr(env)
+}
+
+func configuredMinerAddr(env cmds.Environment) (address.Address, error) {
+	minerValue, err := GetPorcelainAPI(env).ConfigGet("mining.minerAddress")
+	if err != nil {
+		return address.Undef, errors.Wrap(err, "could not get miner address in config")
+	}
+	minerAddr, ok := minerValue.(address.Address)
+	if !ok || minerAddr.Empty() {
+		return address.Undef, errors.New("no miner address given and none configured")
+	}
+	return minerAddr, nil
+}
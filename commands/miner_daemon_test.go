@@ -356,6 +356,42 @@ func TestMinerPower(t *testing.T) {
 	assert.Equal(t, "3072 / 6144", power)
 }
 
+func TestMinerPowerTable(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	fi, err := ioutil.TempFile("", "gengentest")
+	require.NoError(t, err)
+
+	_, err = gengen.GenGenesisCar(testConfig, fi, 0)
+	require.NoError(t, err)
+	require.NoError(t, fi.Close())
+
+	d := th.NewDaemon(t, th.GenesisFile(fi.Name())).Start()
+	defer d.ShutdownSuccess()
+
+	actorLsOutput := d.RunSuccess("actor", "ls")
+
+	scanner := bufio.NewScanner(strings.NewReader(actorLsOutput.ReadStdout()))
+	var addressStruct struct{ Address string }
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "MinerActor") {
+			require.NoError(t, json.Unmarshal([]byte(line), &addressStruct))
+			break
+		}
+	}
+
+	table := d.RunSuccess("miner", "power-table").ReadStdout()
+	assert.Contains(t, table, fmt.Sprintf("%s 3072\n", addressStruct.Address))
+
+	head := strings.Fields(d.RunSuccess("chain", "head").ReadStdout())
+	tableAtHead := d.RunSuccess("miner", "power-table", "--tipset", strings.Join(head, ",")).ReadStdout()
+	assert.Equal(t, table, tableAtHead)
+
+	d.RunFail("invalid block cid", "miner", "power-table", "--tipset", "notacid")
+}
+
 func TestMinerActiveCollateral(t *testing.T) {
 	tf.IntegrationTest(t)
 
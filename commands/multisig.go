@@ -0,0 +1,336 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs-cmdkit"
+	"github.com/ipfs/go-ipfs-cmds"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var multisigCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Manage accounts controlled by several signers",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"approve": multisigApproveCmd,
+		"create":  multisigCreateCmd,
+		"ls":      multisigLsCmd,
+		"propose": multisigProposeCmd,
+	},
+}
+
+var multisigCreateCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Create a multisig account",
+		ShortDescription: `Creates a multisig account of the signers, funded with the value, and waits for it
+to appear on chain. Transactions of the multisig are sent once --threshold signers
+approved them. With --unlock-duration, the value vests over that many blocks.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("signers", true, true, "Addresses of the signers"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.Uint64Option("threshold", "Number of signers that have to approve a transaction").WithDefault(uint64(1)),
+		cmdkit.Uint64Option("unlock-duration", "Number of blocks the value vests over").WithDefault(uint64(0)),
+		cmdkit.StringOption("value", "Value in FIL to fund the multisig with"),
+		cmdkit.StringOption("from", "Address to send from"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := fromAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		signers := make([]address.Address, len(req.Arguments))
+		for i, arg := range req.Arguments {
+			signers[i], err = address.NewFromString(arg)
+			if err != nil {
+				return errors.Wrapf(err, "invalid signer %s", arg)
+			}
+		}
+
+		value := types.ZeroAttoFIL
+		if rawVal, ok := req.Options["value"].(string); ok {
+			value, ok = types.NewAttoFILFromFILString(rawVal)
+			if !ok {
+				return ErrInvalidAmount
+			}
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		threshold, _ := req.Options["threshold"].(uint64)
+		unlockDuration, _ := req.Options["unlock-duration"].(uint64)
+
+		msAddr, err := GetPorcelainAPI(env).MultisigCreate(
+			req.Context,
+			fromAddr,
+			signers,
+			threshold,
+			types.NewBlockHeight(unlockDuration),
+			value,
+			gasPrice,
+			gasLimit,
+		)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(msAddr)
+	},
+	Type: address.Address{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, a *address.Address) error {
+			return PrintString(w, a)
+		}),
+	},
+}
+
+var multisigProposeCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Propose a transaction of a multisig",
+		ShortDescription: `Proposes a transaction of the multisig sending the value to the method of the target,
+called with the params, and waits for the proposal to be mined. The proposal
+counts as approved by the proposer. Prints the id of the transaction.`,
+		LongDescription: `Proposes a transaction of the multisig sending the value to the method of the target,
+called with the params, and waits for the proposal to be mined. The proposal
+counts as approved by the proposer. Prints the id of the transaction.
+
+The params are parsed according to the method's signature. Signers and the
+threshold of the multisig are changed by transactions of the multisig to itself:
+
+  go-filecoin multisig propose <multisig> <multisig> 0 addSigner <signer> true
+  go-filecoin multisig propose <multisig> <multisig> 0 changeThreshold 2`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("multisig", true, false, "Address of the multisig"),
+		cmdkit.StringArg("target", true, false, "Address of the actor to send the transaction to"),
+		cmdkit.StringArg("value", true, false, "Value in FIL to send"),
+		cmdkit.StringArg("method", false, false, "The method to invoke on the target actor"),
+		cmdkit.StringArg("params", false, true, "Params of the method"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address of the signer proposing the transaction"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := fromAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		msAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		target, err := address.NewFromString(req.Arguments[1])
+		if err != nil {
+			return err
+		}
+
+		value, ok := types.NewAttoFILFromFILString(req.Arguments[2])
+		if !ok {
+			return ErrInvalidAmount
+		}
+
+		var method string
+		var params []interface{}
+		if len(req.Arguments) > 3 {
+			method = req.Arguments[3]
+
+			signature, err := GetPorcelainAPI(env).ActorGetSignature(req.Context, target, method)
+			if err != nil {
+				return err
+			}
+			params, err = parseMethodParams(signature.Params, req.Arguments[4:])
+			if err != nil {
+				return err
+			}
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		txID, err := GetPorcelainAPI(env).MultisigPropose(
+			req.Context,
+			fromAddr,
+			msAddr,
+			target,
+			value,
+			gasPrice,
+			gasLimit,
+			method,
+			params...,
+		)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(txID)
+	},
+	Type: uint64(0),
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, txID *uint64) error {
+			return PrintString(w, *txID)
+		}),
+	},
+}
+
+var multisigApproveCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Approve a pending transaction of a multisig",
+		ShortDescription: `Approves the transaction of the multisig and waits for the approval to be mined.
+The transaction is sent once enough signers approved it.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("multisig", true, false, "Address of the multisig"),
+		cmdkit.StringArg("id", true, false, "Id of the transaction"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address of the signer approving the transaction"),
+		priceOption,
+		limitOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := fromAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		msAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		txID, err := strconv.ParseUint(req.Arguments[1], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid transaction id")
+		}
+
+		gasPrice, gasLimit, _, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		c, err := GetPorcelainAPI(env).MultisigApprove(req.Context, fromAddr, msAddr, txID, gasPrice, gasLimit)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(c)
+	},
+	Type: cid.Cid{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, c cid.Cid) error {
+			return PrintString(w, c)
+		}),
+	},
+}
+
+var multisigLsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline:          "Show the signers and pending transactions of a multisig",
+		ShortDescription: `Queries the multisig for its signers, threshold, vesting and pending transactions.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("multisig", true, false, "Address of the multisig"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		msAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		state, err := GetPorcelainAPI(env).MultisigLs(req.Context, msAddr)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(state)
+	},
+	Type: multisig.State{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, state *multisig.State) error {
+			if _, err := fmt.Fprintf(w, "signers: %v\nthreshold: %d\n", state.Signers, state.Threshold); err != nil {
+				return err
+			}
+			if state.UnlockDuration != nil && !state.UnlockDuration.Equal(types.NewBlockHeight(0)) {
+				_, err := fmt.Fprintf(w, "vesting: %s FIL over %s blocks from height %s\n", state.InitialBalance, state.UnlockDuration, state.StartHeight)
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(state.Transactions) == 0 {
+				fmt.Fprintln(w, "no pending transactions") // nolint: errcheck
+				return nil
+			}
+			for _, tx := range state.Transactions {
+				_, err := fmt.Fprintf(w, "%d: to: %s, value: %s, method: %q, approved: %v\n", tx.ID, tx.To, tx.Value, tx.Method, tx.Approved)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
+// parseMethodParams parses the string arguments of a method into values of
+// its param types.
+func parseMethodParams(paramTypes []abi.Type, args []string) ([]interface{}, error) {
+	if len(args) != len(paramTypes) {
+		return nil, fmt.Errorf("method takes %d params, got %d", len(paramTypes), len(args))
+	}
+
+	params := make([]interface{}, len(args))
+	for i, arg := range args {
+		var ok bool
+		switch paramTypes[i] {
+		case abi.Address:
+			addr, err := address.NewFromString(arg)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid address param %s", arg)
+			}
+			params[i], ok = addr, true
+		case abi.AttoFIL:
+			params[i], ok = types.NewAttoFILFromFILString(arg)
+		case abi.BlockHeight:
+			params[i], ok = types.NewBlockHeightFromString(arg, 10)
+		case abi.BytesAmount:
+			params[i], ok = types.NewBytesAmountFromString(arg, 10)
+		case abi.Boolean:
+			b, err := strconv.ParseBool(arg)
+			params[i], ok = b, err == nil
+		case abi.SectorID, abi.Uint64:
+			n, err := strconv.ParseUint(arg, 10, 64)
+			params[i], ok = n, err == nil
+		case abi.String:
+			params[i], ok = arg, true
+		default:
+			return nil, fmt.Errorf("params of type %s are not supported", paramTypes[i])
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid %s param %s", paramTypes[i], arg)
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,94 @@
+package commands_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/fixtures"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestMultisigProposeAndApprove(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	d := th.NewDaemon(
+		t,
+		th.DefaultAddress(fixtures.TestAddresses[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[1]),
+		// must include same-index KeyFilePath when configuring with a TestMiner.
+		th.WithMiner(fixtures.TestMiners[0]),
+		th.KeyFile(fixtures.KeyFilePaths()[0]),
+	).Start()
+	defer d.ShutdownSuccess()
+
+	signer1 := fixtures.TestAddresses[0]
+	signer2 := fixtures.TestAddresses[1]
+
+	// runMined runs a command waiting for its message to be mined, mining a
+	// block after it is sent.
+	runMined := func(args ...string) string {
+		var out string
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			out = d.RunSuccess(args...).ReadStdoutTrimNewlines()
+			wg.Done()
+		}()
+		// ensure mining runs after the command in our goroutine
+		d.MineAndPropagate(time.Second)
+		wg.Wait()
+		return out
+	}
+
+	t.Log("[success] create a multisig of two signers")
+	msAddrStr := runMined("multisig", "create",
+		"--from", signer1,
+		"--threshold", "2",
+		"--value", "10",
+		"--gas-price", "1", "--gas-limit", "300",
+		signer1, signer2,
+	)
+	msAddr, err := address.NewFromString(msAddrStr)
+	require.NoError(t, err)
+	assert.NotEqual(t, address.Undef, msAddr)
+
+	ls := d.RunSuccess("multisig", "ls", msAddrStr).ReadStdout()
+	assert.Contains(t, ls, "threshold: 2")
+	assert.Contains(t, ls, "no pending transactions")
+
+	t.Log("[success] propose a transfer")
+	txID := runMined("multisig", "propose",
+		"--from", signer1,
+		"--gas-price", "1", "--gas-limit", "300",
+		msAddrStr, fixtures.TestAddresses[3], "1",
+	)
+	assert.Equal(t, "0", txID)
+
+	ls = d.RunSuccess("multisig", "ls", msAddrStr).ReadStdout()
+	assert.Contains(t, ls, "0: to: "+fixtures.TestAddresses[3])
+	assert.Contains(t, ls, "approved: ["+signer1+"]")
+
+	t.Log("[failure] invalid transaction id")
+	d.RunFail("invalid transaction id",
+		"multisig", "approve",
+		"--from", signer2,
+		"--gas-price", "1", "--gas-limit", "300",
+		msAddrStr, "not-an-id",
+	)
+
+	t.Log("[success] the second approval sends the transaction")
+	runMined("multisig", "approve",
+		"--from", signer2,
+		"--gas-price", "1", "--gas-limit", "300",
+		msAddrStr, txID,
+	)
+
+	ls = d.RunSuccess("multisig", "ls", msAddrStr).ReadStdout()
+	assert.Contains(t, ls, "no pending transactions")
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-cmdkit"
@@ -18,14 +19,16 @@ var paymentChannelCmd = &cmds.Command{
 		Tagline: "Payment channel operations",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"cancel":  cancelCmd,
-		"close":   closeCmd,
-		"create":  createChannelCmd,
-		"extend":  extendCmd,
-		"ls":      lsCmd,
-		"reclaim": reclaimCmd,
-		"redeem":  redeemCmd,
-		"voucher": voucherCmd,
+		"add-funds": addFundsCmd,
+		"cancel":    cancelCmd,
+		"close":     closeCmd,
+		"create":    createChannelCmd,
+		"extend":    extendCmd,
+		"ls":        lsCmd,
+		"reclaim":   reclaimCmd,
+		"redeem":    redeemCmd,
+		"status":    statusCmd,
+		"voucher":   voucherCmd,
 	},
 }
 
@@ -178,6 +181,67 @@ var lsCmd = &cmds.Command{
 	},
 }
 
+var statusCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Show the tracked state of a payment channel",
+		ShortDescription: `Shows the funds, amount redeemed and eol of a payment channel we are payer or
+target of, along with the vouchers issued and received for it.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("channel", true, false, "Id of the channel"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address for which message is sent"),
+		cmdkit.StringOption("payer", "Address of the channel payer (defaults to from if omitted)"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := fromAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		payerAddr, err := optionalAddr(req.Options["payer"])
+		if err != nil {
+			return err
+		}
+
+		channel, ok := types.NewChannelIDFromString(req.Arguments[0], 10)
+		if !ok {
+			return fmt.Errorf("invalid channel id")
+		}
+
+		info, err := GetPorcelainAPI(env).PaymentChannelStatus(req.Context, fromAddr, payerAddr, channel)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(info)
+	},
+	Type: &paych.ChannelInfo{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, info *paych.ChannelInfo) error {
+			remaining := info.Amount.Sub(info.AmountRedeemed)
+			_, err := fmt.Fprintf(w, "channel %s: payer: %s, target: %s, amt: %s, amt redeemed: %s, remaining: %s, eol: %s\n",
+				info.Channel, info.Payer, info.Target, info.Amount, info.AmountRedeemed, remaining, info.Eol)
+			if err != nil {
+				return err
+			}
+
+			for _, voucher := range info.Issued {
+				if _, err := fmt.Fprintf(w, "issued: amt: %s, valid at: %s\n", voucher.Amount, voucher.ValidAt.String()); err != nil {
+					return err
+				}
+			}
+			for _, voucher := range info.Received {
+				if _, err := fmt.Fprintf(w, "received: amt: %s, valid at: %s\n", voucher.Amount, voucher.ValidAt.String()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+	},
+}
+
 var voucherCmd = &cmds.Command{
 	Helptext: cmdkit.HelpText{
 		Tagline:          "Create a new voucher from a payment channel",
@@ -190,6 +254,9 @@ var voucherCmd = &cmds.Command{
 	Options: []cmdkit.Option{
 		cmdkit.StringOption("from", "Address for which to retrieve channels"),
 		cmdkit.StringOption("validat", "Smallest block height at which target can redeem"),
+		cmdkit.Uint64Option("lane", "Channel lane the voucher pays on, which is no longer allocated to new payments. Defaults to 0"),
+		cmdkit.Uint64Option("nonce", "Nonce of the voucher within its lane. Defaults to 0"),
+		cmdkit.StringOption("merge", "Comma separated lane:nonce pairs of lanes merged into the voucher's lane. The amount must cover their funds"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		fromAddr, err := fromAddrOrDefault(req, env)
@@ -212,7 +279,15 @@ var voucherCmd = &cmds.Command{
 			return err
 		}
 
-		voucher, err := GetPorcelainAPI(env).PaymentChannelVoucher(req.Context, fromAddr, channel, amount, validAt, nil)
+		lane, _ := req.Options["lane"].(uint64)
+		nonce, _ := req.Options["nonce"].(uint64)
+
+		merges, err := optionalVoucherMerges(req.Options["merge"])
+		if err != nil {
+			return err
+		}
+
+		voucher, err := GetPorcelainAPI(env).PaymentChannelVoucher(req.Context, fromAddr, channel, amount, validAt, lane, nonce, merges, nil)
 		if err != nil {
 			return err
 		}
@@ -275,6 +350,9 @@ var redeemCmd = &cmds.Command{
 			&voucher.Channel,
 			voucher.Amount,
 			&voucher.ValidAt,
+			voucher.Lane,
+			voucher.Nonce,
+			voucher.Merges,
 			voucher.Condition,
 			[]byte(voucher.Signature),
 			[]interface{}{},
@@ -450,6 +528,9 @@ var closeCmd = &cmds.Command{
 			&voucher.Channel,
 			voucher.Amount,
 			&voucher.ValidAt,
+			voucher.Lane,
+			voucher.Nonce,
+			voucher.Merges,
 			voucher.Condition,
 			[]byte(voucher.Signature),
 			[]interface{}{},
@@ -594,6 +675,92 @@ var extendCmd = &cmds.Command{
 	},
 }
 
+// AddFundsResult type returned from AddFunds
+type AddFundsResult struct {
+	Cid     cid.Cid
+	GasUsed types.GasUnits
+	Preview bool
+}
+
+var addFundsCmd = &cmds.Command{
+	Helptext: cmdkit.HelpText{
+		Tagline: "Add funds to a payment channel",
+		ShortDescription: `Issues a message adding funds to an existing payment channel without changing its
+eol, then waits for the message to be mined. Vouchers may then be issued up to the new total.`,
+	},
+	Arguments: []cmdkit.Argument{
+		cmdkit.StringArg("channel", true, false, "Id of channel to add funds to"),
+		cmdkit.StringArg("amount", true, false, "Amount in FIL to add to the channel"),
+	},
+	Options: []cmdkit.Option{
+		cmdkit.StringOption("from", "Address of the channel creator"),
+		priceOption,
+		limitOption,
+		previewOption,
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromAddr, err := fromAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		channel, ok := types.NewChannelIDFromString(req.Arguments[0], 10)
+		if !ok {
+			return fmt.Errorf("invalid channel id")
+		}
+
+		amount, ok := types.NewAttoFILFromFILString(req.Arguments[1])
+		if !ok {
+			return ErrInvalidAmount
+		}
+
+		gasPrice, gasLimit, preview, err := parseGasOptions(req)
+		if err != nil {
+			return err
+		}
+
+		if preview {
+			usedGas, err := GetPorcelainAPI(env).MessagePreview(
+				req.Context,
+				fromAddr,
+				address.PaymentBrokerAddress,
+				"addFunds",
+				channel,
+			)
+			if err != nil {
+				return err
+			}
+			return re.Emit(&AddFundsResult{
+				Cid:     cid.Cid{},
+				GasUsed: usedGas,
+				Preview: true,
+			})
+		}
+
+		c, err := GetPorcelainAPI(env).PaymentChannelAddFunds(req.Context, fromAddr, channel, amount, gasPrice, gasLimit)
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(&AddFundsResult{
+			Cid:     c,
+			GasUsed: types.NewGasUnits(0),
+			Preview: false,
+		})
+	},
+	Type: &AddFundsResult{},
+	Encoders: cmds.EncoderMap{
+		cmds.Text: cmds.MakeTypedEncoder(func(req *cmds.Request, w io.Writer, res *AddFundsResult) error {
+			if res.Preview {
+				output := strconv.FormatUint(uint64(res.GasUsed), 10)
+				_, err := w.Write([]byte(output))
+				return err
+			}
+			return PrintString(w, res.Cid)
+		}),
+	},
+}
+
 // CancelResult type returned from Cancel
 type CancelResult struct {
 	Cid     cid.Cid
@@ -204,6 +204,61 @@ func TestPaymentChannelRedeemSuccess(t *testing.T) {
 	assert.Equal(t, voucherAmount, channel.AmountRedeemed)
 }
 
+func TestPaymentChannelRedeemMergedVoucherSuccess(t *testing.T) {
+	tf.IntegrationTest(t)
+
+	ctx, env := fastesting.NewTestEnvironment(context.Background(), t, fast.FilecoinOpts{})
+
+	// Teardown after test ends
+	defer func() {
+		err := env.Teardown(ctx)
+		require.NoError(t, err)
+	}()
+
+	// Start test
+	rsrc := requireNewPaychResource(ctx, t, env)
+
+	channelExpiry := types.NewBlockHeight(20)
+	channelAmount := types.NewAttoFILFromFIL(1000)
+
+	chanid, _ := rsrc.requirePaymentChannel(ctx, t, channelAmount, channelExpiry)
+
+	redeem := func(voucherStr string) *types.MessageReceipt {
+		mcid, err := rsrc.target.PaychRedeem(ctx, voucherStr, fast.AOFromAddr(rsrc.targetAddr), fast.AOPrice(big.NewFloat(1)), fast.AOLimit(300))
+		require.NoError(t, err)
+
+		series.CtxMiningOnce(ctx)
+
+		resp, err := rsrc.target.MessageWait(ctx, mcid)
+		require.NoError(t, err)
+		return resp.Receipt
+	}
+
+	// pay 10 on lane 1 and redeem it
+	laneVoucherStr, err := rsrc.payer.PaychVoucher(ctx, chanid, types.NewAttoFILFromFIL(10), fast.AOFromAddr(rsrc.payerAddr), fast.AOLane(1))
+	require.NoError(t, err)
+	assert.Equal(t, 0, int(redeem(laneVoucherStr).ExitCode))
+
+	// merge lane 1 into lane 0 with a voucher covering its funds and 20 more
+	mergedVoucherStr, err := rsrc.payer.PaychVoucher(ctx, chanid, types.NewAttoFILFromFIL(30), fast.AOFromAddr(rsrc.payerAddr), fast.AOLane(0), fast.AOMerges(types.VoucherMerge{Lane: 1, Nonce: 0}))
+	require.NoError(t, err)
+
+	mergedVoucher, err := types.DecodeVoucher(mergedVoucherStr)
+	require.NoError(t, err)
+	assert.Equal(t, []types.VoucherMerge{{Lane: 1, Nonce: 0}}, mergedVoucher.Merges)
+
+	assert.Equal(t, 0, int(redeem(mergedVoucherStr).ExitCode))
+
+	channels, err := rsrc.target.PaychLs(ctx, fast.AOFromAddr(rsrc.payerAddr))
+	require.NoError(t, err)
+
+	channel := channels[chanid.String()]
+	assert.Equal(t, types.NewAttoFILFromFIL(30), channel.AmountRedeemed)
+
+	// the merged lane's voucher is superseded by the merge
+	assert.Equal(t, paymentbroker.ErrStaleNonce, int(redeem(laneVoucherStr).ExitCode))
+}
+
 func TestPaymentChannelRedeemTooEarlyFails(t *testing.T) {
 	tf.IntegrationTest(t)
 
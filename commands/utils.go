@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	"github.com/ipfs/go-ipfs-cmds"
 	"github.com/pkg/errors"
@@ -102,6 +103,32 @@ func optionalBlockHeight(o interface{}) (ret *types.BlockHeight, err error) {
 	return validAt, nil
 }
 
+// optionalVoucherMerges parses a comma separated list of lane:nonce pairs,
+// each naming a lane merged by a voucher and the nonce it is merged at.
+func optionalVoucherMerges(o interface{}) ([]types.VoucherMerge, error) {
+	if o == nil {
+		return nil, nil
+	}
+
+	var merges []types.VoucherMerge
+	for _, s := range strings.Split(o.(string), ",") {
+		parts := strings.Split(strings.TrimSpace(s), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid merge %s, expected lane:nonce", s)
+		}
+		lane, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid lane in merge %s", s)
+		}
+		nonce, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid nonce in merge %s", s)
+		}
+		merges = append(merges, types.VoucherMerge{Lane: lane, Nonce: nonce})
+	}
+	return merges, nil
+}
+
 func optionalAddr(o interface{}) (ret address.Address, err error) {
 	if o != nil {
 		ret, err = address.NewFromString(o.(string))
@@ -107,19 +107,44 @@ type MiningConfig struct {
 	MinerAddress            address.Address `json:"minerAddress"`
 	AutoSealIntervalSeconds uint            `json:"autoSealIntervalSeconds"`
 	StoragePrice            types.AttoFIL   `json:"storagePrice"`
+	// AutoRepriceAsks makes the storage miner keep one live ask at
+	// StoragePrice, renewing it before it expires.
+	AutoRepriceAsks bool `json:"autoRepriceAsks"`
+	// AskDurationBlocks is the number of blocks asks created by the
+	// repricing policy are valid for.
+	AskDurationBlocks uint64 `json:"askDurationBlocks"`
+	// AutoRedeemVouchers makes the storage miner redeem the best voucher
+	// of each payment channel of its deals as soon as it is valid.
+	AutoRedeemVouchers bool `json:"autoRedeemVouchers"`
+	// VoucherRedeemBatchBlocks is the number of blocks over which voucher
+	// redemptions are batched. Vouchers of channels nearing their eol are
+	// redeemed without waiting for the batch.
+	VoucherRedeemBatchBlocks uint64 `json:"voucherRedeemBatchBlocks"`
+	// ReportConsensusFaults makes the node send the evidence of the
+	// consensus faults it detects to the miner actor of the faulty miner,
+	// paying the gas from the default wallet address.
+	ReportConsensusFaults bool `json:"reportConsensusFaults"`
 }
 
 func newDefaultMiningConfig() *MiningConfig {
 	return &MiningConfig{
-		MinerAddress:            address.Undef,
-		AutoSealIntervalSeconds: 120,
-		StoragePrice:            types.ZeroAttoFIL,
+		MinerAddress:             address.Undef,
+		AutoSealIntervalSeconds:  120,
+		StoragePrice:             types.ZeroAttoFIL,
+		AutoRepriceAsks:          false,
+		AskDurationBlocks:        1000,
+		AutoRedeemVouchers:       false,
+		VoucherRedeemBatchBlocks: 1,
+		ReportConsensusFaults:    false,
 	}
 }
 
 // WalletConfig holds all configuration options related to the wallet.
 type WalletConfig struct {
 	DefaultAddress address.Address `json:"defaultAddress,omitempty"`
+	// RemoteSigner is the endpoint of an external signer holding keys next to
+	// the local wallet, unix:///path/to/socket.
+	RemoteSigner string `json:"remoteSigner,omitempty"`
 }
 
 func newDefaultWalletConfig() *WalletConfig {
@@ -75,7 +75,12 @@ func TestWriteFile(t *testing.T) {
 	"mining": {
 		"minerAddress": "empty",
 		"autoSealIntervalSeconds": 120,
-		"storagePrice": "0"
+		"storagePrice": "0",
+		"autoRepriceAsks": false,
+		"askDurationBlocks": 1000,
+		"autoRedeemVouchers": false,
+		"voucherRedeemBatchBlocks": 1,
+		"reportConsensusFaults": false
 	},
 	"mpool": {
 		"maxPoolSize": 10000,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/clock"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -19,7 +20,7 @@ type BlockValidator interface {
 // BlockSemanticValidator defines an interface used to validate a blocks
 // semantics.
 type BlockSemanticValidator interface {
-	ValidateSemantic(ctx context.Context, child *types.Block, parents *types.TipSet) error
+	ValidateSemantic(ctx context.Context, child *types.Block, parents *types.TipSet, worker address.Address) error
 }
 
 // BlockSyntaxValidator defines an interface used to validate a blocks
@@ -43,8 +44,9 @@ func NewDefaultBlockValidator(blkTime time.Duration, c clock.Clock) *DefaultBloc
 	}
 }
 
-// ValidateSemantic validates a block is correctly derived from its parent.
-func (dv *DefaultBlockValidator) ValidateSemantic(ctx context.Context, child *types.Block, parents *types.TipSet) error {
+// ValidateSemantic validates a block is correctly derived from its parent and
+// signed by worker, the worker of its miner in the parent state.
+func (dv *DefaultBlockValidator) ValidateSemantic(ctx context.Context, child *types.Block, parents *types.TipSet, worker address.Address) error {
 	pmin, err := parents.MinTimestamp()
 	if err != nil {
 		return err
@@ -66,6 +68,10 @@ func (dv *DefaultBlockValidator) ValidateSemantic(ctx context.Context, child *ty
 	if uint64(child.Timestamp) < limit {
 		return fmt.Errorf("block %s with timestamp %d generated too far past parent, expected timestamp < %d", child.Cid().String(), child.Timestamp, limit)
 	}
+
+	if !child.VerifySignature(worker) {
+		return fmt.Errorf("block %s is not signed by worker %s of its miner", child.Cid().String(), worker)
+	}
 	return nil
 }
 
@@ -89,7 +95,9 @@ func (dv *DefaultBlockValidator) ValidateSyntax(ctx context.Context, blk *types.
 	if len(blk.Ticket) == 0 {
 		return fmt.Errorf("block %s has nil ticket", blk.Cid().String())
 	}
-	// TODO validate block signature: 1054
+	if len(blk.BlockSig) == 0 {
+		return fmt.Errorf("block %s has nil signature", blk.Cid().String())
+	}
 	return nil
 }
 
@@ -26,18 +26,32 @@ func TestBlockValidSemantic(t *testing.T) {
 
 	validator := consensus.NewDefaultBlockValidator(blockTime, mclock)
 
+	signer, kis := types.NewMockSignersAndKeyInfo(2)
+	worker, err := kis[0].Address()
+	require.NoError(t, err)
+	other, err := kis[1].Address()
+	require.NoError(t, err)
+
+	// sign signs a block with the key of addr.
+	sign := func(blk *types.Block, addr address.Address) *types.Block {
+		sig, err := signer.SignBytes(blk.SignatureData(), addr)
+		require.NoError(t, err)
+		blk.BlockSig = sig
+		return blk
+	}
+
 	t.Run("reject block with same height as parents", func(t *testing.T) {
 		// passes with valid height
 		c := &types.Block{Height: 2, Timestamp: types.Uint64(ts.Add(blockTime).Unix())}
 		p := &types.Block{Height: 1, Timestamp: types.Uint64(ts.Unix())}
 		parents := consensus.RequireNewTipSet(require.New(t), p)
-		require.NoError(t, validator.ValidateSemantic(ctx, c, &parents))
+		require.NoError(t, validator.ValidateSemantic(ctx, sign(c, worker), &parents, worker))
 
 		// invalidate parent by matching child height
 		p = &types.Block{Height: 2, Timestamp: types.Uint64(ts.Unix())}
 		parents = consensus.RequireNewTipSet(require.New(t), p)
 
-		err := validator.ValidateSemantic(ctx, c, &parents)
+		err := validator.ValidateSemantic(ctx, sign(c, worker), &parents, worker)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid height")
 
@@ -48,11 +62,11 @@ func TestBlockValidSemantic(t *testing.T) {
 		c := &types.Block{Height: 2, Timestamp: types.Uint64(ts.Add(blockTime).Unix())}
 		p := &types.Block{Height: 1, Timestamp: types.Uint64(ts.Unix())}
 		parents := consensus.RequireNewTipSet(require.New(t), p)
-		require.NoError(t, validator.ValidateSemantic(ctx, c, &parents))
+		require.NoError(t, validator.ValidateSemantic(ctx, sign(c, worker), &parents, worker))
 
 		// fails with invalid timestamp
 		c = &types.Block{Height: 2, Timestamp: types.Uint64(ts.Unix())}
-		err := validator.ValidateSemantic(ctx, c, &parents)
+		err := validator.ValidateSemantic(ctx, sign(c, worker), &parents, worker)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "too far")
 
@@ -63,22 +77,47 @@ func TestBlockValidSemantic(t *testing.T) {
 		c := &types.Block{Height: 3, Timestamp: types.Uint64(ts.Add(2 * blockTime).Unix())}
 		p := &types.Block{Height: 1, Timestamp: types.Uint64(ts.Unix())}
 		parents := consensus.RequireNewTipSet(require.New(t), p)
-		err := validator.ValidateSemantic(ctx, c, &parents)
+		err := validator.ValidateSemantic(ctx, sign(c, worker), &parents, worker)
 		require.NoError(t, err)
 
 		// fail when nul block calc is off by one blocktime
 		c = &types.Block{Height: 3, Timestamp: types.Uint64(ts.Add(blockTime).Unix())}
-		err = validator.ValidateSemantic(ctx, c, &parents)
+		err = validator.ValidateSemantic(ctx, sign(c, worker), &parents, worker)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "too far")
 
 		// fail with same timestamp as parent
 		c = &types.Block{Height: 3, Timestamp: types.Uint64(ts.Unix())}
-		err = validator.ValidateSemantic(ctx, c, &parents)
+		err = validator.ValidateSemantic(ctx, sign(c, worker), &parents, worker)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "too far")
 
 	})
+
+	t.Run("reject block not signed by the worker of its miner", func(t *testing.T) {
+		c := &types.Block{Height: 2, Timestamp: types.Uint64(ts.Add(blockTime).Unix())}
+		p := &types.Block{Height: 1, Timestamp: types.Uint64(ts.Unix())}
+		parents := consensus.RequireNewTipSet(require.New(t), p)
+
+		err := validator.ValidateSemantic(ctx, sign(c, other), &parents, worker)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not signed by worker")
+
+		// unsigned
+		c = &types.Block{Height: 2, Timestamp: types.Uint64(ts.Add(blockTime).Unix())}
+		err = validator.ValidateSemantic(ctx, c, &parents, worker)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not signed by worker")
+
+		// altered after signing
+		c = sign(&types.Block{Height: 2, Timestamp: types.Uint64(ts.Add(blockTime).Unix())}, worker)
+		c.Nonce = 1
+		err = validator.ValidateSemantic(ctx, c, &parents, worker)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not signed by worker")
+
+		require.NoError(t, validator.ValidateSemantic(ctx, sign(c, worker), &parents, worker))
+	})
 }
 
 func TestBlockValidSyntax(t *testing.T) {
@@ -96,12 +135,14 @@ func TestBlockValidSyntax(t *testing.T) {
 	validSt := types.NewCidForTestGetter()()
 	validAd := address.NewForTestGetter()()
 	validTi := []byte{1}
+	validSi := types.Signature{1}
 	// create a valid block
 	blk := &types.Block{
 		Timestamp: validTs,
 		StateRoot: validSt,
 		Miner:     validAd,
 		Ticket:    validTi,
+		BlockSig:  validSi,
 		Height:    1,
 	}
 	require.NoError(t, validator.ValidateSyntax(ctx, blk))
@@ -133,4 +174,10 @@ func TestBlockValidSyntax(t *testing.T) {
 	blk.Ticket = validTi
 	require.NoError(t, validator.ValidateSyntax(ctx, blk))
 
+	// invalidate signature
+	blk.BlockSig = nil
+	require.Error(t, validator.ValidateSyntax(ctx, blk))
+	blk.BlockSig = validSi
+	require.NoError(t, validator.ValidateSyntax(ctx, blk))
+
 }
@@ -0,0 +1,89 @@
+package consensus_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestConformanceVectors(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	// vectors are recorded from actor tests by running them with
+	// th.RecordVectorsEnv set to this directory
+	paths, err := filepath.Glob(filepath.Join("..", "fixtures", "vectors", "*.json"))
+	require.NoError(t, err)
+	if len(paths) == 0 {
+		t.Skip("no vectors in fixtures/vectors, record them with `go run ./build generate-vectors`")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			v, err := th.LoadVector(path)
+			require.NoError(t, err)
+			assert.NoError(t, th.RunVector(ctx, v))
+		})
+	}
+}
+
+func TestRecordVector(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	record := func(t *testing.T) *th.Vector {
+		st, vms := th.RequireCreateStorages(ctx, t)
+		signer, _ := types.NewMockSignersAndKeyInfo(1)
+		from := signer.Addresses[0]
+		require.NoError(t, st.SetActor(ctx, from, th.RequireNewAccountActor(t, types.NewAttoFILFromFIL(100))))
+
+		msg := types.NewMessage(from, address.NewForTestGetter()(), 0, types.NewAttoFILFromFIL(10), "", nil)
+		smsg, err := types.NewSignedMessage(*msg, signer, types.NewGasPrice(1), types.NewGasUnits(300))
+		require.NoError(t, err)
+
+		v, amr, err := th.RecordVector(ctx, st, vms, th.TestVectorProcessor, []*types.SignedMessage{smsg}, address.Undef, types.NewBlockHeight(1))
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		require.Len(t, amr.Results, 1)
+		return v
+	}
+
+	t.Run("a recorded vector passes after a round trip through its file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "vectors")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir) // nolint: errcheck
+
+		path, err := th.WriteVector(dir, record(t))
+		require.NoError(t, err)
+		v, err := th.LoadVector(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint64(1), v.Height)
+		assert.Nil(t, v.MinerOwner)
+		assert.NoError(t, th.RunVector(ctx, v))
+	})
+
+	t.Run("a vector fails if the receipts differ", func(t *testing.T) {
+		v := record(t)
+		v.Receipts[0].ExitCode = 1
+		assert.Error(t, th.RunVector(ctx, v))
+	})
+
+	t.Run("a vector fails if the post-state root differs", func(t *testing.T) {
+		v := record(t)
+		v.PostStateRoot = v.PreStateRoot
+		assert.Error(t, th.RunVector(ctx, v))
+	})
+}
@@ -141,12 +141,21 @@ func (c *Expected) BlockTime() time.Duration {
 
 // Weight returns the EC weight of this TipSet in uint64 encoded fixed point
 // representation.
-func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error) {
+func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pStateID cid.Cid) (uint64, error) {
 	ctx = log.Start(ctx, "Expected.Weight")
 	log.LogKV(ctx, "Weight", ts.String())
 	if ts.Len() == 1 && ts.At(0).Cid().Equals(c.genesisCid) {
 		return uint64(0), nil
 	}
+	// Power table views that do not read the state get by without one.
+	var pSt state.Tree
+	var err error
+	if pStateID.Defined() {
+		pSt, err = c.loadStateTree(ctx, pStateID)
+		if err != nil {
+			return uint64(0), err
+		}
+	}
 	// Compute parent weight.
 	parentW, err := ts.ParentWeight()
 	if err != nil {
@@ -158,7 +167,7 @@ func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree)
 		return uint64(0), err
 	}
 	// Each block in the tipset adds ECV + ECPrm * miner_power to parent weight.
-	totalBytes, err := c.PwrTableView.Total(ctx, pSt, c.bstore)
+	totalBytes, err := c.PwrTableView.Total(ctx, pSt, pStateID, c.bstore)
 	if err != nil {
 		return uint64(0), err
 	}
@@ -166,7 +175,7 @@ func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree)
 	floatECV := new(big.Float).SetInt64(int64(ECV))
 	floatECPrM := new(big.Float).SetInt64(int64(ECPrM))
 	for _, blk := range ts.ToSlice() {
-		minerBytes, err := c.PwrTableView.Miner(ctx, pSt, c.bstore, blk.Miner)
+		minerBytes, err := c.PwrTableView.Miner(ctx, pSt, pStateID, c.bstore, blk.Miner)
 		if err != nil {
 			return uint64(0), err
 		}
@@ -188,26 +197,11 @@ func (c *Expected) Weight(ctx context.Context, ts types.TipSet, pSt state.Tree)
 // TODO BLOCK CID CONCAT TIE BREAKER IS NOT IN THE SPEC AND SHOULD BE
 // EVALUATED BEFORE GETTING TO PRODUCTION.
 func (c *Expected) IsHeavier(ctx context.Context, a, b types.TipSet, aStateID, bStateID cid.Cid) (bool, error) {
-	var aSt, bSt state.Tree
-	var err error
-	if aStateID.Defined() {
-		aSt, err = c.loadStateTree(ctx, aStateID)
-		if err != nil {
-			return false, err
-		}
-	}
-	if bStateID.Defined() {
-		bSt, err = c.loadStateTree(ctx, bStateID)
-		if err != nil {
-			return false, err
-		}
-	}
-
-	aW, err := c.Weight(ctx, a, aSt)
+	aW, err := c.Weight(ctx, a, aStateID)
 	if err != nil {
 		return false, err
 	}
-	bW, err := c.Weight(ctx, b, bSt)
+	bW, err := c.Weight(ctx, b, bStateID)
 	if err != nil {
 		return false, err
 	}
@@ -252,22 +246,37 @@ func (c *Expected) RunStateTransition(ctx context.Context, ts types.TipSet, tsMe
 	span.AddAttributes(trace.StringAttribute("tipset", ts.String()))
 	defer tracing.AddErrorEndSpan(ctx, span, &err)
 
+	priorState, err := c.loadStateTree(ctx, priorStateID)
+	if err != nil {
+		return cid.Undef, err
+	}
+	vms := vm.NewStorageMap(c.bstore)
+
 	for i := 0; i < ts.Len(); i++ {
-		if err := c.BlockValidator.ValidateSemantic(ctx, ts.At(i), &ancestors[0]); err != nil {
+		blk := ts.At(i)
+		worker, err := minerWorker(ctx, priorState, vms, blk.Miner)
+		if err != nil {
+			return cid.Undef, errors.Wrapf(err, "failed to get worker of miner %s", blk.Miner)
+		}
+		if err := c.BlockValidator.ValidateSemantic(ctx, blk, &ancestors[0], worker); err != nil {
 			return cid.Undef, err
 		}
 	}
 
-	priorState, err := c.loadStateTree(ctx, priorStateID)
-	if err != nil {
+	if err := c.validateMining(ctx, priorState, priorStateID, ts, ancestors[0]); err != nil {
 		return cid.Undef, err
 	}
 
-	if err := c.validateMining(ctx, priorState, ts, ancestors[0]); err != nil {
+	// Refuse tipsets of protocol versions this node does not know rather
+	// than computing their state with the rules of another version.
+	h, err := ts.Height()
+	if err != nil {
 		return cid.Undef, err
 	}
+	if err := checkProtocolVersion(ctx, priorState, vms, types.NewBlockHeight(h)); err != nil {
+		return cid.Undef, errors.Wrapf(err, "cannot process tipset at height %d", h)
+	}
 
-	vms := vm.NewStorageMap(c.bstore)
 	st, err := c.runMessages(ctx, priorState, vms, ts, tsMessages, tsReceipts, ancestors)
 	if err != nil {
 		return cid.Undef, err
@@ -287,17 +296,16 @@ func (c *Expected) RunStateTransition(ctx context.Context, ts types.TipSet, tsMe
 //      * the block ticket fails the power check, i.e. is not a winning ticket
 //    Returns nil if all the above checks pass.
 // See https://github.com/filecoin-project/specs/blob/master/mining.md#chain-validation
-func (c *Expected) validateMining(ctx context.Context, st state.Tree, ts types.TipSet, parentTs types.TipSet) error {
+func (c *Expected) validateMining(ctx context.Context, st state.Tree, stateID cid.Cid, ts types.TipSet, parentTs types.TipSet) error {
 	for i := 0; i < ts.Len(); i++ {
 		blk := ts.At(i)
-		// TODO: Also need to validate BlockSig
 
 		// TODO: Once we've picked a delay function (see #2119), we need to
 		// verify its proof here. The proof will likely be written to a field on
 		// the mined block.
 
 		// See https://github.com/filecoin-project/specs/blob/master/mining.md#ticket-checking
-		result, err := IsWinningTicket(ctx, c.bstore, c.PwrTableView, st, blk.Ticket, blk.Miner)
+		result, err := IsWinningTicket(ctx, c.bstore, c.PwrTableView, st, stateID, blk.Ticket, blk.Miner)
 		if err != nil {
 			return errors.Wrap(err, "can't check for winning ticket")
 		}
@@ -309,19 +317,32 @@ func (c *Expected) validateMining(ctx context.Context, st state.Tree, ts types.T
 	return nil
 }
 
+// minerWorker returns the address of the worker of a miner, whose key signs
+// the miner's blocks.
+func minerWorker(ctx context.Context, st state.Tree, vms vm.StorageMap, miner address.Address) (address.Address, error) {
+	rets, ec, err := CallQueryMethod(ctx, st, vms, miner, "getWorker", []byte{}, address.Undef, nil)
+	if err != nil {
+		return address.Undef, err
+	}
+	if ec != 0 {
+		return address.Undef, errors.Errorf("non-zero return code from query message: %d", ec)
+	}
+	return address.NewFromBytes(rets[0])
+}
+
 // IsWinningTicket fetches miner power & total power, returns true if it's a winning ticket, false if not,
 //    errors out if minerPower or totalPower can't be found.
 //    See https://github.com/filecoin-project/specs/blob/master/expected-consensus.md
 //    for an explanation of the math here.
-func IsWinningTicket(ctx context.Context, bs blockstore.Blockstore, ptv PowerTableView, st state.Tree,
+func IsWinningTicket(ctx context.Context, bs blockstore.Blockstore, ptv PowerTableView, st state.Tree, stateID cid.Cid,
 	ticket types.Signature, miner address.Address) (bool, error) {
 
-	totalPower, err := ptv.Total(ctx, st, bs)
+	totalPower, err := ptv.Total(ctx, st, stateID, bs)
 	if err != nil {
 		return false, errors.Wrap(err, "Couldn't get totalPower")
 	}
 
-	minerPower, err := ptv.Miner(ctx, st, bs, miner)
+	minerPower, err := ptv.Miner(ctx, st, stateID, bs, miner)
 	if err != nil {
 		return false, errors.Wrap(err, "Couldn't get minerPower")
 	}
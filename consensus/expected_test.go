@@ -17,6 +17,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/vm"
 
 	"github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-hamt-ipld"
 	"github.com/ipfs/go-ipfs-blockstore"
@@ -140,11 +141,75 @@ func TestExpected_RunStateTransition_validateMining(t *testing.T) {
 			emptyReceipts = append(emptyReceipts, []*types.MessageReceipt{})
 		}
 
-		_, err = exp.RunStateTransition(ctx, tipSet, emptyMessages, emptyReceipts, []types.TipSet{pTipSet}, genesisBlock.StateRoot)
+		_, err = exp.RunStateTransition(ctx, tipSet, emptyMessages, emptyReceipts, []types.TipSet{pTipSet}, blocks[0].StateRoot)
 		assert.EqualError(t, err, "can't check for winning ticket: Couldn't get minerPower: something went wrong with the miner power")
 	})
 }
 
+// signatureCheckingValidator is a block validator only checking the
+// signatures of blocks.
+type signatureCheckingValidator struct {
+	th.FakeBlockValidator
+}
+
+func (v *signatureCheckingValidator) ValidateSemantic(ctx context.Context, child *types.Block, parents *types.TipSet, worker address.Address) error {
+	if !child.VerifySignature(worker) {
+		return errors.Errorf("block %s is not signed by worker %s", child.Cid(), worker)
+	}
+	return nil
+}
+
+func TestExpected_RunStateTransition_validatesBlockSignatures(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	cistore, bstore, verifier := setupCborBlockstoreProofs()
+	genesisBlock, err := consensus.DefaultGenesis(cistore, bstore)
+	require.NoError(t, err)
+
+	ptv := th.NewTestPowerTableView(types.NewBytesAmount(1), types.NewBytesAmount(1))
+	exp := consensus.NewExpected(cistore, bstore, th.NewTestProcessor(), &signatureCheckingValidator{}, ptv, genesisBlock.Cid(), verifier, th.BlockTimeTest)
+
+	pTipSet := types.RequireNewTipSet(t, genesisBlock)
+	emptyMessages := [][]*types.SignedMessage{{}, {}, {}}
+	emptyReceipts := [][]*types.MessageReceipt{{}, {}, {}}
+
+	t.Run("accepts blocks signed by the workers of their miners", func(t *testing.T) {
+		stateTree, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(t, err)
+		blocks := requireMakeBlocks(ctx, t, pTipSet, stateTree, vm.NewStorageMap(bstore))
+		tipSet := types.RequireNewTipSet(t, blocks...)
+
+		_, err = exp.RunStateTransition(ctx, tipSet, emptyMessages, emptyReceipts, []types.TipSet{pTipSet}, blocks[0].StateRoot)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects a block signed by another key", func(t *testing.T) {
+		stateTree, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(t, err)
+		blocks := requireMakeBlocks(ctx, t, pTipSet, stateTree, vm.NewStorageMap(bstore))
+		// the worker of the first block's miner signed other data
+		blocks[1].BlockSig = blocks[0].BlockSig
+		tipSet := types.RequireNewTipSet(t, blocks...)
+
+		_, err = exp.RunStateTransition(ctx, tipSet, emptyMessages, emptyReceipts, []types.TipSet{pTipSet}, blocks[0].StateRoot)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not signed by worker")
+	})
+
+	t.Run("rejects a block of an unknown miner", func(t *testing.T) {
+		stateTree, err := state.LoadStateTree(ctx, cistore, genesisBlock.StateRoot, builtin.Actors)
+		require.NoError(t, err)
+		blocks := requireMakeBlocks(ctx, t, pTipSet, stateTree, vm.NewStorageMap(bstore))
+		tipSet := types.RequireNewTipSet(t, blocks...)
+
+		_, err = exp.RunStateTransition(ctx, tipSet, emptyMessages, emptyReceipts, []types.TipSet{pTipSet}, genesisBlock.StateRoot)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get worker of miner")
+	})
+}
+
 func TestIsWinningTicket(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -176,7 +241,7 @@ func TestIsWinningTicket(t *testing.T) {
 			ptv := th.NewTestPowerTableView(types.NewBytesAmount(c.myPower), types.NewBytesAmount(c.totalPower))
 			ticket := [65]byte{}
 			ticket[0] = c.ticket
-			r, err := consensus.IsWinningTicket(ctx, bs, ptv, st, ticket[:], minerAddress)
+			r, err := consensus.IsWinningTicket(ctx, bs, ptv, st, cid.Undef, ticket[:], minerAddress)
 			assert.NoError(t, err)
 			assert.Equal(t, c.wins, r, "%+v", c)
 		}
@@ -193,7 +258,7 @@ func TestIsWinningTicket(t *testing.T) {
 		ptv1 := NewFailingTestPowerTableView(types.NewBytesAmount(testCase.myPower), types.NewBytesAmount(testCase.totalPower))
 		ticket := [65]byte{}
 		ticket[0] = testCase.ticket
-		r, err := consensus.IsWinningTicket(ctx, bs, ptv1, st, ticket[:], minerAddress)
+		r, err := consensus.IsWinningTicket(ctx, bs, ptv1, st, cid.Undef, ticket[:], minerAddress)
 		assert.False(t, r)
 		assert.Equal(t, err.Error(), "Couldn't get totalPower: something went wrong with the total power")
 
@@ -203,7 +268,7 @@ func TestIsWinningTicket(t *testing.T) {
 		ptv2 := NewFailingMinerTestPowerTableView(types.NewBytesAmount(testCase.myPower), types.NewBytesAmount(testCase.totalPower))
 		ticket := [sha256.Size]byte{}
 		ticket[0] = testCase.ticket
-		r, err := consensus.IsWinningTicket(ctx, bs, ptv2, st, ticket[:], minerAddress)
+		r, err := consensus.IsWinningTicket(ctx, bs, ptv2, st, cid.Undef, ticket[:], minerAddress)
 		assert.False(t, r)
 		assert.Equal(t, err.Error(), "Couldn't get minerPower: something went wrong with the miner power")
 
@@ -297,15 +362,15 @@ func NewFailingTestPowerTableView(minerPower, totalPower *types.BytesAmount) *Fa
 	return &FailingTestPowerTableView{minerPower: minerPower, totalPower: totalPower}
 }
 
-func (tv *FailingTestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+func (tv *FailingTestPowerTableView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
 	return tv.totalPower, errors.New("something went wrong with the total power")
 }
 
-func (tv *FailingTestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+func (tv *FailingTestPowerTableView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
 	return tv.minerPower, nil
 }
 
-func (tv *FailingTestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
+func (tv *FailingTestPowerTableView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
 
@@ -315,14 +380,14 @@ func NewFailingMinerTestPowerTableView(minerPower, totalPower *types.BytesAmount
 	return &FailingMinerTestPowerTableView{minerPower: minerPower, totalPower: totalPower}
 }
 
-func (tv *FailingMinerTestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+func (tv *FailingMinerTestPowerTableView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
 	return tv.totalPower, nil
 }
 
-func (tv *FailingMinerTestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+func (tv *FailingMinerTestPowerTableView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
 	return tv.minerPower, errors.New("something went wrong with the miner power")
 }
 
-func (tv *FailingMinerTestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
+func (tv *FailingMinerTestPowerTableView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
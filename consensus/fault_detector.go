@@ -0,0 +1,146 @@
+package consensus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// ConsensusFault is evidence that a miner signed two conflicting blocks:
+// two different blocks at the same height, or with the same parents.
+type ConsensusFault struct {
+	Block1 *types.Block
+	Block2 *types.Block
+}
+
+// FaultReporter reports consensus faults, e.g. by sending their evidence to
+// the miner actor of the faulty miner.
+type FaultReporter interface {
+	ReportConsensusFault(ctx context.Context, fault ConsensusFault) error
+}
+
+// FaultDetector detects consensus faults in the blocks it observes, and
+// collects them as evidence to report to the miner actor of the faulty
+// miner. It only holds blocks within a window of heights below the highest
+// block it observed, so it must only observe validated blocks: a forged
+// block could otherwise move the window and drop the blocks it holds.
+type FaultDetector struct {
+	window uint64
+
+	lk       sync.Mutex
+	reporter FaultReporter
+	highest  uint64
+	// blocks are the signed blocks observed, by miner.
+	blocks map[address.Address][]*types.Block
+	faults []ConsensusFault
+}
+
+// NewFaultDetector returns a fault detector holding blocks within window
+// heights of the highest block it observed.
+func NewFaultDetector(window uint64) *FaultDetector {
+	return &FaultDetector{
+		window: window,
+		blocks: map[address.Address][]*types.Block{},
+	}
+}
+
+// SetReporter sets the reporter of the faults the detector finds.
+func (fd *FaultDetector) SetReporter(reporter FaultReporter) {
+	fd.lk.Lock()
+	defer fd.lk.Unlock()
+
+	fd.reporter = reporter
+}
+
+// Observe checks a block for conflicts with the blocks of its miner observed
+// before, reports the faults it finds to the reporter, if any, and returns
+// them. Unsigned blocks are not evidence and are ignored.
+func (fd *FaultDetector) Observe(ctx context.Context, blk *types.Block) []ConsensusFault {
+	found, reporter := fd.observe(blk)
+	for _, fault := range found {
+		log.Warningf("detected consensus fault of miner %s: blocks %s and %s", fault.Block1.Miner, fault.Block1.Cid(), fault.Block2.Cid())
+		if reporter == nil {
+			continue
+		}
+		if err := reporter.ReportConsensusFault(ctx, fault); err != nil {
+			log.Errorf("failed to report consensus fault of miner %s: %s", fault.Block1.Miner, err)
+		}
+	}
+	return found
+}
+
+// observe records a block and returns the faults it is evidence of, with the
+// reporter to report them to.
+func (fd *FaultDetector) observe(blk *types.Block) ([]ConsensusFault, FaultReporter) {
+	if len(blk.BlockSig) == 0 {
+		return nil, nil
+	}
+
+	fd.lk.Lock()
+	defer fd.lk.Unlock()
+
+	height := uint64(blk.Height)
+	if height+fd.window < fd.highest {
+		return nil, nil
+	}
+	if height > fd.highest {
+		fd.highest = height
+		fd.prune()
+	}
+
+	var found []ConsensusFault
+	for _, observed := range fd.blocks[blk.Miner] {
+		if observed.Cid().Equals(blk.Cid()) {
+			return nil, nil
+		}
+		if observed.ConflictsWith(blk) {
+			found = append(found, ConsensusFault{Block1: observed, Block2: blk})
+		}
+	}
+
+	fd.blocks[blk.Miner] = append(fd.blocks[blk.Miner], blk)
+	fd.faults = append(fd.faults, found...)
+	return found, fd.reporter
+}
+
+// Faults returns the faults detected within the window.
+func (fd *FaultDetector) Faults() []ConsensusFault {
+	fd.lk.Lock()
+	defer fd.lk.Unlock()
+
+	faults := make([]ConsensusFault, len(fd.faults))
+	copy(faults, fd.faults)
+	return faults
+}
+
+// prune drops the blocks and faults below the window. The caller must hold
+// the lock.
+func (fd *FaultDetector) prune() {
+	inWindow := func(blk *types.Block) bool {
+		return uint64(blk.Height)+fd.window >= fd.highest
+	}
+
+	for miner, blocks := range fd.blocks {
+		var kept []*types.Block
+		for _, blk := range blocks {
+			if inWindow(blk) {
+				kept = append(kept, blk)
+			}
+		}
+		if len(kept) == 0 {
+			delete(fd.blocks, miner)
+		} else {
+			fd.blocks[miner] = kept
+		}
+	}
+
+	var faults []ConsensusFault
+	for _, fault := range fd.faults {
+		if inWindow(fault.Block1) && inWindow(fault.Block2) {
+			faults = append(faults, fault)
+		}
+	}
+	fd.faults = faults
+}
@@ -0,0 +1,116 @@
+package consensus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/consensus"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestFaultDetector(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	minerAddr := address.NewForTestGetter()()
+	parents := types.NewTipSetKey(types.SomeCid())
+	block := func(height uint64, nonce uint64, parents types.TipSetKey) *types.Block {
+		return &types.Block{
+			Miner:    minerAddr,
+			Height:   types.Uint64(height),
+			Nonce:    types.Uint64(nonce),
+			Parents:  parents,
+			BlockSig: []byte{0x01},
+		}
+	}
+
+	t.Run("two blocks at the same height are a fault", func(t *testing.T) {
+		fd := NewFaultDetector(10)
+		blk1, blk2 := block(5, 1, parents), block(5, 2, types.NewTipSetKey())
+
+		assert.Empty(t, fd.Observe(ctx, blk1))
+		faults := fd.Observe(ctx, blk2)
+		require.Len(t, faults, 1)
+		assert.Equal(t, blk1, faults[0].Block1)
+		assert.Equal(t, blk2, faults[0].Block2)
+		assert.Equal(t, faults, fd.Faults())
+	})
+
+	t.Run("two blocks with the same parents are a fault", func(t *testing.T) {
+		fd := NewFaultDetector(10)
+
+		assert.Empty(t, fd.Observe(ctx, block(5, 1, parents)))
+		assert.Len(t, fd.Observe(ctx, block(6, 1, parents)), 1)
+	})
+
+	t.Run("blocks of a chain, repeated blocks and unsigned blocks are not faults", func(t *testing.T) {
+		fd := NewFaultDetector(10)
+		blk1 := block(5, 1, parents)
+		blk2 := block(6, 1, types.NewTipSetKey(blk1.Cid()))
+
+		assert.Empty(t, fd.Observe(ctx, blk1))
+		assert.Empty(t, fd.Observe(ctx, blk2))
+		assert.Empty(t, fd.Observe(ctx, blk1))
+
+		unsigned := block(5, 2, parents)
+		unsigned.BlockSig = nil
+		assert.Empty(t, fd.Observe(ctx, unsigned))
+
+		other := block(5, 2, parents)
+		other.Miner = address.NewForTestGetter()()
+		assert.Empty(t, fd.Observe(ctx, other))
+
+		assert.Empty(t, fd.Faults())
+	})
+
+	t.Run("blocks and faults below the window are dropped", func(t *testing.T) {
+		fd := NewFaultDetector(10)
+
+		assert.Empty(t, fd.Observe(ctx, block(5, 1, parents)))
+		assert.Len(t, fd.Observe(ctx, block(5, 2, parents)), 1)
+
+		assert.Empty(t, fd.Observe(ctx, block(16, 1, types.NewTipSetKey())))
+		assert.Empty(t, fd.Faults())
+		assert.Empty(t, fd.Observe(ctx, block(5, 3, parents)))
+	})
+	t.Run("faults are reported to the reporter", func(t *testing.T) {
+		fd := NewFaultDetector(10)
+		reporter := &testFaultReporter{}
+		fd.SetReporter(reporter)
+		blk1, blk2 := block(5, 1, parents), block(5, 2, parents)
+
+		assert.Empty(t, fd.Observe(ctx, blk1))
+		assert.Empty(t, reporter.reported)
+		faults := fd.Observe(ctx, blk2)
+		assert.Equal(t, faults, reporter.reported)
+
+		// a repeated block is not reported again
+		assert.Empty(t, fd.Observe(ctx, blk2))
+		assert.Len(t, reporter.reported, 1)
+	})
+
+	t.Run("a failure to report does not drop the fault", func(t *testing.T) {
+		fd := NewFaultDetector(10)
+		fd.SetReporter(&testFaultReporter{err: errors.New("boom")})
+
+		assert.Empty(t, fd.Observe(ctx, block(5, 1, parents)))
+		assert.Len(t, fd.Observe(ctx, block(5, 2, parents)), 1)
+		assert.Len(t, fd.Faults(), 1)
+	})
+}
+
+type testFaultReporter struct {
+	err      error
+	reported []ConsensusFault
+}
+
+func (r *testFaultReporter) ReportConsensusFault(ctx context.Context, fault ConsensusFault) error {
+	r.reported = append(r.reported, fault)
+	return r.err
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -41,11 +43,13 @@ type minerActorConfig struct {
 
 // Config is used to configure values in the GenesisInitFunction.
 type Config struct {
-	accounts   map[address.Address]types.AttoFIL
-	nonces     map[address.Address]uint64
-	actors     map[address.Address]*actor.Actor
-	miners     map[address.Address]*minerActorConfig
-	proofsMode types.ProofsMode
+	accounts    map[address.Address]types.AttoFIL
+	nonces      map[address.Address]uint64
+	actors      map[address.Address]*actor.Actor
+	miners      map[address.Address]*minerActorConfig
+	proofsMode  types.ProofsMode
+	gasSchedule uint64
+	upgrades    []storagemarket.ProtocolUpgrade
 }
 
 // GenOption is a configuration option for the GenesisInitFunction.
@@ -96,14 +100,35 @@ func ProofsMode(proofsMode types.ProofsMode) GenOption {
 	}
 }
 
+// GasSchedule sets the version of the gas schedule of the network.
+func GasSchedule(version uint64) GenOption {
+	return func(gc *Config) error {
+		gc.gasSchedule = version
+		return nil
+	}
+}
+
+// ProtocolUpgrade schedules the upgrade of the network to a protocol version
+// at the given height. Upgrades must be scheduled in order.
+func ProtocolUpgrade(version uint64, height uint64) GenOption {
+	return func(gc *Config) error {
+		gc.upgrades = append(gc.upgrades, storagemarket.ProtocolUpgrade{
+			Version: version,
+			Height:  types.NewBlockHeight(height),
+		})
+		return nil
+	}
+}
+
 // NewEmptyConfig inits and returns an empty config
 func NewEmptyConfig() *Config {
 	return &Config{
-		accounts:   make(map[address.Address]types.AttoFIL),
-		nonces:     make(map[address.Address]uint64),
-		actors:     make(map[address.Address]*actor.Actor),
-		miners:     make(map[address.Address]*minerActorConfig),
-		proofsMode: types.TestProofsMode,
+		accounts:    make(map[address.Address]types.AttoFIL),
+		nonces:      make(map[address.Address]uint64),
+		actors:      make(map[address.Address]*actor.Actor),
+		miners:      make(map[address.Address]*minerActorConfig),
+		proofsMode:  types.TestProofsMode,
+		gasSchedule: types.FlatGasScheduleVersion,
 	}
 }
 
@@ -160,7 +185,11 @@ func MakeGenesisFunc(opts ...GenOption) GenesisInitFunc {
 				return nil, err
 			}
 		}
-		if err := SetupDefaultActors(ctx, st, storageMap, genCfg.proofsMode); err != nil {
+		if err := SetupDefaultActors(ctx, st, storageMap, storagemarket.NetworkParams{
+			ProofsMode:       genCfg.proofsMode,
+			GasSchedule:      genCfg.gasSchedule,
+			ProtocolUpgrades: genCfg.upgrades,
+		}); err != nil {
 			return nil, err
 		}
 		// Now add any other actors configured.
@@ -169,6 +198,9 @@ func MakeGenesisFunc(opts ...GenOption) GenesisInitFunc {
 				return nil, err
 			}
 		}
+		if err := RegisterActorAddresses(ctx, st, storageMap); err != nil {
+			return nil, err
+		}
 
 		c, err := st.Flush(ctx)
 		if err != nil {
@@ -210,7 +242,16 @@ func DefaultGenesis(cst *hamt.CborIpldStore, bs blockstore.Blockstore) (*types.B
 }
 
 // SetupDefaultActors inits the builtin actors that are required to run filecoin.
-func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.StorageMap, storeType types.ProofsMode) error {
+// The storage market holds the given parameters of the network. Actors set in
+// the state after it are assigned ID addresses by RegisterActorAddresses.
+func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.StorageMap, params storagemarket.NetworkParams) error {
+	if _, err := types.GasScheduleVersion(params.GasSchedule); err != nil {
+		return err
+	}
+	if err := ValidateProtocolUpgrades(params.ProtocolUpgrades); err != nil {
+		return err
+	}
+
 	for addr, val := range defaultAccounts {
 		a, err := account.NewActor(val)
 		if err != nil {
@@ -222,8 +263,16 @@ func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.Storag
 		}
 	}
 
+	initAct := initactor.NewActor()
+	if err := (&initactor.Actor{}).InitializeState(storageMap.NewStorage(address.InitAddress, initAct), nil); err != nil {
+		return err
+	}
+	if err := st.SetActor(ctx, address.InitAddress, initAct); err != nil {
+		return err
+	}
+
 	stAct := storagemarket.NewActor()
-	err := (&storagemarket.Actor{}).InitializeState(storageMap.NewStorage(address.StorageMarketAddress, stAct), storeType)
+	err := (&storagemarket.Actor{}).InitializeState(storageMap.NewStorage(address.StorageMarketAddress, stAct), params)
 	if err != nil {
 		return err
 	}
@@ -236,5 +285,40 @@ func SetupDefaultActors(ctx context.Context, st state.Tree, storageMap vm.Storag
 	if err != nil {
 		return err
 	}
-	return st.SetActor(ctx, address.PaymentBrokerAddress, pbAct)
+	if err := st.SetActor(ctx, address.PaymentBrokerAddress, pbAct); err != nil {
+		return err
+	}
+
+	msfAct := multisig.NewFactoryActor()
+	err = (&multisig.FactoryActor{}).InitializeState(storageMap.NewStorage(address.MultisigFactoryAddress, msfAct), nil)
+	if err != nil {
+		return err
+	}
+	return st.SetActor(ctx, address.MultisigFactoryAddress, msfAct)
+}
+
+// RegisterActorAddresses assigns ID addresses to the actors of a genesis
+// state, in the order of the state tree so the assignment is deterministic.
+func RegisterActorAddresses(ctx context.Context, st state.Tree, storageMap vm.StorageMap) error {
+	// the state tree only walks the actors it flushed
+	if _, err := st.Flush(ctx); err != nil {
+		return err
+	}
+
+	var addrs []address.Address
+	err := st.ForEachActor(ctx, func(addr address.Address, _ *actor.Actor) error {
+		addrs = append(addrs, addr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cachedTree := state.NewCachedStateTree(st)
+	for _, addr := range addrs {
+		if err := vm.RegisterActorAddress(ctx, cachedTree, storageMap, addr); err != nil {
+			return err
+		}
+	}
+	return cachedTree.Commit(ctx)
 }
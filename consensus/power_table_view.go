@@ -2,10 +2,14 @@ package consensus
 
 import (
 	"context"
+	"sync"
 
+	"github.com/hashicorp/golang-lru"
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-blockstore"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -13,19 +17,21 @@ import (
 )
 
 // PowerTableView defines the set of functions used by the ChainManager to view
-// the power table encoded in the tipset's state tree
+// the power table encoded in the tipset's state tree. Callers pass the root of
+// the state tree as stateID along with it, which identifies the state to views
+// caching its power.
 type PowerTableView interface {
 	// Total returns the total bytes stored by all miners in the given
 	// state.
-	Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error)
+	Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error)
 
 	// Miner returns the total bytes stored by the miner of the
 	// input address in the given state.
-	Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error)
+	Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error)
 
 	// HasPower returns true if the input address is associated with a
 	// miner that has storage power in the network.
-	HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool
+	HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool
 }
 
 // MarketView is the power table view used for running expected consensus in
@@ -36,7 +42,7 @@ type MarketView struct{}
 var _ PowerTableView = &MarketView{}
 
 // Total returns the total storage as a BytesAmount.
-func (v *MarketView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+func (v *MarketView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
 	vms := vm.NewStorageMap(bstore)
 	rets, ec, err := CallQueryMethod(ctx, st, vms, address.StorageMarketAddress, "getTotalStorage", []byte{}, address.Undef, nil)
 	if err != nil {
@@ -51,7 +57,7 @@ func (v *MarketView) Total(ctx context.Context, st state.Tree, bstore blockstore
 }
 
 // Miner returns the storage that this miner has committed to the network.
-func (v *MarketView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+func (v *MarketView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
 	vms := vm.NewStorageMap(bstore)
 	rets, ec, err := CallQueryMethod(ctx, st, vms, mAddr, "getPower", []byte{}, address.Undef, nil)
 	if err != nil {
@@ -67,8 +73,8 @@ func (v *MarketView) Miner(ctx context.Context, st state.Tree, bstore blockstore
 
 // HasPower returns true if the provided address belongs to a miner with power
 // in the storage market
-func (v *MarketView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
-	numBytes, err := v.Miner(ctx, st, bstore, mAddr)
+func (v *MarketView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
+	numBytes, err := v.Miner(ctx, st, stateID, bstore, mAddr)
 	if err != nil {
 		if state.IsActorNotFoundError(err) {
 			return false
@@ -79,3 +85,162 @@ func (v *MarketView) HasPower(ctx context.Context, st state.Tree, bstore blockst
 
 	return numBytes.GreaterThan(types.ZeroBytes)
 }
+
+// DefaultPowerTableCacheSize is the number of states a CachedPowerTableView
+// holds the power of by default.
+const DefaultPowerTableCacheSize = 64
+
+// CachedPowerTableView is a PowerTableView caching the power values of
+// another view by state root, so the power of a state is only computed once
+// no matter how often consensus asks for it. It holds the power of a bounded
+// number of recently used states.
+type CachedPowerTableView struct {
+	view PowerTableView
+	// states maps state roots to their *statePower.
+	states *lru.Cache
+}
+
+var _ PowerTableView = &CachedPowerTableView{}
+
+// statePower holds the power values of a state computed so far.
+type statePower struct {
+	lk     sync.Mutex
+	total  *types.BytesAmount
+	miners map[address.Address]*types.BytesAmount
+}
+
+// NewCachedPowerTableView returns a view caching the power values of view for
+// up to size states.
+func NewCachedPowerTableView(view PowerTableView, size int) (*CachedPowerTableView, error) {
+	states, err := lru.New(size)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create power table cache")
+	}
+	return &CachedPowerTableView{view: view, states: states}, nil
+}
+
+// Total returns the total bytes stored by all miners in the given state.
+func (v *CachedPowerTableView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+	sp, err := v.statePower(stateID)
+	if err != nil {
+		return nil, err
+	}
+
+	sp.lk.Lock()
+	defer sp.lk.Unlock()
+
+	if sp.total == nil {
+		total, err := v.view.Total(ctx, st, stateID, bstore)
+		if err != nil {
+			return nil, err
+		}
+		sp.total = total
+	}
+	return sp.total, nil
+}
+
+// Miner returns the bytes stored by the miner of the input address in the
+// given state.
+func (v *CachedPowerTableView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	sp, err := v.statePower(stateID)
+	if err != nil {
+		return nil, err
+	}
+
+	sp.lk.Lock()
+	defer sp.lk.Unlock()
+
+	power, ok := sp.miners[mAddr]
+	if !ok {
+		power, err = v.view.Miner(ctx, st, stateID, bstore, mAddr)
+		if err != nil {
+			return nil, err
+		}
+		sp.miners[mAddr] = power
+	}
+	return power, nil
+}
+
+// HasPower returns true if the provided address belongs to a miner with power
+// in the storage market
+func (v *CachedPowerTableView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
+	numBytes, err := v.Miner(ctx, st, stateID, bstore, mAddr)
+	if err != nil {
+		if state.IsActorNotFoundError(err) {
+			return false
+		}
+
+		panic(err)
+	}
+
+	return numBytes.GreaterThan(types.ZeroBytes)
+}
+
+// statePower returns the cached power values of the state with the given
+// root.
+func (v *CachedPowerTableView) statePower(root cid.Cid) (*statePower, error) {
+	if !root.Defined() {
+		return nil, errors.New("undefined state root")
+	}
+
+	// concurrent callers may both add an entry for a root, in which case
+	// the power of the state is computed twice
+	if sp, ok := v.states.Get(root); ok {
+		return sp.(*statePower), nil
+	}
+	sp := &statePower{miners: map[address.Address]*types.BytesAmount{}}
+	v.states.Add(root, sp)
+	return sp, nil
+}
+
+// PowerTable returns the bytes stored by every miner of the storage market
+// in the given state, as seen by a power table view.
+func PowerTable(ctx context.Context, ptv PowerTableView, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (map[address.Address]*types.BytesAmount, error) {
+	miners, err := storageMarketMiners(ctx, st, vm.NewStorageMap(bstore))
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(map[address.Address]*types.BytesAmount, len(miners))
+	for _, mAddr := range miners {
+		power, err := ptv.Miner(ctx, st, stateID, bstore, mAddr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get power of miner %s", mAddr)
+		}
+		table[mAddr] = power
+	}
+	return table, nil
+}
+
+// storageMarketMiners returns the addresses of the miners the storage market
+// created.
+func storageMarketMiners(ctx context.Context, st state.Tree, vms vm.StorageMap) ([]address.Address, error) {
+	smState, err := readNetworkState(ctx, st, vms)
+	if err != nil {
+		return nil, err
+	}
+	if smState == nil || !smState.Miners.Defined() {
+		return nil, nil
+	}
+
+	smActor, err := st.GetActor(ctx, address.StorageMarketAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get storage market actor")
+	}
+	lookup, err := actor.LoadLookup(ctx, vms.NewStorage(address.StorageMarketAddress, smActor), smState.Miners)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load miners of the storage market")
+	}
+	kvs, err := lookup.Values(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list miners of the storage market")
+	}
+
+	miners := make([]address.Address, len(kvs))
+	for i, kv := range kvs {
+		if miners[i], err = address.NewFromString(kv.Key); err != nil {
+			return nil, errors.Wrapf(err, "invalid miner address %s", kv.Key)
+		}
+	}
+	return miners, nil
+}
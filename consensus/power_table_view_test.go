@@ -0,0 +1,142 @@
+package consensus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-hamt-ipld"
+	"github.com/ipfs/go-ipfs-blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// countingPowerTableView is a power table view counting the power values it
+// computes.
+type countingPowerTableView struct {
+	totals int
+	miners int
+}
+
+func (v *countingPowerTableView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+	v.totals++
+	return types.NewBytesAmount(100), nil
+}
+
+func (v *countingPowerTableView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+	v.miners++
+	return types.NewBytesAmount(10), nil
+}
+
+func (v *countingPowerTableView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
+	return true
+}
+
+func TestCachedPowerTableView(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addrGetter := address.NewForTestGetter()
+	minerAddr := addrGetter()
+	stateIDGetter := types.NewCidForTestGetter()
+
+	t.Run("power values are computed once per state", func(t *testing.T) {
+		st, _ := th.RequireCreateStorages(ctx, t)
+		stateID := stateIDGetter()
+		inner := &countingPowerTableView{}
+		view, err := NewCachedPowerTableView(inner, 2)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			total, err := view.Total(ctx, st, stateID, nil)
+			require.NoError(t, err)
+			assert.Equal(t, types.NewBytesAmount(100), total)
+
+			power, err := view.Miner(ctx, st, stateID, nil, minerAddr)
+			require.NoError(t, err)
+			assert.Equal(t, types.NewBytesAmount(10), power)
+			assert.True(t, view.HasPower(ctx, st, stateID, nil, minerAddr))
+		}
+		assert.Equal(t, 1, inner.totals)
+		assert.Equal(t, 1, inner.miners)
+
+		_, err = view.Miner(ctx, st, stateID, nil, addrGetter())
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.miners)
+
+		_, err = view.Total(ctx, st, stateIDGetter(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.totals)
+	})
+
+	t.Run("the least recently used states are evicted", func(t *testing.T) {
+		st, _ := th.RequireCreateStorages(ctx, t)
+		stateID1 := stateIDGetter()
+		stateID2 := stateIDGetter()
+
+		inner := &countingPowerTableView{}
+		view, err := NewCachedPowerTableView(inner, 1)
+		require.NoError(t, err)
+
+		for _, stateID := range []cid.Cid{stateID1, stateID2, stateID1} {
+			_, err = view.Total(ctx, st, stateID, nil)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, inner.totals)
+	})
+
+	t.Run("an undefined state root is an error", func(t *testing.T) {
+		st, _ := th.RequireCreateStorages(ctx, t)
+		inner := &countingPowerTableView{}
+		view, err := NewCachedPowerTableView(inner, 1)
+		require.NoError(t, err)
+
+		_, err = view.Total(ctx, st, cid.Undef, nil)
+		assert.Error(t, err)
+		_, err = view.Miner(ctx, st, cid.Undef, nil, minerAddr)
+		assert.Error(t, err)
+		assert.Equal(t, 0, inner.totals)
+		assert.Equal(t, 0, inner.miners)
+	})
+}
+
+func TestPowerTable(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	blk, err := DefaultGenesis(cst, bs)
+	require.NoError(t, err)
+	st, err := state.LoadStateTree(ctx, cst, blk.StateRoot, builtin.Actors)
+	require.NoError(t, err)
+	vms := vm.NewStorageMap(bs)
+
+	table, err := PowerTable(ctx, &MarketView{}, st, blk.StateRoot, bs)
+	require.NoError(t, err)
+	assert.Empty(t, table)
+
+	miner1 := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+	miner2 := th.CreateTestMiner(t, st, vms, address.TestAddress, th.RequireRandomPeerID(t))
+	require.NoError(t, vms.Flush())
+	stateID, err := st.Flush(ctx)
+	require.NoError(t, err)
+
+	table, err = PowerTable(ctx, &MarketView{}, st, stateID, bs)
+	require.NoError(t, err)
+	require.Len(t, table, 2)
+	for _, mAddr := range []address.Address{miner1, miner2} {
+		require.Contains(t, table, mAddr)
+		assert.True(t, table[mAddr].Equal(types.ZeroBytes))
+	}
+}
@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"context"
+	"math"
 	"math/big"
 
 	"github.com/ipfs/go-cid"
@@ -11,6 +12,8 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/crypto"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/metrics"
 	"github.com/filecoin-project/go-filecoin/metrics/tracing"
 	"github.com/filecoin-project/go-filecoin/state"
@@ -43,6 +46,9 @@ type BlockRewarder interface {
 type ApplicationResult struct {
 	Receipt        *types.MessageReceipt
 	ExecutionError error
+	// Trace records the execution of the message by a tracing processor. It
+	// is nil otherwise, or if the message failed before execution.
+	Trace *vm.Trace
 }
 
 // ProcessTipSetResponse records the results of successfully applied messages,
@@ -59,6 +65,7 @@ type ProcessTipSetResponse struct {
 type DefaultProcessor struct {
 	signedMessageValidator SignedMessageValidator
 	blockRewarder          BlockRewarder
+	tracing                bool
 }
 
 var _ Processor = (*DefaultProcessor)(nil)
@@ -79,6 +86,16 @@ func NewConfiguredProcessor(validator SignedMessageValidator, rewarder BlockRewa
 	}
 }
 
+// NewTracingProcessor creates a default processor recording the execution
+// trace of each message it applies in its result.
+func NewTracingProcessor() *DefaultProcessor {
+	return &DefaultProcessor{
+		signedMessageValidator: NewDefaultMessageValidator(),
+		blockRewarder:          NewDefaultBlockRewarder(),
+		tracing:                true,
+	}
+}
+
 // ProcessBlock is the entrypoint for validating the state transitions
 // of the messages in a block. When we receive a new block from the
 // network ProcessBlock applies the block's messages to the beginning
@@ -116,13 +133,18 @@ func (p *DefaultProcessor) ProcessBlock(ctx context.Context, st state.Tree, vms
 
 	var emptyResults []*ApplicationResult
 
+	bh := types.NewBlockHeight(uint64(blk.Height))
+	st, _, err = activateProtocolVersion(ctx, st, vms, bh)
+	if err != nil {
+		return nil, err
+	}
+
 	// find miner's owner address
 	minerOwnerAddr, err := minerOwnerAddress(ctx, st, vms, blk.Miner)
 	if err != nil {
 		return nil, err
 	}
 
-	bh := types.NewBlockHeight(uint64(blk.Height))
 	res, faultErr := p.ApplyMessagesAndPayRewards(ctx, st, vms, blkMessages, minerOwnerAddr, bh, ancestors)
 	if faultErr != nil {
 		return emptyResults, faultErr
@@ -155,6 +177,10 @@ func (p *DefaultProcessor) ProcessTipSet(ctx context.Context, st state.Tree, vms
 		return &ProcessTipSetResponse{}, errors.FaultErrorWrap(err, "processing empty tipset")
 	}
 	bh := types.NewBlockHeight(h)
+	st, _, err = activateProtocolVersion(ctx, st, vms, bh)
+	if err != nil {
+		return &ProcessTipSetResponse{}, err
+	}
 	msgFilter := make(map[string]struct{})
 
 	var res ProcessTipSetResponse
@@ -304,7 +330,12 @@ func (p *DefaultProcessor) ApplyMessage(ctx context.Context, st state.Tree, vms
 
 	cachedStateTree := state.NewCachedStateTree(st)
 
-	r, err := p.attemptApplyMessage(ctx, cachedStateTree, vms, msg, bh, gasTracker, ancestors)
+	var msgTrace *vm.Trace
+	if p.tracing {
+		msgTrace = &vm.Trace{}
+	}
+
+	r, err := p.attemptApplyMessage(ctx, cachedStateTree, vms, msg, bh, gasTracker, ancestors, msgTrace)
 	if err == nil {
 		err = cachedStateTree.Commit(ctx)
 		if err != nil {
@@ -350,7 +381,12 @@ func (p *DefaultProcessor) ApplyMessage(ctx context.Context, st state.Tree, vms
 		return nil, errors.FaultErrorWrap(err, "could not set from actor after inc nonce")
 	}
 
-	return &ApplicationResult{Receipt: r, ExecutionError: executionError}, nil
+	result = &ApplicationResult{Receipt: r, ExecutionError: executionError}
+	if msgTrace != nil && !msgTrace.To.Empty() {
+		// the trace is empty if the message failed before execution
+		result.Trace = msgTrace
+	}
+	return result, nil
 }
 
 var (
@@ -374,14 +410,28 @@ var (
 // not make any changes to the state/blockchain and is useful for interrogating
 // actor state. Block height bh is optional; some methods will ignore it.
 func CallQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to address.Address, method string, params []byte, from address.Address, optBh *types.BlockHeight) ([][]byte, uint8, error) {
-	toActor, err := st.GetActor(ctx, to)
+	st, _, err := networkTree(ctx, st, vms)
 	if err != nil {
-		return nil, 1, errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
+		return nil, 1, err
 	}
 
 	// not committing or flushing storage structures guarantees changes won't make it to stored state tree or datastore
 	cachedSt := state.NewCachedStateTree(st)
 
+	to, err = vm.ResolveAddress(ctx, cachedSt, vms, to)
+	if err != nil {
+		return nil, 1, errors.ApplyErrorPermanentWrapf(err, "failed to resolve To address")
+	}
+	from, err = vm.ResolveAddress(ctx, cachedSt, vms, from)
+	if err != nil {
+		return nil, 1, errors.ApplyErrorPermanentWrapf(err, "failed to resolve From address")
+	}
+
+	toActor, err := cachedSt.GetActor(ctx, to)
+	if err != nil {
+		return nil, 1, errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
+	}
+
 	msg := &types.Message{
 		From:   from,
 		To:     to,
@@ -402,6 +452,7 @@ func CallQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to a
 		StorageMap:  vms,
 		GasTracker:  gasTracker,
 		BlockHeight: optBh,
+		Ctx:         ctx,
 	}
 
 	vmCtx := vm.NewVMContext(vmCtxParams)
@@ -410,21 +461,45 @@ func CallQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to a
 }
 
 // PreviewQueryMethod estimates the amount of gas that will be used by a method
-// call. It accepts all the same arguments as CallQueryMethod.
-func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to address.Address, method string, params []byte, from address.Address, optBh *types.BlockHeight) (types.GasUnits, error) {
-	toActor, err := st.GetActor(ctx, to)
+// call carrying a value. It accepts all the same arguments as CallQueryMethod,
+// plus the value, which the call transfers from the sender.
+func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, to address.Address, method string, params []byte, from address.Address, value types.AttoFIL, optBh *types.BlockHeight) (types.GasUnits, error) {
+	st, schedule, err := networkTree(ctx, st, vms)
 	if err != nil {
-		return types.NewGasUnits(0), errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
+		return types.NewGasUnits(0), err
 	}
 
 	// not committing or flushing storage structures guarantees changes won't make it to stored state tree or datastore
 	cachedSt := state.NewCachedStateTree(st)
 
+	to, err = vm.ResolveAddress(ctx, cachedSt, vms, to)
+	if err != nil {
+		return types.NewGasUnits(0), errors.ApplyErrorPermanentWrapf(err, "failed to resolve To address")
+	}
+	from, err = vm.ResolveAddress(ctx, cachedSt, vms, from)
+	if err != nil {
+		return types.NewGasUnits(0), errors.ApplyErrorPermanentWrapf(err, "failed to resolve From address")
+	}
+
+	toActor, err := cachedSt.GetActor(ctx, to)
+	if err != nil {
+		return types.NewGasUnits(0), errors.ApplyErrorPermanentWrapf(err, "failed to get To actor")
+	}
+
+	// the sender only needs to exist if the call transfers a value
+	var fromActor *actor.Actor
+	if !value.Equal(types.ZeroAttoFIL) {
+		fromActor, err = cachedSt.GetActor(ctx, from)
+		if err != nil {
+			return types.NewGasUnits(0), errors.ApplyErrorPermanentWrapf(err, "failed to get From actor")
+		}
+	}
+
 	msg := &types.Message{
 		From:   from,
 		To:     to,
 		Nonce:  0,
-		Value:  types.ZeroAttoFIL,
+		Value:  value,
 		Method: method,
 		Params: params,
 	}
@@ -432,14 +507,26 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 	// Set the gas limit to the max because this message send should always succeed; it doesn't cost gas.
 	gasTracker := vm.NewGasTracker()
 	gasTracker.MsgGasLimit = types.BlockGasLimit
+	gasTracker.Schedule = schedule
+
+	// the estimate includes the cost of including the message in a block
+	msgSize, err := signedMessageSizeBound(*msg)
+	if err != nil {
+		return types.NewGasUnits(0), errors.FaultErrorWrap(err, "failed to marshal message")
+	}
+	if err := gasTracker.Charge(schedule.MessageInclusion(msgSize)); err != nil {
+		return types.NewGasUnits(0), err
+	}
 
 	vmCtxParams := vm.NewContextParams{
+		From:        fromActor,
 		To:          toActor,
 		Message:     msg,
 		State:       cachedSt,
 		StorageMap:  vms,
 		GasTracker:  gasTracker,
 		BlockHeight: optBh,
+		Ctx:         ctx,
 	}
 	vmCtx := vm.NewVMContext(vmCtxParams)
 	_, _, err = vm.Send(ctx, vmCtx)
@@ -447,12 +534,38 @@ func PreviewQueryMethod(ctx context.Context, st state.Tree, vms vm.StorageMap, t
 	return vmCtx.GasUnits(), err
 }
 
+// signedMessageSizeBound returns an upper bound of the serialized size of a
+// message once signed with any nonce, and a gas price and limit the message can
+// afford within a block, since the inclusion of a message is charged for its
+// signed size.
+func signedMessageSizeBound(msg types.Message) (int, error) {
+	msg.Nonce = types.Uint64(math.MaxUint64)
+	smsg := types.SignedMessage{
+		MeteredMessage: *types.NewMeteredMessage(msg, types.NewAttoFILFromFIL(1), types.BlockGasLimit),
+		Signature:      make(types.Signature, crypto.SignatureBytes),
+	}
+	smsgBytes, err := smsg.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	return len(smsgBytes), nil
+}
+
+// NetworkGasSchedule returns the gas schedule of the network of the given
+// state: the schedule of its protocol version, or else the schedule it
+// selected at genesis. Networks without a storage market charge the flat
+// gas schedule.
+func NetworkGasSchedule(ctx context.Context, st state.Tree, vms vm.StorageMap) (*types.GasSchedule, error) {
+	_, schedule, err := networkTree(ctx, st, vms)
+	return schedule, err
+}
+
 // attemptApplyMessage encapsulates the work of trying to apply the message in order
 // to make ApplyMessage more readable. The distinction is that attemptApplyMessage
 // should deal with trying to apply the message to the state tree whereas
 // ApplyMessage should deal with any side effects and how it should be presented
 // to the caller. attemptApplyMessage should only be called from ApplyMessage.
-func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.CachedTree, store vm.StorageMap, msg *types.SignedMessage, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet) (*types.MessageReceipt, error) {
+func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.CachedTree, store vm.StorageMap, msg *types.SignedMessage, bh *types.BlockHeight, gasTracker *vm.GasTracker, ancestors []types.TipSet, msgTrace *vm.Trace) (*types.MessageReceipt, error) {
 	gasTracker.ResetForNewMessage(msg.MeteredMessage)
 	if err := blockGasLimitError(gasTracker); err != nil {
 		return &types.MessageReceipt{
@@ -487,25 +600,66 @@ func (p *DefaultProcessor) attemptApplyMessage(ctx context.Context, st *state.Ca
 		}
 	}
 
-	toActor, err := st.GetOrCreateActor(ctx, msg.To, func() (*actor.Actor, error) {
+	// Charge for including the message in the block before executing it.
+	msgBytes, err := msg.Marshal()
+	if err != nil {
+		return nil, errors.FaultErrorWrap(err, "failed to marshal message")
+	}
+	if err := gasTracker.Charge(gasTracker.Schedule.MessageInclusion(len(msgBytes))); err != nil {
+		return &types.MessageReceipt{
+			ExitCode:   exec.ErrInsufficientGas,
+			GasAttoFIL: msg.GasPrice.MulBigInt(big.NewInt(int64(msg.GasLimit))),
+		}, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
+	// Messages may address their recipient by its ID address, but actors are executed with the address
+	// they are stored under.
+	vmMsg := msg.Message
+	vmMsg.To, err = vm.ResolveAddress(ctx, st, store, msg.To)
+	if err != nil {
+		if errors.ShouldRevert(err) {
+			return &types.MessageReceipt{
+				ExitCode:   errors.CodeError(err),
+				GasAttoFIL: msg.GasPrice.MulBigInt(big.NewInt(int64(gasTracker.Schedule.MessageInclusion(len(msgBytes))))),
+			}, err
+		}
+		return nil, errors.FaultErrorWrap(err, "failed to resolve To address")
+	}
+	if vmMsg.To == vmMsg.From {
+		return &types.MessageReceipt{
+			ExitCode:   errors.CodeError(errSelfSend),
+			GasAttoFIL: types.ZeroAttoFIL,
+		}, errSelfSend
+	}
+
+	created := false
+	toActor, err := st.GetOrCreateActor(ctx, vmMsg.To, func() (*actor.Actor, error) {
 		// Addresses are deterministic so sending a message to a non-existent address must not install an actor,
 		// else actors could be installed ahead of address activation. So here we create the empty, upgradable
 		// actor to collect any balance that may be transferred.
+		created = true
 		return &actor.Actor{}, nil
 	})
 	if err != nil {
 		return nil, errors.FaultErrorWrap(err, "failed to get To actor")
 	}
+	if created {
+		if err := vm.RegisterActorAddress(ctx, st, store, vmMsg.To); err != nil {
+			return nil, errors.FaultErrorWrap(err, "failed to register To actor address")
+		}
+	}
 
 	vmCtxParams := vm.NewContextParams{
 		From:        fromActor,
 		To:          toActor,
-		Message:     &msg.Message,
+		Message:     &vmMsg,
 		State:       st,
 		StorageMap:  store,
 		GasTracker:  gasTracker,
 		BlockHeight: bh,
 		Ancestors:   ancestors,
+		Trace:       msgTrace,
+		Ctx:         ctx,
 	}
 	vmCtx := vm.NewVMContext(vmCtxParams)
 
@@ -540,7 +694,8 @@ type ApplyMessagesResponse struct {
 	TemporaryErrors []error
 }
 
-// ApplyMessagesAndPayRewards begins by paying the block mining reward to the miner's owner. It then applies messages to a state tree.
+// ApplyMessagesAndPayRewards begins by upgrading the state to the protocol version scheduled at the
+// block height and paying the block mining reward to the miner's owner. It then applies messages to a state tree.
 // It returns an ApplyMessagesResponse which wraps the results of message application,
 // groupings of messages with permanent failures, temporary failures, and
 // successes, and the permanent and temporary errors raised during application.
@@ -550,12 +705,21 @@ func (p *DefaultProcessor) ApplyMessagesAndPayRewards(ctx context.Context, st st
 	var emptyRet ApplyMessagesResponse
 	var ret ApplyMessagesResponse
 
+	st, schedule, err := activateProtocolVersion(ctx, st, vms, bh)
+	if err != nil {
+		return emptyRet, err
+	}
+
 	// transfer block reward to miner's owner from network address.
 	if err := p.blockRewarder.BlockReward(ctx, st, minerOwnerAddr); err != nil {
 		return ApplyMessagesResponse{}, err
 	}
+	if err := registerRewardedOwner(ctx, st, vms, minerOwnerAddr); err != nil {
+		return ApplyMessagesResponse{}, err
+	}
 
 	gasTracker := vm.NewGasTracker()
+	gasTracker.Schedule = schedule
 
 	// process all messages
 	for _, smsg := range messages {
@@ -632,6 +796,26 @@ func rewardTransfer(ctx context.Context, fromAddr, toAddr address.Address, value
 	return vm.Transfer(fromActor, toActor, value)
 }
 
+// registerRewardedOwner assigns an ID address to the owner of the miner of a
+// block, in case the reward created its actor.
+func registerRewardedOwner(ctx context.Context, st state.Tree, vms vm.StorageMap, minerOwnerAddr address.Address) error {
+	if minerOwnerAddr.Empty() {
+		return nil
+	}
+
+	cachedTree := state.NewCachedStateTree(st)
+	if _, err := cachedTree.GetActor(ctx, minerOwnerAddr); state.IsActorNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return errors.FaultErrorWrap(err, "could not get miner owner actor")
+	}
+
+	if err := vm.RegisterActorAddress(ctx, cachedTree, vms, minerOwnerAddr); err != nil {
+		return errors.FaultErrorWrap(err, "could not register miner owner address")
+	}
+	return cachedTree.Commit(ctx)
+}
+
 func blockGasLimitError(gasTracker *vm.GasTracker) error {
 	if gasTracker.GasAboveBlockLimit() {
 		return errGasAboveBlockLimit
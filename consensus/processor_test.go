@@ -2,6 +2,7 @@ package consensus_test
 
 import (
 	"context"
+	"math/big"
 	"testing"
 
 	"github.com/ipfs/go-cid"
@@ -15,8 +16,10 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
 	. "github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/state"
 	th "github.com/filecoin-project/go-filecoin/testhelpers"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
@@ -1016,6 +1019,222 @@ func TestBlockGasLimitBehavior(t *testing.T) {
 	})
 }
 
+func TestTracingProcessorRecordsNestedSends(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	ctx := context.Background()
+	vms := th.VMStorage()
+
+	// Install the fake actor so we can execute it.
+	fakeActorCodeCid := types.NewCidForTestGetter()()
+	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
+	defer delete(builtin.Actors, fakeActorCodeCid)
+
+	// applies a message to addr1 sending 100 FIL from addr1 to addr2
+	applyNestedSend := func(processor *DefaultProcessor) (*ApplicationResult, []address.Address, []byte) {
+		addresses, st, mockSigner := setupActorsForGasTest(t, vms, fakeActorCodeCid, 1000)
+
+		params, err := abi.ToEncodedValues(addresses[2])
+		require.NoError(t, err)
+		msg := types.NewMessage(addresses[0], addresses[1], 0, types.ZeroAttoFIL, "nestedBalance", params)
+		smsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(1), types.NewGasUnits(300))
+		require.NoError(t, err)
+
+		res, err := processor.ApplyMessage(ctx, st, vms, smsg, addresses[3], types.NewBlockHeight(0), vm.NewGasTracker(), nil)
+		require.NoError(t, err)
+		require.NoError(t, res.ExecutionError)
+		return res, addresses, params
+	}
+
+	t.Run("default processor records no trace", func(t *testing.T) {
+		res, _, _ := applyNestedSend(NewDefaultProcessor())
+		assert.Nil(t, res.Trace)
+	})
+
+	t.Run("tracing processor records the sends", func(t *testing.T) {
+		res, addresses, params := applyNestedSend(NewTracingProcessor())
+		require.NotNil(t, res.Trace)
+
+		assert.Equal(t, addresses[0], res.Trace.From)
+		assert.Equal(t, addresses[1], res.Trace.To)
+		assert.Equal(t, "nestedBalance", res.Trace.Method)
+		assert.Equal(t, params, res.Trace.Params)
+		assert.Equal(t, uint8(0), res.Trace.ExitCode)
+
+		require.Len(t, res.Trace.Subcalls, 1)
+		sub := res.Trace.Subcalls[0]
+		assert.Equal(t, addresses[1], sub.From)
+		assert.Equal(t, addresses[2], sub.To)
+		assert.Equal(t, types.NewAttoFILFromFIL(100), sub.Value)
+		assert.Equal(t, "", sub.Method)
+		assert.Empty(t, sub.Subcalls)
+	})
+}
+
+func TestApplyMessageChargesGasSchedule(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	ctx := context.Background()
+	vms := th.VMStorage()
+
+	// Install the fake actor so we can execute it.
+	fakeActorCodeCid := types.NewCidForTestGetter()()
+	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
+	defer delete(builtin.Actors, fakeActorCodeCid)
+
+	schedule, err := types.GasScheduleVersion(types.OperationGasScheduleVersion)
+	require.NoError(t, err)
+
+	// sets up the gas test actors on a network selecting the operation gas schedule
+	setup := func() ([]address.Address, state.Tree, *types.MockSigner) {
+		addresses, st, mockSigner := setupActorsForGasTest(t, vms, fakeActorCodeCid, 1000)
+
+		smAct := storagemarket.NewActor()
+		err := (&storagemarket.Actor{}).InitializeState(vms.NewStorage(address.StorageMarketAddress, smAct), storagemarket.NetworkParams{
+			GasSchedule: types.OperationGasScheduleVersion,
+		})
+		require.NoError(t, err)
+		require.NoError(t, st.SetActor(ctx, address.StorageMarketAddress, smAct))
+		return addresses, st, mockSigner
+	}
+
+	t.Run("the network selects the gas schedule", func(t *testing.T) {
+		_, st, _ := setup()
+		networkSchedule, err := NetworkGasSchedule(ctx, st, vms)
+		require.NoError(t, err)
+		assert.Equal(t, schedule, networkSchedule)
+	})
+
+	t.Run("ApplyMessage charges the inclusion of the message", func(t *testing.T) {
+		addresses, st, mockSigner := setup()
+
+		msg := types.NewMessage(addresses[0], addresses[1], 0, types.ZeroAttoFIL, "hasReturnValue", nil)
+		smsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(1), types.NewGasUnits(1000))
+		require.NoError(t, err)
+		msgBytes, err := smsg.Marshal()
+		require.NoError(t, err)
+
+		res, err := NewDefaultProcessor().ApplyMessagesAndPayRewards(ctx, st, vms, []*types.SignedMessage{smsg}, addresses[3], types.NewBlockHeight(0), nil)
+		require.NoError(t, err)
+		require.Len(t, res.Results, 1)
+		require.NoError(t, res.Results[0].ExecutionError)
+
+		// hasReturnValue charges 100 on top of the inclusion
+		gas := schedule.MessageInclusion(len(msgBytes)) + types.NewGasUnits(100)
+		assert.Equal(t, types.NewGasPrice(1).MulBigInt(big.NewInt(int64(gas))), res.Results[0].Receipt.GasAttoFIL)
+	})
+
+	t.Run("messages not covering their inclusion fail", func(t *testing.T) {
+		addresses, st, mockSigner := setup()
+
+		msg := types.NewMessage(addresses[0], addresses[1], 0, types.ZeroAttoFIL, "hasReturnValue", nil)
+		smsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(1), types.NewGasUnits(10))
+		require.NoError(t, err)
+
+		res, err := NewDefaultProcessor().ApplyMessagesAndPayRewards(ctx, st, vms, []*types.SignedMessage{smsg}, addresses[3], types.NewBlockHeight(0), nil)
+		require.NoError(t, err)
+		require.Len(t, res.Results, 1)
+		assert.Error(t, res.Results[0].ExecutionError)
+		assert.Equal(t, uint8(exec.ErrInsufficientGas), res.Results[0].Receipt.ExitCode)
+		assert.Equal(t, types.NewGasPrice(10), res.Results[0].Receipt.GasAttoFIL)
+	})
+
+	t.Run("messages running out of gas reading storage revert without failing the block", func(t *testing.T) {
+		addresses, st, mockSigner := setup()
+
+		// the limit covers the inclusion and the method, but not the storage read of the method
+		msg := types.NewMessage(addresses[0], address.StorageMarketAddress, 0, types.ZeroAttoFIL, "getGasSchedule", nil)
+		smsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(1), types.NewGasUnits(1000))
+		require.NoError(t, err)
+		msgBytes, err := smsg.Marshal()
+		require.NoError(t, err)
+		gasLimit := schedule.MessageInclusion(len(msgBytes)) + actor.DefaultGasCost + 1
+		lowGasMsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(1), gasLimit)
+		require.NoError(t, err)
+		lowGasBytes, err := lowGasMsg.Marshal()
+		require.NoError(t, err)
+		require.Equal(t, len(msgBytes), len(lowGasBytes))
+
+		transfer := types.NewMessage(addresses[0], addresses[2], 1, types.NewAttoFILFromFIL(5), "", nil)
+		transferMsg, err := types.NewSignedMessage(*transfer, mockSigner, types.NewGasPrice(1), types.NewGasUnits(1000))
+		require.NoError(t, err)
+
+		res, err := NewDefaultProcessor().ApplyMessagesAndPayRewards(ctx, st, vms, []*types.SignedMessage{lowGasMsg, transferMsg}, addresses[3], types.NewBlockHeight(0), nil)
+		require.NoError(t, err)
+		require.Len(t, res.Results, 2)
+
+		assert.Error(t, res.Results[0].ExecutionError)
+		assert.Equal(t, uint8(exec.ErrInsufficientGas), res.Results[0].Receipt.ExitCode)
+		assert.Equal(t, types.NewGasPrice(1).MulBigInt(big.NewInt(int64(gasLimit))), res.Results[0].Receipt.GasAttoFIL)
+
+		require.NoError(t, res.Results[1].ExecutionError)
+		assert.Equal(t, uint8(0), res.Results[1].Receipt.ExitCode)
+		recipient, err := st.GetActor(ctx, addresses[2])
+		require.NoError(t, err)
+		assert.Equal(t, types.NewAttoFILFromFIL(5), recipient.Balance)
+	})
+}
+
+func TestPreviewedGasLimitsRunStorageMinerFlow(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	mockSigner, _ := types.NewMockSignersAndKeyInfo(2)
+	owner, rewarded := mockSigner.Addresses[0], mockSigner.Addresses[1]
+
+	cst := hamt.NewCborStore()
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	genesis, err := MakeGenesisFunc(
+		GasSchedule(types.OperationGasScheduleVersion),
+		ActorAccount(owner, types.NewAttoFILFromFIL(10000)),
+		ActorAccount(rewarded, types.ZeroAttoFIL),
+	)(cst, bs)
+	require.NoError(t, err)
+	st, err := state.LoadStateTree(ctx, cst, genesis.StateRoot, builtin.Actors)
+	require.NoError(t, err)
+	vms := vm.NewStorageMap(bs)
+
+	// send previews a message and applies it with the previewed gas limit,
+	// as the services of a storage miner do
+	nonce := uint64(0)
+	send := func(to address.Address, value types.AttoFIL, height uint64, method string, params ...interface{}) (types.GasUnits, *types.MessageReceipt) {
+		encodedParams, err := abi.ToEncodedValues(params...)
+		require.NoError(t, err)
+		bh := types.NewBlockHeight(height)
+
+		gasLimit, err := PreviewQueryMethod(ctx, st, vms, to, method, encodedParams, owner, value, bh)
+		require.NoError(t, err)
+
+		msg := types.NewMessage(owner, to, nonce, value, method, encodedParams)
+		smsg, err := types.NewSignedMessage(*msg, mockSigner, types.NewGasPrice(1), gasLimit)
+		require.NoError(t, err)
+		nonce++
+
+		res, err := NewDefaultProcessor().ApplyMessagesAndPayRewards(ctx, st, vms, []*types.SignedMessage{smsg}, rewarded, bh, nil)
+		require.NoError(t, err)
+		require.Len(t, res.Results, 1)
+		require.NoError(t, res.Results[0].ExecutionError, method)
+		require.Equal(t, uint8(0), res.Results[0].Receipt.ExitCode, method)
+		return gasLimit, res.Results[0].Receipt
+	}
+
+	// miners created at genesis height do not verify proofs, so the flow
+	// runs with fake ones
+	_, receipt := send(address.StorageMarketAddress, types.NewAttoFILFromFIL(1000), 0, "createStorageMiner", types.OneKiBSectorSize, th.RequireRandomPeerID(t))
+	minerAddr, err := address.NewFromBytes(receipt.Return[0])
+	require.NoError(t, err)
+
+	send(minerAddr, types.ZeroAttoFIL, 1, "addAsk", types.NewAttoFILFromFIL(1), big.NewInt(1000))
+
+	commitGas, _ := send(minerAddr, types.ZeroAttoFIL, 1, "commitSector", uint64(1), th.MakeCommitment(), th.MakeCommitment(), th.MakeCommitment(), th.MakeRandomBytes(types.TwoPoRepProofPartitions.ProofLen()), types.NewBlockHeight(100000))
+	// the services used to send with a fixed limit the inclusion of a
+	// commitment alone exceeds
+	assert.True(t, commitGas > types.NewGasUnits(300))
+
+	send(minerAddr, types.ZeroAttoFIL, 2, "submitPoSt", []types.PoStProof{th.MakeRandomPoStProofForTest()}, types.NewFaultSet([]uint64{}), types.EmptyIntSet())
+	send(minerAddr, types.ZeroAttoFIL, 3, "declareFaults", types.NewIntSet(1))
+}
+
 func setupActorsForGasTest(t *testing.T, vms vm.StorageMap, fakeActorCodeCid cid.Cid, senderBalance uint64) ([]address.Address, state.Tree, *types.MockSigner) {
 	addressGenerator := address.NewForTestGetter()
 
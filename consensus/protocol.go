@@ -15,6 +15,7 @@ import (
 
 	"github.com/ipfs/go-cid"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -25,8 +26,9 @@ import (
 // the most theoretically obvious or pleasing and should not be considered
 // finalized.
 type Protocol interface {
-	// Weight returns the weight given to the input ts by this consensus protocol.
-	Weight(ctx context.Context, ts types.TipSet, pSt state.Tree) (uint64, error)
+	// Weight returns the weight given to the input ts by this consensus
+	// protocol, measured in the state with root pStateID of its parent.
+	Weight(ctx context.Context, ts types.TipSet, pStateID cid.Cid) (uint64, error)
 
 	// IsHeaver returns 1 if tipset a is heavier than tipset b and -1 if
 	// tipset b is heavier than tipset a.
@@ -39,8 +41,9 @@ type Protocol interface {
 	// ValidateSyntax validates a single block is correctly formed.
 	ValidateSyntax(ctx context.Context, b *types.Block) error
 
-	// ValidateSemantic validates a block is correctly derived from its parent.
-	ValidateSemantic(ctx context.Context, child *types.Block, parents *types.TipSet) error
+	// ValidateSemantic validates a block is correctly derived from its parent
+	// and signed by worker, the worker of its miner in the parent state.
+	ValidateSemantic(ctx context.Context, child *types.Block, parents *types.TipSet, worker address.Address) error
 
 	// BlockTime returns the block time used by the consensus protocol.
 	BlockTime() time.Duration
@@ -0,0 +1,201 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+	"github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// ProtocolVersion is a version of the rules the network processes state
+// transitions with. Networks schedule the heights at which they upgrade to
+// new versions in their genesis state.
+type ProtocolVersion struct {
+	// Actors are the builtin actor implementations of the version.
+	Actors map[cid.Cid]exec.ExecutableActor
+
+	// GasSchedule, if set, replaces the gas schedule the network selected at
+	// genesis.
+	GasSchedule *types.GasSchedule
+
+	// Migration, if set, migrates the state of the network when it upgrades
+	// to the version.
+	Migration StateMigration
+}
+
+// StateMigration migrates a state to a protocol version at the height of the
+// upgrade. The state executes the builtin actors of the new version.
+type StateMigration func(ctx context.Context, st state.Tree, vms vm.StorageMap, height *types.BlockHeight) error
+
+// ProtocolVersions are the protocol versions this node can process, by
+// version. A node cannot follow a network past an upgrade to a version
+// missing here.
+var ProtocolVersions = map[uint64]*ProtocolVersion{
+	0: {Actors: builtin.Actors},
+}
+
+// protocolVersion returns the protocol version of the given number.
+func protocolVersion(version uint64) (*ProtocolVersion, error) {
+	pv, ok := ProtocolVersions[version]
+	if !ok {
+		return nil, errors.NewFaultErrorf("unsupported protocol version %d", version)
+	}
+	return pv, nil
+}
+
+// ValidateProtocolUpgrades checks that the versions of a protocol upgrade
+// schedule are known and scheduled in order.
+func ValidateProtocolUpgrades(upgrades []storagemarket.ProtocolUpgrade) error {
+	version, height := uint64(0), types.NewBlockHeight(0)
+	for _, upgrade := range upgrades {
+		if upgrade.Version <= version {
+			return fmt.Errorf("protocol version %d is not scheduled after version %d", upgrade.Version, version)
+		}
+		if upgrade.Height == nil || upgrade.Height.LessEqual(height) {
+			return fmt.Errorf("protocol version %d is not scheduled after height %s", upgrade.Version, height)
+		}
+		if _, err := protocolVersion(upgrade.Version); err != nil {
+			return err
+		}
+		version, height = upgrade.Version, upgrade.Height
+	}
+	return nil
+}
+
+// activateProtocolVersion upgrades a state to the protocol version scheduled
+// at the given height, running the migrations of the versions it upgrades
+// to. It returns the state executing the builtin actors of the version, and
+// the gas schedule of the version.
+func activateProtocolVersion(ctx context.Context, st state.Tree, vms vm.StorageMap, bh *types.BlockHeight) (state.Tree, *types.GasSchedule, error) {
+	smState, err := readNetworkState(ctx, st, vms)
+	if err != nil {
+		return nil, nil, err
+	}
+	if smState == nil {
+		// networks without a storage market do not upgrade
+		return st, types.FlatGasSchedule, nil
+	}
+
+	for _, upgrade := range smState.ProtocolUpgrades {
+		if upgrade.Height.GreaterThan(bh) {
+			break
+		}
+		if upgrade.Version <= smState.ProtocolVersion {
+			continue
+		}
+
+		pv, err := protocolVersion(upgrade.Version)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pv.Migration != nil {
+			if err := pv.Migration(ctx, state.NewTreeWithActors(st, pv.Actors), vms, bh); err != nil {
+				return nil, nil, errors.FaultErrorWrapf(err, "failed to migrate state to protocol version %d", upgrade.Version)
+			}
+			// the migration may have changed the storage market
+			if smState, err = readNetworkState(ctx, st, vms); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		smState.ProtocolVersion = upgrade.Version
+		if err := writeNetworkState(ctx, st, vms, smState); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return networkView(st, smState)
+}
+
+// networkTree returns a state executing the builtin actors of the protocol
+// version it was last processed with, and the gas schedule of the version.
+func networkTree(ctx context.Context, st state.Tree, vms vm.StorageMap) (state.Tree, *types.GasSchedule, error) {
+	smState, err := readNetworkState(ctx, st, vms)
+	if err != nil {
+		return nil, nil, err
+	}
+	if smState == nil {
+		return st, types.FlatGasSchedule, nil
+	}
+	return networkView(st, smState)
+}
+
+func networkView(st state.Tree, smState *storagemarket.State) (state.Tree, *types.GasSchedule, error) {
+	pv, err := protocolVersion(smState.ProtocolVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schedule := pv.GasSchedule
+	if schedule == nil {
+		schedule, err = types.GasScheduleVersion(smState.GasSchedule)
+		if err != nil {
+			return nil, nil, errors.FaultErrorWrap(err, "could not get gas schedule")
+		}
+	}
+	return state.NewTreeWithActors(st, pv.Actors), schedule, nil
+}
+
+// checkProtocolVersion returns an error if the protocol version scheduled
+// at the given height is unknown to this node.
+func checkProtocolVersion(ctx context.Context, st state.Tree, vms vm.StorageMap, bh *types.BlockHeight) error {
+	smState, err := readNetworkState(ctx, st, vms)
+	if err != nil || smState == nil {
+		return err
+	}
+	_, err = protocolVersion(smState.ProtocolVersionAt(bh))
+	return err
+}
+
+// readNetworkState reads the network parameters from the state of the
+// storage market. It returns nil if the network has no storage market.
+func readNetworkState(ctx context.Context, st state.Tree, vms vm.StorageMap) (*storagemarket.State, error) {
+	smActor, err := st.GetActor(ctx, address.StorageMarketAddress)
+	if state.IsActorNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.FaultErrorWrap(err, "failed to get storage market actor")
+	}
+	if !smActor.Head.Defined() {
+		return nil, nil
+	}
+
+	raw, err := vms.NewStorage(address.StorageMarketAddress, smActor).Get(smActor.Head)
+	if err != nil {
+		return nil, errors.FaultErrorWrap(err, "failed to load storage market state")
+	}
+	var smState storagemarket.State
+	if err := actor.UnmarshalStorage(raw, &smState); err != nil {
+		return nil, errors.FaultErrorWrap(err, "failed to decode storage market state")
+	}
+	return &smState, nil
+}
+
+// writeNetworkState writes the network parameters to the state of the
+// storage market.
+func writeNetworkState(ctx context.Context, st state.Tree, vms vm.StorageMap, smState *storagemarket.State) error {
+	smActor, err := st.GetActor(ctx, address.StorageMarketAddress)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "failed to get storage market actor")
+	}
+
+	storage := vms.NewStorage(address.StorageMarketAddress, smActor)
+	head, err := storage.Put(smState)
+	if err != nil {
+		return errors.FaultErrorWrap(err, "failed to store storage market state")
+	}
+	if err := storage.Commit(head, smActor.Head); err != nil {
+		return errors.FaultErrorWrap(err, "failed to commit storage market state")
+	}
+	return st.SetActor(ctx, address.StorageMarketAddress, smActor)
+}
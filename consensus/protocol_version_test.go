@@ -0,0 +1,179 @@
+package consensus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-hamt-ipld"
+	"github.com/ipfs/go-ipfs-blockstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// upgradedActor is the implementation of the fake actor after the mock
+// upgrade, which adds a method.
+type upgradedActor struct {
+	actor.FakeActor
+}
+
+var upgradedActorExports = exec.Exports{
+	"version": &exec.FunctionSignature{
+		Params: nil,
+		Return: []abi.Type{abi.Uint64},
+	},
+}
+
+func (a *upgradedActor) Exports() exec.Exports {
+	return upgradedActorExports
+}
+
+// Version returns the protocol version the actor was upgraded to.
+func (a *upgradedActor) Version(ctx exec.VMContext) (uint64, uint8, error) {
+	return 1, 0, nil
+}
+
+func TestProtocolUpgrade(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	ctx := context.Background()
+
+	fakeActorCodeCid := types.NewCidForTestGetter()()
+	builtin.Actors[fakeActorCodeCid] = &actor.FakeActor{}
+	defer delete(builtin.Actors, fakeActorCodeCid)
+
+	// the mock upgrade swaps the fake actor code, selects the operation gas
+	// schedule and funds an account
+	upgradedActors := map[cid.Cid]exec.ExecutableActor{}
+	for code, act := range builtin.Actors {
+		upgradedActors[code] = act
+	}
+	upgradedActors[fakeActorCodeCid] = &upgradedActor{}
+	operationSchedule, err := types.GasScheduleVersion(types.OperationGasScheduleVersion)
+	require.NoError(t, err)
+
+	migratedAddr := address.NewForTestGetter()()
+	migrations := 0
+	ProtocolVersions[1] = &ProtocolVersion{
+		Actors:      upgradedActors,
+		GasSchedule: operationSchedule,
+		Migration: func(ctx context.Context, st state.Tree, vms vm.StorageMap, height *types.BlockHeight) error {
+			migrations++
+			// the migration runs at the height of the upgrade
+			assert.Equal(t, types.NewBlockHeight(7), height)
+			return st.SetActor(ctx, migratedAddr, th.RequireNewAccountActor(t, types.NewAttoFILFromFIL(1)))
+		},
+	}
+	defer delete(ProtocolVersions, 1)
+
+	st, vms := requireUpgradeGenesis(t, ProtocolUpgrade(1, 5))
+	fakeAddr := address.NewForTestGetter()()
+	require.NoError(t, st.SetActor(ctx, fakeAddr, th.RequireNewFakeActor(t, vms, fakeAddr, fakeActorCodeCid)))
+
+	processor := NewConfiguredProcessor(NewDefaultMessageValidator(), &th.TestBlockRewarder{})
+	process := func(height uint64) {
+		_, err := processor.ApplyMessagesAndPayRewards(ctx, st, vms, nil, address.Undef, types.NewBlockHeight(height), nil)
+		require.NoError(t, err)
+	}
+
+	t.Run("the network runs its genesis version before the upgrade", func(t *testing.T) {
+		process(4)
+		assert.Equal(t, 0, migrations)
+
+		_, code, err := CallQueryMethod(ctx, st, vms, fakeAddr, "version", nil, address.Undef, nil)
+		assert.Error(t, err)
+		assert.NotEqual(t, uint8(0), code)
+
+		schedule, err := NetworkGasSchedule(ctx, st, vms)
+		require.NoError(t, err)
+		assert.Equal(t, types.FlatGasSchedule, schedule)
+	})
+
+	t.Run("the first state processed after the upgrade height is migrated", func(t *testing.T) {
+		// heights 5 and 6 are null rounds
+		process(7)
+		assert.Equal(t, 1, migrations)
+
+		_, err := st.GetActor(ctx, migratedAddr)
+		assert.NoError(t, err)
+	})
+
+	t.Run("the network runs the upgraded version after the upgrade", func(t *testing.T) {
+		process(8)
+		assert.Equal(t, 1, migrations)
+
+		ret, code, err := CallQueryMethod(ctx, st, vms, fakeAddr, "version", nil, address.Undef, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint8(0), code)
+		version, err := abi.Deserialize(ret[0], abi.Uint64)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), version.Val)
+
+		schedule, err := NetworkGasSchedule(ctx, st, vms)
+		require.NoError(t, err)
+		assert.Equal(t, operationSchedule, schedule)
+	})
+
+	t.Run("nodes without the version cannot process past the upgrade", func(t *testing.T) {
+		st, vms := requireUpgradeGenesis(t, ProtocolUpgrade(1, 5))
+		upgrade := ProtocolVersions[1]
+		delete(ProtocolVersions, 1)
+		defer func() { ProtocolVersions[1] = upgrade }()
+
+		_, err := processor.ApplyMessagesAndPayRewards(ctx, st, vms, nil, address.Undef, types.NewBlockHeight(4), nil)
+		assert.NoError(t, err)
+		_, err = processor.ApplyMessagesAndPayRewards(ctx, st, vms, nil, address.Undef, types.NewBlockHeight(5), nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateProtocolUpgrades(t *testing.T) {
+	tf.BadUnitTestWithSideEffects(t)
+
+	ProtocolVersions[1] = &ProtocolVersion{Actors: builtin.Actors}
+	ProtocolVersions[2] = &ProtocolVersion{Actors: builtin.Actors}
+	defer delete(ProtocolVersions, 1)
+	defer delete(ProtocolVersions, 2)
+
+	upgrade := func(version, height uint64) storagemarket.ProtocolUpgrade {
+		return storagemarket.ProtocolUpgrade{Version: version, Height: types.NewBlockHeight(height)}
+	}
+
+	assert.NoError(t, ValidateProtocolUpgrades(nil))
+	assert.NoError(t, ValidateProtocolUpgrades([]storagemarket.ProtocolUpgrade{upgrade(1, 5), upgrade(2, 10)}))
+
+	// versions are unknown, out of order, or scheduled at genesis
+	assert.Error(t, ValidateProtocolUpgrades([]storagemarket.ProtocolUpgrade{upgrade(3, 5)}))
+	assert.Error(t, ValidateProtocolUpgrades([]storagemarket.ProtocolUpgrade{upgrade(2, 5), upgrade(1, 10)}))
+	assert.Error(t, ValidateProtocolUpgrades([]storagemarket.ProtocolUpgrade{upgrade(1, 10), upgrade(2, 5)}))
+	assert.Error(t, ValidateProtocolUpgrades([]storagemarket.ProtocolUpgrade{upgrade(1, 0)}))
+
+	// genesis rejects invalid schedules
+	_, err := MakeGenesisFunc(ProtocolUpgrade(3, 5))(hamt.NewCborStore(), blockstore.NewBlockstore(datastore.NewMapDatastore()))
+	assert.Error(t, err)
+}
+
+func requireUpgradeGenesis(t *testing.T, opts ...GenOption) (state.Tree, vm.StorageMap) {
+	cst := hamt.NewCborStore()
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	blk, err := MakeGenesisFunc(opts...)(cst, bs)
+	require.NoError(t, err)
+
+	st, err := state.LoadStateTree(context.Background(), cst, blk.StateRoot, builtin.Actors)
+	require.NoError(t, err)
+	return st, vm.NewStorageMap(bs)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/state"
 	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-blockstore"
 
 	"github.com/stretchr/testify/require"
@@ -19,17 +20,17 @@ type TestView struct{}
 var _ PowerTableView = &TestView{}
 
 // Total always returns 1.
-func (tv *TestView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+func (tv *TestView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
 	return types.NewBytesAmount(1), nil
 }
 
 // Miner always returns 1.
-func (tv *TestView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+func (tv *TestView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
 	return types.NewBytesAmount(1), nil
 }
 
 // HasPower always returns true.
-func (tv *TestView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
+func (tv *TestView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
 
@@ -51,17 +52,17 @@ func NewTestPowerTableView(minerPower uint64, totalPower uint64) *TestPowerTable
 }
 
 // Total always returns value that was supplied to NewTestPowerTableView.
-func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (uint64, error) {
+func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (uint64, error) {
 	return tv.totalPower, nil
 }
 
 // Miner always returns value that was supplied to NewTestPowerTableView.
-func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error) {
+func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (uint64, error) {
 	return tv.minerPower, nil
 }
 
 // HasPower always returns true.
-func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
+func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
 
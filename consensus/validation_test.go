@@ -17,7 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-var keys = types.MustGenerateKeyInfo(2, 42)
+var keys = append(types.MustGenerateKeyInfo(2, 42), types.MustGenerateBLSKeyInfo(1)...)
 var signer = types.NewMockSigner(keys)
 var addresses = make([]address.Address, len(keys))
 
@@ -50,6 +50,17 @@ func TestMessageValidator(t *testing.T) {
 
 	})
 
+	t.Run("valid BLS signature", func(t *testing.T) {
+		msg := newMessage(t, addresses[2], bob, 100, 5, 1, 0)
+		assert.NoError(t, validator.Validate(ctx, msg, actor))
+	})
+
+	t.Run("invalid BLS signature fails", func(t *testing.T) {
+		msg := newMessage(t, addresses[2], bob, 100, 5, 1, 0)
+		msg.Signature = newMessage(t, alice, bob, 100, 5, 1, 0).Signature
+		assert.Error(t, validator.Validate(ctx, msg, actor))
+	})
+
 	t.Run("self send fails", func(t *testing.T) {
 		msg := newMessage(t, alice, alice, 100, 5, 1, 0)
 		assert.Errorf(t, validator.Validate(ctx, msg, actor), "self")
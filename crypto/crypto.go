@@ -16,6 +16,10 @@ const PrivateKeyBytes = 32
 // PublicKeyBytes is the size of a serialized public key.
 const PublicKeyBytes = 65
 
+// SignatureBytes is the size of a serialized signature, including the
+// recovery byte.
+const SignatureBytes = 65
+
 // PublicKey returns the public key for this private key.
 func PublicKey(sk []byte) []byte {
 	x, y := secp256k1.S256().ScalarBaseMult(sk)
@@ -0,0 +1,119 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	secp256k1 "github.com/ipsn/go-secp256k1"
+)
+
+// HardenedOffset is added to the index of hardened BIP-32 children, whose
+// derivation requires the parent private key.
+const HardenedOffset uint32 = 1 << 31
+
+// masterKeySalt is the HMAC key deriving the BIP-32 master key from a seed.
+var masterKeySalt = []byte("Bitcoin seed")
+
+// DeriveKey derives the private key at the BIP-32 derivation path, for example
+// m/44'/461'/0'/0/0, from a seed such as the one of a BIP-39 mnemonic.
+func DeriveKey(seed []byte, path string) ([]byte, error) {
+	indices, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, masterKeySalt)
+	mac.Write(seed) // nolint: errcheck
+	sum := mac.Sum(nil)
+
+	key, chainCode := sum[:PrivateKeyBytes], sum[PrivateKeyBytes:]
+	if !validPrivateKey(new(big.Int).SetBytes(key)) {
+		return nil, fmt.Errorf("seed derives an invalid master key")
+	}
+
+	for _, index := range indices {
+		key, chainCode, err = deriveChildKey(key, chainCode, index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// ParseDerivationPath parses a BIP-32 derivation path into child indices,
+// with HardenedOffset added to those of hardened children, marked by a '.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("derivation path %q does not start with m", path)
+	}
+
+	var indices []uint32
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'")
+		index, err := strconv.ParseUint(strings.TrimSuffix(part, "'"), 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q in derivation path %q", part, path)
+		}
+
+		if hardened {
+			index += uint64(HardenedOffset)
+		}
+		indices = append(indices, uint32(index))
+	}
+	return indices, nil
+}
+
+func deriveChildKey(key, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0}, key...)
+	} else {
+		data = compressedPublicKey(key)
+	}
+	data = append(data, make([]byte, 4)...)
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data) // nolint: errcheck
+	sum := mac.Sum(nil)
+
+	curveOrder := secp256k1.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:PrivateKeyBytes])
+	if tweak.Cmp(curveOrder) >= 0 {
+		return nil, nil, fmt.Errorf("index %d derives an invalid key", index)
+	}
+
+	child := tweak.Add(tweak, new(big.Int).SetBytes(key))
+	child.Mod(child, curveOrder)
+	if !validPrivateKey(child) {
+		return nil, nil, fmt.Errorf("index %d derives an invalid key", index)
+	}
+
+	childKey := make([]byte, PrivateKeyBytes)
+	blob := child.Bytes()
+	copy(childKey[PrivateKeyBytes-len(blob):], blob)
+
+	return childKey, sum[PrivateKeyBytes:], nil
+}
+
+// compressedPublicKey returns the 33 byte SEC1 compressed public key of the
+// private key, as BIP-32 hashes it.
+func compressedPublicKey(sk []byte) []byte {
+	x, y := secp256k1.S256().ScalarBaseMult(sk)
+
+	out := make([]byte, 33)
+	out[0] = 2 + byte(y.Bit(0))
+	blob := x.Bytes()
+	copy(out[33-len(blob):], blob)
+	return out
+}
+
+func validPrivateKey(k *big.Int) bool {
+	return k.Sign() > 0 && k.Cmp(secp256k1.S256().Params().N) < 0
+}
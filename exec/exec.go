@@ -76,6 +76,7 @@ type VMContext interface {
 	MyBalance() types.AttoFIL
 	IsFromAccountActor() bool
 	Charge(cost types.GasUnits) error
+	GasSchedule() *types.GasSchedule
 	SampleChainRandomness(sampleHeight *types.BlockHeight) ([]byte, error)
 
 	CreateNewActor(addr address.Address, code cid.Cid, initalizationParams interface{}) error
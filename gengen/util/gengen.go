@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	mrand "math/rand"
 	"strconv"
 
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/account"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/crypto"
@@ -30,6 +32,10 @@ import (
 	"github.com/pkg/errors"
 )
 
+// bootstrapSectorExpiration is the expiration height of the sectors the
+// miners of the genesis block commit, which never expire.
+var bootstrapSectorExpiration = types.NewBlockHeight(math.MaxUint64)
+
 // CreateStorageMinerConfig holds configuration options used to create a storage
 // miner in the genesis block. Note: Instances of this struct can be created
 // from the contents of fixtures/setup.json, which means that a JSON
@@ -66,6 +72,13 @@ type GenesisCfg struct {
 
 	// ProofsMode affects sealing, sector packing, PoSt, etc. in the proofs library
 	ProofsMode types.ProofsMode
+
+	// GasSchedule is the version of the gas schedule charged by the network
+	GasSchedule uint64
+
+	// ProtocolUpgrades schedules the heights at which the network upgrades
+	// to new protocol versions
+	ProtocolUpgrades []storagemarket.ProtocolUpgrade
 }
 
 // RenderedGenInfo contains information about a genesis block creation
@@ -107,13 +120,20 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 	st := state.NewEmptyStateTreeWithActors(cst, builtin.Actors)
 	storageMap := vm.NewStorageMap(bs)
 
-	if err := consensus.SetupDefaultActors(ctx, st, storageMap, cfg.ProofsMode); err != nil {
+	if err := consensus.SetupDefaultActors(ctx, st, storageMap, storagemarket.NetworkParams{
+		ProofsMode:       cfg.ProofsMode,
+		GasSchedule:      cfg.GasSchedule,
+		ProtocolUpgrades: cfg.ProtocolUpgrades,
+	}); err != nil {
 		return nil, err
 	}
 
 	if err := setupPrealloc(st, keys, cfg.PreAlloc); err != nil {
 		return nil, err
 	}
+	if err := consensus.RegisterActorAddresses(ctx, st, storageMap); err != nil {
+		return nil, err
+	}
 
 	miners, err := setupMiners(st, storageMap, keys, cfg.Miners, pnrg)
 	if err != nil {
@@ -135,6 +155,9 @@ func GenGen(ctx context.Context, cfg *GenesisCfg, cst *hamt.CborIpldStore, bs bl
 	if err := cst.Blocks.AddBlock(types.PaymentBrokerActorCodeObj); err != nil {
 		return nil, err
 	}
+	if err := cst.Blocks.AddBlock(types.InitActorCodeObj); err != nil {
+		return nil, err
+	}
 
 	stateRoot, err := st.Flush(ctx)
 	if err != nil {
@@ -303,7 +326,7 @@ func setupMiners(st state.Tree, sm vm.StorageMap, keys []*types.KeyInfo, miners
 			if _, err := pnrg.Read(sealProof[:]); err != nil {
 				return nil, err
 			}
-			_, err := applyMessageDirect(ctx, st, sm, addr, maddr, types.NewAttoFILFromFIL(0), "commitSector", sectorID, commD, commR, commRStar, sealProof)
+			_, err := applyMessageDirect(ctx, st, sm, addr, maddr, types.NewAttoFILFromFIL(0), "commitSector", sectorID, commD, commR, commRStar, sealProof, bootstrapSectorExpiration)
 			if err != nil {
 				return nil, err
 			}
@@ -31,7 +31,12 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 		return nil, errors.Wrap(err, "get state tree")
 	}
 
-	if !w.powerTable.HasPower(ctx, stateTree, w.blockstore, w.minerAddr) {
+	stateID, err := w.getStateRoot(baseTipSet)
+	if err != nil {
+		return nil, errors.Wrap(err, "get state root")
+	}
+
+	if !w.powerTable.HasPower(ctx, stateTree, stateID, w.blockstore, w.minerAddr) {
 		return nil, errors.Errorf("bad miner address, miner must store files before mining: %s", w.minerAddr)
 	}
 
@@ -106,6 +111,10 @@ func (w *DefaultWorker) Generate(ctx context.Context,
 		// TODO when #2961 is resolved do the needful here.
 		Timestamp: types.Uint64(time.Now().Unix()),
 	}
+	next.BlockSig, err = w.workerSigner.SignBytes(next.SignatureData(), w.minerWorker)
+	if err != nil {
+		return nil, errors.Wrap(err, "sign block")
+	}
 
 	for i, msg := range res.PermanentFailures {
 		// We will not be able to apply this message in the future because the error was permanent.
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-blockstore"
 
 	"github.com/filecoin-project/go-filecoin/address"
@@ -128,16 +129,16 @@ func NewTestPowerTableView(n uint64) *TestPowerTableView {
 }
 
 // Total always returns n.
-func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
 	return types.NewBytesAmount(tv.n), nil
 }
 
 // Miner always returns 1.
-func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
 	return types.NewBytesAmount(uint64(1)), nil
 }
 
 // HasPower always returns true.
-func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
+func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
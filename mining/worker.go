@@ -46,6 +46,10 @@ type Worker interface {
 // its own function to facilitate testing.
 type GetStateTree func(context.Context, types.TipSet) (state.Tree, error)
 
+// GetStateRoot is a function that gets the root of the aggregate state tree of
+// a TipSet, which identifies the state to the power table.
+type GetStateRoot func(types.TipSet) (cid.Cid, error)
+
 // GetWeight is a function that calculates the weight of a TipSet.  Weight is
 // expressed as two uint64s comprising a rational number.
 type GetWeight func(context.Context, types.TipSet) (uint64, error)
@@ -84,6 +88,7 @@ type DefaultWorker struct {
 
 	// consensus things
 	getStateTree GetStateTree
+	getStateRoot GetStateRoot
 	getWeight    GetWeight
 	getAncestors GetAncestors
 
@@ -106,6 +111,7 @@ type WorkerParameters struct {
 
 	// consensus things
 	GetStateTree GetStateTree
+	GetStateRoot GetStateRoot
 	GetWeight    GetWeight
 	GetAncestors GetAncestors
 
@@ -135,6 +141,7 @@ func NewDefaultWorkerWithDeps(parameters WorkerParameters,
 	return &DefaultWorker{
 		api:            parameters.API,
 		getStateTree:   parameters.GetStateTree,
+		getStateRoot:   parameters.GetStateRoot,
 		getWeight:      parameters.GetWeight,
 		getAncestors:   parameters.GetAncestors,
 		messageSource:  parameters.MessageSource,
@@ -173,6 +180,12 @@ func (w *DefaultWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCoun
 		outCh <- Output{Err: err}
 		return false
 	}
+	stateID, err := w.getStateRoot(base)
+	if err != nil {
+		log.Errorf("Worker.Mine couldn't get state root for tipset: %s", err.Error())
+		outCh <- Output{Err: err}
+		return false
+	}
 
 	log.Debugf("Mining on tipset: %s, with %d null blocks.", base.String(), nullBlkCount)
 	if ctx.Err() != nil {
@@ -208,7 +221,7 @@ func (w *DefaultWorker) Mine(ctx context.Context, base types.TipSet, nullBlkCoun
 
 	// TODO: Test the interplay of isWinningTicket() and createPoSTFunc()
 	// https://github.com/filecoin-project/go-filecoin/issues/1791
-	weHaveAWinner, err := consensus.IsWinningTicket(ctx, w.blockstore, w.powerTable, st, ticket, w.minerAddr)
+	weHaveAWinner, err := consensus.IsWinningTicket(ctx, w.blockstore, w.powerTable, st, stateID, ticket, w.minerAddr)
 
 	if err != nil {
 		log.Errorf("Worker.Mine couldn't compute ticket: %s", err.Error())
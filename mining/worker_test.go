@@ -67,6 +67,7 @@ func Test_Mine(t *testing.T) {
 			WorkerSigner:   mockSigner,
 
 			GetStateTree: getStateTree,
+			GetStateRoot: getStateRootTest,
 			GetWeight:    getWeightTest,
 			GetAncestors: getAncestors,
 
@@ -95,6 +96,7 @@ func Test_Mine(t *testing.T) {
 			WorkerSigner:   mockSigner,
 
 			GetStateTree: makeExplodingGetStateTree(st),
+			GetStateRoot: getStateRootTest,
 			GetWeight:    getWeightTest,
 			GetAncestors: getAncestors,
 
@@ -126,6 +128,7 @@ func Test_Mine(t *testing.T) {
 			WorkerSigner:   mockSigner,
 
 			GetStateTree: getStateTree,
+			GetStateRoot: getStateRootTest,
 			GetWeight:    getWeightTest,
 			GetAncestors: getAncestors,
 
@@ -270,6 +273,7 @@ func TestGenerateMultiBlockTipSet(t *testing.T) {
 		WorkerSigner:   mockSigner,
 
 		GetStateTree: getStateTree,
+		GetStateRoot: getStateRootTest,
 		GetWeight:    getWeightTest,
 		GetAncestors: getAncestors,
 
@@ -333,6 +337,7 @@ func TestGeneratePoolBlockResults(t *testing.T) {
 		WorkerSigner:   mockSigner,
 
 		GetStateTree: getStateTree,
+		GetStateRoot: getStateRootTest,
 		GetWeight:    getWeightTest,
 		GetAncestors: getAncestors,
 
@@ -441,6 +446,7 @@ func TestGenerateSetsBasicFields(t *testing.T) {
 		WorkerSigner:   mockSigner,
 
 		GetStateTree: getStateTree,
+		GetStateRoot: getStateRootTest,
 		GetWeight:    getWeightTest,
 		GetAncestors: getAncestors,
 
@@ -502,6 +508,7 @@ func TestGenerateWithoutMessages(t *testing.T) {
 		WorkerSigner:   mockSigner,
 
 		GetStateTree: getStateTree,
+		GetStateRoot: getStateRootTest,
 		GetWeight:    getWeightTest,
 		GetAncestors: getAncestors,
 
@@ -555,6 +562,7 @@ func TestGenerateError(t *testing.T) {
 		WorkerSigner:   mockSigner,
 
 		GetStateTree: makeExplodingGetStateTree(st),
+		GetStateRoot: getStateRootTest,
 		GetWeight:    getWeightTest,
 		GetAncestors: getAncestors,
 
@@ -604,6 +612,10 @@ func (st *stateTreeForTest) Flush(ctx context.Context) (cid.Cid, error) {
 	return st.TestFlush(ctx)
 }
 
+func getStateRootTest(ts types.TipSet) (cid.Cid, error) {
+	return ts.At(0).StateRoot, nil
+}
+
 func getWeightTest(c context.Context, ts types.TipSet) (uint64, error) {
 	w, err := ts.ParentWeight()
 	if err != nil {
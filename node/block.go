@@ -3,9 +3,12 @@ package node
 import (
 	"context"
 
+	"github.com/ipfs/go-cid"
 	"github.com/pkg/errors"
 	"go.opencensus.io/trace"
 
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
 	"github.com/filecoin-project/go-filecoin/metrics/tracing"
 	"github.com/filecoin-project/go-filecoin/net"
 	"github.com/filecoin-project/go-filecoin/net/pubsub"
@@ -71,3 +74,43 @@ func (node *Node) processBlock(ctx context.Context, pubSubMsg pubsub.Message) (e
 
 	return nil
 }
+
+// faultReporterAPI is the subset of the porcelain.API that
+// consensusFaultReporter uses.
+type faultReporterAPI interface {
+	WalletDefaultAddress() (address.Address, error)
+	MinerPreviewReportConsensusFault(ctx context.Context, from address.Address, block1, block2 *types.Block) (types.GasUnits, error)
+	MinerReportConsensusFault(ctx context.Context, from address.Address, block1, block2 *types.Block, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error)
+}
+
+// consensusFaultReporter reports the consensus faults the node detects to the
+// miner actor of the faulty miner, from the default address of the wallet.
+type consensusFaultReporter struct {
+	api faultReporterAPI
+}
+
+var _ consensus.FaultReporter = (*consensusFaultReporter)(nil)
+
+// ReportConsensusFault sends the evidence of a fault without waiting for the
+// message to be mined.
+func (r *consensusFaultReporter) ReportConsensusFault(ctx context.Context, fault consensus.ConsensusFault) error {
+	from, err := r.api.WalletDefaultAddress()
+	if err != nil {
+		return errors.Wrap(err, "no address to report the fault from")
+	}
+
+	gasUnits, err := r.api.MinerPreviewReportConsensusFault(ctx, from, fault.Block1, fault.Block2)
+	if err != nil {
+		return errors.Wrap(err, "failed to preview reporting the fault")
+	}
+
+	// TODO: determine this by querying historical prices
+	gasPrice := types.NewGasPrice(1)
+
+	msgCid, err := r.api.MinerReportConsensusFault(ctx, from, fault.Block1, fault.Block2, gasPrice, gasUnits)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send reportConsensusFault message from %s", from)
+	}
+	log.Infof("reported consensus fault of miner %s in message %s", fault.Block1.Miner, msgCid)
+	return nil
+}
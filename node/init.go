@@ -23,6 +23,9 @@ var ErrLittleBits = errors.New("Bitsize less than 1024 is considered unsafe") //
 type InitCfg struct {
 	PeerKey                 ci.PrivKey
 	DefaultWalletAddress    address.Address
+	WalletPassphrase        []byte
+	WalletMnemonic          string
+	WalletRestoreCount      uint
 	AutoSealIntervalSeconds uint
 }
 
@@ -44,6 +47,23 @@ func DefaultWalletAddressOpt(addr address.Address) InitOpt {
 	}
 }
 
+// WalletPassphraseOpt sets the passphrase the wallet keys are encrypted with.
+// Without it, the keys are encrypted with the empty passphrase.
+func WalletPassphraseOpt(passphrase []byte) InitOpt {
+	return func(c *InitCfg) {
+		c.WalletPassphrase = passphrase
+	}
+}
+
+// WalletMnemonicOpt restores the wallet from the recovery phrase, deriving its
+// first count addresses, instead of generating a new recovery phrase.
+func WalletMnemonicOpt(mnemonic string, count uint) InitOpt {
+	return func(c *InitCfg) {
+		c.WalletMnemonic = mnemonic
+		c.WalletRestoreCount = count
+	}
+}
+
 // AutoSealIntervalSecondsOpt configures the daemon to check for and seal any staged sectors on an interval.
 func AutoSealIntervalSecondsOpt(autoSealIntervalSeconds uint) InitOpt {
 	return func(c *InitCfg) {
@@ -83,16 +103,17 @@ func Init(ctx context.Context, r repo.Repo, gen consensus.GenesisInitFunc, opts
 
 	newConfig.Mining.AutoSealIntervalSeconds = cfg.AutoSealIntervalSeconds
 
+	// TODO: but behind a config option if this should be generated
+	needAddress := cfg.DefaultWalletAddress == (address.Undef) && r.Config().Wallet.DefaultAddress == (address.Undef)
+	addrs, err := initWallet(r, cfg, needAddress)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize wallet")
+	}
+
 	if cfg.DefaultWalletAddress != (address.Undef) {
 		newConfig.Wallet.DefaultAddress = cfg.DefaultWalletAddress
-	} else if r.Config().Wallet.DefaultAddress == (address.Undef) {
-		// TODO: but behind a config option if this should be generated
-		addr, err := newAddress(r)
-		if err != nil {
-			return errors.Wrap(err, "failed to generate default address")
-		}
-
-		newConfig.Wallet.DefaultAddress = addr
+	} else if needAddress {
+		newConfig.Wallet.DefaultAddress = addrs[0]
 	}
 
 	if err := r.ReplaceConfig(newConfig); err != nil {
@@ -118,18 +139,42 @@ func makePrivateKey(nbits int) (ci.PrivKey, error) {
 	return sk, nil
 }
 
-// newAddress creates a new private-public keypair in the default wallet
-// and returns the address for it.
-func newAddress(r repo.Repo) (address.Address, error) {
+// initWallet sets up the recovery phrase of the default wallet, encrypted
+// with the passphrase, and derives the addresses to restore from it. At least
+// one address is derived if needAddress is set.
+func initWallet(r repo.Repo, cfg *InitCfg, needAddress bool) ([]address.Address, error) {
 	backend, err := wallet.NewDSBackend(r.WalletDatastore())
 	if err != nil {
-		return address.Undef, errors.Wrap(err, "failed to set up wallet backend")
+		return nil, errors.Wrap(err, "failed to set up wallet backend")
 	}
 
-	addr, err := backend.NewAddress()
-	if err != nil {
-		return address.Undef, errors.Wrap(err, "failed to create address")
+	if err := backend.Unlock(cfg.WalletPassphrase, 0); err != nil {
+		return nil, errors.Wrap(err, "failed to unlock wallet")
 	}
 
-	return addr, err
+	mnemonic := cfg.WalletMnemonic
+	if mnemonic == "" {
+		mnemonic, err = wallet.NewMnemonic()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to generate recovery phrase")
+		}
+	}
+	if err := backend.SetMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+
+	count := cfg.WalletRestoreCount
+	if count == 0 && needAddress {
+		count = 1
+	}
+
+	var addrs []address.Address
+	for i := uint(0); i < count; i++ {
+		addr, err := backend.DeriveAddress()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to derive address")
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
 }
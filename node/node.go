@@ -58,6 +58,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/plumbing/cst"
 	"github.com/filecoin-project/go-filecoin/plumbing/dag"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/plumbing/strgdls"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
@@ -89,6 +90,7 @@ type nodeChainReader interface {
 	GetHead() types.TipSetKey
 	GetTipSet(types.TipSetKey) (types.TipSet, error)
 	GetTipSetState(ctx context.Context, tsKey types.TipSetKey) (state.Tree, error)
+	GetTipSetStateRoot(tsKey types.TipSetKey) (cid.Cid, error)
 	HeadEvents() *ps.PubSub
 	Load(context.Context) error
 	Stop()
@@ -109,6 +111,10 @@ type Node struct {
 	Syncer       nodeChainSyncer
 	PowerTable   consensus.PowerTableView
 
+	// FaultDetector collects the consensus faults in the blocks the node
+	// validates.
+	FaultDetector *consensus.FaultDetector
+
 	BlockMiningAPI *block.MiningAPI
 	PorcelainAPI   *porcelain.API
 	RetrievalAPI   *retrieval.API
@@ -140,7 +146,10 @@ type Node struct {
 	miningDoneWg *sync.WaitGroup
 
 	// Storage Market Interfaces
-	StorageMiner *storage.Miner
+	StorageMiner           *storage.Miner
+	StorageAskRepricer     *storage.AskRepricer
+	StorageDealTracker     *storage.DealTracker
+	StorageVoucherRedeemer *storage.VoucherRedeemer
 
 	// Retrieval Interfaces
 	RetrievalMiner *retrieval.Miner
@@ -194,6 +203,11 @@ type Config struct {
 	Rewarder    consensus.BlockRewarder
 	Repo        repo.Repo
 	IsRelay     bool
+
+	// UnlockWallet unlocks the wallet with WalletPassphrase when the node is
+	// built. The wallet starts locked otherwise.
+	UnlockWallet     bool
+	WalletPassphrase []byte
 }
 
 // ConfigOpt is a configuration option for a filecoin node.
@@ -215,6 +229,15 @@ func IsRelay() ConfigOpt {
 	}
 }
 
+// UnlockWallet unlocks the wallet with the passphrase when the node is built.
+func UnlockWallet(passphrase []byte) ConfigOpt {
+	return func(c *Config) error {
+		c.UnlockWallet = true
+		c.WalletPassphrase = passphrase
+		return nil
+	}
+}
+
 // BlockTime sets the blockTime.
 func BlockTime(blockTime time.Duration) ConfigOpt {
 	return func(c *Config) error {
@@ -410,7 +433,10 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	chainStore := chain.NewStore(nc.Repo.ChainDatastore(), &ipldCborStore, &state.TreeStateLoader{}, genCid)
 	messageStore := chain.NewMessageStore(&ipldCborStore)
 	chainState := cst.NewChainStateProvider(chainStore, messageStore, &ipldCborStore)
-	powerTable := &consensus.MarketView{}
+	powerTable, err := consensus.NewCachedPowerTableView(&consensus.MarketView{}, consensus.DefaultPowerTableCacheSize)
+	if err != nil {
+		return nil, err
+	}
 
 	// set up processor
 	var processor consensus.Processor
@@ -440,7 +466,20 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to set up wallet backend")
 	}
-	fcWallet := wallet.New(backend)
+	if nc.UnlockWallet {
+		if err := backend.Unlock(nc.WalletPassphrase, 0); err != nil {
+			return nil, errors.Wrap(err, "failed to unlock wallet")
+		}
+	}
+	backends := []wallet.Backend{backend}
+	if signer := nc.Repo.Config().Wallet.RemoteSigner; signer != "" {
+		remote, err := wallet.NewRemoteBackend(signer)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to set up remote signer wallet backend")
+		}
+		backends = append(backends, remote)
+	}
+	fcWallet := wallet.New(backends...)
 
 	// only the syncer gets the storage which is online connected
 	chainSyncer := chain.NewSyncer(nodeConsensus, chainStore, messageStore, fetcher)
@@ -473,6 +512,7 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 		Wallet:       fcWallet,
 		Router:       router,
 	}
+	nd.FaultDetector = chainSyncer.FaultDetector()
 
 	nd.PorcelainAPI = porcelain.New(plumbing.New(&plumbing.APIDeps{
 		Bitswap:       bswap,
@@ -487,9 +527,14 @@ func (nc *Config) Build(ctx context.Context) (*Node, error) {
 		MsgWaiter:     msg.NewWaiter(chainStore, messageStore, bs, &ipldCborStore),
 		Network:       net.New(peerHost, pubsub.NewPublisher(fsub), pubsub.NewSubscriber(fsub), net.NewRouter(router), bandwidthTracker, net.NewPinger(peerHost, pingService)),
 		Outbox:        outbox,
+		Paychs:        paych.New(nc.Repo.Datastore()),
+		PowerTable:    powerTable,
 		SectorBuilder: nd.SectorBuilder,
 		Wallet:        fcWallet,
 	}))
+	if nd.Repo.Config().Mining.ReportConsensusFaults {
+		nd.FaultDetector.SetReporter(&consensusFaultReporter{api: nd.PorcelainAPI})
+	}
 
 	// Bootstrapping network peers.
 	periodStr := nd.Repo.Config().Bootstrap.Period
@@ -609,6 +654,9 @@ func (node *Node) Start(ctx context.Context) error {
 			return err
 		}
 
+		// Follow and verify deals proposed by this node as a storage client.
+		node.StorageDealTracker.Start(syncCtx)
+
 		// Start heartbeats.
 		if err := node.setupHeartbeatServices(ctx); err != nil {
 			return errors.Wrap(err, "failed to start heartbeat services")
@@ -739,6 +787,16 @@ func (node *Node) handleNewChainHeads(ctx context.Context, prevHead types.TipSet
 					log.Error(err)
 				}
 			}
+			if node.StorageAskRepricer != nil {
+				if err := node.StorageAskRepricer.OnNewHeaviestTipSet(newHead); err != nil {
+					log.Error(err)
+				}
+			}
+			if node.StorageVoucherRedeemer != nil {
+				if err := node.StorageVoucherRedeemer.OnNewHeaviestTipSet(newHead); err != nil {
+					log.Error(err)
+				}
+			}
 		case <-ctx.Done():
 			return
 		}
@@ -767,6 +825,9 @@ func (node *Node) Stop(ctx context.Context) {
 	node.StopMining(ctx)
 
 	node.cancelSubscriptions()
+	if node.StorageDealTracker != nil {
+		node.StorageDealTracker.Stop()
+	}
 	node.ChainReader.Stop()
 
 	if node.SectorBuilder() != nil {
@@ -876,6 +937,15 @@ func (node *Node) StartMining(ctx context.Context) error {
 	}
 	node.StorageMiner = storageMiner
 
+	askRepricer, err := initAskRepricerForNode(ctx, node)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize ask repricer")
+	}
+	node.StorageAskRepricer = askRepricer
+
+	// vouchers are redeemed by the channel target, which is the miner owner
+	node.StorageVoucherRedeemer = storage.NewVoucherRedeemer(minerAddr, minerOwnerAddr, node.PorcelainAPI)
+
 	// loop, turning sealing-results into commitSector messages to be included
 	// in the chain
 	go func() {
@@ -886,11 +956,33 @@ func (node *Node) StartMining(ctx context.Context) error {
 					log.Errorf("failed to seal sector with id %d: %s", result.SectorID, result.SealingErr.Error())
 				} else if result.SealingResult != nil {
 
-					// TODO: determine these algorithmically by simulating call and querying historical prices
+					val := result.SealingResult
+					expiration, err := node.StorageMiner.SectorExpiration(miningCtx, val.SectorID)
+					if err != nil {
+						log.Errorf("failed to get expiration of sector with id %d: %s", val.SectorID, err)
+						continue
+					}
+
+					gasUnits, err := node.PorcelainAPI.MessagePreview(
+						miningCtx,
+						minerOwnerAddr,
+						minerAddr,
+						"commitSector",
+						val.SectorID,
+						val.CommD[:],
+						val.CommR[:],
+						val.CommRStar[:],
+						val.Proof[:],
+						expiration,
+					)
+					if err != nil {
+						log.Errorf("failed to preview commitSector message for sector with id %d: %s", val.SectorID, err)
+						continue
+					}
+
+					// TODO: determine this by querying historical prices
 					gasPrice := types.NewGasPrice(1)
-					gasUnits := types.NewGasUnits(300)
 
-					val := result.SealingResult
 					// This call can fail due to, e.g. nonce collisions. Our miners existence depends on this.
 					// We should deal with this, but MessageSendWithRetry is problematic.
 					msgCid, err := node.PorcelainAPI.MessageSend(
@@ -906,6 +998,7 @@ func (node *Node) StartMining(ctx context.Context) error {
 						val.CommR[:],
 						val.CommRStar[:],
 						val.Proof[:],
+						expiration,
 					)
 					if err != nil {
 						log.Errorf("failed to send commitSector message from %s to %s for sector with id %d: %s", minerOwnerAddr, minerAddr, val.SectorID, err)
@@ -1026,6 +1119,20 @@ func initStorageMinerForNode(ctx context.Context, node *Node) (*storage.Miner, e
 	return miner, nil
 }
 
+func initAskRepricerForNode(ctx context.Context, node *Node) (*storage.AskRepricer, error) {
+	minerAddr, err := node.MiningAddress()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get node's mining address")
+	}
+
+	workerAddress, err := node.PorcelainAPI.MinerGetWorker(ctx, minerAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get miner's worker address")
+	}
+
+	return storage.NewAskRepricer(minerAddr, workerAddress, node.PorcelainAPI), nil
+}
+
 // StopMining stops mining on new blocks.
 func (node *Node) StopMining(ctx context.Context) {
 	node.setIsMining(false)
@@ -1084,13 +1191,16 @@ func (node *Node) setupProtocols() error {
 
 	// set up storage client and api
 	smc := storage.NewClient(node.host, node.PorcelainAPI)
-	smcAPI := storage.NewAPI(smc)
+	renewer := storage.NewRenewer(node.PorcelainAPI, smc, node.RetrievalAPI)
+	replicator := storage.NewReplicator(node.PorcelainAPI, smc)
+	smcAPI := storage.NewAPI(smc, renewer, replicator)
 	node.StorageAPI = &smcAPI
+	node.StorageDealTracker = storage.NewDealTracker(node.PorcelainAPI, smc.QueryDeal)
 	return nil
 }
 
 // CreateMiningWorker creates a mining.Worker for the node using the configured
-// getStateTree, getWeight, and getAncestors functions for the node
+// getStateTree, getStateRoot, getWeight, and getAncestors functions for the node
 func (node *Node) CreateMiningWorker(ctx context.Context) (mining.Worker, error) {
 	processor := consensus.NewDefaultProcessor()
 
@@ -1118,6 +1228,7 @@ func (node *Node) CreateMiningWorker(ctx context.Context) (mining.Worker, error)
 		WorkerSigner:   node.Wallet,
 
 		GetStateTree: node.getStateTree,
+		GetStateRoot: node.getStateRoot,
 		GetWeight:    node.getWeight,
 		GetAncestors: node.getAncestors,
 
@@ -1133,6 +1244,11 @@ func (node *Node) getStateTree(ctx context.Context, ts types.TipSet) (state.Tree
 	return node.ChainReader.GetTipSetState(ctx, ts.Key())
 }
 
+// getStateRoot is the default GetStateRoot function for the mining worker.
+func (node *Node) getStateRoot(ts types.TipSet) (cid.Cid, error) {
+	return node.ChainReader.GetTipSetStateRoot(ts.Key())
+}
+
 // getWeight is the default GetWeight function for the mining worker.
 func (node *Node) getWeight(ctx context.Context, ts types.TipSet) (uint64, error) {
 	parent, err := ts.Parents()
@@ -1141,13 +1257,13 @@ func (node *Node) getWeight(ctx context.Context, ts types.TipSet) (uint64, error
 	}
 	// TODO handle genesis cid more gracefully
 	if parent.Len() == 0 {
-		return node.Consensus.Weight(ctx, ts, nil)
+		return node.Consensus.Weight(ctx, ts, cid.Undef)
 	}
-	pSt, err := node.ChainReader.GetTipSetState(ctx, parent)
+	pStateID, err := node.ChainReader.GetTipSetStateRoot(parent)
 	if err != nil {
 		return uint64(0), err
 	}
-	return node.Consensus.Weight(ctx, ts, pSt)
+	return node.Consensus.Weight(ctx, ts, pStateID)
 }
 
 // getAncestors is the default GetAncestors function for the mining worker.
@@ -1,10 +1,18 @@
 package node
 
 import (
+	"context"
+	"errors"
 	"testing"
 
-	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
 )
 
 func TestMakePrivateKey(t *testing.T) {
@@ -25,3 +33,59 @@ func TestMakePrivateKey(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, goodKey)
 }
+
+type testFaultReporterAPI struct {
+	defaultErr error
+
+	from     address.Address
+	block1   *types.Block
+	block2   *types.Block
+	gasLimit types.GasUnits
+}
+
+func (api *testFaultReporterAPI) WalletDefaultAddress() (address.Address, error) {
+	return address.TestAddress, api.defaultErr
+}
+
+func (api *testFaultReporterAPI) MinerPreviewReportConsensusFault(ctx context.Context, from address.Address, block1, block2 *types.Block) (types.GasUnits, error) {
+	return types.NewGasUnits(5500), nil
+}
+
+func (api *testFaultReporterAPI) MinerReportConsensusFault(ctx context.Context, from address.Address, block1, block2 *types.Block, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	api.from, api.block1, api.block2, api.gasLimit = from, block1, block2, gasLimit
+	return types.NewCidForTestGetter()(), nil
+}
+
+func TestConsensusFaultReporter(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	block := func(nonce uint64) *types.Block {
+		return &types.Block{Miner: address.TestAddress2, Height: types.Uint64(5), Nonce: types.Uint64(nonce), BlockSig: []byte{0x01}}
+	}
+
+	t.Run("detected faults are sent from the default address", func(t *testing.T) {
+		api := &testFaultReporterAPI{}
+		fd := consensus.NewFaultDetector(10)
+		fd.SetReporter(&consensusFaultReporter{api: api})
+		blk1, blk2 := block(1), block(2)
+
+		assert.Empty(t, fd.Observe(ctx, blk1))
+		assert.Nil(t, api.block1)
+
+		require.Len(t, fd.Observe(ctx, blk2), 1)
+		assert.Equal(t, address.TestAddress, api.from)
+		assert.Equal(t, blk1, api.block1)
+		assert.Equal(t, blk2, api.block2)
+		assert.Equal(t, types.NewGasUnits(5500), api.gasLimit)
+	})
+
+	t.Run("a fault is not sent without a default address", func(t *testing.T) {
+		api := &testFaultReporterAPI{defaultErr: errors.New("no default address")}
+		reporter := &consensusFaultReporter{api: api}
+
+		err := reporter.ReportConsensusFault(ctx, consensus.ConsensusFault{Block1: block(1), Block2: block(2)})
+		require.Error(t, err)
+		assert.Nil(t, api.block1)
+	})
+}
@@ -278,6 +278,8 @@ func GenNode(t *testing.T, tno *TestNodeOptions) *Node {
 	localCfgOpts, err := OptionsFromRepo(r)
 	require.NoError(t, err)
 
+	// test repos are initialized without a wallet passphrase
+	localCfgOpts = append(localCfgOpts, UnlockWallet(nil))
 	localCfgOpts = append(localCfgOpts, tno.ConfigOpts...)
 
 	// enables or disables libp2p
@@ -17,6 +17,7 @@ import (
 	ma "github.com/multiformats/go-multiaddr"
 
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/chain"
 	"github.com/filecoin-project/go-filecoin/consensus"
@@ -28,6 +29,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/plumbing/cst"
 	"github.com/filecoin-project/go-filecoin/plumbing/dag"
 	"github.com/filecoin-project/go-filecoin/plumbing/msg"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/plumbing/strgdls"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
@@ -55,6 +57,8 @@ type API struct {
 	msgWaiter     *msg.Waiter
 	network       *net.Network
 	outbox        *core.Outbox
+	paychs        *paych.Manager
+	powerTable    consensus.PowerTableView
 	sectorBuilder func() sectorbuilder.SectorBuilder
 	storagedeals  *strgdls.Store
 	wallet        *wallet.Wallet
@@ -74,6 +78,8 @@ type APIDeps struct {
 	MsgWaiter     *msg.Waiter
 	Network       *net.Network
 	Outbox        *core.Outbox
+	Paychs        *paych.Manager
+	PowerTable    consensus.PowerTableView
 	SectorBuilder func() sectorbuilder.SectorBuilder
 	Wallet        *wallet.Wallet
 }
@@ -94,6 +100,8 @@ func New(deps *APIDeps) *API {
 		msgWaiter:     deps.MsgWaiter,
 		network:       deps.Network,
 		outbox:        deps.Outbox,
+		paychs:        deps.Paychs,
+		powerTable:    deps.PowerTable,
 		sectorBuilder: deps.SectorBuilder,
 		storagedeals:  deps.Deals,
 		wallet:        deps.Wallet,
@@ -206,6 +214,12 @@ func (api *API) MessagePreview(ctx context.Context, from, to address.Address, me
 	return api.msgPreviewer.Preview(ctx, from, to, method, params...)
 }
 
+// MessagePreviewWithValue previews the Gas cost of a message carrying a value, which the
+// message transfers from the sender as it would when mined.
+func (api *API) MessagePreviewWithValue(ctx context.Context, from, to address.Address, value types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error) {
+	return api.msgPreviewer.PreviewWithValue(ctx, from, to, value, method, params...)
+}
+
 // MessageQuery calls an actor's method using the most recent chain state. It is read-only,
 // it does not change any state. It is use to interrogate actor state. The from address
 // is optional; if not provided, an address will be chosen from the node's wallet.
@@ -226,6 +240,13 @@ func (api *API) MessageFind(ctx context.Context, msgCid cid.Cid) (*msg.ChainMess
 	return api.msgWaiter.Find(ctx, msgCid)
 }
 
+// MessageReplay re-executes an on-chain message against the state it was
+// applied to and returns its receipt, with the trace of its execution if
+// trace is set.
+func (api *API) MessageReplay(ctx context.Context, msgCid cid.Cid, trace bool) (*msg.ReplayResult, error) {
+	return api.msgWaiter.Replay(ctx, msgCid, trace)
+}
+
 // MessageWait invokes the callback when a message with the given cid appears on chain.
 // It will find the message in both the case that it is already on chain and
 // the case that it appears in a newly mined block. An error is returned if one is
@@ -235,6 +256,12 @@ func (api *API) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.
 	return api.msgWaiter.Wait(ctx, msgCid, cb)
 }
 
+// MinerGetPowerTable returns the power of every miner at a tipset. The empty
+// key selects the head tipset.
+func (api *API) MinerGetPowerTable(ctx context.Context, tsKey types.TipSetKey) (map[address.Address]*types.BytesAmount, error) {
+	return api.msgQueryer.PowerTable(ctx, api.powerTable, tsKey)
+}
+
 // PubSubSubscribe subscribes to a topic for notifications from the filecoin network
 func (api *API) PubSubSubscribe(topic string) (pubsub.Subscription, error) {
 	return api.network.Subscribe(topic)
@@ -290,6 +317,33 @@ func (api *API) NetworkPeers(ctx context.Context, verbose, latency, streams bool
 	return api.network.Peers(ctx, verbose, latency, streams)
 }
 
+// PaymentChannelSync records the on-chain state of a payment channel in the
+// local payment channel store, tracking the channel if it is not yet tracked.
+func (api *API) PaymentChannelSync(payer address.Address, channel *types.ChannelID, pc *paymentbroker.PaymentChannel) error {
+	return api.paychs.Sync(payer, channel, pc)
+}
+
+// PaymentChannelAddIssuedVoucher records a voucher issued for a tracked payment
+// channel. It fails if the vouchers issued across lanes exceed the channel balance.
+func (api *API) PaymentChannelAddIssuedVoucher(voucher *types.PaymentVoucher) error {
+	return api.paychs.AddIssuedVoucher(voucher)
+}
+
+// PaymentChannelAllocateLane returns an unused lane of a tracked payment channel.
+func (api *API) PaymentChannelAllocateLane(payer address.Address, channel *types.ChannelID) (uint64, error) {
+	return api.paychs.AllocateLane(payer, channel)
+}
+
+// PaymentChannelAddReceivedVoucher records a voucher received for a payment channel.
+func (api *API) PaymentChannelAddReceivedVoucher(voucher *types.PaymentVoucher) error {
+	return api.paychs.AddReceivedVoucher(voucher)
+}
+
+// PaymentChannelInfo returns the locally tracked state of a payment channel.
+func (api *API) PaymentChannelInfo(payer address.Address, channel *types.ChannelID) (*paych.ChannelInfo, error) {
+	return api.paychs.Get(payer, channel)
+}
+
 // SignBytes uses private key information associated with the given address to sign the given bytes.
 func (api *API) SignBytes(data []byte, addr address.Address) (types.Signature, error) {
 	return api.wallet.SignBytes(data, addr)
@@ -310,9 +364,30 @@ func (api *API) WalletGetPubKeyForAddress(addr address.Address) ([]byte, error)
 	return api.wallet.GetPubKeyForAddress(addr)
 }
 
-// WalletNewAddress generates a new wallet address
-func (api *API) WalletNewAddress() (address.Address, error) {
-	return wallet.NewAddress(api.wallet)
+// WalletNewAddress generates a new wallet address using the given protocol
+func (api *API) WalletNewAddress(protocol address.Protocol) (address.Address, error) {
+	return wallet.NewAddress(api.wallet, protocol)
+}
+
+// WalletDeriveAddress derives the next wallet address from the wallet recovery phrase
+func (api *API) WalletDeriveAddress() (address.Address, error) {
+	return wallet.DeriveAddress(api.wallet)
+}
+
+// WalletMnemonic returns the recovery phrase the wallet addresses are derived from
+func (api *API) WalletMnemonic() (string, error) {
+	return wallet.Mnemonic(api.wallet)
+}
+
+// WalletUnlock unlocks the wallet with the passphrase for timeout, or until it
+// is locked if timeout is zero
+func (api *API) WalletUnlock(passphrase []byte, timeout time.Duration) error {
+	return api.wallet.Unlock(passphrase, timeout)
+}
+
+// WalletLock locks the wallet
+func (api *API) WalletLock() {
+	api.wallet.Lock()
 }
 
 // WalletImport adds a given set of KeyInfos to the wallet
@@ -39,6 +39,11 @@ func NewPreviewer(chainReader previewerChainReader, cst *hamt.CborIpldStore, bs
 
 // Preview sends a read-only message to an actor.
 func (p *Previewer) Preview(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) (types.GasUnits, error) {
+	return p.PreviewWithValue(ctx, optFrom, to, types.ZeroAttoFIL, method, params...)
+}
+
+// PreviewWithValue sends a read-only message carrying a value to an actor.
+func (p *Previewer) PreviewWithValue(ctx context.Context, optFrom, to address.Address, value types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error) {
 	encodedParams, err := abi.ToEncodedValues(params...)
 	if err != nil {
 		return types.NewGasUnits(0), errors.Wrap(err, "failed to encode message params")
@@ -58,7 +63,7 @@ func (p *Previewer) Preview(ctx context.Context, optFrom, to address.Address, me
 	}
 
 	vms := vm.NewStorageMap(p.bs)
-	usedGas, err := consensus.PreviewQueryMethod(ctx, st, vms, to, method, encodedParams, optFrom, types.NewBlockHeight(h))
+	usedGas, err := consensus.PreviewQueryMethod(ctx, st, vms, to, method, encodedParams, optFrom, value, types.NewBlockHeight(h))
 	if err != nil {
 		return types.NewGasUnits(0), errors.Wrap(err, "query method returned an error")
 	}
@@ -3,6 +3,7 @@ package msg
 import (
 	"context"
 
+	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-hamt-ipld"
 	bstore "github.com/ipfs/go-ipfs-blockstore"
 	"github.com/pkg/errors"
@@ -19,6 +20,7 @@ import (
 type queryerChainReader interface {
 	GetHead() types.TipSetKey
 	GetTipSetState(context.Context, types.TipSetKey) (state.Tree, error)
+	GetTipSetStateRoot(types.TipSetKey) (cid.Cid, error)
 	GetTipSet(types.TipSetKey) (types.TipSet, error)
 }
 
@@ -66,3 +68,20 @@ func (q *Queryer) Query(ctx context.Context, optFrom, to address.Address, method
 	}
 	return r, nil
 }
+
+// PowerTable returns the power of every miner at a tipset, as seen by a power
+// table view. The empty key selects the head tipset.
+func (q *Queryer) PowerTable(ctx context.Context, ptv consensus.PowerTableView, tsKey types.TipSetKey) (map[address.Address]*types.BytesAmount, error) {
+	if tsKey.Empty() {
+		tsKey = q.chainReader.GetHead()
+	}
+	stateID, err := q.chainReader.GetTipSetStateRoot(tsKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get state root of tipset %s", tsKey)
+	}
+	st, err := q.chainReader.GetTipSetState(ctx, tsKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load state of tipset %s", tsKey)
+	}
+	return consensus.PowerTable(ctx, ptv, st, stateID, q.bs)
+}
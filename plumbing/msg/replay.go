@@ -0,0 +1,127 @@
+package msg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/sampling"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// ReplayResult is the outcome of replaying an on-chain message.
+type ReplayResult struct {
+	Message        *types.SignedMessage
+	Receipt        *types.MessageReceipt
+	ExecutionError string    `json:",omitempty"`
+	Trace          *vm.Trace `json:",omitempty"`
+}
+
+// Replay re-applies the messages of the tipset containing a message to the
+// state of its parent, and returns the receipt of the message. With trace set,
+// the result also records the internal sends of the message.
+func (w *Waiter) Replay(ctx context.Context, msgCid cid.Cid, trace bool) (*ReplayResult, error) {
+	ts, smsg, err := w.findTipSet(ctx, msgCid)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := ts.Parents()
+	if err != nil {
+		return nil, err
+	}
+	st, err := w.chainReader.GetTipSetState(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load parent state")
+	}
+
+	tsHeight, err := ts.Height()
+	if err != nil {
+		return nil, err
+	}
+	tsBlockHeight := types.NewBlockHeight(tsHeight)
+	ancestorHeight := types.NewBlockHeight(consensus.AncestorRoundsNeeded)
+	parentTs, err := w.chainReader.GetTipSet(ids)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := chain.GetRecentAncestors(ctx, parentTs, w.chainReader, tsBlockHeight, ancestorHeight, sampling.LookbackParameter)
+	if err != nil {
+		return nil, err
+	}
+
+	var tsMessages [][]*types.SignedMessage
+	for i := 0; i < ts.Len(); i++ {
+		msgs, err := w.messageProvider.LoadMessages(ctx, ts.At(i).Messages)
+		if err != nil {
+			return nil, err
+		}
+		tsMessages = append(tsMessages, msgs)
+	}
+
+	processor := consensus.NewDefaultProcessor()
+	if trace {
+		processor = consensus.NewTracingProcessor()
+	}
+	res, err := processor.ProcessTipSet(ctx, st, vm.NewStorageMap(w.bs), ts, tsMessages, ancestors)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, failed := res.Failures[msgCid]; failed {
+		return nil, fmt.Errorf("message %s was not applied", msgCid)
+	}
+	j, err := w.msgIndexOfTipSet(ctx, msgCid, ts, res.Failures)
+	if err != nil {
+		return nil, err
+	}
+	if j >= len(res.Results) {
+		return nil, fmt.Errorf("no result for message %s", msgCid)
+	}
+
+	result := &ReplayResult{
+		Message: smsg,
+		Receipt: res.Results[j].Receipt,
+		Trace:   res.Results[j].Trace,
+	}
+	if res.Results[j].ExecutionError != nil {
+		result.ExecutionError = res.Results[j].ExecutionError.Error()
+	}
+	return result, nil
+}
+
+// findTipSet returns the tipset of the chain containing a message, and the
+// message.
+func (w *Waiter) findTipSet(ctx context.Context, msgCid cid.Cid) (types.TipSet, *types.SignedMessage, error) {
+	head, err := w.chainReader.GetTipSet(w.chainReader.GetHead())
+	if err != nil {
+		return types.UndefTipSet, nil, err
+	}
+
+	for iterator := chain.IterAncestors(ctx, w.chainReader, head); !iterator.Complete(); err = iterator.Next() {
+		if err != nil {
+			return types.UndefTipSet, nil, err
+		}
+		for i := 0; i < iterator.Value().Len(); i++ {
+			msgs, err := w.messageProvider.LoadMessages(ctx, iterator.Value().At(i).Messages)
+			if err != nil {
+				return types.UndefTipSet, nil, err
+			}
+			for _, msg := range msgs {
+				c, err := msg.Cid()
+				if err != nil {
+					return types.UndefTipSet, nil, err
+				}
+				if c.Equals(msgCid) {
+					return iterator.Value(), msg, nil
+				}
+			}
+		}
+	}
+	return types.UndefTipSet, nil, fmt.Errorf("message %s not found on chain", msgCid)
+}
@@ -0,0 +1,113 @@
+package msg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/chain"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/core"
+	th "github.com/filecoin-project/go-filecoin/testhelpers"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestReplay(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	sender, minerOwner, minerWorker := mockSigner.Addresses[0], mockSigner.Addresses[1], mockSigner.Addresses[1]
+	minerAddr := mockSigner.Addresses[2]
+
+	testGen := consensus.MakeGenesisFunc(
+		consensus.ActorAccount(sender, types.NewAttoFILFromFIL(10000)),
+		consensus.ActorAccount(minerOwner, types.ZeroAttoFIL),
+		consensus.MinerActor(minerAddr, minerOwner, th.RequireRandomPeerID(t), types.ZeroAttoFIL, types.OneKiBSectorSize),
+	)
+	cst, chainStore, msgStore, waiter := setupTestWithGif(t, testGen)
+
+	// createStorageMiner sends its value on to the miner it creates
+	collateral := types.NewAttoFILFromFIL(100)
+	params := actor.MustConvertParams(types.OneKiBSectorSize, th.RequireRandomPeerID(t))
+	msg := types.NewMessage(sender, address.StorageMarketAddress, 0, collateral, "createStorageMiner", params)
+	smsg, err := types.NewSignedMessage(*msg, &mockSigner, types.NewGasPrice(1), types.NewGasUnits(10000))
+	require.NoError(t, err)
+	msgCid, err := smsg.Cid()
+	require.NoError(t, err)
+
+	// mine the message in a block on top of the genesis block
+	headTipSet, err := chainStore.GetTipSet(chainStore.GetHead())
+	require.NoError(t, err)
+	baseBlock := headTipSet.At(0)
+
+	blk := th.RequireMkFakeChild(t,
+		th.FakeChildParams{
+			MinerAddr:   minerAddr,
+			Parent:      headTipSet,
+			GenesisCid:  chainStore.GenesisCid(),
+			StateRoot:   baseBlock.StateRoot,
+			Signer:      mockSigner,
+			MinerWorker: minerWorker,
+		})
+	blk.Messages, err = msgStore.StoreMessages(ctx, []*types.SignedMessage{smsg})
+	require.NoError(t, err)
+	blk.MessageReceipts, err = msgStore.StoreReceipts(ctx, []*types.MessageReceipt{})
+	require.NoError(t, err)
+	core.MustPut(cst, blk)
+
+	ts := th.RequireNewTipSet(t, blk)
+	require.NoError(t, chainStore.PutTipSetAndState(ctx, &chain.TipSetAndState{
+		TipSet:          ts,
+		TipSetStateRoot: baseBlock.StateRoot,
+	}))
+	require.NoError(t, chainStore.SetHead(ctx, ts))
+
+	t.Run("replays the message and traces the messages it sent", func(t *testing.T) {
+		res, err := waiter.Replay(ctx, msgCid, true)
+		require.NoError(t, err)
+
+		assert.True(t, types.SmsgCidsEqual(smsg, res.Message))
+		assert.Equal(t, uint8(0), res.Receipt.ExitCode)
+		assert.Empty(t, res.ExecutionError)
+		require.Len(t, res.Receipt.Return, 1)
+		newMiner, err := address.NewFromBytes(res.Receipt.Return[0])
+		require.NoError(t, err)
+
+		require.NotNil(t, res.Trace)
+		assert.Equal(t, sender, res.Trace.From)
+		assert.Equal(t, address.StorageMarketAddress, res.Trace.To)
+		assert.Equal(t, collateral, res.Trace.Value)
+		assert.Equal(t, "createStorageMiner", res.Trace.Method)
+		assert.Equal(t, uint8(0), res.Trace.ExitCode)
+		assert.NotZero(t, res.Trace.GasCharged)
+
+		require.Len(t, res.Trace.Subcalls, 1)
+		sub := res.Trace.Subcalls[0]
+		assert.Equal(t, address.StorageMarketAddress, sub.From)
+		assert.Equal(t, newMiner, sub.To)
+		assert.Equal(t, collateral, sub.Value)
+		assert.Empty(t, sub.Subcalls)
+	})
+
+	t.Run("replays the same receipt without a trace", func(t *testing.T) {
+		traced, err := waiter.Replay(ctx, msgCid, true)
+		require.NoError(t, err)
+
+		res, err := waiter.Replay(ctx, msgCid, false)
+		require.NoError(t, err)
+		assert.Equal(t, traced.Receipt, res.Receipt)
+		assert.Nil(t, res.Trace)
+	})
+
+	t.Run("a message not on chain is an error", func(t *testing.T) {
+		_, err := waiter.Replay(ctx, types.SomeCid(), true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found on chain")
+	})
+}
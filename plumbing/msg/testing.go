@@ -41,6 +41,7 @@ func requireCommonDepsWithGifAndBlockstore(t *testing.T, gif consensus.GenesisIn
 	messageStore := chain.NewMessageStore(cst)
 	backend, err := wallet.NewDSBackend(r.WalletDatastore())
 	require.NoError(t, err)
+	require.NoError(t, backend.Unlock(nil, 0))
 	wallet := wallet.New(backend)
 
 	return &commonDeps{
@@ -0,0 +1,255 @@
+package paych
+
+import (
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func init() {
+	cbor.RegisterCborType(ChannelInfo{})
+}
+
+// PaymentChannelPrefix is the datastore prefix for tracked payment channels
+const PaymentChannelPrefix = "paymentchannels"
+
+// ErrChannelNotFound is returned when a payment channel is not tracked by the manager
+var ErrChannelNotFound = errors.New("payment channel not found")
+
+// ErrInsufficientFunds is returned when a voucher would exceed the funds of its channel
+var ErrInsufficientFunds = errors.New("voucher amount exceeds channel balance")
+
+// ChannelInfo is the locally tracked state of a payment channel, combining
+// the last known on-chain state with the vouchers issued or received for it.
+type ChannelInfo struct {
+	// Payer is the address of the account that created the channel.
+	Payer address.Address `json:"payer"`
+
+	// Channel is the id of the channel, unique per payer.
+	Channel *types.ChannelID `json:"channel"`
+
+	// Target is the address of the account that redeems the channel funds.
+	Target address.Address `json:"target"`
+
+	// Amount is the total amount of FIL held by the channel.
+	Amount types.AttoFIL `json:"amount"`
+
+	// AmountRedeemed is the amount of FIL already redeemed by the target.
+	AmountRedeemed types.AttoFIL `json:"amount_redeemed"`
+
+	// Eol is the block height at which the channel expires.
+	Eol *types.BlockHeight `json:"eol"`
+
+	// Issued are the vouchers created for this channel by its payer.
+	Issued []*types.PaymentVoucher `json:"issued"`
+
+	// Received are the vouchers received for this channel by its target.
+	Received []*types.PaymentVoucher `json:"received"`
+
+	// NextLane is the lowest lane not yet allocated by the payer, nor issued
+	// a voucher on.
+	NextLane uint64 `json:"next_lane"`
+}
+
+// IssuedAmount returns the funds of the channel committed by issued vouchers.
+func (ci *ChannelInfo) IssuedAmount() types.AttoFIL {
+	return committedAmount(ci.Issued)
+}
+
+// ReceivedAmount returns the funds of the channel committed by received vouchers.
+func (ci *ChannelInfo) ReceivedAmount() types.AttoFIL {
+	return committedAmount(ci.Received)
+}
+
+// HasReceivedOnLane returns whether a voucher has been received on the given lane.
+func (ci *ChannelInfo) HasReceivedOnLane(lane uint64) bool {
+	for _, v := range ci.Received {
+		if v.Lane == lane {
+			return true
+		}
+	}
+	return false
+}
+
+// Manager tracks the payment channels this node is the payer or target of.
+type Manager struct {
+	ds repo.Datastore
+
+	// lk serializes read-modify-write updates of channel records.
+	lk sync.Mutex
+}
+
+// New returns a new Manager storing channels in the given datastore.
+func New(ds repo.Datastore) *Manager {
+	return &Manager{ds: ds}
+}
+
+// Sync records the given on-chain state of a channel, starting to track the
+// channel if it is not already tracked.
+func (m *Manager) Sync(payer address.Address, channel *types.ChannelID, pc *paymentbroker.PaymentChannel) error {
+	return m.update(payer, channel, true, func(info *ChannelInfo) error {
+		info.Target = pc.Target
+		info.Amount = pc.Amount
+		info.AmountRedeemed = pc.AmountRedeemed
+		info.Eol = pc.Eol
+		return nil
+	})
+}
+
+// AddIssuedVoucher records a voucher created for a tracked channel. It fails
+// with ErrInsufficientFunds if the vouchers issued across all lanes would
+// commit more than the channel balance. The voucher's lane is reserved, so
+// that it is never allocated to another series of payments.
+func (m *Manager) AddIssuedVoucher(voucher *types.PaymentVoucher) error {
+	return m.update(voucher.Payer, &voucher.Channel, false, func(info *ChannelInfo) error {
+		issued := append(info.Issued, voucher)
+		if committed := committedAmount(issued); committed.GreaterThan(info.Amount) {
+			return errors.Wrapf(ErrInsufficientFunds, "vouchers commit %s but channel holds %s", committed, info.Amount)
+		}
+		info.Issued = issued
+		if voucher.Lane >= info.NextLane {
+			info.NextLane = voucher.Lane + 1
+		}
+		return nil
+	})
+}
+
+// AllocateLane returns a lane of a tracked channel no voucher has been
+// issued on yet, so that a new series of payments can share the channel.
+func (m *Manager) AllocateLane(payer address.Address, channel *types.ChannelID) (uint64, error) {
+	var lane uint64
+	err := m.update(payer, channel, false, func(info *ChannelInfo) error {
+		lane = info.NextLane
+		info.NextLane++
+		return nil
+	})
+	return lane, err
+}
+
+// AddReceivedVoucher records a voucher received for a channel, starting to
+// track the channel if it is not already tracked.
+func (m *Manager) AddReceivedVoucher(voucher *types.PaymentVoucher) error {
+	return m.update(voucher.Payer, &voucher.Channel, true, func(info *ChannelInfo) error {
+		if info.Target.Empty() {
+			info.Target = voucher.Target
+		}
+		info.Received = append(info.Received, voucher)
+		return nil
+	})
+}
+
+// Get returns the tracked state of a channel.
+func (m *Manager) Get(payer address.Address, channel *types.ChannelID) (*ChannelInfo, error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	return m.get(payer, channel)
+}
+
+// Ls returns all tracked channels.
+func (m *Manager) Ls() ([]*ChannelInfo, error) {
+	results, err := m.ds.Query(query.Query{Prefix: "/" + PaymentChannelPrefix})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query payment channels from datastore")
+	}
+	defer results.Close() // nolint: errcheck
+
+	var channels []*ChannelInfo
+	for entry := range results.Next() {
+		if entry.Error != nil {
+			return nil, entry.Error
+		}
+		var info ChannelInfo
+		if err := cbor.DecodeInto(entry.Value, &info); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal payment channel")
+		}
+		channels = append(channels, &info)
+	}
+	return channels, nil
+}
+
+func (m *Manager) update(payer address.Address, channel *types.ChannelID, create bool, fn func(info *ChannelInfo) error) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	info, err := m.get(payer, channel)
+	if err == ErrChannelNotFound && create {
+		info = &ChannelInfo{
+			Payer:          payer,
+			Channel:        channel,
+			Amount:         types.ZeroAttoFIL,
+			AmountRedeemed: types.ZeroAttoFIL,
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if err := fn(info); err != nil {
+		return err
+	}
+
+	datum, err := cbor.DumpObject(info)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal payment channel")
+	}
+	if err := m.ds.Put(channelKey(payer, channel), datum); err != nil {
+		return errors.Wrap(err, "could not save payment channel to disk")
+	}
+	return nil
+}
+
+func (m *Manager) get(payer address.Address, channel *types.ChannelID) (*ChannelInfo, error) {
+	datum, err := m.ds.Get(channelKey(payer, channel))
+	if err == datastore.ErrNotFound {
+		return nil, ErrChannelNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get payment channel from datastore")
+	}
+
+	var info ChannelInfo
+	if err := cbor.DecodeInto(datum, &info); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal payment channel")
+	}
+	return &info, nil
+}
+
+// committedAmount returns the sum over lanes of the amount of the latest
+// voucher of each lane. Lanes merged by another lane's latest voucher are
+// left out, their funds being included in that voucher's amount.
+func committedAmount(vouchers []*types.PaymentVoucher) types.AttoFIL {
+	latest := make(map[uint64]*types.PaymentVoucher)
+	for _, v := range vouchers {
+		current, ok := latest[v.Lane]
+		if !ok || v.Nonce > current.Nonce || (v.Nonce == current.Nonce && v.Amount.GreaterThan(current.Amount)) {
+			latest[v.Lane] = v
+		}
+	}
+
+	merged := make(map[uint64]bool)
+	for _, v := range latest {
+		for _, merge := range v.Merges {
+			merged[merge.Lane] = true
+		}
+	}
+
+	total := types.ZeroAttoFIL
+	for lane, v := range latest {
+		if !merged[lane] {
+			total = total.Add(v.Amount)
+		}
+	}
+	return total
+}
+
+func channelKey(payer address.Address, channel *types.ChannelID) datastore.Key {
+	return datastore.KeyWithNamespaces([]string{PaymentChannelPrefix, payer.String(), channel.String()})
+}
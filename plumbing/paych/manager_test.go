@@ -0,0 +1,167 @@
+package paych_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
+	"github.com/filecoin-project/go-filecoin/repo"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestManagerTracksIssuedVouchers(t *testing.T) {
+	tf.UnitTest(t)
+
+	addrGetter := address.NewForTestGetter()
+	payer := addrGetter()
+	target := addrGetter()
+	channel := types.NewChannelID(7)
+
+	manager := paych.New(repo.NewInMemoryRepo().Datastore())
+
+	voucher := &types.PaymentVoucher{
+		Channel: *channel,
+		Payer:   payer,
+		Target:  target,
+		Amount:  types.NewAttoFILFromFIL(10),
+		ValidAt: *types.NewBlockHeight(20),
+	}
+
+	t.Run("refuses vouchers for untracked channels", func(t *testing.T) {
+		err := manager.AddIssuedVoucher(voucher)
+		assert.Equal(t, paych.ErrChannelNotFound, err)
+	})
+
+	require.NoError(t, manager.Sync(payer, channel, &paymentbroker.PaymentChannel{
+		Target:         target,
+		Amount:         types.NewAttoFILFromFIL(10),
+		AmountRedeemed: types.NewAttoFILFromFIL(2),
+		Eol:            types.NewBlockHeight(100),
+	}))
+
+	require.NoError(t, manager.AddIssuedVoucher(voucher))
+
+	t.Run("refuses vouchers above the channel balance", func(t *testing.T) {
+		tooMuch := *voucher
+		tooMuch.Amount = types.NewAttoFILFromFIL(11)
+		err := manager.AddIssuedVoucher(&tooMuch)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), paych.ErrInsufficientFunds.Error())
+	})
+
+	info, err := manager.Get(payer, channel)
+	require.NoError(t, err)
+	assert.Equal(t, target, info.Target)
+	assert.Equal(t, types.NewAttoFILFromFIL(10), info.Amount)
+	assert.Equal(t, types.NewAttoFILFromFIL(2), info.AmountRedeemed)
+	assert.Equal(t, types.NewBlockHeight(100), info.Eol)
+	require.Len(t, info.Issued, 1)
+	assert.Equal(t, voucher.Amount, info.Issued[0].Amount)
+	assert.Len(t, info.Received, 0)
+}
+
+func TestManagerTracksReceivedVouchers(t *testing.T) {
+	tf.UnitTest(t)
+
+	addrGetter := address.NewForTestGetter()
+	payer := addrGetter()
+	target := addrGetter()
+
+	manager := paych.New(repo.NewInMemoryRepo().Datastore())
+
+	for i := uint64(1); i <= 2; i++ {
+		require.NoError(t, manager.AddReceivedVoucher(&types.PaymentVoucher{
+			Channel: *types.NewChannelID(i),
+			Payer:   payer,
+			Target:  target,
+			Amount:  types.NewAttoFILFromFIL(i),
+			ValidAt: *types.NewBlockHeight(20),
+		}))
+	}
+
+	info, err := manager.Get(payer, types.NewChannelID(2))
+	require.NoError(t, err)
+	assert.Equal(t, target, info.Target)
+	require.Len(t, info.Received, 1)
+	assert.Equal(t, types.NewAttoFILFromFIL(2), info.Received[0].Amount)
+
+	_, err = manager.Get(target, types.NewChannelID(2))
+	assert.Equal(t, paych.ErrChannelNotFound, err)
+
+	channels, err := manager.Ls()
+	require.NoError(t, err)
+	assert.Len(t, channels, 2)
+}
+
+func TestManagerTracksLanes(t *testing.T) {
+	tf.UnitTest(t)
+
+	addrGetter := address.NewForTestGetter()
+	payer := addrGetter()
+	target := addrGetter()
+	channel := types.NewChannelID(3)
+
+	manager := paych.New(repo.NewInMemoryRepo().Datastore())
+
+	require.NoError(t, manager.Sync(payer, channel, &paymentbroker.PaymentChannel{
+		Target:         target,
+		Amount:         types.NewAttoFILFromFIL(10),
+		AmountRedeemed: types.ZeroAttoFIL,
+		Eol:            types.NewBlockHeight(100),
+	}))
+
+	newVoucher := func(lane, nonce, amount uint64, merges ...types.VoucherMerge) *types.PaymentVoucher {
+		return &types.PaymentVoucher{
+			Channel: *channel,
+			Payer:   payer,
+			Target:  target,
+			Amount:  types.NewAttoFILFromFIL(amount),
+			Lane:    lane,
+			Nonce:   nonce,
+			Merges:  merges,
+		}
+	}
+
+	for i := uint64(0); i < 2; i++ {
+		lane, err := manager.AllocateLane(payer, channel)
+		require.NoError(t, err)
+		assert.Equal(t, i, lane)
+	}
+
+	require.NoError(t, manager.AddIssuedVoucher(newVoucher(0, 0, 2)))
+	require.NoError(t, manager.AddIssuedVoucher(newVoucher(0, 1, 4)))
+	require.NoError(t, manager.AddIssuedVoucher(newVoucher(1, 0, 5)))
+
+	t.Run("refuses vouchers above the channel balance across lanes", func(t *testing.T) {
+		err := manager.AddIssuedVoucher(newVoucher(1, 1, 7))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), paych.ErrInsufficientFunds.Error())
+	})
+
+	t.Run("counts merged lanes once", func(t *testing.T) {
+		require.NoError(t, manager.AddIssuedVoucher(newVoucher(1, 1, 10, types.VoucherMerge{Lane: 0, Nonce: 1})))
+
+		info, err := manager.Get(payer, channel)
+		require.NoError(t, err)
+		assert.Equal(t, types.NewAttoFILFromFIL(10), info.IssuedAmount())
+		assert.Equal(t, uint64(2), info.NextLane)
+	})
+
+	t.Run("reserves lanes vouchers are issued on", func(t *testing.T) {
+		require.NoError(t, manager.AddIssuedVoucher(newVoucher(5, 0, 0)))
+
+		lane, err := manager.AllocateLane(payer, channel)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(6), lane)
+	})
+
+	t.Run("refuses lanes of untracked channels", func(t *testing.T) {
+		_, err := manager.AllocateLane(target, channel)
+		assert.Equal(t, paych.ErrChannelNotFound, err)
+	})
+}
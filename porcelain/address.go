@@ -0,0 +1,54 @@
+package porcelain
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/state"
+)
+
+type alPlumbing interface {
+	ActorGet(ctx context.Context, addr address.Address) (*actor.Actor, error)
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+}
+
+// AddressLookup resolves an address in both directions through the init
+// actor: given either the address an actor was created at or the ID address
+// assigned to it, it returns both. The ID address is empty for actors of
+// states predating the init actor.
+func AddressLookup(ctx context.Context, plumbing alPlumbing, addr address.Address) (address.Address, address.Address, error) {
+	isID := addr.Protocol() == address.ID
+	if isID && !initactor.IsAssignable(addr) {
+		// singleton actors are created at their ID address
+		return addr, addr, nil
+	}
+
+	if _, err := plumbing.ActorGet(ctx, address.InitAddress); err != nil {
+		if state.IsActorNotFoundError(err) && !isID {
+			return addr, address.Undef, nil
+		}
+		return address.Undef, address.Undef, errors.Wrap(err, "failed to get init actor")
+	}
+
+	method := "getIDAddress"
+	if isID {
+		method = "getRobustAddress"
+	}
+	res, err := plumbing.MessageQuery(ctx, address.Undef, address.InitAddress, method, addr)
+	if err != nil {
+		return address.Undef, address.Undef, err
+	}
+	resolved, err := address.NewFromBytes(res[0])
+	if err != nil {
+		return address.Undef, address.Undef, err
+	}
+
+	if isID {
+		return resolved, addr, nil
+	}
+	return addr, resolved, nil
+}
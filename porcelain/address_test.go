@@ -0,0 +1,95 @@
+package porcelain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+type alTestPlumbing struct {
+	noInitActor bool
+	ids         map[address.Address]address.Address
+}
+
+type actorNotFoundError struct{}
+
+func (e actorNotFoundError) Error() string {
+	return "actor not found"
+}
+
+func (e actorNotFoundError) ActorNotFound() bool {
+	return true
+}
+
+func (altp *alTestPlumbing) ActorGet(ctx context.Context, addr address.Address) (*actor.Actor, error) {
+	if altp.noInitActor {
+		return nil, actorNotFoundError{}
+	}
+	return initactor.NewActor(), nil
+}
+
+func (altp *alTestPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	addr := params[0].(address.Address)
+	for robust, id := range altp.ids {
+		if method == "getIDAddress" && addr == robust {
+			return [][]byte{id.Bytes()}, nil
+		}
+		if method == "getRobustAddress" && addr == id {
+			return [][]byte{robust.Bytes()}, nil
+		}
+	}
+	return nil, errors.New("address was not assigned an ID")
+}
+
+func TestAddressLookup(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addrGetter := address.NewForTestGetter()
+	robust := addrGetter()
+	id, err := address.NewIDAddress(initactor.FirstActorID)
+	require.NoError(t, err)
+	plumbing := &alTestPlumbing{ids: map[address.Address]address.Address{robust: id}}
+
+	t.Run("resolves in both directions", func(t *testing.T) {
+		for _, addr := range []address.Address{robust, id} {
+			gotRobust, gotID, err := porcelain.AddressLookup(ctx, plumbing, addr)
+			require.NoError(t, err)
+			assert.Equal(t, robust, gotRobust)
+			assert.Equal(t, id, gotID)
+		}
+	})
+
+	t.Run("singleton addresses resolve to themselves", func(t *testing.T) {
+		gotRobust, gotID, err := porcelain.AddressLookup(ctx, plumbing, address.StorageMarketAddress)
+		require.NoError(t, err)
+		assert.Equal(t, address.StorageMarketAddress, gotRobust)
+		assert.Equal(t, address.StorageMarketAddress, gotID)
+	})
+
+	t.Run("unassigned addresses fail", func(t *testing.T) {
+		_, _, err := porcelain.AddressLookup(ctx, plumbing, addrGetter())
+		assert.Error(t, err)
+	})
+
+	t.Run("addresses have no ID without an init actor", func(t *testing.T) {
+		plumbing := &alTestPlumbing{noInitActor: true}
+
+		gotRobust, gotID, err := porcelain.AddressLookup(ctx, plumbing, robust)
+		require.NoError(t, err)
+		assert.Equal(t, robust, gotRobust)
+		assert.True(t, gotID.Empty())
+
+		_, _, err = porcelain.AddressLookup(ctx, plumbing, id)
+		assert.Error(t, err)
+	})
+}
@@ -7,11 +7,14 @@ import (
 
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 
 	minerActor "github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/plumbing"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/proofs"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -106,6 +109,11 @@ func (a *API) MinerPreviewCreate(
 	return MinerPreviewCreate(ctx, a, fromAddr, sectorSize, pid)
 }
 
+// MinerGetAsks queries for all asks of the given miner
+func (a *API) MinerGetAsks(ctx context.Context, minerAddr address.Address) ([]minerActor.Ask, error) {
+	return MinerGetAsks(ctx, a, minerAddr)
+}
+
 // MinerGetAsk queries for an ask of the given miner
 func (a *API) MinerGetAsk(ctx context.Context, minerAddr address.Address, askID uint64) (minerActor.Ask, error) {
 	return MinerGetAsk(ctx, a, minerAddr, askID)
@@ -116,6 +124,16 @@ func (a *API) MinerGetOwnerAddress(ctx context.Context, minerAddr address.Addres
 	return MinerGetOwnerAddress(ctx, a, minerAddr)
 }
 
+// MinerGetPendingOwner queries for the new owner proposed for the given miner
+func (a *API) MinerGetPendingOwner(ctx context.Context, minerAddr address.Address) (address.Address, error) {
+	return MinerGetPendingOwner(ctx, a, minerAddr)
+}
+
+// MinerChangeOwner proposes or accepts a new owner of a miner and waits for the message to be mined
+func (a *API) MinerChangeOwner(ctx context.Context, from, minerAddr, owner address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return MinerChangeOwner(ctx, a, from, minerAddr, owner, gasPrice, gasLimit)
+}
+
 // MinerGetSectorSize queries for the sector size of the given miner.
 func (a *API) MinerGetSectorSize(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error) {
 	return MinerGetSectorSize(ctx, a, minerAddr)
@@ -141,6 +159,22 @@ func (a *API) MinerGetPeerID(ctx context.Context, minerAddr address.Address) (pe
 	return MinerGetPeerID(ctx, a, minerAddr)
 }
 
+// MinerGetMultiaddrs queries for the multiaddrs the given miner advertises
+func (a *API) MinerGetMultiaddrs(ctx context.Context, minerAddr address.Address) ([]ma.Multiaddr, error) {
+	return MinerGetMultiaddrs(ctx, a, minerAddr)
+}
+
+// MinerUpdateMultiaddrs replaces the multiaddrs a miner advertises and waits for the message to be mined
+func (a *API) MinerUpdateMultiaddrs(ctx context.Context, from, minerAddr address.Address, addrs []ma.Multiaddr, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return MinerUpdateMultiaddrs(ctx, a, from, minerAddr, addrs, gasPrice, gasLimit)
+}
+
+// MinerConnect makes sure the peer of a miner can be reached, dialing the
+// multiaddrs the miner advertises if the peer cannot be found
+func (a *API) MinerConnect(ctx context.Context, minerAddr address.Address, minerPID peer.ID) error {
+	return MinerConnect(ctx, a, minerAddr, minerPID)
+}
+
 // MinerSetPrice configures the price of storage. See implementation for details.
 func (a *API) MinerSetPrice(ctx context.Context, from address.Address, miner address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits, price types.AttoFIL, expiry *big.Int) (MinerSetPriceResponse, error) {
 	return MinerSetPrice(ctx, a, from, miner, gasPrice, gasLimit, price, expiry)
@@ -161,6 +195,46 @@ func (a *API) MinerGetCollateral(ctx context.Context, minerAddr address.Address)
 	return MinerGetCollateral(ctx, a, minerAddr)
 }
 
+// MinerGetCollateralStatus queries for the required and available collateral of the given miner
+func (a *API) MinerGetCollateralStatus(ctx context.Context, minerAddr address.Address) (MinerCollateral, error) {
+	return MinerGetCollateralStatus(ctx, a, minerAddr)
+}
+
+// MinerAddCollateral adds collateral to a miner and waits for the message to be mined
+func (a *API) MinerAddCollateral(ctx context.Context, from, minerAddr address.Address, amount types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return MinerAddCollateral(ctx, a, from, minerAddr, amount, gasPrice, gasLimit)
+}
+
+// MinerWithdrawCollateral withdraws available collateral of a miner to its owner and waits for the message to be mined
+func (a *API) MinerWithdrawCollateral(ctx context.Context, from, minerAddr address.Address, amount types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return MinerWithdrawCollateral(ctx, a, from, minerAddr, amount, gasPrice, gasLimit)
+}
+
+// MinerGetDeclaredFaults queries for the sectors the given miner declared faulty
+func (a *API) MinerGetDeclaredFaults(ctx context.Context, minerAddr address.Address) (types.IntSet, error) {
+	return MinerGetDeclaredFaults(ctx, a, minerAddr)
+}
+
+// MinerDeclareFaults declares sectors of a miner faulty and waits for the message to be mined
+func (a *API) MinerDeclareFaults(ctx context.Context, from, minerAddr address.Address, sectorIDs types.IntSet, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return MinerDeclareFaults(ctx, a, from, minerAddr, sectorIDs, gasPrice, gasLimit)
+}
+
+// MinerDeclareRecovery declares faulty sectors of a miner recovered and waits for the message to be mined
+func (a *API) MinerDeclareRecovery(ctx context.Context, from, minerAddr address.Address, sectorIDs types.IntSet, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return MinerDeclareRecovery(ctx, a, from, minerAddr, sectorIDs, gasPrice, gasLimit)
+}
+
+// MinerReportConsensusFault sends two conflicting blocks of a miner to its miner actor as evidence of a consensus fault
+func (a *API) MinerReportConsensusFault(ctx context.Context, from address.Address, block1, block2 *types.Block, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return MinerReportConsensusFault(ctx, a, from, block1, block2, gasPrice, gasLimit)
+}
+
+// MinerPreviewReportConsensusFault calculates the amount of Gas needed for a call to MinerReportConsensusFault
+func (a *API) MinerPreviewReportConsensusFault(ctx context.Context, from address.Address, block1, block2 *types.Block) (types.GasUnits, error) {
+	return MinerPreviewReportConsensusFault(ctx, a, from, block1, block2)
+}
+
 // MinerPreviewSetPrice calculates the amount of Gas needed for a call to MinerSetPrice.
 // This method accepts all the same arguments as MinerSetPrice.
 func (a *API) MinerPreviewSetPrice(
@@ -178,6 +252,12 @@ func (a *API) ProtocolParameters(ctx context.Context) (*ProtocolParams, error) {
 	return ProtocolParameters(ctx, a)
 }
 
+// AddressLookup returns the address an actor was created at and the ID
+// address assigned to it, given either.
+func (a *API) AddressLookup(ctx context.Context, addr address.Address) (address.Address, address.Address, error) {
+	return AddressLookup(ctx, a, addr)
+}
+
 // WalletBalance returns the current balance of the given wallet address.
 func (a *API) WalletBalance(ctx context.Context, address address.Address) (types.AttoFIL, error) {
 	return WalletBalance(ctx, a, address)
@@ -205,9 +285,80 @@ func (a *API) PaymentChannelVoucher(
 	channel *types.ChannelID,
 	amount types.AttoFIL,
 	validAt *types.BlockHeight,
+	lane uint64,
+	nonce uint64,
+	merges []types.VoucherMerge,
 	condition *types.Predicate,
 ) (voucher *types.PaymentVoucher, err error) {
-	return PaymentChannelVoucher(ctx, a, fromAddr, channel, amount, validAt, condition)
+	return PaymentChannelVoucher(ctx, a, fromAddr, channel, amount, validAt, lane, nonce, merges, condition)
+}
+
+// PaymentChannelAddFunds adds funds to a payment channel and waits for the message to be mined
+func (a *API) PaymentChannelAddFunds(
+	ctx context.Context,
+	fromAddr address.Address,
+	channel *types.ChannelID,
+	amount types.AttoFIL,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+) (cid.Cid, error) {
+	return PaymentChannelAddFunds(ctx, a, fromAddr, channel, amount, gasPrice, gasLimit)
+}
+
+// PaymentChannelStatus returns the tracked state of a payment channel
+func (a *API) PaymentChannelStatus(
+	ctx context.Context,
+	fromAddr address.Address,
+	payerAddr address.Address,
+	channel *types.ChannelID,
+) (*paych.ChannelInfo, error) {
+	return PaymentChannelStatus(ctx, a, fromAddr, payerAddr, channel)
+}
+
+// MultisigCreate creates a multisig actor and waits for it to appear on chain
+func (a *API) MultisigCreate(
+	ctx context.Context,
+	fromAddr address.Address,
+	signers []address.Address,
+	threshold uint64,
+	unlockDuration *types.BlockHeight,
+	value types.AttoFIL,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+) (address.Address, error) {
+	return MultisigCreate(ctx, a, fromAddr, signers, threshold, unlockDuration, value, gasPrice, gasLimit)
+}
+
+// MultisigPropose proposes a transaction of a multisig and returns its id
+func (a *API) MultisigPropose(
+	ctx context.Context,
+	fromAddr address.Address,
+	msAddr address.Address,
+	to address.Address,
+	value types.AttoFIL,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	method string,
+	params ...interface{},
+) (uint64, error) {
+	return MultisigPropose(ctx, a, fromAddr, msAddr, to, value, gasPrice, gasLimit, method, params...)
+}
+
+// MultisigApprove approves a pending transaction of a multisig
+func (a *API) MultisigApprove(
+	ctx context.Context,
+	fromAddr address.Address,
+	msAddr address.Address,
+	txID uint64,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+) (cid.Cid, error) {
+	return MultisigApprove(ctx, a, fromAddr, msAddr, txID, gasPrice, gasLimit)
+}
+
+// MultisigLs returns the state of a multisig
+func (a *API) MultisigLs(ctx context.Context, msAddr address.Address) (*multisig.State, error) {
+	return MultisigLs(ctx, a, msAddr)
 }
 
 // ClientListAsks returns a channel with asks from the latest chain state
@@ -215,6 +366,11 @@ func (a *API) ClientListAsks(ctx context.Context) <-chan Ask {
 	return ClientListAsks(ctx, a)
 }
 
+// ClientFindAsks returns the unexpired asks that pass the filter, cheapest first
+func (a *API) ClientFindAsks(ctx context.Context, filter AskFilter) ([]AskInfo, error) {
+	return ClientFindAsks(ctx, a, filter)
+}
+
 // CalculatePoSt invokes the sector builder to calculate a proof-of-spacetime.
 func (a *API) CalculatePoSt(ctx context.Context, sortedCommRs proofs.SortedCommRs, seed types.PoStChallengeSeed) ([]types.PoStProof, []uint64, error) {
 	return CalculatePoSt(ctx, a, sortedCommRs, seed)
@@ -3,6 +3,7 @@ package porcelain
 import (
 	"context"
 	"math/big"
+	"sort"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/address"
@@ -10,6 +11,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/types"
 
 	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
 )
 
 // Ask is a result of querying for an ask, it may contain an error
@@ -109,3 +111,104 @@ func getAskByID(ctx context.Context, plumbing claPlubming, addr address.Address,
 		Miner:  addr,
 	}, nil
 }
+
+// AskFilter selects and orders the asks returned by ClientFindAsks. Nil or
+// zero fields do not filter.
+type AskFilter struct {
+	// MaxPrice is the highest price per byte per block to accept.
+	MaxPrice *types.AttoFIL
+	// MinSectorSize is the smallest miner sector size to accept.
+	MinSectorSize *types.BytesAmount
+	// MinPower is the least storage power a miner must have.
+	MinPower *types.BytesAmount
+	// PieceSize and Duration are the size in bytes and the duration in blocks
+	// of the intended deal, used to compute the total cost of each ask.
+	PieceSize uint64
+	Duration  uint64
+}
+
+// AskInfo is an unexpired ask with details about its miner and the total cost
+// of a deal made against it.
+type AskInfo struct {
+	Miner      address.Address    `json:"miner"`
+	ID         uint64             `json:"id"`
+	Price      types.AttoFIL      `json:"price"`
+	Expiry     *types.BlockHeight `json:"expiry"`
+	SectorSize *types.BytesAmount `json:"sectorSize"`
+	Power      *types.BytesAmount `json:"power"`
+	TotalCost  types.AttoFIL      `json:"totalCost"`
+}
+
+type cfaPlumbing interface {
+	ChainBlockHeight() (*types.BlockHeight, error)
+	ClientListAsks(ctx context.Context) <-chan Ask
+	MinerGetPower(ctx context.Context, minerAddr address.Address) (MinerPower, error)
+	MinerGetSectorSize(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error)
+}
+
+// ClientFindAsks returns the asks that have not expired at the current block
+// height and pass the filter, ordered by ascending total cost of a deal for
+// the filter's piece size and duration.
+func ClientFindAsks(ctx context.Context, plumbing cfaPlumbing, filter AskFilter) ([]AskInfo, error) {
+	height, err := plumbing.ChainBlockHeight()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get current block height")
+	}
+
+	type minerInfo struct {
+		sectorSize *types.BytesAmount
+		power      *types.BytesAmount
+	}
+	miners := make(map[address.Address]minerInfo)
+
+	var infos []AskInfo
+	for ask := range plumbing.ClientListAsks(ctx) {
+		if ask.Error != nil {
+			return nil, ask.Error
+		}
+		if ask.Expiry.LessEqual(height) {
+			continue
+		}
+		if filter.MaxPrice != nil && ask.Price.GreaterThan(*filter.MaxPrice) {
+			continue
+		}
+
+		info, ok := miners[ask.Miner]
+		if !ok {
+			sectorSize, err := plumbing.MinerGetSectorSize(ctx, ask.Miner)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not get sector size of miner %s", ask.Miner)
+			}
+			power, err := plumbing.MinerGetPower(ctx, ask.Miner)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not get power of miner %s", ask.Miner)
+			}
+			info = minerInfo{sectorSize: sectorSize, power: &power.Power}
+			miners[ask.Miner] = info
+		}
+		if filter.MinSectorSize != nil && info.sectorSize.LessThan(filter.MinSectorSize) {
+			continue
+		}
+		if filter.MinPower != nil && info.power.LessThan(filter.MinPower) {
+			continue
+		}
+
+		infos = append(infos, AskInfo{
+			Miner:      ask.Miner,
+			ID:         ask.ID,
+			Price:      ask.Price,
+			Expiry:     ask.Expiry,
+			SectorSize: info.sectorSize,
+			Power:      info.power,
+			TotalCost:  ask.Price.MulBigInt(new(big.Int).Mul(new(big.Int).SetUint64(filter.PieceSize), new(big.Int).SetUint64(filter.Duration))),
+		})
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		if !infos[i].TotalCost.Equal(infos[j].TotalCost) {
+			return infos[i].TotalCost.LessThan(infos[j].TotalCost)
+		}
+		return infos[i].Price.LessThan(infos[j].Price)
+	})
+	return infos, nil
+}
@@ -16,6 +16,7 @@ import (
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type claPlumbing struct {
@@ -129,3 +130,113 @@ func TestClientListAsks(t *testing.T) {
 		assert.Error(t, result.Error, "MESSAGE FAILURE")
 	})
 }
+
+type cfaPlumbing struct {
+	asks        []porcelain.Ask
+	sectorSizes map[address.Address]*types.BytesAmount
+	powers      map[address.Address]*types.BytesAmount
+}
+
+func newCfaPlumbing(minerCount int) (*cfaPlumbing, []address.Address) {
+	addrGetter := address.NewForTestGetter()
+	plumbing := &cfaPlumbing{
+		sectorSizes: make(map[address.Address]*types.BytesAmount),
+		powers:      make(map[address.Address]*types.BytesAmount),
+	}
+
+	var miners []address.Address
+	for i := 0; i < minerCount; i++ {
+		minerAddr := addrGetter()
+		miners = append(miners, minerAddr)
+		plumbing.sectorSizes[minerAddr] = types.TwoHundredFiftySixMiBSectorSize
+		plumbing.powers[minerAddr] = types.NewBytesAmount(uint64(1000 * (i + 1)))
+	}
+	return plumbing, miners
+}
+
+func (cfa *cfaPlumbing) ChainBlockHeight() (*types.BlockHeight, error) {
+	return types.NewBlockHeight(10), nil
+}
+
+func (cfa *cfaPlumbing) ClientListAsks(ctx context.Context) <-chan porcelain.Ask {
+	out := make(chan porcelain.Ask, len(cfa.asks))
+	for _, ask := range cfa.asks {
+		out <- ask
+	}
+	close(out)
+	return out
+}
+
+func (cfa *cfaPlumbing) MinerGetPower(ctx context.Context, minerAddr address.Address) (porcelain.MinerPower, error) {
+	return porcelain.MinerPower{Power: *cfa.powers[minerAddr], Total: *types.NewBytesAmount(1000000)}, nil
+}
+
+func (cfa *cfaPlumbing) MinerGetSectorSize(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error) {
+	return cfa.sectorSizes[minerAddr], nil
+}
+
+func TestClientFindAsks(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	expiry := types.NewBlockHeight(100)
+
+	t.Run("sorts unexpired asks by total cost", func(t *testing.T) {
+		plumbing, miners := newCfaPlumbing(2)
+		plumbing.asks = []porcelain.Ask{
+			{Miner: miners[0], ID: 0, Price: types.NewAttoFILFromFIL(3), Expiry: expiry},
+			{Miner: miners[1], ID: 0, Price: types.NewAttoFILFromFIL(2), Expiry: expiry},
+			{Miner: miners[1], ID: 1, Price: types.NewAttoFILFromFIL(1), Expiry: types.NewBlockHeight(10)},
+		}
+
+		infos, err := porcelain.ClientFindAsks(ctx, plumbing, porcelain.AskFilter{PieceSize: 10, Duration: 5})
+		require.NoError(t, err)
+
+		require.Len(t, infos, 2)
+		assert.Equal(t, miners[1], infos[0].Miner)
+		assert.Equal(t, types.NewAttoFILFromFIL(100), infos[0].TotalCost)
+		assert.Equal(t, types.NewBytesAmount(2000), infos[0].Power)
+		assert.Equal(t, types.TwoHundredFiftySixMiBSectorSize, infos[0].SectorSize)
+		assert.Equal(t, miners[0], infos[1].Miner)
+		assert.Equal(t, types.NewAttoFILFromFIL(150), infos[1].TotalCost)
+	})
+
+	t.Run("computes total costs beyond uint64", func(t *testing.T) {
+		plumbing, miners := newCfaPlumbing(1)
+		plumbing.asks = []porcelain.Ask{{Miner: miners[0], Price: types.NewAttoFIL(big.NewInt(1)), Expiry: expiry}}
+
+		infos, err := porcelain.ClientFindAsks(ctx, plumbing, porcelain.AskFilter{PieceSize: 1 << 40, Duration: 1 << 40})
+		require.NoError(t, err)
+
+		require.Len(t, infos, 1)
+		assert.Equal(t, types.NewAttoFIL(new(big.Int).Lsh(big.NewInt(1), 80)), infos[0].TotalCost)
+	})
+
+	t.Run("filters by price, sector size and power", func(t *testing.T) {
+		plumbing, miners := newCfaPlumbing(4)
+		plumbing.sectorSizes[miners[1]] = types.OneKiBSectorSize
+		for i, minerAddr := range miners {
+			plumbing.asks = append(plumbing.asks, porcelain.Ask{Miner: minerAddr, Price: types.NewAttoFILFromFIL(uint64(i + 1)), Expiry: expiry})
+		}
+
+		maxPrice := types.NewAttoFILFromFIL(3)
+		infos, err := porcelain.ClientFindAsks(ctx, plumbing, porcelain.AskFilter{
+			MaxPrice:      &maxPrice,
+			MinSectorSize: types.TwoHundredFiftySixMiBSectorSize,
+			MinPower:      types.NewBytesAmount(2000),
+		})
+		require.NoError(t, err)
+
+		// miner 0 has too little power, miner 1 too small sectors and miner 3 asks too much
+		require.Len(t, infos, 1)
+		assert.Equal(t, miners[2], infos[0].Miner)
+	})
+
+	t.Run("returns ask errors", func(t *testing.T) {
+		plumbing, _ := newCfaPlumbing(0)
+		plumbing.asks = []porcelain.Ask{{Error: errors.New("ASK FAILURE")}}
+
+		_, err := porcelain.ClientFindAsks(ctx, plumbing, porcelain.AskFilter{})
+		assert.Error(t, err, "ASK FAILURE")
+	})
+}
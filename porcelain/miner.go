@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	logging "github.com/ipfs/go-log"
 	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/abi"
@@ -17,6 +19,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor/builtin/storagemarket"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/net"
 	"github.com/filecoin-project/go-filecoin/types"
 	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
 )
@@ -281,6 +284,24 @@ func MinerGetOwnerAddress(ctx context.Context, plumbing minerQueryAndDeserialize
 	return address.NewFromBytes(res[0])
 }
 
+// MinerGetPendingOwner queries for the new owner proposed for the given
+// miner. The address is empty if no owner change is pending.
+func MinerGetPendingOwner(ctx context.Context, plumbing minerQueryAndDeserialize, minerAddr address.Address) (address.Address, error) {
+	res, err := plumbing.MessageQuery(ctx, address.Undef, minerAddr, "getPendingOwner")
+	if err != nil {
+		return address.Undef, err
+	}
+
+	return address.NewFromBytes(res[0])
+}
+
+// MinerChangeOwner proposes a new owner for a miner when sent from the owner,
+// or accepts the proposal when sent from the proposed owner, and waits for
+// the message to be mined.
+func MinerChangeOwner(ctx context.Context, plumbing mccAPI, from, minerAddr, owner address.Address, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return minerSendAndWait(ctx, plumbing, from, minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "changeOwner", owner)
+}
+
 // queryAndDeserialize is a convenience method. It sends a query message to a
 // miner and, based on the method return-type, deserializes to the appropriate
 // ABI type.
@@ -349,6 +370,145 @@ func MinerGetLastCommittedSectorID(ctx context.Context, plumbing minerQueryAndDe
 	return lastUsedSectorID, nil
 }
 
+// MinerCollateral is the collateral a miner is required to hold, and the
+// balance in excess of it that its owner may withdraw.
+type MinerCollateral struct {
+	Required  types.AttoFIL
+	Available types.AttoFIL
+}
+
+// MinerGetCollateralStatus queries the required and available collateral of
+// a given miner.
+func MinerGetCollateralStatus(ctx context.Context, plumbing mgaAPI, minerAddr address.Address) (MinerCollateral, error) {
+	required, err := MinerGetCollateral(ctx, plumbing, minerAddr)
+	if err != nil {
+		return MinerCollateral{}, err
+	}
+
+	rets, err := plumbing.MessageQuery(
+		ctx,
+		address.Undef,
+		minerAddr,
+		"getAvailableCollateral",
+	)
+	if err != nil {
+		return MinerCollateral{}, err
+	}
+
+	return MinerCollateral{
+		Required:  required,
+		Available: types.NewAttoFILFromBytes(rets[0]),
+	}, nil
+}
+
+// mccAPI is the subset of the plumbing.API that the porcelain sending a
+// message to a miner and waiting for it uses.
+type mccAPI interface {
+	MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+}
+
+// MinerAddCollateral sends amount from the owner or worker of a miner to its
+// collateral and waits for the message to be mined.
+func MinerAddCollateral(ctx context.Context, plumbing mccAPI, from, minerAddr address.Address, amount types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return minerSendAndWait(ctx, plumbing, from, minerAddr, amount, gasPrice, gasLimit, "addCollateral")
+}
+
+// MinerWithdrawCollateral withdraws amount of the available collateral of a
+// miner to its owner and waits for the message to be mined.
+func MinerWithdrawCollateral(ctx context.Context, plumbing mccAPI, from, minerAddr address.Address, amount types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return minerSendAndWait(ctx, plumbing, from, minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "withdrawCollateral", amount)
+}
+
+// minerSendAndWait sends a message to a miner and waits for it to be mined,
+// returning the error of the miner actor if the message failed.
+func minerSendAndWait(ctx context.Context, plumbing mccAPI, from, minerAddr address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	msgCid, err := plumbing.MessageSend(ctx, from, minerAddr, value, gasPrice, gasLimit, method, params...)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "couldn't send message")
+	}
+
+	err = plumbing.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, minerActor.Errors)
+		}
+		return nil
+	})
+	return msgCid, err
+}
+
+// MinerGetDeclaredFaults queries the sectors a given miner declared faulty
+// and has not declared recovered.
+func MinerGetDeclaredFaults(ctx context.Context, plumbing mgaAPI, minerAddr address.Address) (types.IntSet, error) {
+	rets, err := plumbing.MessageQuery(
+		ctx,
+		address.Undef,
+		minerAddr,
+		"getDeclaredFaults",
+	)
+	if err != nil {
+		return types.EmptyIntSet(), err
+	}
+
+	faultsVal, err := abi.Deserialize(rets[0], abi.IntSet)
+	if err != nil {
+		return types.EmptyIntSet(), errors.Wrap(err, "deserialization failed")
+	}
+	faults, ok := faultsVal.Val.(types.IntSet)
+	if !ok {
+		return types.EmptyIntSet(), errors.New("type assertion failed")
+	}
+	return faults, nil
+}
+
+// MinerDeclareFaults declares sectors of a miner faulty ahead of a PoSt and
+// waits for the message to be mined.
+func MinerDeclareFaults(ctx context.Context, plumbing mccAPI, from, minerAddr address.Address, sectorIDs types.IntSet, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return minerSendAndWait(ctx, plumbing, from, minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "declareFaults", sectorIDs)
+}
+
+// MinerDeclareRecovery declares sectors of a miner that were declared faulty
+// recovered and waits for the message to be mined.
+func MinerDeclareRecovery(ctx context.Context, plumbing mccAPI, from, minerAddr address.Address, sectorIDs types.IntSet, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return minerSendAndWait(ctx, plumbing, from, minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "declareRecovery", sectorIDs)
+}
+
+// mrcfAPI is the subset of the plumbing.API that MinerReportConsensusFault uses.
+type mrcfAPI interface {
+	MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+}
+
+// MinerReportConsensusFault sends two conflicting blocks of a miner to its
+// miner actor as evidence of a consensus fault. It does not wait for the
+// message to be mined.
+func MinerReportConsensusFault(ctx context.Context, plumbing mrcfAPI, from address.Address, block1, block2 *types.Block, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	if block1.Miner != block2.Miner {
+		return cid.Undef, errors.New("blocks of different miners are not evidence of a consensus fault")
+	}
+
+	return plumbing.MessageSend(ctx, from, block1.Miner, types.ZeroAttoFIL, gasPrice, gasLimit, "reportConsensusFault", block1.ToNode().RawData(), block2.ToNode().RawData())
+}
+
+// mprcfAPI is the subset of the plumbing.API that MinerPreviewReportConsensusFault uses.
+type mprcfAPI interface {
+	MessagePreview(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, error)
+}
+
+// MinerPreviewReportConsensusFault calculates the amount of Gas needed for a
+// call to MinerReportConsensusFault. This method accepts all the same
+// arguments as MinerReportConsensusFault.
+func MinerPreviewReportConsensusFault(ctx context.Context, plumbing mprcfAPI, from address.Address, block1, block2 *types.Block) (types.GasUnits, error) {
+	if block1.Miner != block2.Miner {
+		return types.NewGasUnits(0), errors.New("blocks of different miners are not evidence of a consensus fault")
+	}
+
+	usedGas, err := plumbing.MessagePreview(ctx, from, block1.Miner, "reportConsensusFault", block1.ToNode().RawData(), block2.ToNode().RawData())
+	if err != nil {
+		return types.NewGasUnits(0), errors.Wrap(err, "couldn't preview message")
+	}
+	return usedGas, nil
+}
+
 // MinerGetWorker queries for the public key of the given miner
 func MinerGetWorker(ctx context.Context, plumbing minerQueryAndDeserialize, minerAddr address.Address) (address.Address, error) {
 	res, err := plumbing.MessageQuery(ctx, address.Undef, minerAddr, "getWorker")
@@ -379,6 +539,30 @@ func MinerGetAsk(ctx context.Context, plumbing mgaAPI, minerAddr address.Address
 	return ask, nil
 }
 
+// MinerGetAsks queries for all asks of the given miner, including expired
+// asks that have not been removed from its state yet.
+func MinerGetAsks(ctx context.Context, plumbing mgaAPI, minerAddr address.Address) ([]minerActor.Ask, error) {
+	ret, err := plumbing.MessageQuery(ctx, address.Undef, minerAddr, "getAsks")
+	if err != nil {
+		return nil, err
+	}
+
+	var askIDs []uint64
+	if err := cbor.DecodeInto(ret[0], &askIDs); err != nil {
+		return nil, err
+	}
+
+	asks := make([]minerActor.Ask, 0, len(askIDs))
+	for _, id := range askIDs {
+		ask, err := MinerGetAsk(ctx, plumbing, minerAddr, id)
+		if err != nil {
+			return nil, err
+		}
+		asks = append(asks, ask)
+	}
+	return asks, nil
+}
+
 // mgpidAPI is the subset of the plumbing.API that MinerGetPeerID uses.
 type mgpidAPI interface {
 	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
@@ -398,6 +582,79 @@ func MinerGetPeerID(ctx context.Context, plumbing mgpidAPI, minerAddr address.Ad
 	return pid, nil
 }
 
+// MinerGetMultiaddrs queries for the multiaddrs the given miner advertises.
+func MinerGetMultiaddrs(ctx context.Context, plumbing mgpidAPI, minerAddr address.Address) ([]ma.Multiaddr, error) {
+	res, err := plumbing.MessageQuery(ctx, address.Undef, minerAddr, "getMultiaddrs")
+	if err != nil {
+		return nil, err
+	}
+
+	addrsVal, err := abi.Deserialize(res[0], abi.Multiaddrs)
+	if err != nil {
+		return nil, errors.Wrap(err, "deserialization failed")
+	}
+	addrs, ok := addrsVal.Val.([]ma.Multiaddr)
+	if !ok {
+		return nil, errors.New("type assertion failed")
+	}
+	return addrs, nil
+}
+
+// MinerUpdateMultiaddrs replaces the multiaddrs a miner advertises and waits
+// for the message to be mined.
+func MinerUpdateMultiaddrs(ctx context.Context, plumbing mccAPI, from, minerAddr address.Address, addrs []ma.Multiaddr, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	return minerSendAndWait(ctx, plumbing, from, minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "updateMultiaddrs", addrs)
+}
+
+// minerFindPeerTimeout bounds the DHT lookup of a miner's peer, after which
+// the miner is dialed at its advertised multiaddrs.
+const minerFindPeerTimeout = 15 * time.Second
+
+// mconnAPI is the subset of the plumbing.API that MinerConnect uses.
+type mconnAPI interface {
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	NetworkFindPeer(ctx context.Context, peerID peer.ID) (peer.AddrInfo, error)
+	NetworkConnect(ctx context.Context, addrs []string) (<-chan net.ConnectionResult, error)
+}
+
+// MinerConnect makes sure the peer of a miner can be reached. The peer is
+// looked up through the DHT and, if the lookup fails, dialed at the
+// multiaddrs the miner advertises on chain.
+func MinerConnect(ctx context.Context, plumbing mconnAPI, minerAddr address.Address, minerPID peer.ID) error {
+	findCtx, cancel := context.WithTimeout(ctx, minerFindPeerTimeout)
+	_, findErr := plumbing.NetworkFindPeer(findCtx, minerPID)
+	cancel()
+	if findErr == nil {
+		return nil
+	}
+
+	addrs, err := MinerGetMultiaddrs(ctx, plumbing, minerAddr)
+	if err != nil {
+		return errors.Wrap(err, "failed to get miner multiaddrs")
+	}
+	if len(addrs) == 0 {
+		return errors.Wrapf(findErr, "miner %s advertises no multiaddrs and its peer could not be found", minerAddr)
+	}
+
+	peerAddrs := make([]string, len(addrs))
+	for i, addr := range addrs {
+		peerAddrs[i] = fmt.Sprintf("%s/ipfs/%s", addr, minerPID.Pretty())
+	}
+	results, err := plumbing.NetworkConnect(ctx, peerAddrs)
+	if err != nil {
+		return err
+	}
+
+	// the miner is reachable if any of its multiaddrs can be dialed
+	err = errors.Errorf("failed to connect to miner %s", minerAddr)
+	for result := range results {
+		if result.Err == nil {
+			err = nil
+		}
+	}
+	return err
+}
+
 // MinerProvingPeriod contains a miners proving period start and end as well
 // as a set of their proving set.
 type MinerProvingPeriod struct {
@@ -11,6 +11,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/net"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
 	. "github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/repo"
@@ -20,6 +21,7 @@ import (
 	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/stretchr/testify/assert"
@@ -39,6 +41,7 @@ func newMinerCreate(t *testing.T, msgFail bool, address address.Address) *minerC
 	repo := repo.NewInMemoryRepo()
 	backend, err := wallet.NewDSBackend(repo.WalletDatastore())
 	require.NoError(t, err)
+	require.NoError(t, backend.Unlock(nil, 0))
 	return &minerCreate{
 		testing: t,
 		address: address,
@@ -74,7 +77,7 @@ func (mpc *minerCreate) MessageWait(ctx context.Context, msgCid cid.Cid, cb func
 }
 
 func (mpc *minerCreate) WalletDefaultAddress() (address.Address, error) {
-	return wallet.NewAddress(mpc.wallet)
+	return wallet.NewAddress(mpc.wallet, address.SECP256K1)
 }
 
 func TestMinerCreate(t *testing.T) {
@@ -126,8 +129,9 @@ type minerPreviewCreate struct {
 func newMinerPreviewCreate(t *testing.T) *minerPreviewCreate {
 	repo := repo.NewInMemoryRepo()
 	backend, err := wallet.NewDSBackend(repo.WalletDatastore())
-	wallet := wallet.New(backend)
 	require.NoError(t, err)
+	require.NoError(t, backend.Unlock(nil, 0))
+	wallet := wallet.New(backend)
 	return &minerPreviewCreate{
 		wallet: wallet,
 	}
@@ -146,7 +150,7 @@ func (mpc *minerPreviewCreate) NetworkGetPeerID() peer.ID {
 }
 
 func (mpc *minerPreviewCreate) WalletDefaultAddress() (address.Address, error) {
-	return wallet.NewAddress(mpc.wallet)
+	return wallet.NewAddress(mpc.wallet, address.SECP256K1)
 }
 
 func TestMinerPreviewCreate(t *testing.T) {
@@ -530,6 +534,42 @@ func TestMinerGetAsk(t *testing.T) {
 	assert.Equal(t, big.NewInt(4), ask.ID)
 }
 
+type minerGetAsksPlumbing struct{}
+
+func (mgap *minerGetAsksPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	if method == "getAsks" {
+		out, err := cbor.DumpObject([]uint64{2, 5})
+		if err != nil {
+			panic("Could not encode ask ids")
+		}
+		return [][]byte{out}, nil
+	}
+
+	id := params[0].(*big.Int)
+	out, err := cbor.DumpObject(miner.Ask{
+		Price:  types.NewAttoFILFromFIL(id.Uint64()),
+		Expiry: types.NewBlockHeight(41),
+		ID:     id,
+	})
+	if err != nil {
+		panic("Could not encode ask")
+	}
+	return [][]byte{out}, nil
+}
+
+func TestMinerGetAsks(t *testing.T) {
+	tf.UnitTest(t)
+
+	asks, err := MinerGetAsks(context.Background(), &minerGetAsksPlumbing{}, address.TestAddress2)
+	require.NoError(t, err)
+
+	require.Len(t, asks, 2)
+	assert.Equal(t, big.NewInt(2), asks[0].ID)
+	assert.Equal(t, types.NewAttoFILFromFIL(2), asks[0].Price)
+	assert.Equal(t, big.NewInt(5), asks[1].ID)
+	assert.Equal(t, types.NewAttoFILFromFIL(5), asks[1].Price)
+}
+
 func requirePeerID() peer.ID {
 	id, err := peer.IDB58Decode("QmWbMozPyW6Ecagtxq7SXBXXLY5BNdP1GwHB2WoZCKMvcb")
 	if err != nil {
@@ -579,3 +619,250 @@ func TestMinerGetLastCommittedSectorID(t *testing.T) {
 
 	assert.Equal(t, int(lastCommittedSectorID), 5432)
 }
+
+type minerGetCollateralStatusPlumbing struct{}
+
+func (minerGetCollateralStatusPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	switch method {
+	case "getActiveCollateral":
+		return [][]byte{types.NewAttoFILFromFIL(2).Bytes()}, nil
+	case "getAvailableCollateral":
+		return [][]byte{types.NewAttoFILFromFIL(5).Bytes()}, nil
+	}
+	return nil, fmt.Errorf("unsupported method: %s", method)
+}
+
+func TestMinerGetCollateralStatus(t *testing.T) {
+	tf.UnitTest(t)
+
+	collateral, err := MinerGetCollateralStatus(context.Background(), &minerGetCollateralStatusPlumbing{}, address.TestAddress2)
+	require.NoError(t, err)
+
+	assert.Equal(t, types.NewAttoFILFromFIL(2), collateral.Required)
+	assert.Equal(t, types.NewAttoFILFromFIL(5), collateral.Available)
+}
+
+type minerCollateralPlumbing struct {
+	msgCid   cid.Cid
+	exitCode uint8
+
+	value  types.AttoFIL
+	method string
+	params []interface{}
+}
+
+func (mcp *minerCollateralPlumbing) MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	mcp.value, mcp.method, mcp.params = value, method, params
+	mcp.msgCid = types.NewCidForTestGetter()()
+	return mcp.msgCid, nil
+}
+
+func (mcp *minerCollateralPlumbing) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+	return cb(&types.Block{}, &types.SignedMessage{}, &types.MessageReceipt{ExitCode: mcp.exitCode})
+}
+
+func TestMinerCollateral(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	amount := types.NewAttoFILFromFIL(3)
+
+	t.Run("adds the amount as the value of the message", func(t *testing.T) {
+		plumbing := &minerCollateralPlumbing{}
+		c, err := MinerAddCollateral(ctx, plumbing, address.TestAddress, address.TestAddress2, amount, types.NewGasPrice(1), types.NewGasUnits(300))
+		require.NoError(t, err)
+
+		assert.Equal(t, plumbing.msgCid, c)
+		assert.Equal(t, "addCollateral", plumbing.method)
+		assert.Equal(t, amount, plumbing.value)
+	})
+
+	t.Run("withdraws the amount as a parameter of the message", func(t *testing.T) {
+		plumbing := &minerCollateralPlumbing{}
+		_, err := MinerWithdrawCollateral(ctx, plumbing, address.TestAddress, address.TestAddress2, amount, types.NewGasPrice(1), types.NewGasUnits(300))
+		require.NoError(t, err)
+
+		assert.Equal(t, "withdrawCollateral", plumbing.method)
+		assert.Equal(t, types.ZeroAttoFIL, plumbing.value)
+		assert.Equal(t, []interface{}{amount}, plumbing.params)
+	})
+
+	t.Run("reports the error of the miner actor", func(t *testing.T) {
+		plumbing := &minerCollateralPlumbing{exitCode: miner.ErrInsufficientCollateral}
+		_, err := MinerWithdrawCollateral(ctx, plumbing, address.TestAddress, address.TestAddress2, amount, types.NewGasPrice(1), types.NewGasUnits(300))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "insufficient collateral")
+	})
+}
+
+type minerGetDeclaredFaultsPlumbing struct{}
+
+func (minerGetDeclaredFaultsPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	value := &abi.Value{Type: abi.IntSet, Val: types.NewIntSet(3, 5)}
+	encoded, err := value.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{encoded}, nil
+}
+
+func TestMinerGetDeclaredFaults(t *testing.T) {
+	tf.UnitTest(t)
+
+	faults, err := MinerGetDeclaredFaults(context.Background(), &minerGetDeclaredFaultsPlumbing{}, address.TestAddress2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []uint64{3, 5}, faults.Values())
+}
+
+type minerReportConsensusFaultPlumbing struct {
+	sent   bool
+	from   address.Address
+	to     address.Address
+	method string
+	params []interface{}
+}
+
+func (mrp *minerReportConsensusFaultPlumbing) MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	mrp.sent = true
+	mrp.from, mrp.to, mrp.method, mrp.params = from, to, method, params
+	return types.NewCidForTestGetter()(), nil
+}
+
+type minerPreviewReportConsensusFaultPlumbing struct {
+	to     address.Address
+	method string
+	params []interface{}
+}
+
+func (mpp *minerPreviewReportConsensusFaultPlumbing) MessagePreview(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, error) {
+	mpp.to, mpp.method, mpp.params = to, method, params
+	return types.NewGasUnits(5000), nil
+}
+
+func TestMinerPreviewReportConsensusFault(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	block := func(miner address.Address, nonce uint64) *types.Block {
+		return &types.Block{Miner: miner, Height: types.Uint64(5), Nonce: types.Uint64(nonce), BlockSig: []byte{0x01}}
+	}
+
+	t.Run("previews sending the blocks to the miner of the blocks", func(t *testing.T) {
+		plumbing := &minerPreviewReportConsensusFaultPlumbing{}
+		usedGas, err := MinerPreviewReportConsensusFault(ctx, plumbing, address.TestAddress, block(address.TestAddress2, 1), block(address.TestAddress2, 2))
+		require.NoError(t, err)
+
+		assert.Equal(t, types.NewGasUnits(5000), usedGas)
+		assert.Equal(t, address.TestAddress2, plumbing.to)
+		assert.Equal(t, "reportConsensusFault", plumbing.method)
+		assert.Len(t, plumbing.params, 2)
+	})
+
+	t.Run("blocks of different miners are not previewed", func(t *testing.T) {
+		plumbing := &minerPreviewReportConsensusFaultPlumbing{}
+		_, err := MinerPreviewReportConsensusFault(ctx, plumbing, address.TestAddress, block(address.TestAddress2, 1), block(address.TestAddress, 2))
+		require.Error(t, err)
+		assert.Empty(t, plumbing.method)
+	})
+}
+
+func TestMinerReportConsensusFault(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	block := func(miner address.Address, nonce uint64) *types.Block {
+		return &types.Block{Miner: miner, Height: types.Uint64(5), Nonce: types.Uint64(nonce), BlockSig: []byte{0x01}}
+	}
+
+	t.Run("sends the blocks to the miner of the blocks", func(t *testing.T) {
+		plumbing := &minerReportConsensusFaultPlumbing{}
+		blk1, blk2 := block(address.TestAddress2, 1), block(address.TestAddress2, 2)
+		_, err := MinerReportConsensusFault(ctx, plumbing, address.TestAddress, blk1, blk2, types.NewGasPrice(1), types.NewGasUnits(300))
+		require.NoError(t, err)
+
+		assert.Equal(t, address.TestAddress, plumbing.from)
+		assert.Equal(t, address.TestAddress2, plumbing.to)
+		assert.Equal(t, "reportConsensusFault", plumbing.method)
+		require.Len(t, plumbing.params, 2)
+
+		decoded, err := types.DecodeBlock(plumbing.params[0].([]byte))
+		require.NoError(t, err)
+		assert.True(t, blk1.Cid().Equals(decoded.Cid()))
+		decoded, err = types.DecodeBlock(plumbing.params[1].([]byte))
+		require.NoError(t, err)
+		assert.True(t, blk2.Cid().Equals(decoded.Cid()))
+	})
+
+	t.Run("blocks of different miners are not sent", func(t *testing.T) {
+		plumbing := &minerReportConsensusFaultPlumbing{}
+		_, err := MinerReportConsensusFault(ctx, plumbing, address.TestAddress, block(address.TestAddress2, 1), block(address.TestAddress, 2), types.NewGasPrice(1), types.NewGasUnits(300))
+		require.Error(t, err)
+		assert.False(t, plumbing.sent)
+	})
+}
+
+type minerConnectPlumbing struct {
+	findErr    error
+	addrs      []ma.Multiaddr
+	connectErr error
+
+	dialed          []string
+	findHadDeadline bool
+}
+
+func (mcp *minerConnectPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	value := &abi.Value{Type: abi.Multiaddrs, Val: mcp.addrs}
+	encoded, err := value.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{encoded}, nil
+}
+
+func (mcp *minerConnectPlumbing) NetworkFindPeer(ctx context.Context, peerID peer.ID) (peer.AddrInfo, error) {
+	_, mcp.findHadDeadline = ctx.Deadline()
+	return peer.AddrInfo{ID: peerID}, mcp.findErr
+}
+
+func (mcp *minerConnectPlumbing) NetworkConnect(ctx context.Context, addrs []string) (<-chan net.ConnectionResult, error) {
+	mcp.dialed = append(mcp.dialed, addrs...)
+	results := make(chan net.ConnectionResult, len(addrs))
+	for range addrs {
+		results <- net.ConnectionResult{Err: mcp.connectErr}
+	}
+	close(results)
+	return results, nil
+}
+
+func TestMinerConnect(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	pid := requirePeerID()
+	addr, err := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/6000")
+	require.NoError(t, err)
+
+	t.Run("a peer found through the DHT is not dialed", func(t *testing.T) {
+		plumbing := &minerConnectPlumbing{addrs: []ma.Multiaddr{addr}}
+
+		require.NoError(t, MinerConnect(ctx, plumbing, address.TestAddress2, pid))
+		assert.Empty(t, plumbing.dialed)
+		assert.True(t, plumbing.findHadDeadline)
+	})
+
+	t.Run("a peer not found is dialed at the advertised multiaddrs", func(t *testing.T) {
+		plumbing := &minerConnectPlumbing{findErr: errors.New("not found"), addrs: []ma.Multiaddr{addr}}
+
+		require.NoError(t, MinerConnect(ctx, plumbing, address.TestAddress2, pid))
+		assert.Equal(t, []string{fmt.Sprintf("/ip4/127.0.0.1/tcp/6000/ipfs/%s", pid.Pretty())}, plumbing.dialed)
+	})
+
+	t.Run("errors if the peer cannot be found or dialed", func(t *testing.T) {
+		plumbing := &minerConnectPlumbing{findErr: errors.New("not found")}
+		assert.Error(t, MinerConnect(ctx, plumbing, address.TestAddress2, pid))
+
+		plumbing = &minerConnectPlumbing{findErr: errors.New("not found"), addrs: []ma.Multiaddr{addr}, connectErr: errors.New("refused")}
+		assert.Error(t, MinerConnect(ctx, plumbing, address.TestAddress2, pid))
+	})
+}
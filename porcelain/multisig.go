@@ -0,0 +1,167 @@
+package porcelain
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+// msSendPlumbing is the subset of the plumbing.API the multisig methods
+// sending messages use.
+type msSendPlumbing interface {
+	MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+	WalletDefaultAddress() (address.Address, error)
+}
+
+// MultisigCreate creates a multisig of the signers, funded with value, and
+// waits for it to appear on chain. Transactions of the multisig need threshold
+// signers to approve them. The value vests over unlockDuration blocks, none if
+// zero. It returns the address of the multisig.
+func MultisigCreate(
+	ctx context.Context,
+	plumbing msSendPlumbing,
+	fromAddr address.Address,
+	signers []address.Address,
+	threshold uint64,
+	unlockDuration *types.BlockHeight,
+	value types.AttoFIL,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+) (_ address.Address, err error) {
+	if fromAddr.Empty() {
+		fromAddr, err = plumbing.WalletDefaultAddress()
+		if err != nil {
+			return address.Undef, err
+		}
+	}
+
+	msgCid, err := plumbing.MessageSend(ctx, fromAddr, address.MultisigFactoryAddress, value, gasPrice, gasLimit, "createMultisig", signers, threshold, unlockDuration)
+	if err != nil {
+		return address.Undef, errors.Wrap(err, "couldn't send message")
+	}
+
+	var msAddr address.Address
+	err = plumbing.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) (err error) {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, multisig.Errors)
+		}
+		msAddr, err = address.NewFromBytes(receipt.Return[0])
+		return err
+	})
+	if err != nil {
+		return address.Undef, err
+	}
+	return msAddr, nil
+}
+
+// MultisigPropose proposes a transaction of the multisig sending value to the
+// method of an actor, and waits for the proposal to be mined. The proposal
+// counts as approved by fromAddr, and the transaction is sent right away if
+// that meets the threshold. It returns the id of the transaction.
+func MultisigPropose(
+	ctx context.Context,
+	plumbing msSendPlumbing,
+	fromAddr address.Address,
+	msAddr address.Address,
+	to address.Address,
+	value types.AttoFIL,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+	method string,
+	params ...interface{},
+) (_ uint64, err error) {
+	if fromAddr.Empty() {
+		fromAddr, err = plumbing.WalletDefaultAddress()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	encodedParams, err := abi.ToEncodedValues(params...)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid transaction params")
+	}
+
+	msgCid, err := plumbing.MessageSend(ctx, fromAddr, msAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "propose", to, value, method, encodedParams)
+	if err != nil {
+		return 0, errors.Wrap(err, "couldn't send message")
+	}
+
+	var txID uint64
+	err = plumbing.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, multisig.Errors)
+		}
+		val, err := abi.Deserialize(receipt.Return[0], abi.Uint64)
+		if err != nil {
+			return err
+		}
+		txID = val.Val.(uint64)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return txID, nil
+}
+
+// MultisigApprove approves a pending transaction of the multisig and waits for
+// the approval to be mined. The transaction is sent once threshold signers
+// approved it.
+func MultisigApprove(
+	ctx context.Context,
+	plumbing msSendPlumbing,
+	fromAddr address.Address,
+	msAddr address.Address,
+	txID uint64,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+) (_ cid.Cid, err error) {
+	if fromAddr.Empty() {
+		fromAddr, err = plumbing.WalletDefaultAddress()
+		if err != nil {
+			return cid.Undef, err
+		}
+	}
+
+	msgCid, err := plumbing.MessageSend(ctx, fromAddr, msAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "approve", txID)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "couldn't send message")
+	}
+
+	err = plumbing.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, multisig.Errors)
+		}
+		return nil
+	})
+	return msgCid, err
+}
+
+type msLsPlumbing interface {
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+}
+
+// MultisigLs returns the state of a multisig: its signers, threshold, vesting
+// and pending transactions.
+func MultisigLs(ctx context.Context, plumbing msLsPlumbing, msAddr address.Address) (*multisig.State, error) {
+	values, err := plumbing.MessageQuery(ctx, address.Undef, msAddr, "getState")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query multisig state")
+	}
+
+	var state multisig.State
+	if err := cbor.DecodeInto(values[0], &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
@@ -0,0 +1,186 @@
+package porcelain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/multisig"
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/porcelain"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+type msTestPlumbing struct {
+	defaultAddr address.Address
+	msgCid      cid.Cid
+	receipt     *types.MessageReceipt
+	state       *multisig.State
+
+	from   address.Address
+	to     address.Address
+	value  types.AttoFIL
+	method string
+	params []interface{}
+}
+
+func newMsTestPlumbing(ret ...[]byte) *msTestPlumbing {
+	return &msTestPlumbing{
+		defaultAddr: address.NewForTestGetter()(),
+		msgCid:      types.NewCidForTestGetter()(),
+		receipt:     &types.MessageReceipt{ExitCode: 0, Return: ret},
+	}
+}
+
+func (mtp *msTestPlumbing) MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	mtp.from = from
+	mtp.to = to
+	mtp.value = value
+	mtp.method = method
+	mtp.params = params
+	return mtp.msgCid, nil
+}
+
+func (mtp *msTestPlumbing) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+	return cb(nil, nil, mtp.receipt)
+}
+
+func (mtp *msTestPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	mtp.to = to
+	mtp.method = method
+	data, err := cbor.DumpObject(mtp.state)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{data}, nil
+}
+
+func (mtp *msTestPlumbing) WalletDefaultAddress() (address.Address, error) {
+	return mtp.defaultAddr, nil
+}
+
+func TestMultisigCreate(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addrGetter := address.NewForTestGetter()
+	msAddr := addrGetter()
+	signers := []address.Address{addrGetter(), addrGetter()}
+
+	t.Run("sends the signers to the factory and returns the multisig address", func(t *testing.T) {
+		plumbing := newMsTestPlumbing(msAddr.Bytes())
+
+		addr, err := MultisigCreate(ctx, plumbing, address.Undef, signers, 2, types.NewBlockHeight(10), types.NewAttoFILFromFIL(100), types.NewGasPrice(1), types.NewGasUnits(300))
+		require.NoError(t, err)
+		assert.Equal(t, msAddr, addr)
+
+		assert.Equal(t, plumbing.defaultAddr, plumbing.from)
+		assert.Equal(t, address.MultisigFactoryAddress, plumbing.to)
+		assert.Equal(t, types.NewAttoFILFromFIL(100), plumbing.value)
+		assert.Equal(t, "createMultisig", plumbing.method)
+		assert.Equal(t, []interface{}{signers, uint64(2), types.NewBlockHeight(10)}, plumbing.params)
+	})
+
+	t.Run("returns the error of a failed creation", func(t *testing.T) {
+		plumbing := newMsTestPlumbing()
+		plumbing.receipt.ExitCode = multisig.ErrInvalidThreshold
+
+		_, err := MultisigCreate(ctx, plumbing, signers[0], signers, 3, types.NewBlockHeight(0), types.ZeroAttoFIL, types.NewGasPrice(1), types.NewGasUnits(300))
+		require.Error(t, err)
+		assert.Equal(t, signers[0], plumbing.from)
+		assert.Contains(t, err.Error(), multisig.Errors[multisig.ErrInvalidThreshold].Error())
+	})
+}
+
+func TestMultisigPropose(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addrGetter := address.NewForTestGetter()
+	msAddr := addrGetter()
+	to := addrGetter()
+
+	txID, err := (&abi.Value{Type: abi.Uint64, Val: uint64(7)}).Serialize()
+	require.NoError(t, err)
+	plumbing := newMsTestPlumbing(txID)
+
+	id, err := MultisigPropose(ctx, plumbing, address.Undef, msAddr, to, types.NewAttoFILFromFIL(5), types.NewGasPrice(1), types.NewGasUnits(300), "addSigner", to, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), id)
+
+	assert.Equal(t, plumbing.defaultAddr, plumbing.from)
+	assert.Equal(t, msAddr, plumbing.to)
+	assert.Equal(t, types.ZeroAttoFIL, plumbing.value)
+	assert.Equal(t, "propose", plumbing.method)
+
+	// the transaction params are abi encoded for the proposed method
+	encoded, err := abi.ToEncodedValues(to, true)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{to, types.NewAttoFILFromFIL(5), "addSigner", encoded}, plumbing.params)
+}
+
+func TestMultisigApprove(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addrGetter := address.NewForTestGetter()
+	msAddr := addrGetter()
+	from := addrGetter()
+
+	t.Run("sends the approval of the transaction", func(t *testing.T) {
+		plumbing := newMsTestPlumbing()
+
+		msgCid, err := MultisigApprove(ctx, plumbing, from, msAddr, 7, types.NewGasPrice(1), types.NewGasUnits(300))
+		require.NoError(t, err)
+		assert.Equal(t, plumbing.msgCid, msgCid)
+
+		assert.Equal(t, from, plumbing.from)
+		assert.Equal(t, msAddr, plumbing.to)
+		assert.Equal(t, "approve", plumbing.method)
+		assert.Equal(t, []interface{}{uint64(7)}, plumbing.params)
+	})
+
+	t.Run("returns the error of a failed approval", func(t *testing.T) {
+		plumbing := newMsTestPlumbing()
+		plumbing.receipt.ExitCode = multisig.ErrAlreadyApproved
+
+		_, err := MultisigApprove(ctx, plumbing, from, msAddr, 7, types.NewGasPrice(1), types.NewGasUnits(300))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), multisig.Errors[multisig.ErrAlreadyApproved].Error())
+	})
+}
+
+func TestMultisigLs(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addrGetter := address.NewForTestGetter()
+	msAddr := addrGetter()
+	signers := []address.Address{addrGetter(), addrGetter()}
+
+	plumbing := newMsTestPlumbing()
+	plumbing.state = multisig.NewState(signers, 2, types.NewAttoFILFromFIL(100), types.NewBlockHeight(1), types.NewBlockHeight(10))
+	plumbing.state.Transactions = []*multisig.Transaction{{
+		ID:       3,
+		To:       signers[0],
+		Value:    types.NewAttoFILFromFIL(5),
+		Approved: []address.Address{signers[1]},
+	}}
+
+	state, err := MultisigLs(ctx, plumbing, msAddr)
+	require.NoError(t, err)
+
+	assert.Equal(t, msAddr, plumbing.to)
+	assert.Equal(t, "getState", plumbing.method)
+	assert.Equal(t, signers, state.Signers)
+	assert.Equal(t, uint64(2), state.Threshold)
+	require.Len(t, state.Transactions, 1)
+	assert.Equal(t, uint64(3), state.Transactions[0].ID)
+	assert.Equal(t, []address.Address{signers[1]}, state.Transactions[0].Approved)
+}
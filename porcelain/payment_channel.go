@@ -2,12 +2,17 @@ package porcelain
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/types"
+	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
 type pclPlumbing interface {
@@ -53,11 +58,16 @@ func PaymentChannelLs(
 
 type pcvPlumbing interface {
 	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	PaymentChannelAddIssuedVoucher(voucher *types.PaymentVoucher) error
+	PaymentChannelSync(payer address.Address, channel *types.ChannelID, pc *paymentbroker.PaymentChannel) error
 	SignBytes(data []byte, addr address.Address) (types.Signature, error)
 	WalletDefaultAddress() (address.Address, error)
 }
 
-// PaymentChannelVoucher returns a signed payment channel voucher
+// PaymentChannelVoucher returns a signed payment channel voucher. The voucher
+// may merge other lanes of the channel into its own, its amount then covering
+// the funds of those lanes. The voucher is recorded in the local payment
+// channel store, which refuses vouchers for more than the channel balance.
 func PaymentChannelVoucher(
 	ctx context.Context,
 	plumbing pcvPlumbing,
@@ -65,6 +75,9 @@ func PaymentChannelVoucher(
 	channel *types.ChannelID,
 	amount types.AttoFIL,
 	validAt *types.BlockHeight,
+	lane uint64,
+	nonce uint64,
+	merges []types.VoucherMerge,
 	condition *types.Predicate,
 ) (voucher *types.PaymentVoucher, err error) {
 	if fromAddr.Empty() {
@@ -74,6 +87,10 @@ func PaymentChannelVoucher(
 		}
 	}
 
+	if _, err := syncPaymentChannel(ctx, plumbing, fromAddr, fromAddr, channel); err != nil {
+		return nil, err
+	}
+
 	values, err := plumbing.MessageQuery(
 		ctx,
 		fromAddr,
@@ -89,11 +106,133 @@ func PaymentChannelVoucher(
 		return nil, err
 	}
 
-	sig, err := paymentbroker.SignVoucher(channel, amount, validAt, fromAddr, condition, plumbing)
+	voucher.Lane = lane
+	voucher.Nonce = nonce
+	voucher.Merges = merges
+
+	sig, err := paymentbroker.SignVoucher(channel, amount, validAt, lane, nonce, merges, fromAddr, condition, plumbing)
 	if err != nil {
 		return nil, err
 	}
 	voucher.Signature = sig
 
+	if err := plumbing.PaymentChannelAddIssuedVoucher(voucher); err != nil {
+		return nil, err
+	}
+
 	return voucher, nil
 }
+
+type pcafPlumbing interface {
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+	PaymentChannelSync(payer address.Address, channel *types.ChannelID, pc *paymentbroker.PaymentChannel) error
+	WalletDefaultAddress() (address.Address, error)
+}
+
+// PaymentChannelAddFunds adds amount to the funds of a payment channel created
+// by fromAddr and waits for the message to be mined. The new channel total is
+// then recorded in the local payment channel store, so that vouchers up to it
+// can be issued.
+func PaymentChannelAddFunds(
+	ctx context.Context,
+	plumbing pcafPlumbing,
+	fromAddr address.Address,
+	channel *types.ChannelID,
+	amount types.AttoFIL,
+	gasPrice types.AttoFIL,
+	gasLimit types.GasUnits,
+) (_ cid.Cid, err error) {
+	if fromAddr.Empty() {
+		fromAddr, err = plumbing.WalletDefaultAddress()
+		if err != nil {
+			return cid.Undef, err
+		}
+	}
+
+	msgCid, err := plumbing.MessageSend(ctx, fromAddr, address.PaymentBrokerAddress, amount, gasPrice, gasLimit, "addFunds", channel)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "couldn't send message")
+	}
+
+	err = plumbing.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, paymentbroker.Errors)
+		}
+		return nil
+	})
+	if err != nil {
+		return msgCid, err
+	}
+
+	if _, err := syncPaymentChannel(ctx, plumbing, fromAddr, fromAddr, channel); err != nil {
+		return msgCid, err
+	}
+	return msgCid, nil
+}
+
+type pcsPlumbing interface {
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	PaymentChannelInfo(payer address.Address, channel *types.ChannelID) (*paych.ChannelInfo, error)
+	PaymentChannelSync(payer address.Address, channel *types.ChannelID, pc *paymentbroker.PaymentChannel) error
+	WalletDefaultAddress() (address.Address, error)
+}
+
+// PaymentChannelStatus returns the state of a payment channel we are payer or
+// target of, with the vouchers issued and received for it. The on-chain state
+// of the channel is refreshed in the local payment channel store first.
+func PaymentChannelStatus(
+	ctx context.Context,
+	plumbing pcsPlumbing,
+	fromAddr address.Address,
+	payerAddr address.Address,
+	channel *types.ChannelID,
+) (*paych.ChannelInfo, error) {
+	var err error
+	if fromAddr.Empty() {
+		fromAddr, err = plumbing.WalletDefaultAddress()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if payerAddr.Empty() {
+		payerAddr = fromAddr
+	}
+
+	found, err := syncPaymentChannel(ctx, plumbing, fromAddr, payerAddr, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := plumbing.PaymentChannelInfo(payerAddr, channel)
+	if err == paych.ErrChannelNotFound && !found {
+		return nil, fmt.Errorf("no payment channel %s for payer %s", channel, payerAddr)
+	}
+	return info, err
+}
+
+type pcSyncPlumbing interface {
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	PaymentChannelSync(payer address.Address, channel *types.ChannelID, pc *paymentbroker.PaymentChannel) error
+	WalletDefaultAddress() (address.Address, error)
+}
+
+// syncPaymentChannel records the on-chain state of a payment channel in the
+// local payment channel store. It returns false if the channel is not on chain.
+func syncPaymentChannel(ctx context.Context, plumbing pcSyncPlumbing, fromAddr, payerAddr address.Address, channel *types.ChannelID) (bool, error) {
+	channels, err := PaymentChannelLs(ctx, plumbing, fromAddr, payerAddr)
+	if err != nil {
+		return false, errors.Wrap(err, "could not get payment channels of payer")
+	}
+
+	pc, ok := channels[channel.KeyString()]
+	if !ok {
+		return false, nil
+	}
+	if err := plumbing.PaymentChannelSync(payerAddr, channel, pc); err != nil {
+		return false, errors.Wrap(err, "could not track payment channel")
+	}
+	return true, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -11,7 +12,9 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/repo"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -50,16 +53,63 @@ func TestPaymentChannelLs(t *testing.T) {
 }
 
 type testPaymentChannelVoucherPlumbing struct {
-	testing *testing.T
-	voucher *types.PaymentVoucher
+	testing  *testing.T
+	voucher  *types.PaymentVoucher
+	channels map[string]*paymentbroker.PaymentChannel
+	manager  *paych.Manager
+}
+
+func newTestPaymentChannelVoucherPlumbing(t *testing.T, voucher *types.PaymentVoucher, channelAmount types.AttoFIL) *testPaymentChannelVoucherPlumbing {
+	return &testPaymentChannelVoucherPlumbing{
+		testing: t,
+		voucher: voucher,
+		channels: map[string]*paymentbroker.PaymentChannel{
+			voucher.Channel.KeyString(): {
+				Target:         voucher.Target,
+				Amount:         channelAmount,
+				AmountRedeemed: types.ZeroAttoFIL,
+				Eol:            types.NewBlockHeight(100),
+			},
+		},
+		manager: paych.New(repo.NewInMemoryRepo().Datastore()),
+	}
 }
 
 func (p *testPaymentChannelVoucherPlumbing) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	if method == "ls" {
+		chnls, err := cbor.DumpObject(p.channels)
+		require.NoError(p.testing, err)
+		return [][]byte{chnls}, nil
+	}
+
 	result, err := actor.MarshalStorage(p.voucher)
 	require.NoError(p.testing, err)
 	return [][]byte{result}, nil
 }
 
+func (p *testPaymentChannelVoucherPlumbing) MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	require.Equal(p.testing, "addFunds", method)
+	channel := p.channels[params[0].(*types.ChannelID).KeyString()]
+	channel.Amount = channel.Amount.Add(value)
+	return types.SomeCid(), nil
+}
+
+func (p *testPaymentChannelVoucherPlumbing) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+	return cb(&types.Block{}, &types.SignedMessage{}, &types.MessageReceipt{ExitCode: 0})
+}
+
+func (p *testPaymentChannelVoucherPlumbing) PaymentChannelAddIssuedVoucher(voucher *types.PaymentVoucher) error {
+	return p.manager.AddIssuedVoucher(voucher)
+}
+
+func (p *testPaymentChannelVoucherPlumbing) PaymentChannelInfo(payer address.Address, channel *types.ChannelID) (*paych.ChannelInfo, error) {
+	return p.manager.Get(payer, channel)
+}
+
+func (p *testPaymentChannelVoucherPlumbing) PaymentChannelSync(payer address.Address, channel *types.ChannelID, pc *paymentbroker.PaymentChannel) error {
+	return p.manager.Sync(payer, channel, pc)
+}
+
 func (p *testPaymentChannelVoucherPlumbing) SignBytes(data []byte, addr address.Address) (types.Signature, error) {
 	return []byte("test"), nil
 }
@@ -86,10 +136,7 @@ func TestPaymentChannelVoucher(t *testing.T) {
 			},
 		}
 
-		plumbing := &testPaymentChannelVoucherPlumbing{
-			testing: t,
-			voucher: expectedVoucher,
-		}
+		plumbing := newTestPaymentChannelVoucherPlumbing(t, expectedVoucher, types.NewAttoFILFromFIL(10))
 		ctx := context.Background()
 
 		voucher, err := porcelain.PaymentChannelVoucher(
@@ -99,6 +146,9 @@ func TestPaymentChannelVoucher(t *testing.T) {
 			types.NewChannelID(5),
 			types.NewAttoFILFromFIL(10),
 			types.NewBlockHeight(0),
+			2,
+			1,
+			[]types.VoucherMerge{{Lane: 0, Nonce: 3}},
 			&types.Predicate{
 				To:     address.Undef,
 				Method: "someMethod",
@@ -111,9 +161,76 @@ func TestPaymentChannelVoucher(t *testing.T) {
 		assert.Equal(t, expectedVoucher.Target, voucher.Target)
 		assert.Equal(t, expectedVoucher.Amount, voucher.Amount)
 		assert.Equal(t, expectedVoucher.ValidAt, voucher.ValidAt)
+		assert.Equal(t, uint64(2), voucher.Lane)
+		assert.Equal(t, uint64(1), voucher.Nonce)
+		assert.Equal(t, []types.VoucherMerge{{Lane: 0, Nonce: 3}}, voucher.Merges)
 		assert.Equal(t, expectedVoucher.Condition.To, voucher.Condition.To)
 		assert.Equal(t, expectedVoucher.Condition.Method, voucher.Condition.Method)
 		assert.Equal(t, expectedVoucher.Condition.Params, voucher.Condition.Params)
 		assert.NotEqual(t, expectedVoucher.Signature, voucher.Signature)
+
+		status, err := porcelain.PaymentChannelStatus(ctx, plumbing, address.Undef, address.Undef, types.NewChannelID(5))
+		require.NoError(t, err)
+		assert.Equal(t, types.NewAttoFILFromFIL(10), status.Amount)
+		assert.Equal(t, types.NewBlockHeight(100), status.Eol)
+		require.Len(t, status.Issued, 1)
+		assert.Equal(t, voucher.Signature, status.Issued[0].Signature)
+	})
+
+	t.Run("refuses vouchers above the channel balance", func(t *testing.T) {
+		expectedVoucher := &types.PaymentVoucher{
+			Channel: *types.NewChannelID(5),
+			Amount:  types.NewAttoFILFromFIL(10),
+		}
+		plumbing := newTestPaymentChannelVoucherPlumbing(t, expectedVoucher, types.NewAttoFILFromFIL(5))
+
+		_, err := porcelain.PaymentChannelVoucher(
+			context.Background(),
+			plumbing,
+			address.Undef,
+			types.NewChannelID(5),
+			types.NewAttoFILFromFIL(10),
+			types.NewBlockHeight(0),
+			0,
+			0,
+			nil,
+			nil,
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), paych.ErrInsufficientFunds.Error())
+	})
+}
+
+func TestPaymentChannelAddFunds(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	expectedVoucher := &types.PaymentVoucher{
+		Channel: *types.NewChannelID(5),
+		Amount:  types.NewAttoFILFromFIL(10),
+	}
+	plumbing := newTestPaymentChannelVoucherPlumbing(t, expectedVoucher, types.NewAttoFILFromFIL(5))
+
+	_, err := porcelain.PaymentChannelAddFunds(ctx, plumbing, address.Undef, types.NewChannelID(5), types.NewAttoFILFromFIL(5), types.NewGasPrice(1), types.NewGasUnits(300))
+	require.NoError(t, err)
+
+	// the new total is tracked before any voucher is issued
+	status, err := porcelain.PaymentChannelStatus(ctx, plumbing, address.Undef, address.Undef, types.NewChannelID(5))
+	require.NoError(t, err)
+	assert.Equal(t, types.NewAttoFILFromFIL(10), status.Amount)
+
+	_, err = porcelain.PaymentChannelVoucher(ctx, plumbing, address.Undef, types.NewChannelID(5), types.NewAttoFILFromFIL(10), types.NewBlockHeight(0), 0, 0, nil, nil)
+	require.NoError(t, err)
+}
+
+func TestPaymentChannelStatus(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("fails for unknown channels", func(t *testing.T) {
+		plumbing := newTestPaymentChannelVoucherPlumbing(t, &types.PaymentVoucher{Channel: *types.NewChannelID(5)}, types.NewAttoFILFromFIL(5))
+
+		_, err := porcelain.PaymentChannelStatus(context.Background(), plumbing, address.Undef, address.Undef, types.NewChannelID(6))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no payment channel 6")
 	})
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
@@ -22,7 +23,12 @@ type cpPlumbing interface {
 	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
 	MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
 	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+	PaymentChannelAddIssuedVoucher(voucher *types.PaymentVoucher) error
+	PaymentChannelAllocateLane(payer address.Address, channel *types.ChannelID) (uint64, error)
+	PaymentChannelInfo(payer address.Address, channel *types.ChannelID) (*paych.ChannelInfo, error)
+	PaymentChannelSync(payer address.Address, channel *types.ChannelID, pc *paymentbroker.PaymentChannel) error
 	SignBytes(data []byte, addr address.Address) (types.Signature, error)
+	WalletDefaultAddress() (address.Address, error)
 }
 
 // CreatePaymentsParams structures all the parameters for the CreatePayments command. All values are required.
@@ -59,6 +65,11 @@ type CreatePaymentsParams struct {
 	// be greater than the current block height plus Duration.
 	ChannelExpiry types.BlockHeight
 
+	// ExistingChannel is an optional payment channel from From to To to pay with
+	// instead of creating a new one. The payments then use a new lane of the channel,
+	// and the channel funds not committed to its other lanes must cover Value.
+	ExistingChannel *types.ChannelID
+
 	// GasPrice is the price of gas to be paid to create the payment channel
 	GasPrice types.AttoFIL
 
@@ -74,9 +85,13 @@ type CreatePaymentsReturn struct {
 	// Channel is the id of the payment channel
 	Channel *types.ChannelID
 
-	// ChannelMsgCid is the id of the message sent to create the payment channel
+	// ChannelMsgCid is the id of the message sent to create the payment channel. It is
+	// undefined when paying with an existing channel.
 	ChannelMsgCid cid.Cid
 
+	// Lane is the lane of the payment channel the vouchers pay on
+	Lane uint64
+
 	// GasAttoFIL is the amount spent on gas creating the channel
 	GasAttoFIL types.AttoFIL
 
@@ -85,6 +100,8 @@ type CreatePaymentsReturn struct {
 }
 
 // CreatePayments establishes a payment channel and creates multiple payments against it.
+// If an existing channel is given, a new lane of that channel is used instead, so that
+// several deals can be paid from one funded channel.
 //
 // Each payment except the last will get a condition that calls verifyPieceInclusion on the recipient's miner
 // actor to ensure the storage miner is still storing the file at the time of redemption.
@@ -110,7 +127,7 @@ func CreatePayments(ctx context.Context, plumbing cpPlumbing, config CreatePayme
 
 	// validate that channel expiry gives us enough time
 	lastPayment := currentHeight.Add(types.NewBlockHeight(config.Duration))
-	if config.ChannelExpiry.LessThan(lastPayment) {
+	if config.ExistingChannel == nil && config.ChannelExpiry.LessThan(lastPayment) {
 		return nil, fmt.Errorf("channel would expire (%s) before last payment is made (%s)", config.ChannelExpiry.String(), lastPayment)
 	}
 
@@ -118,32 +135,21 @@ func CreatePayments(ctx context.Context, plumbing cpPlumbing, config CreatePayme
 		CreatePaymentsParams: config,
 	}
 
-	// Create channel
-	response.ChannelMsgCid, err = plumbing.MessageSend(ctx,
-		config.From,
-		address.PaymentBrokerAddress,
-		config.Value,
-		config.GasPrice,
-		config.GasLimit,
-		"createChannel",
-		config.To,
-		&config.ChannelExpiry)
+	if config.ExistingChannel != nil {
+		response.Channel = config.ExistingChannel
+		err = checkExistingChannel(ctx, plumbing, config, lastPayment)
+	} else {
+		err = createChannel(ctx, plumbing, config, response)
+	}
 	if err != nil {
 		return response, err
 	}
 
-	// wait for response
-	err = plumbing.MessageWait(ctx, response.ChannelMsgCid, func(block *types.Block, message *types.SignedMessage, receipt *types.MessageReceipt) error {
-		if receipt.ExitCode != 0 {
-			return fmt.Errorf("createChannel failed %d", receipt.ExitCode)
-		}
-
-		response.Channel = types.NewChannelIDFromBytes(receipt.Return[0])
-		response.GasAttoFIL = receipt.GasAttoFIL
-		return nil
-	})
+	// each series of payments gets its own lane, so that its amounts add up
+	// with those of other payments from the same channel
+	response.Lane, err = plumbing.PaymentChannelAllocateLane(config.From, response.Channel)
 	if err != nil {
-		return response, err
+		return response, errors.Wrap(err, "could not allocate payment channel lane")
 	}
 
 	// compute value per payment. Roughly value/num payments. Exactly ceil(value*interval/duration).
@@ -169,7 +175,7 @@ func CreatePayments(ctx context.Context, plumbing cpPlumbing, config CreatePayme
 		}
 
 		validAt := currentHeight.Add(types.NewBlockHeight(uint64(i+1) * config.PaymentInterval))
-		err = createPayment(ctx, plumbing, response, voucherAmount, validAt, condition)
+		err = createPayment(ctx, plumbing, response, voucherAmount, validAt, uint64(i), condition)
 		if err != nil {
 			return response, err
 		}
@@ -177,7 +183,7 @@ func CreatePayments(ctx context.Context, plumbing cpPlumbing, config CreatePayme
 
 	// create last payment
 	validAt := currentHeight.Add(types.NewBlockHeight(config.Duration))
-	err = createPayment(ctx, plumbing, response, config.Value, validAt, nil)
+	err = createPayment(ctx, plumbing, response, config.Value, validAt, uint64(len(response.Vouchers)), nil)
 	if err != nil {
 		return response, err
 	}
@@ -185,7 +191,80 @@ func CreatePayments(ctx context.Context, plumbing cpPlumbing, config CreatePayme
 	return response, nil
 }
 
-func createPayment(ctx context.Context, plumbing cpPlumbing, response *CreatePaymentsReturn, amount types.AttoFIL, validAt *types.BlockHeight, condition *types.Predicate) error {
+// createChannel creates a new payment channel funded with the payments value
+// and starts tracking it.
+func createChannel(ctx context.Context, plumbing cpPlumbing, config CreatePaymentsParams, response *CreatePaymentsReturn) error {
+	var err error
+	response.ChannelMsgCid, err = plumbing.MessageSend(ctx,
+		config.From,
+		address.PaymentBrokerAddress,
+		config.Value,
+		config.GasPrice,
+		config.GasLimit,
+		"createChannel",
+		config.To,
+		&config.ChannelExpiry)
+	if err != nil {
+		return err
+	}
+
+	// wait for response
+	err = plumbing.MessageWait(ctx, response.ChannelMsgCid, func(block *types.Block, message *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != 0 {
+			return fmt.Errorf("createChannel failed %d", receipt.ExitCode)
+		}
+
+		response.Channel = types.NewChannelIDFromBytes(receipt.Return[0])
+		response.GasAttoFIL = receipt.GasAttoFIL
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// track the new channel so the vouchers issued against it are recorded
+	err = plumbing.PaymentChannelSync(config.From, response.Channel, &paymentbroker.PaymentChannel{
+		Target:         config.To,
+		Amount:         config.Value,
+		AmountRedeemed: types.ZeroAttoFIL,
+		Eol:            &config.ChannelExpiry,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not track payment channel")
+	}
+	return nil
+}
+
+// checkExistingChannel syncs an existing payment channel from the chain and
+// checks it can carry the payments.
+func checkExistingChannel(ctx context.Context, plumbing cpPlumbing, config CreatePaymentsParams, lastPayment *types.BlockHeight) error {
+	found, err := syncPaymentChannel(ctx, plumbing, config.From, config.From, config.ExistingChannel)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("payment channel %s of %s not found", config.ExistingChannel, config.From)
+	}
+
+	info, err := plumbing.PaymentChannelInfo(config.From, config.ExistingChannel)
+	if err != nil {
+		return err
+	}
+	if info.Target != config.To {
+		return fmt.Errorf("payment channel target is %s, not %s", info.Target, config.To)
+	}
+	if info.Eol.LessThan(lastPayment) {
+		return fmt.Errorf("channel would expire (%s) before last payment is made (%s)", info.Eol, lastPayment)
+	}
+
+	available := info.Amount.Sub(info.IssuedAmount())
+	if available.LessThan(config.Value) {
+		return errors.Wrapf(paych.ErrInsufficientFunds, "channel has %s available but payments need %s", available, config.Value)
+	}
+	return nil
+}
+
+func createPayment(ctx context.Context, plumbing cpPlumbing, response *CreatePaymentsReturn, amount types.AttoFIL, validAt *types.BlockHeight, nonce uint64, condition *types.Predicate) error {
 	ret, err := plumbing.MessageQuery(ctx,
 		response.From,
 		address.PaymentBrokerAddress,
@@ -204,12 +283,19 @@ func createPayment(ctx context.Context, plumbing cpPlumbing, response *CreatePay
 		return err
 	}
 
-	sig, err := paymentbroker.SignVoucher(&voucher.Channel, amount, validAt, voucher.Payer, condition, plumbing)
+	voucher.Lane = response.Lane
+	voucher.Nonce = nonce
+
+	sig, err := paymentbroker.SignVoucher(&voucher.Channel, amount, validAt, voucher.Lane, voucher.Nonce, voucher.Merges, voucher.Payer, condition, plumbing)
 	if err != nil {
 		return err
 	}
 	voucher.Signature = sig
 
+	if err := plumbing.PaymentChannelAddIssuedVoucher(&voucher); err != nil {
+		return err
+	}
+
 	response.Vouchers = append(response.Vouchers, &voucher)
 	return nil
 }
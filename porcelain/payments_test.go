@@ -9,7 +9,9 @@ import (
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	. "github.com/filecoin-project/go-filecoin/porcelain"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -26,6 +28,9 @@ const (
 type paymentsTestPlumbing struct {
 	height *types.BlockHeight
 	msgCid cid.Cid
+	synced map[string]*paymentbroker.PaymentChannel
+	issued []*types.PaymentVoucher
+	lanes  uint64
 
 	messageSend  func(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
 	messageWait  func(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
@@ -41,6 +46,7 @@ func newTestCreatePaymentsPlumbing() *paymentsTestPlumbing {
 	return &paymentsTestPlumbing{
 		msgCid: msgCid,
 		height: types.NewBlockHeight(startingBlock),
+		synced: make(map[string]*paymentbroker.PaymentChannel),
 		messageSend: func(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
 			payer = from
 			target = params[0].(address.Address)
@@ -87,6 +93,43 @@ func (ptp *paymentsTestPlumbing) ChainBlockHeight() (*types.BlockHeight, error)
 	return ptp.height, nil
 }
 
+func (ptp *paymentsTestPlumbing) PaymentChannelAddIssuedVoucher(voucher *types.PaymentVoucher) error {
+	ptp.issued = append(ptp.issued, voucher)
+	return nil
+}
+
+func (ptp *paymentsTestPlumbing) PaymentChannelAllocateLane(payer address.Address, channel *types.ChannelID) (uint64, error) {
+	lane := ptp.lanes
+	ptp.lanes++
+	return lane, nil
+}
+
+func (ptp *paymentsTestPlumbing) PaymentChannelInfo(payer address.Address, channel *types.ChannelID) (*paych.ChannelInfo, error) {
+	pc, ok := ptp.synced[channel.KeyString()]
+	if !ok {
+		return nil, paych.ErrChannelNotFound
+	}
+	return &paych.ChannelInfo{
+		Payer:          payer,
+		Channel:        channel,
+		Target:         pc.Target,
+		Amount:         pc.Amount,
+		AmountRedeemed: pc.AmountRedeemed,
+		Eol:            pc.Eol,
+		Issued:         ptp.issued,
+		NextLane:       ptp.lanes,
+	}, nil
+}
+
+func (ptp *paymentsTestPlumbing) PaymentChannelSync(payer address.Address, channel *types.ChannelID, pc *paymentbroker.PaymentChannel) error {
+	ptp.synced[channel.KeyString()] = pc
+	return nil
+}
+
+func (ptp *paymentsTestPlumbing) WalletDefaultAddress() (address.Address, error) {
+	return address.Undef, nil
+}
+
 func (ptp *paymentsTestPlumbing) SignBytes(data []byte, addr address.Address) (types.Signature, error) {
 	return []byte("signature"), nil
 }
@@ -146,6 +189,8 @@ func TestCreatePayments(t *testing.T) {
 			assert.Equal(t, config.To, voucher.Target)
 			assert.Equal(t, *types.NewBlockHeight(startingBlock).Add(types.NewBlockHeight(config.PaymentInterval * uint64(i+1))), voucher.ValidAt)
 			assert.Equal(t, expectedValuePerPayment.MulBigInt(big.NewInt(int64(i+1))), voucher.Amount)
+			assert.Equal(t, uint64(0), voucher.Lane)
+			assert.Equal(t, uint64(i), voucher.Nonce)
 
 			// assert all vouchers other than the last have a valid condition
 			assert.NotNil(t, voucher.Condition)
@@ -164,6 +209,13 @@ func TestCreatePayments(t *testing.T) {
 		assert.Equal(t, config.To, paymentResponse.Vouchers[9].Target)
 		assert.Equal(t, config.Value, paymentResponse.Vouchers[9].Amount)
 		assert.Nil(t, paymentResponse.Vouchers[9].Condition)
+
+		// the channel and its vouchers are tracked
+		channel, ok := successPlumbing.synced[paymentResponse.Channel.KeyString()]
+		require.True(t, ok)
+		assert.Equal(t, config.To, channel.Target)
+		assert.Equal(t, config.Value, channel.Amount)
+		assert.Equal(t, paymentResponse.Vouchers, successPlumbing.issued)
 	})
 
 	t.Run("Payments constructed correctly when paymentInterval does not divide duration", func(t *testing.T) {
@@ -292,3 +344,74 @@ func TestCreatePayments(t *testing.T) {
 		assert.Contains(t, err.Error(), "MessageQuery")
 	})
 }
+
+func TestCreatePaymentsWithExistingChannel(t *testing.T) {
+	tf.UnitTest(t)
+
+	newPlumbing := func(target address.Address, amount uint64) *paymentsTestPlumbing {
+		plumbing := newTestCreatePaymentsPlumbing()
+		plumbing.messageSend = func(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+			return cid.Undef, errors.New("no message should be sent")
+		}
+		voucherQuery := plumbing.messageQuery
+		plumbing.messageQuery = func(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+			if method != "ls" {
+				return voucherQuery(ctx, optFrom, to, method, params...)
+			}
+			channels := map[string]*paymentbroker.PaymentChannel{
+				types.NewChannelID(channelID).KeyString(): {
+					Target:         target,
+					Amount:         types.NewAttoFILFromFIL(amount),
+					AmountRedeemed: types.ZeroAttoFIL,
+					Eol:            types.NewBlockHeight(1000),
+				},
+			}
+			channelsBytes, err := actor.MarshalStorage(channels)
+			require.NoError(t, err)
+			return [][]byte{channelsBytes}, nil
+		}
+		return plumbing
+	}
+
+	t.Run("pays on a new lane of the channel", func(t *testing.T) {
+		config := validPaymentsConfig()
+		config.ExistingChannel = types.NewChannelID(channelID)
+		plumbing := newPlumbing(config.To, 200)
+		plumbing.lanes = 1
+
+		paymentResponse, err := CreatePayments(context.Background(), plumbing, config)
+		require.NoError(t, err)
+
+		assert.False(t, paymentResponse.ChannelMsgCid.Defined())
+		assert.Equal(t, config.ExistingChannel, paymentResponse.Channel)
+		assert.Equal(t, uint64(1), paymentResponse.Lane)
+		require.Len(t, paymentResponse.Vouchers, 10)
+		for _, voucher := range paymentResponse.Vouchers {
+			assert.Equal(t, uint64(1), voucher.Lane)
+		}
+	})
+
+	t.Run("refuses channels without enough uncommitted funds", func(t *testing.T) {
+		config := validPaymentsConfig()
+		config.ExistingChannel = types.NewChannelID(channelID)
+		plumbing := newPlumbing(config.To, 200)
+		plumbing.issued = []*types.PaymentVoucher{{
+			Channel: *config.ExistingChannel,
+			Amount:  types.NewAttoFILFromFIL(150),
+		}}
+
+		_, err := CreatePayments(context.Background(), plumbing, config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), paych.ErrInsufficientFunds.Error())
+	})
+
+	t.Run("refuses channels to another target", func(t *testing.T) {
+		config := validPaymentsConfig()
+		config.ExistingChannel = types.NewChannelID(channelID)
+		plumbing := newPlumbing(config.From, 200)
+
+		_, err := CreatePayments(context.Background(), plumbing, config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "payment channel target")
+	})
+}
@@ -156,13 +156,26 @@ func buildDealRedeemParams(ctx context.Context, plumbing dealRedeemPlumbing, dea
 		return []interface{}{}, errors.New("no remaining redeemable vouchers found")
 	}
 
+	// conditional vouchers require the miner to prove it stores the piece
+	redeemerParams := []interface{}{}
+	if voucher.Condition != nil {
+		proofInfo := deal.Response.ProofInfo
+		if proofInfo == nil || len(proofInfo.PieceInclusionProof) == 0 {
+			return []interface{}{}, errors.New("voucher is conditional but deal has no piece inclusion proof yet")
+		}
+		redeemerParams = []interface{}{proofInfo.SectorID, proofInfo.PieceInclusionProof}
+	}
+
 	return []interface{}{
 		voucher.Payer,
 		&voucher.Channel,
 		voucher.Amount,
 		&voucher.ValidAt,
+		voucher.Lane,
+		voucher.Nonce,
+		voucher.Merges,
 		voucher.Condition,
 		[]byte(voucher.Signature),
-		[]interface{}{},
+		redeemerParams,
 	}, nil
 }
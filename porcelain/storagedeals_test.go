@@ -87,6 +87,7 @@ type testRedeemPlumbing struct {
 	gasPrice    types.GasUnits
 	messageCid  cid.Cid
 	vouchers    []*types.PaymentVoucher
+	proofInfo   *storagedeal.ProofInfo
 
 	ResultingFromAddr       address.Address
 	ResultingActorAddr      address.Address
@@ -95,6 +96,7 @@ type testRedeemPlumbing struct {
 	ResultingVoucherChannel *types.ChannelID
 	ResultingVoucherAmount  types.AttoFIL
 	ResultingVoucherValidAt *types.BlockHeight
+	ResultingRedeemerParams []interface{}
 }
 
 func (trp *testRedeemPlumbing) ChainBlockHeight() (*types.BlockHeight, error) {
@@ -110,6 +112,9 @@ func (trp *testRedeemPlumbing) DealGet(_ context.Context, c cid.Cid) (*storagede
 				Vouchers: trp.vouchers,
 			},
 		},
+		Response: &storagedeal.Response{
+			ProofInfo: trp.proofInfo,
+		},
 	}
 
 	return deal, nil
@@ -134,6 +139,7 @@ func (trp *testRedeemPlumbing) MessageSend(_ context.Context, fromAddr address.A
 	trp.ResultingVoucherChannel = params[1].(*types.ChannelID)
 	trp.ResultingVoucherAmount = params[2].(types.AttoFIL)
 	trp.ResultingVoucherValidAt = params[3].(*types.BlockHeight)
+	trp.ResultingRedeemerParams = params[9].([]interface{})
 	return trp.messageCid, nil
 }
 
@@ -192,6 +198,43 @@ func TestDealRedeem(t *testing.T) {
 	assert.Equal(t, messageCid, resultCid)
 }
 
+func TestDealRedeemConditionalVoucher(t *testing.T) {
+	tf.UnitTest(t)
+
+	addressGetter := address.NewForTestGetter()
+	cidGetter := types.NewCidForTestGetter()
+	dealCid := cidGetter()
+	fromAddr := addressGetter()
+	voucher := &types.PaymentVoucher{
+		Payer:     addressGetter(),
+		Channel:   *types.NewChannelID(0),
+		Amount:    types.NewAttoFILFromFIL(1),
+		ValidAt:   *types.NewBlockHeight(10),
+		Condition: &types.Predicate{To: addressGetter(), Method: "verifyPieceInclusion"},
+	}
+	plumbing := &testRedeemPlumbing{
+		t:           t,
+		blockHeight: types.NewBlockHeight(25),
+		dealCid:     dealCid,
+		messageCid:  cidGetter(),
+		vouchers:    []*types.PaymentVoucher{voucher},
+	}
+
+	t.Run("fails without a piece inclusion proof", func(t *testing.T) {
+		_, err := porcelain.DealRedeem(context.Background(), plumbing, fromAddr, dealCid, types.NewAttoFILFromFIL(0), types.NewGasUnits(0))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no piece inclusion proof")
+	})
+
+	t.Run("supplies the piece inclusion proof", func(t *testing.T) {
+		plumbing.proofInfo = &storagedeal.ProofInfo{SectorID: 7, PieceInclusionProof: []byte{1, 2, 3}}
+
+		_, err := porcelain.DealRedeem(context.Background(), plumbing, fromAddr, dealCid, types.NewAttoFILFromFIL(0), types.NewGasUnits(0))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{uint64(7), []byte{1, 2, 3}}, plumbing.ResultingRedeemerParams)
+	})
+}
+
 func TestDealRedeemPreview(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -31,6 +31,7 @@ func newWdaTestPlumbing(t *testing.T) *wdaTestPlumbing {
 	repo := repo.NewInMemoryRepo()
 	backend, err := wallet.NewDSBackend(repo.WalletDatastore())
 	require.NoError(t, err)
+	require.NoError(t, backend.Unlock(nil, 0))
 	return &wdaTestPlumbing{
 		config: cfg.NewConfig(repo),
 		wallet: wallet.New(backend),
@@ -54,8 +55,8 @@ func (wdatp *wdaTestPlumbing) WalletAddresses() []address.Address {
 	return wdatp.wallet.Addresses()
 }
 
-func (wdatp *wdaTestPlumbing) WalletNewAddress() (address.Address, error) {
-	return wallet.NewAddress(wdatp.wallet)
+func (wdatp *wdaTestPlumbing) WalletNewAddress(protocol address.Protocol) (address.Address, error) {
+	return wallet.NewAddress(wdatp.wallet, protocol)
 }
 
 func TestWalletBalance(t *testing.T) {
@@ -81,7 +82,7 @@ func TestWalletDefaultAddress(t *testing.T) {
 	t.Run("it returns the configured wallet default if it exists", func(t *testing.T) {
 		wdatp := newWdaTestPlumbing(t)
 
-		addr, err := wdatp.WalletNewAddress()
+		addr, err := wdatp.WalletNewAddress(address.SECP256K1)
 		require.NoError(t, err)
 		err = wdatp.ConfigSet("wallet.defaultAddress", addr.String())
 		require.NoError(t, err)
@@ -95,7 +96,7 @@ func TestWalletDefaultAddress(t *testing.T) {
 
 		addresses := []address.Address{}
 		for i := 0; i < 10; i++ {
-			a, err := wdatp.WalletNewAddress()
+			a, err := wdatp.WalletNewAddress(address.SECP256K1)
 			require.NoError(t, err)
 			addresses = append(addresses, a)
 		}
@@ -22,5 +22,5 @@ func NewAPI(rc *Client) API {
 
 // RetrievePiece retrieves bytes referenced by CID pieceCID
 func (a *API) RetrievePiece(ctx context.Context, pieceCID cid.Cid, mpid peer.ID, minerAddr address.Address) (io.ReadCloser, error) {
-	return a.rc.RetrievePiece(ctx, mpid, pieceCID)
+	return a.rc.RetrievePiece(ctx, minerAddr, mpid, pieceCID)
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	cbu "github.com/filecoin-project/go-filecoin/cborutil"
 	"github.com/filecoin-project/go-filecoin/net"
 )
@@ -24,6 +25,7 @@ import (
 const RetrievePieceChunkSize = 256 << 8
 
 type clientPorcelainAPI interface {
+	MinerConnect(ctx context.Context, minerAddr address.Address, minerPID peer.ID) error
 	PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error
 }
 
@@ -44,7 +46,12 @@ func NewClient(host host.Host, api clientPorcelainAPI) *Client {
 }
 
 // RetrievePiece connects to a miner and transfers a piece of content.
-func (sc *Client) RetrievePiece(ctx context.Context, minerPeerID peer.ID, pieceCID cid.Cid) (io.ReadCloser, error) {
+func (sc *Client) RetrievePiece(ctx context.Context, minerAddr address.Address, minerPeerID peer.ID, pieceCID cid.Cid) (io.ReadCloser, error) {
+	// a miner that cannot be reached fails the ping below
+	if err := sc.api.MinerConnect(ctx, minerAddr, minerPeerID); err != nil {
+		sc.log.Warningf("failed to connect to retrieval miner %s: %s", minerAddr, err)
+	}
+
 	err := sc.api.PingMinerWithTimeout(ctx, minerPeerID, 15*time.Second)
 	if err == net.ErrPingSelf {
 		return nil, errors.New("attempting to retrieve piece from self. This is currently unsupported.  Please use a separate go-filecoin node as client")
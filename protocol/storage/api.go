@@ -12,12 +12,14 @@ import (
 
 // API here is the API for a storage client.
 type API struct {
-	sc *Client
+	sc         *Client
+	renewer    *Renewer
+	replicator *Replicator
 }
 
 // NewAPI creates a new API for a storage client.
-func NewAPI(storageClient *Client) API {
-	return API{sc: storageClient}
+func NewAPI(storageClient *Client, renewer *Renewer, replicator *Replicator) API {
+	return API{sc: storageClient, renewer: renewer, replicator: replicator}
 }
 
 // ProposeStorageDeal calls the storage client ProposeDeal function
@@ -27,6 +29,11 @@ func (a *API) ProposeStorageDeal(ctx context.Context, data cid.Cid, miner addres
 	return a.sc.ProposeDeal(ctx, miner, data, askid, duration, allowDuplicates)
 }
 
+// StoreWithReplicas calls the replicator StoreReplicas function
+func (a *API) StoreWithReplicas(ctx context.Context, data cid.Cid, duration uint64, replicas int, allowDuplicates bool) (*ReplicationResult, error) {
+	return a.replicator.StoreReplicas(ctx, data, duration, replicas, allowDuplicates)
+}
+
 // QueryStorageDeal calls the storage client QueryDeal function
 func (a *API) QueryStorageDeal(ctx context.Context, prop cid.Cid) (*storagedeal.Response, error) {
 	return a.sc.QueryDeal(ctx, prop)
@@ -36,3 +43,8 @@ func (a *API) QueryStorageDeal(ctx context.Context, prop cid.Cid) (*storagedeal.
 func (a *API) Payments(ctx context.Context, dealCid cid.Cid) ([]*types.PaymentVoucher, error) {
 	return a.sc.LoadVouchersForDeal(ctx, dealCid)
 }
+
+// RenewStorageDeals calls the renewer RenewDeals function
+func (a *API) RenewStorageDeals(ctx context.Context, within uint64, duration uint64) (<-chan RenewalResult, error) {
+	return a.renewer.RenewDeals(ctx, within, duration)
+}
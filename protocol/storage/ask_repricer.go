@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+const (
+	// askRenewalMargin is the number of blocks before its expiry at which the
+	// repricing policy replaces the miner's live ask.
+	askRenewalMargin = 10
+
+	// TODO: replace this with a query to pick a reasonable gas price.
+	askGasPrice = 1
+)
+
+// askRepricerPorcelain is the subset of the porcelain API that AskRepricer needs.
+type askRepricerPorcelain interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	MessagePreview(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, error)
+	MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
+	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+	MinerGetAsks(ctx context.Context, minerAddr address.Address) ([]miner.Ask, error)
+}
+
+// AskRepricer implements the optional ask repricing policy of a storage miner.
+// When mining.autoRepriceAsks is set, it keeps exactly one live ask at the
+// configured mining.storagePrice, replacing it shortly before it expires and
+// canceling any other live asks.
+type AskRepricer struct {
+	minerAddr  address.Address
+	workerAddr address.Address
+	api        askRepricerPorcelain
+
+	// lk guards inProgress.
+	lk         sync.Mutex
+	inProgress bool
+}
+
+// NewAskRepricer creates a new ask repricer for the given miner.
+func NewAskRepricer(minerAddr, workerAddr address.Address, api askRepricerPorcelain) *AskRepricer {
+	return &AskRepricer{
+		minerAddr:  minerAddr,
+		workerAddr: workerAddr,
+		api:        api,
+	}
+}
+
+// OnNewHeaviestTipSet is a callback called by node, every time the latest head
+// is updated. If the repricing policy is enabled and no repricing is in
+// progress, it starts repricing the miner's asks in the background.
+func (ar *AskRepricer) OnNewHeaviestTipSet(ts types.TipSet) error {
+	enabled, err := ar.api.ConfigGet("mining.autoRepriceAsks")
+	if err != nil {
+		return err
+	}
+	if on, ok := enabled.(bool); !ok || !on {
+		return nil
+	}
+
+	height, err := ts.Height()
+	if err != nil {
+		return err
+	}
+
+	ar.lk.Lock()
+	defer ar.lk.Unlock()
+	if ar.inProgress {
+		return nil
+	}
+	ar.inProgress = true
+
+	go func() {
+		defer func() {
+			ar.lk.Lock()
+			ar.inProgress = false
+			ar.lk.Unlock()
+		}()
+
+		if err := ar.Reprice(context.Background(), types.NewBlockHeight(height)); err != nil {
+			log.Errorf("failed to reprice asks: %s", err)
+		}
+	}()
+	return nil
+}
+
+// Reprice makes sure that the miner has one live ask at the configured storage
+// price that does not expire within the renewal margin of the given height,
+// and cancels all other live asks. It waits for the messages it sends to be
+// mined.
+func (ar *AskRepricer) Reprice(ctx context.Context, height *types.BlockHeight) error {
+	price, err := ar.storagePrice()
+	if err != nil {
+		return err
+	}
+
+	durationVal, err := ar.api.ConfigGet("mining.askDurationBlocks")
+	if err != nil {
+		return err
+	}
+	duration, ok := durationVal.(uint64)
+	if !ok || duration <= askRenewalMargin {
+		return errors.Errorf("mining.askDurationBlocks must be greater than %d", askRenewalMargin)
+	}
+
+	asks, err := ar.api.MinerGetAsks(ctx, ar.minerAddr)
+	if err != nil {
+		return errors.Wrap(err, "failed to get asks")
+	}
+
+	renewBefore := height.Add(types.NewBlockHeight(askRenewalMargin))
+	hasCurrentAsk := false
+	var stale []*big.Int
+	for _, ask := range asks {
+		if ask.Expiry.LessEqual(height) {
+			// expired asks are removed by the actor when asks are added or canceled
+			continue
+		}
+		if !hasCurrentAsk && ask.Price.Equal(price) && ask.Expiry.GreaterThan(renewBefore) {
+			hasCurrentAsk = true
+			continue
+		}
+		stale = append(stale, ask.ID)
+	}
+
+	if !hasCurrentAsk {
+		log.Infof("adding ask at price %s for %d blocks", price, duration)
+		if err := ar.sendAndWait(ctx, "addAsk", price, big.NewInt(0).SetUint64(duration)); err != nil {
+			return errors.Wrap(err, "failed to add ask")
+		}
+	}
+
+	for _, id := range stale {
+		log.Infof("canceling ask %s", id)
+		if err := ar.sendAndWait(ctx, "cancelAsk", id); err != nil {
+			return errors.Wrapf(err, "failed to cancel ask %s", id)
+		}
+	}
+	return nil
+}
+
+func (ar *AskRepricer) storagePrice() (types.AttoFIL, error) {
+	storagePrice, err := ar.api.ConfigGet("mining.storagePrice")
+	if err != nil {
+		return types.ZeroAttoFIL, err
+	}
+	price, ok := storagePrice.(types.AttoFIL)
+	if !ok {
+		return types.ZeroAttoFIL, errors.New("could not retrieve storagePrice from config")
+	}
+	return price, nil
+}
+
+func (ar *AskRepricer) sendAndWait(ctx context.Context, method string, params ...interface{}) error {
+	gasLimit, err := ar.api.MessagePreview(ctx, ar.workerAddr, ar.minerAddr, method, params...)
+	if err != nil {
+		return errors.Wrapf(err, "failed to preview %s", method)
+	}
+
+	msgCid, err := ar.api.MessageSend(ctx, ar.workerAddr, ar.minerAddr, types.ZeroAttoFIL, types.NewGasPrice(askGasPrice), gasLimit, method, params...)
+	if err != nil {
+		return err
+	}
+
+	return ar.api.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+		if receipt.ExitCode != uint8(0) {
+			return vmErrors.VMExitCodeToError(receipt.ExitCode, miner.Errors)
+		}
+		return nil
+	})
+}
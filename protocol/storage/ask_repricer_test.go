@@ -0,0 +1,164 @@
+package storage_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/address"
+	. "github.com/filecoin-project/go-filecoin/protocol/storage"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestAskRepricer(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	price := types.NewAttoFILFromFIL(5)
+	height := types.NewBlockHeight(100)
+
+	t.Run("adds an ask when there is none", func(t *testing.T) {
+		api := newAskRepricerTestAPI(price)
+		repricer := NewAskRepricer(api.minerAddr, api.workerAddr, api)
+
+		require.NoError(t, repricer.Reprice(ctx, height))
+
+		require.Len(t, api.sent, 1)
+		assert.Equal(t, "addAsk", api.sent[0].method)
+		assert.Equal(t, price, api.sent[0].params[0])
+		assert.Equal(t, big.NewInt(1000), api.sent[0].params[1])
+		assert.Equal(t, api.previewGas, api.sent[0].gasLimit)
+	})
+
+	t.Run("keeps a live ask at the storage price", func(t *testing.T) {
+		api := newAskRepricerTestAPI(price)
+		api.addAsk(0, price, 500)
+		repricer := NewAskRepricer(api.minerAddr, api.workerAddr, api)
+
+		require.NoError(t, repricer.Reprice(ctx, height))
+		assert.Len(t, api.sent, 0)
+	})
+
+	t.Run("renews an ask that is about to expire", func(t *testing.T) {
+		api := newAskRepricerTestAPI(price)
+		api.addAsk(0, price, 105)
+		repricer := NewAskRepricer(api.minerAddr, api.workerAddr, api)
+
+		require.NoError(t, repricer.Reprice(ctx, height))
+
+		require.Len(t, api.sent, 2)
+		assert.Equal(t, "addAsk", api.sent[0].method)
+		assert.Equal(t, "cancelAsk", api.sent[1].method)
+		assert.Equal(t, big.NewInt(0), api.sent[1].params[0])
+	})
+
+	t.Run("replaces asks at another price", func(t *testing.T) {
+		api := newAskRepricerTestAPI(price)
+		api.addAsk(0, types.NewAttoFILFromFIL(3), 500)
+		api.addAsk(1, types.NewAttoFILFromFIL(4), 90)
+		api.addAsk(2, price, 500)
+		api.addAsk(3, price, 600)
+		repricer := NewAskRepricer(api.minerAddr, api.workerAddr, api)
+
+		require.NoError(t, repricer.Reprice(ctx, height))
+
+		// the expired ask is left for the actor to remove and only one ask
+		// at the storage price is kept
+		require.Len(t, api.sent, 2)
+		assert.Equal(t, "cancelAsk", api.sent[0].method)
+		assert.Equal(t, big.NewInt(0), api.sent[0].params[0])
+		assert.Equal(t, "cancelAsk", api.sent[1].method)
+		assert.Equal(t, big.NewInt(3), api.sent[1].params[0])
+	})
+
+	t.Run("does nothing when disabled", func(t *testing.T) {
+		api := newAskRepricerTestAPI(price)
+		api.enabled = false
+		repricer := NewAskRepricer(api.minerAddr, api.workerAddr, api)
+
+		require.NoError(t, repricer.OnNewHeaviestTipSet(types.RequireNewTipSet(t, &types.Block{Height: 100})))
+		assert.Len(t, api.sent, 0)
+	})
+
+	t.Run("reports failed messages", func(t *testing.T) {
+		api := newAskRepricerTestAPI(price)
+		api.exitCode = miner.ErrCallerUnauthorized
+		repricer := NewAskRepricer(api.minerAddr, api.workerAddr, api)
+
+		err := repricer.Reprice(ctx, height)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not authorized")
+	})
+}
+
+type askRepricerTestMessage struct {
+	from     address.Address
+	gasLimit types.GasUnits
+	method   string
+	params   []interface{}
+}
+
+type askRepricerTestAPI struct {
+	minerAddr  address.Address
+	workerAddr address.Address
+	enabled    bool
+	price      types.AttoFIL
+	asks       []miner.Ask
+	sent       []askRepricerTestMessage
+	previewGas types.GasUnits
+	exitCode   uint8
+}
+
+func newAskRepricerTestAPI(price types.AttoFIL) *askRepricerTestAPI {
+	addrGetter := address.NewForTestGetter()
+	return &askRepricerTestAPI{
+		minerAddr:  addrGetter(),
+		workerAddr: addrGetter(),
+		enabled:    true,
+		price:      price,
+		previewGas: types.NewGasUnits(5300),
+	}
+}
+
+func (api *askRepricerTestAPI) addAsk(id int64, price types.AttoFIL, expiry uint64) {
+	api.asks = append(api.asks, miner.Ask{
+		ID:     big.NewInt(id),
+		Price:  price,
+		Expiry: types.NewBlockHeight(expiry),
+	})
+}
+
+func (api *askRepricerTestAPI) ConfigGet(dottedPath string) (interface{}, error) {
+	switch dottedPath {
+	case "mining.autoRepriceAsks":
+		return api.enabled, nil
+	case "mining.askDurationBlocks":
+		return uint64(1000), nil
+	case "mining.storagePrice":
+		return api.price, nil
+	}
+	return nil, nil
+}
+
+func (api *askRepricerTestAPI) MessagePreview(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, error) {
+	return api.previewGas, nil
+}
+
+func (api *askRepricerTestAPI) MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	api.sent = append(api.sent, askRepricerTestMessage{from: from, gasLimit: gasLimit, method: method, params: params})
+	return types.SomeCid(), nil
+}
+
+func (api *askRepricerTestAPI) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+	return cb(&types.Block{}, &types.SignedMessage{}, &types.MessageReceipt{ExitCode: api.exitCode})
+}
+
+func (api *askRepricerTestAPI) MinerGetAsks(ctx context.Context, minerAddr address.Address) ([]miner.Ask, error) {
+	return api.asks, nil
+}
@@ -47,9 +47,6 @@ const (
 
 	// CreateChannelGasPrice is the gas price of the message used to create the payment channel
 	CreateChannelGasPrice = 1
-
-	// CreateChannelGasLimit is the gas limit of the message used to create the payment channel
-	CreateChannelGasLimit = 300
 )
 
 type clientPorcelainAPI interface {
@@ -61,11 +58,13 @@ type clientPorcelainAPI interface {
 	DAGCat(context.Context, cid.Cid) (io.Reader, error)
 	DealPut(*storagedeal.Deal) error
 	DealsLs(context.Context) (<-chan *porcelain.StorageDealLsResult, error)
+	MessagePreviewWithValue(ctx context.Context, from, to address.Address, value types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error)
 	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
 	MinerGetAsk(ctx context.Context, minerAddr address.Address, askID uint64) (miner.Ask, error)
 	MinerGetSectorSize(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error)
 	MinerGetOwnerAddress(ctx context.Context, minerAddr address.Address) (address.Address, error)
 	MinerGetPeerID(ctx context.Context, minerAddr address.Address) (peer.ID, error)
+	MinerConnect(ctx context.Context, minerAddr address.Address, minerPID peer.ID) error
 	types.Signer
 	PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error
 	WalletDefaultAddress() (address.Address, error)
@@ -90,35 +89,31 @@ func NewClient(host host.Host, api clientPorcelainAPI) *Client {
 	return smc
 }
 
+// Piece is data prepared for storage deals. Its piece commitment is computed
+// once and may be used in proposals to any number of miners.
+type Piece struct {
+	Ref   cid.Cid
+	Size  uint64
+	CommP types.CommP
+}
+
 // ProposeDeal proposes a storage deal to a miner.  Pass allowDuplicates = true to
 // allow duplicate proposals without error.
 func (smc *Client) ProposeDeal(ctx context.Context, miner address.Address, data cid.Cid, askID uint64, duration uint64, allowDuplicates bool) (*storagedeal.Response, error) {
-	pid, err := smc.api.MinerGetPeerID(ctx, miner)
+	piece, err := smc.PreparePiece(ctx, data)
 	if err != nil {
 		return nil, err
 	}
+	return smc.ProposePiece(ctx, miner, piece, askID, duration, allowDuplicates)
+}
 
-	minerAlive := make(chan error, 1)
-	go func() {
-		defer close(minerAlive)
-		minerAlive <- smc.api.PingMinerWithTimeout(ctx, pid, 15*time.Second)
-	}()
-
+// PreparePiece determines the size of the data and generates its piece commitment.
+func (smc *Client) PreparePiece(ctx context.Context, data cid.Cid) (*Piece, error) {
 	pieceSize, err := smc.api.DAGGetFileSize(ctx, data)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to determine the size of the data")
 	}
 
-	sectorSize, err := smc.api.MinerGetSectorSize(ctx, miner)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get sector size")
-	}
-
-	maxUserBytes := libsectorbuilder.GetMaxUserBytesPerStagedSector(sectorSize.Uint64())
-	if pieceSize > maxUserBytes {
-		return nil, fmt.Errorf("piece is %d bytes but sector size is %d bytes", pieceSize, maxUserBytes)
-	}
-
 	pieceReader, err := smc.api.DAGCat(ctx, data)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to make piece reader")
@@ -134,6 +129,45 @@ func (smc *Client) ProposeDeal(ctx context.Context, miner address.Address, data
 		return nil, errors.Wrap(err, "failed to generate piece commitment")
 	}
 
+	return &Piece{
+		Ref:   data,
+		Size:  pieceSize,
+		CommP: res.CommP,
+	}, nil
+}
+
+// ProposePiece proposes a storage deal for a prepared piece to a miner. Pass
+// allowDuplicates = true to allow duplicate proposals without error.
+func (smc *Client) ProposePiece(ctx context.Context, miner address.Address, piece *Piece, askID uint64, duration uint64, allowDuplicates bool) (*storagedeal.Response, error) {
+	pid, err := smc.api.MinerGetPeerID(ctx, miner)
+	if err != nil {
+		return nil, err
+	}
+
+	// a miner that cannot be reached fails the ping below
+	if err := smc.api.MinerConnect(ctx, miner, pid); err != nil {
+		smc.log.Warningf("failed to connect to miner %s: %s", miner, err)
+	}
+
+	minerAlive := make(chan error, 1)
+	go func() {
+		defer close(minerAlive)
+		minerAlive <- smc.api.PingMinerWithTimeout(ctx, pid, 15*time.Second)
+	}()
+
+	data := piece.Ref
+	pieceSize := piece.Size
+
+	sectorSize, err := smc.api.MinerGetSectorSize(ctx, miner)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get sector size")
+	}
+
+	maxUserBytes := libsectorbuilder.GetMaxUserBytesPerStagedSector(sectorSize.Uint64())
+	if pieceSize > maxUserBytes {
+		return nil, fmt.Errorf("piece is %d bytes but sector size is %d bytes", pieceSize, maxUserBytes)
+	}
+
 	ask, err := smc.api.MinerGetAsk(ctx, miner, askID)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get ask price")
@@ -188,18 +222,24 @@ func (smc *Client) ProposeDeal(ctx context.Context, miner address.Address, data
 		ctxPaymentSetup, cancel := context.WithTimeout(ctx, 5*smc.api.BlockTime())
 		defer cancel()
 
+		channelExpiry := chainHeight.Add(types.NewBlockHeight(duration + ChannelExpiryInterval))
+		gasLimit, err := smc.api.MessagePreviewWithValue(ctxPaymentSetup, fromAddress, address.PaymentBrokerAddress, totalCost, "createChannel", minerOwner, channelExpiry)
+		if err != nil {
+			return nil, errors.Wrap(err, "error previewing payment channel creation")
+		}
+
 		cpResp, err := smc.api.CreatePayments(ctxPaymentSetup, porcelain.CreatePaymentsParams{
 			From:            fromAddress,
 			To:              minerOwner,
 			Value:           totalCost,
 			Duration:        duration,
 			MinerAddress:    miner,
-			CommP:           res.CommP,
+			CommP:           piece.CommP,
 			PaymentInterval: VoucherInterval,
 			PieceSize:       types.NewBytesAmount(pieceSize),
-			ChannelExpiry:   *chainHeight.Add(types.NewBlockHeight(duration + ChannelExpiryInterval)),
+			ChannelExpiry:   *channelExpiry,
 			GasPrice:        types.NewAttoFIL(big.NewInt(CreateChannelGasPrice)),
-			GasLimit:        types.NewGasUnits(CreateChannelGasLimit),
+			GasLimit:        gasLimit,
 		})
 		if err != nil {
 			return nil, errors.Wrap(err, "error creating payment")
@@ -231,7 +271,13 @@ func (smc *Client) ProposeDeal(ctx context.Context, miner address.Address, data
 
 	// Note: currently the miner requests the data out of band
 
-	if err := smc.recordResponse(ctx, &response, miner, proposal); err != nil {
+	deal := &storagedeal.Deal{
+		Miner:       miner,
+		Proposal:    proposal,
+		CommP:       piece.CommP,
+		StartHeight: chainHeight,
+	}
+	if err := smc.recordResponse(ctx, &response, deal); err != nil {
 		return nil, errors.Wrap(err, "failed to track response")
 	}
 	smc.log.Debugf("proposed deal for: %s, %v\n", miner.String(), proposal)
@@ -239,8 +285,8 @@ func (smc *Client) ProposeDeal(ctx context.Context, miner address.Address, data
 	return &response, nil
 }
 
-func (smc *Client) recordResponse(ctx context.Context, resp *storagedeal.Response, miner address.Address, p *storagedeal.Proposal) error {
-	proposalCid, err := convert.ToCid(p)
+func (smc *Client) recordResponse(ctx context.Context, resp *storagedeal.Response, deal *storagedeal.Deal) error {
+	proposalCid, err := convert.ToCid(deal.Proposal)
 	if err != nil {
 		return errors.New("failed to get cid of proposal")
 	}
@@ -255,11 +301,8 @@ func (smc *Client) recordResponse(ctx context.Context, resp *storagedeal.Respons
 		return errors.Wrapf(err, "failed to check for existing deal: %s", proposalCid.String())
 	}
 
-	return smc.api.DealPut(&storagedeal.Deal{
-		Miner:    miner,
-		Proposal: p,
-		Response: resp,
-	})
+	deal.Response = resp
+	return smc.api.DealPut(deal)
 }
 
 func (smc *Client) checkDealResponse(ctx context.Context, resp *storagedeal.Response) error {
@@ -295,6 +338,10 @@ func (smc *Client) QueryDeal(ctx context.Context, proposalCid cid.Cid) (*storage
 		return nil, err
 	}
 
+	if err := smc.api.MinerConnect(ctx, mineraddr, minerpid); err != nil {
+		smc.log.Warningf("failed to connect to miner %s: %s", mineraddr, err)
+	}
+
 	q := storagedeal.QueryRequest{Cid: proposalCid}
 	var resp storagedeal.Response
 	err = smc.ProtocolRequestFunc(ctx, queryDealProtocol, minerpid, smc.host, q, &resp)
@@ -3,6 +3,7 @@ package storage_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"math/big"
 	"testing"
@@ -12,11 +13,14 @@ import (
 	"github.com/libp2p/go-libp2p-core/host"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-core/protocol"
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/net"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	. "github.com/filecoin-project/go-filecoin/protocol/storage"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
@@ -91,6 +95,10 @@ func TestProposeDeal(t *testing.T) {
 		assert.Equal(t, &testAPI.msgCid, proposal.Payment.ChannelMsgCid)
 	})
 
+	t.Run("and creates the payment channel with the previewed gas limit", func(t *testing.T) {
+		assert.Equal(t, testAPI.previewGas, testAPI.paymentsGasLimit)
+	})
+
 	t.Run("and creates payment info", func(t *testing.T) {
 		assert.Equal(t, int(duration/VoucherInterval), len(proposal.Payment.Vouchers))
 
@@ -194,19 +202,116 @@ func TestProposeDealFailsWhenADealAlreadyExists(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestProposeDealConnectsToMinerAtOnChainMultiaddrs(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	addressCreator := address.NewForTestGetter()
+
+	testNode := newTestClientNode(func(request interface{}) (interface{}, error) {
+		p := request.(*storagedeal.SignedDealProposal)
+		pcid, err := convert.ToCid(p.Proposal)
+		require.NoError(t, err)
+		return &storagedeal.Response{
+			State:       storagedeal.Accepted,
+			Message:     "OK",
+			ProposalCid: pcid,
+		}, nil
+	})
+
+	minerMultiaddr, err := ma.NewMultiaddr("/ip4/10.0.0.1/tcp/6000")
+	require.NoError(t, err)
+
+	t.Run("a miner that cannot be found in the DHT is dialed at its multiaddrs", func(t *testing.T) {
+		pieceSize := uint64(7)
+		testAPI := newMinerConnectTestAPI(newTestClientAPI(t, bytes.NewReader(make([]byte, pieceSize)), pieceSize), minerMultiaddr)
+		client := NewClient(th.NewFakeHost(), testAPI)
+		client.ProtocolRequestFunc = testNode.MakeTestProtocolRequest
+
+		_, err := client.ProposeDeal(ctx, addressCreator(), types.SomeCid(), uint64(67), uint64(10000), false)
+		require.NoError(t, err)
+
+		pid, err := testAPI.MinerGetPeerID(ctx, address.Undef)
+		require.NoError(t, err)
+		assert.Equal(t, []string{minerMultiaddr.String() + "/ipfs/" + pid.Pretty()}, testAPI.dialed)
+	})
+
+	t.Run("a miner that advertises no multiaddrs cannot be reached", func(t *testing.T) {
+		pieceSize := uint64(7)
+		testAPI := newMinerConnectTestAPI(newTestClientAPI(t, bytes.NewReader(make([]byte, pieceSize)), pieceSize))
+		client := NewClient(th.NewFakeHost(), testAPI)
+		client.ProtocolRequestFunc = testNode.MakeTestProtocolRequest
+
+		_, err := client.ProposeDeal(ctx, addressCreator(), types.SomeCid(), uint64(67), uint64(10000), false)
+		require.Error(t, err)
+		assert.Empty(t, testAPI.dialed)
+	})
+}
+
+// minerConnectTestAPI connects to miners with porcelain.MinerConnect, over a
+// network in which the DHT lookup of the miner fails and only the peers dialed
+// at the multiaddrs advertised on chain can be pinged.
+type minerConnectTestAPI struct {
+	*clientTestAPI
+	multiaddrs []ma.Multiaddr
+	dialed     []string
+}
+
+func newMinerConnectTestAPI(api *clientTestAPI, multiaddrs ...ma.Multiaddr) *minerConnectTestAPI {
+	return &minerConnectTestAPI{clientTestAPI: api, multiaddrs: multiaddrs}
+}
+
+func (mct *minerConnectTestAPI) MinerConnect(ctx context.Context, minerAddr address.Address, minerPID peer.ID) error {
+	return porcelain.MinerConnect(ctx, mct, minerAddr, minerPID)
+}
+
+func (mct *minerConnectTestAPI) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	if method != "getMultiaddrs" {
+		return mct.clientTestAPI.MessageQuery(ctx, optFrom, to, method, params...)
+	}
+	encoded, err := (&abi.Value{Type: abi.Multiaddrs, Val: mct.multiaddrs}).Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{encoded}, nil
+}
+
+func (mct *minerConnectTestAPI) NetworkFindPeer(ctx context.Context, peerID peer.ID) (peer.AddrInfo, error) {
+	return peer.AddrInfo{}, errors.New("routing: not found")
+}
+
+func (mct *minerConnectTestAPI) NetworkConnect(ctx context.Context, addrs []string) (<-chan net.ConnectionResult, error) {
+	mct.dialed = append(mct.dialed, addrs...)
+	results := make(chan net.ConnectionResult, len(addrs))
+	for range addrs {
+		results <- net.ConnectionResult{}
+	}
+	close(results)
+	return results, nil
+}
+
+func (mct *minerConnectTestAPI) PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error {
+	if len(mct.dialed) == 0 {
+		return errors.New("failed to dial miner")
+	}
+	return nil
+}
+
 type clientTestAPI struct {
-	askPrice       types.AttoFIL
-	createdPayment bool
-	blockHeight    *types.BlockHeight
-	channelID      *types.ChannelID
-	msgCid         cid.Cid
-	payer          address.Address
-	target         address.Address
-	perPayment     types.AttoFIL
-	testing        *testing.T
-	deals          map[cid.Cid]*storagedeal.Deal
-	pieceReader    io.Reader
-	pieceSize      uint64
+	askPrice         types.AttoFIL
+	createdPayment   bool
+	blockHeight      *types.BlockHeight
+	channelID        *types.ChannelID
+	msgCid           cid.Cid
+	payer            address.Address
+	target           address.Address
+	perPayment       types.AttoFIL
+	previewGas       types.GasUnits
+	paymentsGasLimit types.GasUnits
+	testing          *testing.T
+	deals            map[cid.Cid]*storagedeal.Deal
+	pieceReader      io.Reader
+	pieceSize        uint64
 }
 
 func newTestClientAPI(t *testing.T, pieceReader io.Reader, pieceSize uint64) *clientTestAPI {
@@ -222,6 +327,7 @@ func newTestClientAPI(t *testing.T, pieceReader io.Reader, pieceSize uint64) *cl
 		payer:          addressGetter(),
 		target:         addressGetter(),
 		perPayment:     types.NewAttoFILFromFIL(10),
+		previewGas:     types.NewGasUnits(2500),
 		testing:        t,
 		deals:          make(map[cid.Cid]*storagedeal.Deal),
 		pieceReader:    pieceReader,
@@ -239,6 +345,7 @@ func (ctp *clientTestAPI) ChainBlockHeight() (*types.BlockHeight, error) {
 
 func (ctp *clientTestAPI) CreatePayments(ctx context.Context, config porcelain.CreatePaymentsParams) (*porcelain.CreatePaymentsReturn, error) {
 	ctp.createdPayment = true
+	ctp.paymentsGasLimit = config.GasLimit
 	resp := &porcelain.CreatePaymentsReturn{
 		CreatePaymentsParams: config,
 		Channel:              ctp.channelID,
@@ -295,6 +402,10 @@ func (ctp *clientTestAPI) MinerGetPeerID(ctx context.Context, minerAddr address.
 	return id, nil
 }
 
+func (ctp *clientTestAPI) MinerConnect(ctx context.Context, minerAddr address.Address, minerPID peer.ID) error {
+	return nil
+}
+
 func (ctp *clientTestAPI) PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error {
 	return nil
 }
@@ -357,6 +468,10 @@ func (ctp *clientTestAPI) DealPut(storageDeal *storagedeal.Deal) error {
 	return nil
 }
 
+func (ctp *clientTestAPI) MessagePreviewWithValue(ctx context.Context, from, to address.Address, value types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error) {
+	return ctp.previewGas, nil
+}
+
 func (ctp *clientTestAPI) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
 	return [][]byte{{byte(types.TestProofsMode)}}, nil
 }
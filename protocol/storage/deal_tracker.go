@@ -0,0 +1,352 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// CommitmentWaitRounds is the number of rounds a client waits for the sector
+// commitment message reported by a miner to appear on chain before it
+// considers the deal suspicious.
+const CommitmentWaitRounds = 100
+
+// dealTrackerPorcelain is the subset of the porcelain API that DealTracker needs.
+type dealTrackerPorcelain interface {
+	BlockTime() time.Duration
+	ChainBlockHeight() (*types.BlockHeight, error)
+	ConfigGet(dottedPath string) (interface{}, error)
+	DealGet(context.Context, cid.Cid) (*storagedeal.Deal, error)
+	DealPut(*storagedeal.Deal) error
+	DealsLs(context.Context) (<-chan *porcelain.StorageDealLsResult, error)
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+	MinerGetSectorSize(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error)
+}
+
+// DealTracker follows the deals this node has proposed as a client. It polls
+// miners for updates to unfinished deals and, once a miner reports a deal
+// complete, checks on chain that the sector holding the piece was committed
+// and that the miner's piece inclusion proof is valid. The outcome is recorded
+// on the deal in the deals store.
+type DealTracker struct {
+	api       dealTrackerPorcelain
+	queryDeal func(context.Context, cid.Cid) (*storagedeal.Response, error)
+	log       logging.EventLogger
+
+	// lk guards commitHeights and waiting.
+	lk sync.Mutex
+	// commitHeights maps deals whose sector commitment has been found on
+	// chain to the height at which it was included.
+	commitHeights map[cid.Cid]*types.BlockHeight
+	// waiting is the set of deals for which a commitment message is awaited.
+	waiting map[cid.Cid]struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDealTracker creates a new deal tracker. queryDeal is used to ask a miner
+// for the current state of a deal, typically Client.QueryDeal.
+func NewDealTracker(api dealTrackerPorcelain, queryDeal func(context.Context, cid.Cid) (*storagedeal.Response, error)) *DealTracker {
+	return &DealTracker{
+		api:           api,
+		queryDeal:     queryDeal,
+		log:           logging.Logger("storage/dealtracker"),
+		commitHeights: make(map[cid.Cid]*types.BlockHeight),
+		waiting:       make(map[cid.Cid]struct{}),
+	}
+}
+
+// Start polls for deal updates once every block time until the context is
+// canceled or Stop is called.
+func (dt *DealTracker) Start(ctx context.Context) {
+	ctx, dt.cancel = context.WithCancel(ctx)
+
+	dt.wg.Add(1)
+	go func() {
+		defer dt.wg.Done()
+
+		ticker := time.NewTicker(dt.api.BlockTime())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := dt.Poll(ctx); err != nil {
+					dt.log.Errorf("failed to poll storage deals: %s", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops polling and waits for outstanding verifications to return.
+func (dt *DealTracker) Stop() {
+	if dt.cancel != nil {
+		dt.cancel()
+	}
+	dt.wg.Wait()
+}
+
+// Poll queries miners for updates to all unfinished client deals and verifies
+// the deals that have been reported complete.
+func (dt *DealTracker) Poll(ctx context.Context) error {
+	deals, err := dt.trackedDeals(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, deal := range deals {
+		if err := dt.updateDeal(ctx, deal); err != nil {
+			dt.log.Warningf("failed to update deal %s: %s", deal.Response.ProposalCid, err)
+		}
+	}
+	return nil
+}
+
+// trackedDeals returns the deals made by this node as a client which have
+// neither failed nor been verified yet.
+func (dt *DealTracker) trackedDeals(ctx context.Context) ([]*storagedeal.Deal, error) {
+	var minerAddr address.Address
+	if val, err := dt.api.ConfigGet("mining.minerAddress"); err == nil {
+		minerAddr, _ = val.(address.Address)
+	}
+
+	dealsCh, err := dt.api.DealsLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var deals []*storagedeal.Deal
+	for result := range dealsCh {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		deal := result.Deal
+		if !minerAddr.Empty() && deal.Miner == minerAddr {
+			continue
+		}
+		if deal.Verification != storagedeal.Unverified {
+			continue
+		}
+		if deal.Response.State == storagedeal.Rejected || deal.Response.State == storagedeal.Failed {
+			continue
+		}
+		deals = append(deals, &deal)
+	}
+	return deals, nil
+}
+
+func (dt *DealTracker) updateDeal(ctx context.Context, deal *storagedeal.Deal) error {
+	proposalCid := deal.Response.ProposalCid
+
+	if deal.Response.State != storagedeal.Complete {
+		resp, err := dt.queryDeal(ctx, proposalCid)
+		if err != nil {
+			return errors.Wrap(err, "failed to query miner for deal")
+		}
+
+		// The miner does not know about the deal (anymore). Keep the last
+		// response we have, the client is in a better position to act on it.
+		if resp.State == storagedeal.Unknown {
+			return nil
+		}
+		if !resp.ProposalCid.Equals(proposalCid) {
+			return fmt.Errorf("miner responded for deal %s instead", resp.ProposalCid)
+		}
+
+		if resp.State != deal.Response.State || resp.Message != deal.Response.Message {
+			deal.Response = resp
+			if err := dt.api.DealPut(deal); err != nil {
+				return errors.Wrap(err, "failed to store updated deal response")
+			}
+		}
+
+		if resp.State != storagedeal.Complete {
+			return nil
+		}
+	}
+
+	// Deals proposed before the client recorded piece commitments can't be verified.
+	if deal.CommP == (types.CommP{}) {
+		return nil
+	}
+
+	return dt.verifyDeal(ctx, deal)
+}
+
+// verifyDeal checks a deal the miner has reported complete against the chain.
+// It first waits for the sector commitment message, then verifies the piece
+// inclusion proof. Since the proof can only be verified once the miner has
+// proven the sector, the deal is considered suspicious only if verification
+// is still failing after the miner's first proving period has ended.
+func (dt *DealTracker) verifyDeal(ctx context.Context, deal *storagedeal.Deal) error {
+	proposalCid := deal.Response.ProposalCid
+	proofInfo := deal.Response.ProofInfo
+	if proofInfo == nil {
+		return dt.setVerification(ctx, proposalCid, storagedeal.Suspicious, "miner reported deal complete without proof info")
+	}
+
+	dt.lk.Lock()
+	commitHeight, found := dt.commitHeights[proposalCid]
+	dt.lk.Unlock()
+	if !found {
+		dt.waitForCommitment(ctx, deal)
+		return nil
+	}
+
+	_, verifyErr := dt.api.MessageQuery(
+		ctx,
+		address.Undef,
+		deal.Miner,
+		"verifyPieceInclusion",
+		deal.CommP[:],
+		deal.Proposal.Size,
+		proofInfo.SectorID,
+		proofInfo.PieceInclusionProof,
+	)
+	if verifyErr == nil {
+		return dt.setVerification(ctx, proposalCid, storagedeal.Verified, "")
+	}
+
+	sectorSize, err := dt.api.MinerGetSectorSize(ctx, deal.Miner)
+	if err != nil {
+		return errors.Wrap(err, "failed to get sector size")
+	}
+	deadline := commitHeight.Add(types.NewBlockHeight(miner.ProvingPeriodDuration(sectorSize))).Add(miner.GenerationAttackTime(sectorSize))
+
+	height, err := dt.api.ChainBlockHeight()
+	if err != nil {
+		return err
+	}
+	if height.GreaterThan(deadline) {
+		return dt.setVerification(ctx, proposalCid, storagedeal.Suspicious, fmt.Sprintf("piece inclusion proof did not verify: %s", verifyErr))
+	}
+
+	return nil
+}
+
+// waitForCommitment waits in the background for the sector commitment message
+// of a deal to appear on chain and checks it commits the expected sector.
+func (dt *DealTracker) waitForCommitment(ctx context.Context, deal *storagedeal.Deal) {
+	proposalCid := deal.Response.ProposalCid
+
+	dt.lk.Lock()
+	if _, ok := dt.waiting[proposalCid]; ok {
+		dt.lk.Unlock()
+		return
+	}
+	dt.waiting[proposalCid] = struct{}{}
+	dt.lk.Unlock()
+
+	dt.wg.Add(1)
+	go func() {
+		defer dt.wg.Done()
+		defer func() {
+			dt.lk.Lock()
+			delete(dt.waiting, proposalCid)
+			dt.lk.Unlock()
+		}()
+
+		waitCtx, cancel := context.WithTimeout(ctx, CommitmentWaitRounds*dt.api.BlockTime())
+		defer cancel()
+
+		var commitBlock *types.Block
+		var commitMsg *types.SignedMessage
+		var commitReceipt *types.MessageReceipt
+		err := dt.api.MessageWait(waitCtx, deal.Response.ProofInfo.CommitmentMessage, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+			commitBlock, commitMsg, commitReceipt = blk, smsg, receipt
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		var verr error
+		if err == context.DeadlineExceeded {
+			verr = errors.New("sector commitment message not found on chain")
+		} else if err != nil {
+			dt.log.Warningf("failed waiting for commitment of deal %s: %s", proposalCid, err)
+			return
+		} else {
+			verr = checkCommitment(deal, types.NewBlockHeight(uint64(commitBlock.Height)), &commitMsg.Message, commitReceipt)
+		}
+
+		if verr != nil {
+			if err := dt.setVerification(ctx, proposalCid, storagedeal.Suspicious, verr.Error()); err != nil {
+				dt.log.Errorf("failed to mark deal %s suspicious: %s", proposalCid, err)
+			}
+			return
+		}
+
+		dt.lk.Lock()
+		dt.commitHeights[proposalCid] = types.NewBlockHeight(uint64(commitBlock.Height))
+		dt.lk.Unlock()
+	}()
+}
+
+func (dt *DealTracker) setVerification(ctx context.Context, proposalCid cid.Cid, verification storagedeal.Verification, message string) error {
+	// Re-read the deal, the copy being verified may be stale.
+	deal, err := dt.api.DealGet(ctx, proposalCid)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get deal %s", proposalCid)
+	}
+
+	deal.Verification = verification
+	deal.VerificationMessage = message
+	if err := dt.api.DealPut(deal); err != nil {
+		return errors.Wrap(err, "failed to store deal verification")
+	}
+
+	dt.log.Infof("deal %s is %s %s", proposalCid, verification, message)
+	return nil
+}
+
+// checkCommitment checks that a message successfully committed the sector the
+// miner claims holds the deal's piece, until after the deal ends.
+func checkCommitment(deal *storagedeal.Deal, commitHeight *types.BlockHeight, msg *types.Message, receipt *types.MessageReceipt) error {
+	if receipt.ExitCode != 0 {
+		return fmt.Errorf("sector commitment message failed with exit code %d", receipt.ExitCode)
+	}
+	if msg.To != deal.Miner {
+		return fmt.Errorf("sector commitment message was sent to %s instead of miner %s", msg.To, deal.Miner)
+	}
+	if msg.Method != "commitSector" {
+		return fmt.Errorf("sector commitment message calls %s instead of commitSector", msg.Method)
+	}
+
+	sig, ok := (&miner.Actor{}).Exports()["commitSector"]
+	if !ok {
+		return errors.New("miner actor does not export commitSector")
+	}
+	vals, err := abi.DecodeValues(msg.Params, sig.Params)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode sector commitment params")
+	}
+	sectorID, ok := vals[0].Val.(uint64)
+	if !ok || sectorID != deal.Response.ProofInfo.SectorID {
+		return fmt.Errorf("sector commitment message commits sector %v instead of %d", vals[0].Val, deal.Response.ProofInfo.SectorID)
+	}
+	expiration, ok := vals[5].Val.(*types.BlockHeight)
+	dealEnd := commitHeight.Add(types.NewBlockHeight(deal.Proposal.Duration))
+	if !ok || expiration.LessThan(dealEnd) {
+		return fmt.Errorf("sector expires at %v before the deal ends at %s", vals[5].Val, dealEnd)
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	. "github.com/filecoin-project/go-filecoin/protocol/storage"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestDealTrackerUpdatesDealResponses(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	api, deal := newDealTrackerTestAPI(t, storagedeal.Accepted)
+
+	tracker := NewDealTracker(api, func(ctx context.Context, c cid.Cid) (*storagedeal.Response, error) {
+		return &storagedeal.Response{
+			State:       storagedeal.Staged,
+			ProposalCid: c,
+		}, nil
+	})
+
+	require.NoError(t, tracker.Poll(ctx))
+
+	stored, err := api.DealGet(ctx, deal.Response.ProposalCid)
+	require.NoError(t, err)
+	assert.Equal(t, storagedeal.Staged, stored.Response.State)
+	assert.Equal(t, storagedeal.Unverified, stored.Verification)
+}
+
+func TestDealTrackerVerifiesCompletedDeals(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	t.Run("marks deal verified when commitment and proof check out", func(t *testing.T) {
+		api, deal := newDealTrackerTestAPI(t, storagedeal.Complete)
+		tracker := NewDealTracker(api, failingQueryDeal(t))
+
+		verifyDeal(ctx, t, tracker)
+
+		stored, err := api.DealGet(ctx, deal.Response.ProposalCid)
+		require.NoError(t, err)
+		assert.Equal(t, storagedeal.Verified, stored.Verification)
+		assert.Equal(t, "verifyPieceInclusion", api.queriedMethod)
+	})
+
+	t.Run("marks deal suspicious when commitment is for another sector", func(t *testing.T) {
+		api, deal := newDealTrackerTestAPI(t, storagedeal.Complete)
+		api.commitMsg.Params = commitSectorParams(t, deal.Response.ProofInfo.SectorID+1, 100)
+		tracker := NewDealTracker(api, failingQueryDeal(t))
+
+		verifyDeal(ctx, t, tracker)
+
+		stored, err := api.DealGet(ctx, deal.Response.ProposalCid)
+		require.NoError(t, err)
+		assert.Equal(t, storagedeal.Suspicious, stored.Verification)
+		assert.Contains(t, stored.VerificationMessage, "instead of")
+	})
+
+	t.Run("marks deal suspicious when the sector expires before the deal ends", func(t *testing.T) {
+		api, deal := newDealTrackerTestAPI(t, storagedeal.Complete)
+		// the sector is committed at height 10, and the deal ends at 60
+		api.commitMsg.Params = commitSectorParams(t, deal.Response.ProofInfo.SectorID, 59)
+		tracker := NewDealTracker(api, failingQueryDeal(t))
+
+		verifyDeal(ctx, t, tracker)
+
+		stored, err := api.DealGet(ctx, deal.Response.ProposalCid)
+		require.NoError(t, err)
+		assert.Equal(t, storagedeal.Suspicious, stored.Verification)
+		assert.Contains(t, stored.VerificationMessage, "before the deal ends")
+	})
+
+	t.Run("marks deal suspicious when commitment failed", func(t *testing.T) {
+		api, deal := newDealTrackerTestAPI(t, storagedeal.Complete)
+		api.commitReceipt.ExitCode = 1
+		tracker := NewDealTracker(api, failingQueryDeal(t))
+
+		verifyDeal(ctx, t, tracker)
+
+		stored, err := api.DealGet(ctx, deal.Response.ProposalCid)
+		require.NoError(t, err)
+		assert.Equal(t, storagedeal.Suspicious, stored.Verification)
+	})
+
+	t.Run("tolerates failing proofs until the first proving period ends", func(t *testing.T) {
+		api, deal := newDealTrackerTestAPI(t, storagedeal.Complete)
+		api.queryErr = errors.New("proofs out of date")
+		tracker := NewDealTracker(api, failingQueryDeal(t))
+
+		verifyDeal(ctx, t, tracker)
+
+		stored, err := api.DealGet(ctx, deal.Response.ProposalCid)
+		require.NoError(t, err)
+		assert.Equal(t, storagedeal.Unverified, stored.Verification)
+
+		sectorSize := types.OneKiBSectorSize
+		api.height = types.NewBlockHeight(uint64(api.commitBlock.Height) + miner.ProvingPeriodDuration(sectorSize)).Add(miner.GenerationAttackTime(sectorSize)).Add(types.NewBlockHeight(1))
+		require.NoError(t, tracker.Poll(ctx))
+
+		stored, err = api.DealGet(ctx, deal.Response.ProposalCid)
+		require.NoError(t, err)
+		assert.Equal(t, storagedeal.Suspicious, stored.Verification)
+		assert.Contains(t, stored.VerificationMessage, "proofs out of date")
+	})
+}
+
+// verifyDeal polls the tracker until it has found the deal's sector
+// commitment and attempted to verify its piece inclusion proof.
+func verifyDeal(ctx context.Context, t *testing.T, tracker *DealTracker) {
+	require.NoError(t, tracker.Poll(ctx))
+	// Stop waits for the background wait for the commitment message to return.
+	tracker.Stop()
+	require.NoError(t, tracker.Poll(ctx))
+}
+
+func failingQueryDeal(t *testing.T) func(context.Context, cid.Cid) (*storagedeal.Response, error) {
+	return func(context.Context, cid.Cid) (*storagedeal.Response, error) {
+		t.Fatal("completed deals should not be queried")
+		return nil, nil
+	}
+}
+
+func commitSectorParams(t *testing.T, sectorID uint64, expiration uint64) []byte {
+	comm := make([]byte, types.CommitmentBytesLen)
+	params, err := abi.ToEncodedValues(sectorID, comm, comm, comm, types.PoRepProof{}, types.NewBlockHeight(expiration))
+	require.NoError(t, err)
+	return params
+}
+
+type dealTrackerTestAPI struct {
+	deals         map[cid.Cid]*storagedeal.Deal
+	height        *types.BlockHeight
+	commitBlock   *types.Block
+	commitMsg     *types.SignedMessage
+	commitReceipt *types.MessageReceipt
+	queryErr      error
+	queriedMethod string
+}
+
+func newDealTrackerTestAPI(t *testing.T, state storagedeal.State) (*dealTrackerTestAPI, *storagedeal.Deal) {
+	minerAddr := address.NewForTestGetter()()
+	sectorID := uint64(42)
+
+	deal := &storagedeal.Deal{
+		Miner: minerAddr,
+		Proposal: &storagedeal.Proposal{
+			PieceRef:     types.SomeCid(),
+			Size:         types.NewBytesAmount(100),
+			MinerAddress: minerAddr,
+			Duration:     50,
+		},
+		Response: &storagedeal.Response{
+			State:       state,
+			ProposalCid: types.NewCidForTestGetter()(),
+			ProofInfo: &storagedeal.ProofInfo{
+				SectorID:            sectorID,
+				CommitmentMessage:   types.SomeCid(),
+				PieceInclusionProof: []byte("proof"),
+			},
+		},
+		CommP: types.CommP{1, 2, 3},
+	}
+
+	api := &dealTrackerTestAPI{
+		deals:       map[cid.Cid]*storagedeal.Deal{deal.Response.ProposalCid: deal},
+		height:      types.NewBlockHeight(20),
+		commitBlock: &types.Block{Height: 10},
+		commitMsg: &types.SignedMessage{
+			MeteredMessage: types.MeteredMessage{
+				Message: types.Message{
+					To:     minerAddr,
+					Method: "commitSector",
+					Params: commitSectorParams(t, sectorID, 100),
+				},
+			},
+		},
+		commitReceipt: &types.MessageReceipt{ExitCode: 0},
+	}
+	return api, deal
+}
+
+func (api *dealTrackerTestAPI) BlockTime() time.Duration {
+	return 100 * time.Millisecond
+}
+
+func (api *dealTrackerTestAPI) ChainBlockHeight() (*types.BlockHeight, error) {
+	return api.height, nil
+}
+
+func (api *dealTrackerTestAPI) ConfigGet(dottedPath string) (interface{}, error) {
+	return address.Undef, nil
+}
+
+func (api *dealTrackerTestAPI) DealGet(_ context.Context, dealCid cid.Cid) (*storagedeal.Deal, error) {
+	deal, ok := api.deals[dealCid]
+	if !ok {
+		return nil, porcelain.ErrDealNotFound
+	}
+	// return a copy, as a datastore would
+	dealCopy := *deal
+	return &dealCopy, nil
+}
+
+func (api *dealTrackerTestAPI) DealPut(deal *storagedeal.Deal) error {
+	api.deals[deal.Response.ProposalCid] = deal
+	return nil
+}
+
+func (api *dealTrackerTestAPI) DealsLs(_ context.Context) (<-chan *porcelain.StorageDealLsResult, error) {
+	results := make(chan *porcelain.StorageDealLsResult, len(api.deals))
+	for _, deal := range api.deals {
+		results <- &porcelain.StorageDealLsResult{Deal: *deal}
+	}
+	close(results)
+	return results, nil
+}
+
+func (api *dealTrackerTestAPI) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	api.queriedMethod = method
+	return nil, api.queryErr
+}
+
+func (api *dealTrackerTestAPI) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+	return cb(api.commitBlock, api.commitMsg, api.commitReceipt)
+}
+
+func (api *dealTrackerTestAPI) MinerGetSectorSize(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error) {
+	return types.OneKiBSectorSize, nil
+}
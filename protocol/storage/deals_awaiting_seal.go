@@ -122,6 +122,16 @@ func (dealsAwaitingSeal *dealsAwaitingSeal) onSealFail(ctx context.Context, sect
 	delete(dealsAwaitingSeal.SectorsToDeals, sectorID)
 }
 
+// dealsInSector returns the cids of the deals known to have pieces in a sector
+// that has not been sealed yet.
+func (dealsAwaitingSeal *dealsAwaitingSeal) dealsInSector(sectorID uint64) []cid.Cid {
+	dealsAwaitingSeal.l.Lock()
+	defer dealsAwaitingSeal.l.Unlock()
+
+	deals := dealsAwaitingSeal.SectorsToDeals[sectorID]
+	return append([]cid.Cid(nil), deals...)
+}
+
 func (dealsAwaitingSeal *dealsAwaitingSeal) commitMessageCid(sectorID uint64) (cid.Cid, bool) {
 	sectorData, ok := dealsAwaitingSeal.SealedSectors[sectorID]
 	if !ok {
@@ -22,10 +22,12 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/abi"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
 	cbu "github.com/filecoin-project/go-filecoin/cborutil"
 	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/proofs/libsectorbuilder"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
@@ -41,7 +43,7 @@ const (
 	makeDealProtocol  = protocol.ID("/fil/storage/mk/1.0.0")
 	queryDealProtocol = protocol.ID("/fil/storage/qry/1.0.0")
 
-	// TODO: replace this with a queries to pick reasonable gas price and limits.
+	// TODO: replace this with a query to pick a reasonable gas price.
 	submitPostGasPrice = 1
 
 	waitForPaymentChannelDuration = 2 * time.Minute
@@ -82,9 +84,14 @@ type minerPorcelain interface {
 	DealGet(context.Context, cid.Cid) (*storagedeal.Deal, error)
 	DealPut(*storagedeal.Deal) error
 
+	MessagePreview(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, error)
+	MessagePreviewWithValue(ctx context.Context, from, to address.Address, value types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error)
 	MessageSend(ctx context.Context, from, to address.Address, value types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error)
 	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
 	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+
+	PaymentChannelAddReceivedVoucher(voucher *types.PaymentVoucher) error
+	PaymentChannelInfo(payer address.Address, channel *types.ChannelID) (*paych.ChannelInfo, error)
 }
 
 // prover computes PoSts for submission by a miner.
@@ -230,13 +237,19 @@ func (sm *Miner) validateDealPayment(ctx context.Context, p *storagedeal.Proposa
 		return errors.New("payments start after deal start interval")
 	}
 
+	lane := p.Payment.Vouchers[0].Lane
 	lastValidAt := expectedFirstPayment
 	for _, v := range p.Payment.Vouchers {
 		// confirm signature is valid against expected actor and channel id
-		if !paymentbroker.VerifyVoucherSignature(p.Payment.Payer, p.Payment.Channel, v.Amount, &v.ValidAt, v.Condition, v.Signature) {
+		if !paymentbroker.VerifyVoucherSignature(p.Payment.Payer, p.Payment.Channel, v.Amount, &v.ValidAt, v.Lane, v.Nonce, v.Merges, v.Condition, v.Signature) {
 			return errors.New("invalid signature in voucher")
 		}
 
+		// the amounts of a deal only add up on a single lane
+		if v.Lane != lane {
+			return errors.New("payment vouchers are not all on the same lane")
+		}
+
 		// make sure voucher validAt is not spaced to far apart
 		expectedValidAt := lastValidAt.Add(types.NewBlockHeight(VoucherInterval))
 		if v.ValidAt.GreaterThan(expectedValidAt) {
@@ -261,6 +274,21 @@ func (sm *Miner) validateDealPayment(ctx context.Context, p *storagedeal.Proposa
 		return fmt.Errorf("last payment (%s) does not cover total price (%s)", lastVoucher.Amount.String(), p.TotalPrice.String())
 	}
 
+	// a channel shared with other deals must still cover this deal on a lane of its own
+	info, err := sm.porcelainAPI.PaymentChannelInfo(p.Payment.Payer, p.Payment.Channel)
+	if err != nil && err != paych.ErrChannelNotFound {
+		return errors.Wrap(err, "could not get payment channel info")
+	}
+	if err == nil {
+		if info.HasReceivedOnLane(lane) {
+			return fmt.Errorf("payment channel lane %d already pays for another deal", lane)
+		}
+		committed := info.ReceivedAmount().Add(lastVoucher.Amount)
+		if committed.GreaterThan(channel.Amount) {
+			return fmt.Errorf("payment channel does not contain enough funds for all its deals (%s < %s)", channel.Amount.String(), committed.String())
+		}
+	}
+
 	// require channel expires at or after last voucher + ChannelExpiryInterval
 	expectedEol := lastVoucher.ValidAt.Add(types.NewBlockHeight(ChannelExpiryInterval))
 	if channel.Eol.LessThan(expectedEol) {
@@ -284,8 +312,11 @@ func (sm *Miner) getStoragePrice() (types.AttoFIL, error) {
 
 // some parts of this should be porcelain
 func (sm *Miner) getPaymentChannel(ctx context.Context, p *storagedeal.Proposal) (*paymentbroker.PaymentChannel, error) {
-	// wait for create channel message
+	// wait for create channel message, unless the proposal pays with an existing channel
 	messageCid := p.Payment.ChannelMsgCid
+	if messageCid == nil || !messageCid.Defined() {
+		return sm.queryPaymentChannel(ctx, p)
+	}
 
 	waitCtx, waitCancel := context.WithDeadline(ctx, time.Now().Add(waitForPaymentChannelDuration))
 	err := sm.porcelainAPI.MessageWait(waitCtx, *messageCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
@@ -299,6 +330,10 @@ func (sm *Miner) getPaymentChannel(ctx context.Context, p *storagedeal.Proposal)
 		return nil, err
 	}
 
+	return sm.queryPaymentChannel(ctx, p)
+}
+
+func (sm *Miner) queryPaymentChannel(ctx context.Context, p *storagedeal.Proposal) (*paymentbroker.PaymentChannel, error) {
 	payer := p.Payment.Payer
 
 	ret, err := sm.porcelainAPI.MessageQuery(ctx, address.Undef, address.PaymentBrokerAddress, "ls", payer)
@@ -343,6 +378,13 @@ func acceptProposal(sm *Miner, p *storagedeal.Proposal) (*storagedeal.Response,
 		return nil, errors.Wrap(err, "Could not persist miner deal")
 	}
 
+	// the deal holds the vouchers, so failing to track them is not fatal
+	for _, voucher := range p.Payment.Vouchers {
+		if err := sm.porcelainAPI.PaymentChannelAddReceivedVoucher(voucher); err != nil {
+			log.Errorf("failed to track voucher for payment channel %s: %s", voucher.Channel.String(), err)
+		}
+	}
+
 	// TODO: use some sort of nicer scheduler
 	go sm.processStorageDeal(proposalCid)
 
@@ -504,6 +546,30 @@ func (sm *Miner) saveDealsAwaitingSeal() error {
 	return nil
 }
 
+// SectorExpiration returns the height a sealed sector should expire at when
+// it is committed now: after the longest of the deals with pieces in it ends,
+// allowing for the commitment message to take CommitmentWaitRounds to land
+// on chain. A sector without deals lasts for one proving period.
+func (sm *Miner) SectorExpiration(ctx context.Context, sectorID uint64) (*types.BlockHeight, error) {
+	height, err := sm.porcelainAPI.ChainBlockHeight()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get chain height")
+	}
+
+	lifetime := miner.ProvingPeriodDuration(sm.sectorSize)
+	for _, dealCid := range sm.dealsAwaitingSeal.dealsInSector(sectorID) {
+		deal, err := sm.porcelainAPI.DealGet(ctx, dealCid)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get deal %s", dealCid)
+		}
+		if deal.Proposal.Duration > lifetime {
+			lifetime = deal.Proposal.Duration
+		}
+	}
+
+	return height.Add(types.NewBlockHeight(CommitmentWaitRounds + lifetime)), nil
+}
+
 // OnCommitmentSent is a callback, called when a sector seal message was posted to the chain.
 func (sm *Miner) OnCommitmentSent(sector *sectorbuilder.SealedSectorMetadata, msgCid cid.Cid, err error) {
 	ctx := context.Background()
@@ -773,12 +839,52 @@ func (sm *Miner) submitPoSt(ctx context.Context, start, end *types.BlockHeight,
 		log.Errorf("failed to calculate PoSt: %s", err)
 		return
 	}
+
 	// TODO #2998. The done set should be updated by CLI users.
 	// Using the 0 value is just a placeholder until that work lands.
 	done := types.EmptyIntSet()
 
+	// The gas limit is previewed against the head, with the late fee the
+	// submission carries. A PoSt of the sectors remaining after declaring
+	// faults cannot be verified until the declaration is mined, so the limit
+	// is previewed for the PoSt reporting the faults, which proves the same
+	// sectors against the head.
+	gasLimit, err := sm.porcelainAPI.MessagePreviewWithValue(ctx, sm.workerAddr, sm.minerAddr, submission.Fee, "submitPoSt", submission.Proofs, submission.Faults, done)
+	if err != nil {
+		log.Errorf("failed to preview PoSt submission: %s", err)
+		return
+	}
+
+	// Faults reported with the PoSt drop their sectors, while declared
+	// faults cost a lower penalty and can recover. Declare the faults and
+	// prove the other sectors instead, falling back to reporting them if the
+	// declaration cannot be sent. Both messages are sent from the worker
+	// without waiting, the nonce orders the declaration before the PoSt, so
+	// that the PoSt is not held up past the end of the proving period.
+	if faults := submission.Faults.SectorIds; faults.Size() > 0 {
+		if err := sm.declareFaults(ctx, faults); err != nil {
+			log.Warningf("failed to declare faults %s, reporting them with the PoSt: %s", faults, err)
+		} else {
+			var proven []PoStInputs
+			for _, input := range inputs {
+				if !faults.Has(input.SectorID) {
+					proven = append(proven, input)
+				}
+			}
+			if len(proven) == 0 {
+				log.Infof("declared all proven sectors faulty, no PoSt to submit")
+				return
+			}
+
+			submission, err = sm.prover.CalculatePoSt(ctx, start, end, proven)
+			if err != nil {
+				log.Errorf("failed to calculate PoSt: %s", err)
+				return
+			}
+		}
+	}
 	gasPrice := types.NewGasPrice(submitPostGasPrice)
-	_, err = sm.porcelainAPI.MessageSend(ctx, sm.workerAddr, sm.minerAddr, submission.Fee, gasPrice, submission.GasLimit, "submitPoSt", submission.Proofs, submission.Faults, done)
+	_, err = sm.porcelainAPI.MessageSend(ctx, sm.workerAddr, sm.minerAddr, submission.Fee, gasPrice, gasLimit, "submitPoSt", submission.Proofs, submission.Faults, done)
 	if err != nil {
 		log.Errorf("failed to submit PoSt: %s", err)
 		return
@@ -786,3 +892,15 @@ func (sm *Miner) submitPoSt(ctx context.Context, start, end *types.BlockHeight,
 
 	log.Info("submitted PoSt")
 }
+
+// declareFaults sends a message declaring sectors of the miner faulty.
+func (sm *Miner) declareFaults(ctx context.Context, sectorIDs types.IntSet) error {
+	gasLimit, err := sm.porcelainAPI.MessagePreview(ctx, sm.workerAddr, sm.minerAddr, "declareFaults", sectorIDs)
+	if err != nil {
+		return errors.Wrap(err, "failed to preview declaring faults")
+	}
+
+	gasPrice := types.NewGasPrice(submitPostGasPrice)
+	_, err = sm.porcelainAPI.MessageSend(ctx, sm.workerAddr, sm.minerAddr, types.ZeroAttoFIL, gasPrice, gasLimit, "declareFaults", sectorIDs)
+	return err
+}
@@ -16,10 +16,12 @@ import (
 	"github.com/filecoin-project/go-filecoin/abi"
 	"github.com/filecoin-project/go-filecoin/actor"
 	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/plumbing/cfg"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/porcelain"
 	"github.com/filecoin-project/go-filecoin/proofs/sectorbuilder"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
@@ -216,6 +218,48 @@ func TestReceiveStorageProposal(t *testing.T) {
 		assert.Contains(t, res.Message, "voucher amount")
 	})
 
+	t.Run("Accepts proposals on a new lane of a channel paying for other deals", func(t *testing.T) {
+		porcelainAPI := newMinerTestPorcelain(t, defaultMinerPrice)
+		porcelainAPI.lane = 1
+		porcelainAPI.channelInfo = &paych.ChannelInfo{
+			Received: testPaymentVouchers(newMinerTestPorcelain(t, defaultMinerPrice), VoucherInterval, defaultAmountInc),
+		}
+		miner, proposal := newMinerTestSetup(porcelainAPI, VoucherInterval, defaultAmountInc)
+
+		res, err := miner.receiveStorageProposal(context.Background(), proposal)
+		require.NoError(t, err)
+
+		assert.Equal(t, storagedeal.Accepted, res.State, res.Message)
+	})
+
+	t.Run("Rejects proposals on a lane already paying for another deal", func(t *testing.T) {
+		porcelainAPI, miner, proposal := defaultMinerTestSetup(t, VoucherInterval, defaultAmountInc)
+		porcelainAPI.channelInfo = &paych.ChannelInfo{
+			Received: testPaymentVouchers(porcelainAPI, VoucherInterval, defaultAmountInc),
+		}
+
+		res, err := miner.receiveStorageProposal(context.Background(), proposal)
+		require.NoError(t, err)
+
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Contains(t, res.Message, "already pays for another deal")
+	})
+
+	t.Run("Rejects proposals with vouchers on several lanes", func(t *testing.T) {
+		porcelainAPI, miner, _ := defaultMinerTestSetup(t, VoucherInterval, defaultAmountInc)
+
+		vouchers := testPaymentVouchers(porcelainAPI, VoucherInterval, defaultAmountInc)
+		porcelainAPI.lane = 1
+		vouchers[9] = testPaymentVouchers(porcelainAPI, VoucherInterval, defaultAmountInc)[9]
+		proposal := testSignedDealProposal(porcelainAPI, vouchers, defaultPieceSize)
+
+		res, err := miner.receiveStorageProposal(context.Background(), proposal)
+		require.NoError(t, err)
+
+		assert.Equal(t, storagedeal.Rejected, res.State)
+		assert.Contains(t, res.Message, "not all on the same lane")
+	})
+
 	t.Run("Rejects proposals with invalid signature", func(t *testing.T) {
 		_, miner, proposal := defaultMinerTestSetup(t, VoucherInterval, defaultAmountInc)
 		proposal.Signature = []byte{'0', '0', '0'}
@@ -326,6 +370,32 @@ func TestOnCommitmentAddedToChain(t *testing.T) {
 	})
 }
 
+func TestSectorExpiration(t *testing.T) {
+	tf.UnitTest(t)
+
+	cidGetter := types.NewCidForTestGetter()
+	proposalCid := cidGetter()
+	sectorID := uint64(777)
+
+	t.Run("a sector expires after its longest deal", func(t *testing.T) {
+		porcelainAPI, miner, proposal := minerWithAcceptedDealTestSetup(t, proposalCid, sectorID)
+
+		expiration, err := miner.SectorExpiration(context.Background(), sectorID)
+		require.NoError(t, err)
+		lifetime := types.NewBlockHeight(CommitmentWaitRounds + proposal.Proposal.Duration)
+		assert.Equal(t, porcelainAPI.blockHeight.Add(lifetime), expiration)
+	})
+
+	t.Run("a sector without deals lasts for a proving period", func(t *testing.T) {
+		porcelainAPI, sm, _ := minerWithAcceptedDealTestSetup(t, proposalCid, sectorID)
+
+		expiration, err := sm.SectorExpiration(context.Background(), sectorID+1)
+		require.NoError(t, err)
+		lifetime := types.NewBlockHeight(CommitmentWaitRounds + miner.ProvingPeriodDuration(types.OneKiBSectorSize))
+		assert.Equal(t, porcelainAPI.blockHeight.Add(lifetime), expiration)
+	})
+}
+
 func TestOnNewHeaviestTipSet(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -453,6 +523,56 @@ func TestOnNewHeaviestTipSet(t *testing.T) {
 		// assert proof generated in sector builder is sent to submitPoSt
 		require.Equal(t, 3, len(postParams))
 		assert.Equal(t, []types.PoStProof{[]byte("test proof")}, postParams[0])
+		assert.Equal(t, api.previewGas, api.gasLimits["submitPoSt"])
+	})
+
+	t.Run("declares faults before submitting a PoSt of the other sectors", func(t *testing.T) {
+		api, miner, _ := minerWithAcceptedDealTestSetup(t, proposalCid, sector.SectorID)
+		miner.prover = &FakeProver{FaultySectors: []uint64{43}}
+
+		declareParams := []interface{}{}
+		postParams := []interface{}{}
+		var sent []string
+
+		handlers := successMessageHandlers(t)
+		handlers["getProvingSetCommitments"] = func(a address.Address, v types.AttoFIL, p ...interface{}) ([][]byte, error) {
+			commitments := map[string]types.Commitments{}
+			commitments["42"] = types.Commitments{}
+			commitments["43"] = types.Commitments{}
+			return mustEncodeResults(t, commitments), nil
+		}
+		handlers["declareFaults"] = func(a address.Address, v types.AttoFIL, p ...interface{}) ([][]byte, error) {
+			declareParams = p
+			sent = append(sent, "declareFaults")
+			return [][]byte{}, nil
+		}
+		handlers["submitPoSt"] = func(a address.Address, v types.AttoFIL, p ...interface{}) ([][]byte, error) {
+			postParams = p
+			sent = append(sent, "submitPoSt")
+			return [][]byte{}, nil
+		}
+		api.messageHandlers = handlers
+
+		height := uint64(20500)
+		api.blockHeight = types.NewBlockHeight(height)
+		block := &types.Block{Height: types.Uint64(height)}
+		ts, err := types.NewTipSet(block)
+		require.NoError(t, err)
+
+		err = miner.OnNewHeaviestTipSet(ts)
+		require.NoError(t, err)
+
+		time.Sleep(1 * time.Second)
+
+		require.Equal(t, 1, len(declareParams))
+		assert.Equal(t, []uint64{43}, declareParams[0].(types.IntSet).Values())
+
+		// the declared sector is no longer reported as a fault
+		require.Equal(t, 3, len(postParams))
+		assert.Equal(t, 0, postParams[1].(types.FaultSet).SectorIds.Size())
+
+		// the declaration is sent first, so that its nonce comes before the PoSt
+		assert.Equal(t, []string{"declareFaults", "submitPoSt"}, sent)
 	})
 
 	t.Run("Does not post if block height is too low", func(t *testing.T) {
@@ -545,6 +665,8 @@ type minerTestPorcelain struct {
 	payerAddress    address.Address
 	targetAddress   address.Address
 	channelID       *types.ChannelID
+	channelInfo     *paych.ChannelInfo
+	lane            uint64
 	messageCid      *cid.Cid
 	signer          types.MockSigner
 	noChannels      bool
@@ -554,6 +676,8 @@ type minerTestPorcelain struct {
 	deals           map[cid.Cid]*storagedeal.Deal
 	walletBalance   types.AttoFIL
 	messageHandlers map[string]func(address.Address, types.AttoFIL, ...interface{}) ([][]byte, error)
+	previewGas      types.GasUnits
+	gasLimits       map[string]types.GasUnits
 
 	testing *testing.T
 }
@@ -590,6 +714,8 @@ func newMinerTestPorcelain(t *testing.T, minerPriceString string) *minerTestPorc
 		deals:           make(map[cid.Cid]*storagedeal.Deal),
 		walletBalance:   types.NewAttoFILFromFIL(100),
 		messageHandlers: messageHandlerMap{},
+		previewGas:      types.NewGasUnits(12000),
+		gasLimits:       make(map[string]types.GasUnits),
 
 		testing: t,
 	}
@@ -599,7 +725,16 @@ func (mtp *minerTestPorcelain) ActorGetSignature(ctx context.Context, actorAddr
 	return builtin.Actors[types.MinerActorCodeCid].Exports()[method], nil
 }
 
+func (mtp *minerTestPorcelain) MessagePreview(ctx context.Context, from, to address.Address, method string, params ...interface{}) (types.GasUnits, error) {
+	return mtp.previewGas, nil
+}
+
+func (mtp *minerTestPorcelain) MessagePreviewWithValue(ctx context.Context, from, to address.Address, val types.AttoFIL, method string, params ...interface{}) (types.GasUnits, error) {
+	return mtp.previewGas, nil
+}
+
 func (mtp *minerTestPorcelain) MessageSend(ctx context.Context, from, to address.Address, val types.AttoFIL, gasPrice types.AttoFIL, gasLimit types.GasUnits, method string, params ...interface{}) (cid.Cid, error) {
+	mtp.gasLimits[method] = gasLimit
 	handler, ok := mtp.messageHandlers[method]
 	if ok {
 		_, err := handler(to, val, params...)
@@ -741,7 +876,7 @@ func testPaymentVouchers(porcelainAPI *minerTestPorcelain, voucherInterval int,
 	for i := 0; i < 10; i++ {
 		validAt := porcelainAPI.paymentStart.Add(types.NewBlockHeight(uint64((i + 1) * voucherInterval)))
 		amount := types.NewAttoFILFromFIL(uint64(i+1) * amountInc)
-		signature, err := paymentbroker.SignVoucher(porcelainAPI.channelID, amount, validAt, porcelainAPI.payerAddress, nil, porcelainAPI.signer)
+		signature, err := paymentbroker.SignVoucher(porcelainAPI.channelID, amount, validAt, porcelainAPI.lane, uint64(i), nil, porcelainAPI.payerAddress, nil, porcelainAPI.signer)
 		require.NoError(porcelainAPI.testing, err, "could not sign valid proposal")
 
 		vouchers[i] = &types.PaymentVoucher{
@@ -750,6 +885,8 @@ func testPaymentVouchers(porcelainAPI *minerTestPorcelain, voucherInterval int,
 			Target:    porcelainAPI.targetAddress,
 			Amount:    amount,
 			ValidAt:   *validAt,
+			Lane:      porcelainAPI.lane,
+			Nonce:     uint64(i),
 			Signature: signature,
 		}
 	}
@@ -806,3 +943,14 @@ func (mtp *minerTestPorcelain) DealPut(storageDeal *storagedeal.Deal) error {
 	mtp.deals[storageDeal.Response.ProposalCid] = storageDeal
 	return nil
 }
+
+func (mtp *minerTestPorcelain) PaymentChannelAddReceivedVoucher(voucher *types.PaymentVoucher) error {
+	return nil
+}
+
+func (mtp *minerTestPorcelain) PaymentChannelInfo(payer address.Address, channel *types.ChannelID) (*paych.ChannelInfo, error) {
+	if mtp.channelInfo == nil {
+		return nil, paych.ErrChannelNotFound
+	}
+	return mtp.channelInfo, nil
+}
@@ -16,9 +16,6 @@ const (
 	// fee necessary due to late submission. The miner expects the PoSt message to be mined
 	// into a block at most `buffer` rounds in the future.
 	postSubmissionDelayBufferRounds = 10
-
-	// This will likely depend on the sector size and proving period.
-	submitPostGasLimit = 300
 )
 
 // ProofReader provides information about the blockchain to the proving process.
@@ -59,10 +56,9 @@ type PoStInputs struct {
 
 // PoStSubmission is the information to be submitted on-chain for a proof.
 type PoStSubmission struct {
-	Proofs   []types.PoStProof
-	Fee      types.AttoFIL
-	GasLimit types.GasUnits
-	Faults   types.FaultSet
+	Proofs []types.PoStProof
+	Fee    types.AttoFIL
+	Faults types.FaultSet
 }
 
 // NewProver constructs a new Prover.
@@ -122,10 +118,9 @@ func (p *Prover) CalculatePoSt(ctx context.Context, start, end *types.BlockHeigh
 	}
 
 	return &PoStSubmission{
-		Proofs:   proof,
-		Fee:      feeDue,
-		GasLimit: types.NewGasUnits(submitPostGasLimit),
-		Faults:   types.NewFaultSet(faults),
+		Proofs: proof,
+		Fee:    feeDue,
+		Faults: types.NewFaultSet(faults),
 	}, nil
 }
 
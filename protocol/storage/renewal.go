@@ -0,0 +1,391 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/miner"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// minerPingTimeout bounds how long the renewer waits for a miner to answer a
+// ping before treating it as offline.
+const minerPingTimeout = 15 * time.Second
+
+// localDataTimeout bounds how long the renewer looks for a deal's data in the
+// local dag before falling back to retrieving it from a miner.
+const localDataTimeout = 30 * time.Second
+
+// renewerPorcelainAPI is the subset of the porcelain API that Renewer needs.
+type renewerPorcelainAPI interface {
+	ChainBlockHeight() (*types.BlockHeight, error)
+	ClientListAsks(ctx context.Context) <-chan porcelain.Ask
+	ConfigGet(dottedPath string) (interface{}, error)
+	DAGGetFileSize(context.Context, cid.Cid) (uint64, error)
+	DAGImportData(context.Context, io.Reader) (ipld.Node, error)
+	DealGet(context.Context, cid.Cid) (*storagedeal.Deal, error)
+	DealPut(*storagedeal.Deal) error
+	DealsLs(context.Context) (<-chan *porcelain.StorageDealLsResult, error)
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	MinerGetPeerID(ctx context.Context, minerAddr address.Address) (peer.ID, error)
+	PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error
+}
+
+// dealProposer proposes new storage deals, typically a Client.
+type dealProposer interface {
+	ProposeDeal(ctx context.Context, miner address.Address, data cid.Cid, askID uint64, duration uint64, allowDuplicates bool) (*storagedeal.Response, error)
+}
+
+// pieceRetriever retrieves a piece from a miner, typically a retrieval.API.
+type pieceRetriever interface {
+	RetrievePiece(ctx context.Context, pieceCID cid.Cid, mpid peer.ID, minerAddr address.Address) (io.ReadCloser, error)
+}
+
+// RenewalResult describes the outcome of renewing or repairing a single deal.
+type RenewalResult struct {
+	// ProposalCid identifies the deal that was renewed.
+	ProposalCid cid.Cid
+	PieceRef    cid.Cid
+	// OldMiner is the miner that stored the renewed deal.
+	OldMiner address.Address
+	// Miner is the miner the new deal was proposed to.
+	Miner address.Address
+	// Repaired is true if the old miner was slashed or unreachable and the
+	// piece was stored with another miner.
+	Repaired bool
+	Response *storagedeal.Response
+	Error    error
+}
+
+// Renewer renews client deals that are about to end. Deals are re-proposed
+// to the same miner when it is healthy, and otherwise repaired by storing the
+// piece with the miner that offers the best current ask.
+type Renewer struct {
+	api       renewerPorcelainAPI
+	proposer  dealProposer
+	retriever pieceRetriever
+	log       logging.EventLogger
+}
+
+// NewRenewer creates a new deal renewer.
+func NewRenewer(api renewerPorcelainAPI, proposer dealProposer, retriever pieceRetriever) *Renewer {
+	return &Renewer{
+		api:       api,
+		proposer:  proposer,
+		retriever: retriever,
+		log:       logging.Logger("storage/renewer"),
+	}
+}
+
+// RenewDeals renews every stored client deal that ends within the given
+// number of blocks of the current chain height. A duration of zero renews each
+// deal for its original duration. The returned channel receives one result per
+// renewed deal and is closed when all deals have been processed.
+func (r *Renewer) RenewDeals(ctx context.Context, within uint64, duration uint64) (<-chan RenewalResult, error) {
+	deals, err := r.expiringDeals(ctx, within)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan RenewalResult)
+	go func() {
+		defer close(out)
+		for _, deal := range deals {
+			result := r.renewDeal(ctx, deal, deals, duration)
+			select {
+			case out <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// expiringDeals returns the client deals ending within the given number of
+// blocks that have not been renewed yet.
+func (r *Renewer) expiringDeals(ctx context.Context, within uint64) ([]*storagedeal.Deal, error) {
+	height, err := r.api.ChainBlockHeight()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get current block height")
+	}
+	limit := height.Add(types.NewBlockHeight(within))
+
+	minerAddr, err := r.api.ConfigGet("mining.minerAddress")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get current miner address")
+	}
+
+	dealCh, err := r.api.DealsLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var deals []*storagedeal.Deal
+	for result := range dealCh {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		deal := result.Deal
+		if deal.Miner == minerAddr || deal.RenewedAs != nil {
+			continue
+		}
+		if deal.Response == nil || deal.Response.State == storagedeal.Rejected || deal.Response.State == storagedeal.Failed {
+			continue
+		}
+		end := dealEndHeight(&deal)
+		if end == nil || end.GreaterThan(limit) {
+			continue
+		}
+		deals = append(deals, &deal)
+	}
+	return deals, nil
+}
+
+// dealEndHeight returns the height at which a deal's storage ends, or nil if
+// it cannot be determined. Deals recorded before their start height was stored
+// end with their last payment.
+func dealEndHeight(deal *storagedeal.Deal) *types.BlockHeight {
+	if deal.StartHeight != nil {
+		return deal.StartHeight.Add(types.NewBlockHeight(deal.Proposal.Duration))
+	}
+
+	var end *types.BlockHeight
+	for _, v := range deal.Proposal.Payment.Vouchers {
+		if end == nil || v.ValidAt.GreaterThan(end) {
+			end = &v.ValidAt
+		}
+	}
+	return end
+}
+
+func (r *Renewer) renewDeal(ctx context.Context, deal *storagedeal.Deal, deals []*storagedeal.Deal, duration uint64) RenewalResult {
+	result := RenewalResult{
+		ProposalCid: deal.Response.ProposalCid,
+		PieceRef:    deal.Proposal.PieceRef,
+		OldMiner:    deal.Miner,
+	}
+	if duration == 0 {
+		duration = deal.Proposal.Duration
+	}
+
+	asks, err := currentAsks(ctx, r.api)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	healthy, reason := r.minerHealthy(ctx, deal)
+	if healthy {
+		// Prefer the miner already storing the piece and only move the data
+		// when it no longer accepts it.
+		result.Miner, result.Response, err = r.proposeToAsks(ctx, deal.Proposal.PieceRef, duration, asksFrom(asks, deal.Miner))
+		if err != nil {
+			r.log.Infof("could not renew deal %s with miner %s, looking for another miner: %s", result.ProposalCid, deal.Miner, err)
+		}
+	} else {
+		r.log.Infof("repairing deal %s: %s", result.ProposalCid, reason)
+		result.Repaired = true
+
+		if err := r.ensurePieceAvailable(ctx, deal, deals); err != nil {
+			result.Error = errors.Wrapf(err, "could not recover piece %s", deal.Proposal.PieceRef)
+			return result
+		}
+	}
+
+	if result.Response == nil {
+		result.Miner, result.Response, err = r.proposeToAsks(ctx, deal.Proposal.PieceRef, duration, asksExcluding(asks, deal.Miner))
+		if err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	if err := r.markRenewed(ctx, deal.Response.ProposalCid, result.Response.ProposalCid); err != nil {
+		result.Error = err
+	}
+	return result
+}
+
+// askLister lists the asks in the storage market.
+type askLister interface {
+	ChainBlockHeight() (*types.BlockHeight, error)
+	ClientListAsks(ctx context.Context) <-chan porcelain.Ask
+}
+
+// currentAsks returns all unexpired asks ordered by ascending price.
+func currentAsks(ctx context.Context, api askLister) ([]porcelain.Ask, error) {
+	height, err := api.ChainBlockHeight()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get current block height")
+	}
+
+	var asks []porcelain.Ask
+	for ask := range api.ClientListAsks(ctx) {
+		if ask.Error != nil {
+			return nil, errors.Wrap(ask.Error, "could not list asks")
+		}
+		if ask.Expiry.LessEqual(height) {
+			continue
+		}
+		asks = append(asks, ask)
+	}
+
+	sort.SliceStable(asks, func(i, j int) bool {
+		return asks[i].Price.LessThan(asks[j].Price)
+	})
+	return asks, nil
+}
+
+func asksFrom(asks []porcelain.Ask, minerAddr address.Address) []porcelain.Ask {
+	var out []porcelain.Ask
+	for _, ask := range asks {
+		if ask.Miner == minerAddr {
+			out = append(out, ask)
+		}
+	}
+	return out
+}
+
+func asksExcluding(asks []porcelain.Ask, minerAddr address.Address) []porcelain.Ask {
+	var out []porcelain.Ask
+	for _, ask := range asks {
+		if ask.Miner != minerAddr {
+			out = append(out, ask)
+		}
+	}
+	return out
+}
+
+// proposeToAsks proposes a deal for the piece to each ask in turn until a
+// miner accepts it.
+func (r *Renewer) proposeToAsks(ctx context.Context, pieceRef cid.Cid, duration uint64, asks []porcelain.Ask) (address.Address, *storagedeal.Response, error) {
+	if len(asks) == 0 {
+		return address.Undef, nil, errors.New("no current asks available")
+	}
+
+	var lastErr error
+	for _, ask := range asks {
+		resp, err := r.proposer.ProposeDeal(ctx, ask.Miner, pieceRef, ask.ID, duration, true)
+		if err != nil {
+			lastErr = err
+			r.log.Infof("miner %s did not accept deal for %s: %s", ask.Miner, pieceRef, err)
+			continue
+		}
+		if resp.State == storagedeal.Rejected || resp.State == storagedeal.Failed {
+			lastErr = fmt.Errorf("deal %s: %s", resp.State, resp.Message)
+			continue
+		}
+		return ask.Miner, resp, nil
+	}
+	return address.Undef, nil, errors.Wrap(lastErr, "no miner accepted the deal")
+}
+
+// minerHealthy reports whether the miner storing a deal can be trusted to keep
+// storing it. If not, it also returns the reason.
+func (r *Renewer) minerHealthy(ctx context.Context, deal *storagedeal.Deal) (bool, string) {
+	if deal.Verification == storagedeal.Suspicious {
+		return false, fmt.Sprintf("deal failed verification: %s", deal.VerificationMessage)
+	}
+
+	ret, err := r.api.MessageQuery(ctx, address.Undef, deal.Miner, "getPoStState")
+	if err != nil {
+		return false, fmt.Sprintf("could not get PoSt state of miner %s: %s", deal.Miner, err)
+	}
+	if len(ret) > 0 && big.NewInt(0).SetBytes(ret[0]).Int64() == miner.PoStStateAfterGenerationAttackThreshold {
+		return false, fmt.Sprintf("miner %s missed its proof of storage and can be slashed", deal.Miner)
+	}
+
+	pid, err := r.api.MinerGetPeerID(ctx, deal.Miner)
+	if err != nil {
+		return false, fmt.Sprintf("could not get peer id of miner %s: %s", deal.Miner, err)
+	}
+	if err := r.api.PingMinerWithTimeout(ctx, pid, minerPingTimeout); err != nil {
+		return false, fmt.Sprintf("miner %s is offline: %s", deal.Miner, err)
+	}
+	return true, ""
+}
+
+// ensurePieceAvailable makes sure a deal's data is in the local dag so that it
+// can be proposed to another miner. Data that is not available locally is
+// retrieved from the other miners storing the same piece and, as a last resort,
+// from the deal's own miner.
+func (r *Renewer) ensurePieceAvailable(ctx context.Context, deal *storagedeal.Deal, deals []*storagedeal.Deal) error {
+	pieceRef := deal.Proposal.PieceRef
+
+	localCtx, cancel := context.WithTimeout(ctx, localDataTimeout)
+	_, err := r.api.DAGGetFileSize(localCtx, pieceRef)
+	cancel()
+	if err == nil {
+		return nil
+	}
+
+	var sources []address.Address
+	for _, other := range deals {
+		if other.Proposal.PieceRef.Equals(pieceRef) && other.Miner != deal.Miner {
+			sources = append(sources, other.Miner)
+		}
+	}
+	sources = append(sources, deal.Miner)
+
+	for _, source := range sources {
+		if err = r.retrievePiece(ctx, pieceRef, source); err == nil {
+			return nil
+		}
+		r.log.Infof("could not retrieve piece %s from miner %s: %s", pieceRef, source, err)
+	}
+	return err
+}
+
+func (r *Renewer) retrievePiece(ctx context.Context, pieceRef cid.Cid, minerAddr address.Address) error {
+	pid, err := r.api.MinerGetPeerID(ctx, minerAddr)
+	if err != nil {
+		return err
+	}
+
+	reader, err := r.retriever.RetrievePiece(ctx, pieceRef, pid, minerAddr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			r.log.Errorf("failed to close piece reader: %s", err)
+		}
+	}()
+
+	node, err := r.api.DAGImportData(ctx, reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to import retrieved piece")
+	}
+	if !node.Cid().Equals(pieceRef) {
+		return fmt.Errorf("retrieved data has cid %s, expected %s", node.Cid(), pieceRef)
+	}
+	return nil
+}
+
+// markRenewed records on the old deal which deal replaced it, so that it is
+// not renewed again.
+func (r *Renewer) markRenewed(ctx context.Context, old cid.Cid, renewal cid.Cid) error {
+	deal, err := r.api.DealGet(ctx, old)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get deal %s", old)
+	}
+	deal.RenewedAs = &renewal
+	if err := r.api.DealPut(deal); err != nil {
+		return errors.Wrapf(err, "failed to store deal %s", old)
+	}
+	return nil
+}
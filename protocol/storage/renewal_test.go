@@ -0,0 +1,285 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	. "github.com/filecoin-project/go-filecoin/protocol/storage"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestRenewDealsRenewsWithSameMiner(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	api := newRenewerTestAPI(t)
+	proposer := &renewerTestProposer{newCid: api.newCid}
+
+	ending := api.addDeal(api.miner, types.SomeCid(), 100, 50)
+	later := api.addDeal(api.miner, types.SomeCid(), 100, 5000)
+
+	renewer := NewRenewer(api, proposer, &renewerTestRetriever{})
+	results := collectRenewals(ctx, t, renewer, 200, 0)
+
+	require.Len(t, results, 1)
+	result := results[0]
+	require.NoError(t, result.Error)
+	assert.Equal(t, ending.Response.ProposalCid, result.ProposalCid)
+	assert.Equal(t, api.miner, result.Miner)
+	assert.False(t, result.Repaired)
+
+	// the cheapest ask of the same miner is used, for the original duration
+	require.Len(t, proposer.proposals, 1)
+	assert.Equal(t, uint64(2), proposer.proposals[0].askID)
+	assert.Equal(t, uint64(100), proposer.proposals[0].duration)
+
+	stored, err := api.DealGet(ctx, ending.Response.ProposalCid)
+	require.NoError(t, err)
+	require.NotNil(t, stored.RenewedAs)
+	assert.Equal(t, result.Response.ProposalCid, *stored.RenewedAs)
+
+	stored, err = api.DealGet(ctx, later.Response.ProposalCid)
+	require.NoError(t, err)
+	assert.Nil(t, stored.RenewedAs)
+
+	// renewed deals are not renewed again
+	assert.Len(t, collectRenewals(ctx, t, renewer, 200, 0), 0)
+}
+
+func TestRenewDealsFallsBackToBestAsk(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	api := newRenewerTestAPI(t)
+	proposer := &renewerTestProposer{newCid: api.newCid, rejects: map[address.Address]bool{api.miner: true}}
+	api.addDeal(api.miner, types.SomeCid(), 100, 50)
+
+	results := collectRenewals(ctx, t, NewRenewer(api, proposer, &renewerTestRetriever{}), 200, 300)
+
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Error)
+	assert.Equal(t, api.otherMiner, results[0].Miner)
+	assert.False(t, results[0].Repaired)
+	assert.Equal(t, uint64(300), proposer.proposals[len(proposer.proposals)-1].duration)
+}
+
+func TestRenewDealsRepairsDealsOfOfflineMiners(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	api := newRenewerTestAPI(t)
+	api.offline = map[peer.ID]bool{api.peers[api.miner]: true}
+	api.localDataErr = errors.New("not found")
+
+	data := []byte("data to repair")
+	node := dag.NewRawNode(data)
+	deal := api.addDeal(api.miner, node.Cid(), 100, 50)
+
+	proposer := &renewerTestProposer{newCid: api.newCid}
+	retriever := &renewerTestRetriever{data: data}
+	results := collectRenewals(ctx, t, NewRenewer(api, proposer, retriever), 200, 0)
+
+	require.Len(t, results, 1)
+	result := results[0]
+	require.NoError(t, result.Error)
+	assert.True(t, result.Repaired)
+	assert.Equal(t, deal.Miner, result.OldMiner)
+	assert.Equal(t, api.otherMiner, result.Miner)
+
+	assert.Equal(t, []address.Address{api.miner}, retriever.retrievedFrom)
+	assert.True(t, api.imported)
+	for _, p := range proposer.proposals {
+		assert.NotEqual(t, api.miner, p.miner)
+	}
+}
+
+func collectRenewals(ctx context.Context, t *testing.T, renewer *Renewer, within, duration uint64) []RenewalResult {
+	resultCh, err := renewer.RenewDeals(ctx, within, duration)
+	require.NoError(t, err)
+
+	var results []RenewalResult
+	for result := range resultCh {
+		results = append(results, result)
+	}
+	return results
+}
+
+type renewerTestAPI struct {
+	t            *testing.T
+	deals        map[cid.Cid]*storagedeal.Deal
+	height       *types.BlockHeight
+	miner        address.Address
+	otherMiner   address.Address
+	asks         []porcelain.Ask
+	peers        map[address.Address]peer.ID
+	offline      map[peer.ID]bool
+	localDataErr error
+	imported     bool
+	newCid       func() cid.Cid
+}
+
+func newRenewerTestAPI(t *testing.T) *renewerTestAPI {
+	addrGetter := address.NewForTestGetter()
+	minerAddr := addrGetter()
+	otherMiner := addrGetter()
+	expiry := types.NewBlockHeight(1000)
+
+	return &renewerTestAPI{
+		t:          t,
+		deals:      make(map[cid.Cid]*storagedeal.Deal),
+		newCid:     types.NewCidForTestGetter(),
+		height:     types.NewBlockHeight(100),
+		miner:      minerAddr,
+		otherMiner: otherMiner,
+		asks: []porcelain.Ask{
+			{Miner: minerAddr, ID: 1, Price: types.NewAttoFILFromFIL(30), Expiry: expiry},
+			{Miner: minerAddr, ID: 2, Price: types.NewAttoFILFromFIL(20), Expiry: expiry},
+			{Miner: minerAddr, ID: 3, Price: types.NewAttoFILFromFIL(1), Expiry: types.NewBlockHeight(10)},
+			{Miner: otherMiner, ID: 1, Price: types.NewAttoFILFromFIL(25), Expiry: expiry},
+		},
+		peers: map[address.Address]peer.ID{
+			minerAddr:  peer.ID("miner"),
+			otherMiner: peer.ID("other miner"),
+		},
+	}
+}
+
+// addDeal stores a client deal with the given miner that ends endsIn blocks
+// after the current height.
+func (api *renewerTestAPI) addDeal(minerAddr address.Address, pieceRef cid.Cid, duration uint64, endsIn uint64) *storagedeal.Deal {
+	deal := &storagedeal.Deal{
+		Miner: minerAddr,
+		Proposal: &storagedeal.Proposal{
+			PieceRef:     pieceRef,
+			Size:         types.NewBytesAmount(100),
+			Duration:     duration,
+			MinerAddress: minerAddr,
+		},
+		Response: &storagedeal.Response{
+			State:       storagedeal.Complete,
+			ProposalCid: api.newCid(),
+		},
+		StartHeight: api.height.Add(types.NewBlockHeight(endsIn)).Sub(types.NewBlockHeight(duration)),
+	}
+	api.deals[deal.Response.ProposalCid] = deal
+	return deal
+}
+
+func (api *renewerTestAPI) ChainBlockHeight() (*types.BlockHeight, error) {
+	return api.height, nil
+}
+
+func (api *renewerTestAPI) ClientListAsks(ctx context.Context) <-chan porcelain.Ask {
+	out := make(chan porcelain.Ask, len(api.asks))
+	for _, ask := range api.asks {
+		out <- ask
+	}
+	close(out)
+	return out
+}
+
+func (api *renewerTestAPI) ConfigGet(dottedPath string) (interface{}, error) {
+	return address.Undef, nil
+}
+
+func (api *renewerTestAPI) DAGGetFileSize(context.Context, cid.Cid) (uint64, error) {
+	if api.imported {
+		return 100, nil
+	}
+	return 100, api.localDataErr
+}
+
+func (api *renewerTestAPI) DAGImportData(ctx context.Context, data io.Reader) (ipld.Node, error) {
+	bs, err := ioutil.ReadAll(data)
+	require.NoError(api.t, err)
+	api.imported = true
+	return dag.NewRawNode(bs), nil
+}
+
+func (api *renewerTestAPI) DealGet(_ context.Context, dealCid cid.Cid) (*storagedeal.Deal, error) {
+	deal, ok := api.deals[dealCid]
+	if !ok {
+		return nil, porcelain.ErrDealNotFound
+	}
+	dealCopy := *deal
+	return &dealCopy, nil
+}
+
+func (api *renewerTestAPI) DealPut(deal *storagedeal.Deal) error {
+	api.deals[deal.Response.ProposalCid] = deal
+	return nil
+}
+
+func (api *renewerTestAPI) DealsLs(_ context.Context) (<-chan *porcelain.StorageDealLsResult, error) {
+	results := make(chan *porcelain.StorageDealLsResult, len(api.deals))
+	for _, deal := range api.deals {
+		results <- &porcelain.StorageDealLsResult{Deal: *deal}
+	}
+	close(results)
+	return results, nil
+}
+
+func (api *renewerTestAPI) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	require.Equal(api.t, "getPoStState", method)
+	return [][]byte{{1}}, nil
+}
+
+func (api *renewerTestAPI) MinerGetPeerID(ctx context.Context, minerAddr address.Address) (peer.ID, error) {
+	return api.peers[minerAddr], nil
+}
+
+func (api *renewerTestAPI) PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error {
+	if api.offline[p] {
+		return errors.New("no response")
+	}
+	return nil
+}
+
+type renewerTestProposal struct {
+	miner    address.Address
+	askID    uint64
+	duration uint64
+}
+
+type renewerTestProposer struct {
+	newCid    func() cid.Cid
+	proposals []renewerTestProposal
+	rejects   map[address.Address]bool
+}
+
+func (p *renewerTestProposer) ProposeDeal(ctx context.Context, miner address.Address, data cid.Cid, askID uint64, duration uint64, allowDuplicates bool) (*storagedeal.Response, error) {
+	p.proposals = append(p.proposals, renewerTestProposal{miner: miner, askID: askID, duration: duration})
+	if p.rejects[miner] {
+		return &storagedeal.Response{State: storagedeal.Rejected, Message: "no thanks"}, nil
+	}
+	return &storagedeal.Response{
+		State:       storagedeal.Accepted,
+		ProposalCid: p.newCid(),
+	}, nil
+}
+
+type renewerTestRetriever struct {
+	data          []byte
+	retrievedFrom []address.Address
+}
+
+func (r *renewerTestRetriever) RetrievePiece(ctx context.Context, pieceCID cid.Cid, mpid peer.ID, minerAddr address.Address) (io.ReadCloser, error) {
+	r.retrievedFrom = append(r.retrievedFrom, minerAddr)
+	return ioutil.NopCloser(bytes.NewReader(r.data)), nil
+}
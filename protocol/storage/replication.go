@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/proofs/libsectorbuilder"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// replicatorPorcelainAPI is the subset of the porcelain API that Replicator needs.
+type replicatorPorcelainAPI interface {
+	ChainBlockHeight() (*types.BlockHeight, error)
+	ClientListAsks(ctx context.Context) <-chan porcelain.Ask
+	MinerGetPeerID(ctx context.Context, minerAddr address.Address) (peer.ID, error)
+	MinerGetSectorSize(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error)
+	PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error
+}
+
+// pieceProposer proposes deals for prepared pieces, typically a Client.
+type pieceProposer interface {
+	PreparePiece(ctx context.Context, data cid.Cid) (*Piece, error)
+	ProposePiece(ctx context.Context, miner address.Address, piece *Piece, askID uint64, duration uint64, allowDuplicates bool) (*storagedeal.Response, error)
+}
+
+// ReplicationResult is the combined outcome of storing a piece with several miners.
+type ReplicationResult struct {
+	PieceRef cid.Cid
+	// Deals maps each miner that accepted the piece to its deal.
+	Deals map[address.Address]*storagedeal.Response
+	// Failures maps each miner that was proposed to but did not accept the
+	// piece to the reason.
+	Failures map[address.Address]error
+}
+
+// Replicator stores a piece with several distinct miners.
+type Replicator struct {
+	api      replicatorPorcelainAPI
+	proposer pieceProposer
+	log      logging.EventLogger
+}
+
+// NewReplicator creates a new replicator.
+func NewReplicator(api replicatorPorcelainAPI, proposer pieceProposer) *Replicator {
+	return &Replicator{
+		api:      api,
+		proposer: proposer,
+		log:      logging.Logger("storage/replicator"),
+	}
+}
+
+// StoreReplicas proposes deals for the data to the given number of distinct
+// miners. Miners are chosen from the current asks by price, among those whose
+// sectors fit the piece and that answer a ping. The piece commitment is
+// computed once, and miners that reject the deal are replaced by the next
+// best miner until no candidates remain. The result contains the deals made,
+// even if fewer replicas than requested could be stored.
+func (r *Replicator) StoreReplicas(ctx context.Context, data cid.Cid, duration uint64, replicas int, allowDuplicates bool) (*ReplicationResult, error) {
+	if replicas < 1 {
+		return nil, errors.New("at least one replica must be requested")
+	}
+
+	piece, err := r.proposer.PreparePiece(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := r.candidateAsks(ctx, piece.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReplicationResult{
+		PieceRef: data,
+		Deals:    make(map[address.Address]*storagedeal.Response),
+		Failures: make(map[address.Address]error),
+	}
+	for _, ask := range candidates {
+		if len(result.Deals) == replicas {
+			break
+		}
+
+		resp, err := r.proposer.ProposePiece(ctx, ask.Miner, piece, ask.ID, duration, allowDuplicates)
+		if err == nil && (resp.State == storagedeal.Rejected || resp.State == storagedeal.Failed) {
+			err = fmt.Errorf("deal %s: %s", resp.State, resp.Message)
+		}
+		if err != nil {
+			r.log.Infof("miner %s did not accept piece %s: %s", ask.Miner, data, err)
+			result.Failures[ask.Miner] = err
+			continue
+		}
+		result.Deals[ask.Miner] = resp
+	}
+
+	if len(result.Deals) < replicas {
+		return result, fmt.Errorf("stored %d of %d replicas: not enough miners accepted the piece", len(result.Deals), replicas)
+	}
+	return result, nil
+}
+
+// candidateAsks returns the cheapest ask of each reachable miner whose sectors
+// can hold a piece of the given size, ordered by ascending price.
+func (r *Replicator) candidateAsks(ctx context.Context, pieceSize uint64) ([]porcelain.Ask, error) {
+	asks, err := currentAsks(ctx, r.api)
+	if err != nil {
+		return nil, err
+	}
+
+	// asks are sorted by price, so the first ask of each miner is its cheapest
+	seen := make(map[address.Address]bool)
+	var cheapest []porcelain.Ask
+	for _, ask := range asks {
+		if seen[ask.Miner] {
+			continue
+		}
+		seen[ask.Miner] = true
+		cheapest = append(cheapest, ask)
+	}
+
+	usable := make([]bool, len(cheapest))
+	var wg sync.WaitGroup
+	for i, ask := range cheapest {
+		wg.Add(1)
+		go func(i int, minerAddr address.Address) {
+			defer wg.Done()
+			if err := r.checkMiner(ctx, minerAddr, pieceSize); err != nil {
+				r.log.Infof("not proposing to miner %s: %s", minerAddr, err)
+				return
+			}
+			usable[i] = true
+		}(i, ask.Miner)
+	}
+	wg.Wait()
+
+	var candidates []porcelain.Ask
+	for i, ask := range cheapest {
+		if usable[i] {
+			candidates = append(candidates, ask)
+		}
+	}
+	return candidates, nil
+}
+
+// checkMiner returns an error if the miner's sectors cannot hold the piece or
+// the miner does not answer a ping.
+func (r *Replicator) checkMiner(ctx context.Context, minerAddr address.Address, pieceSize uint64) error {
+	sectorSize, err := r.api.MinerGetSectorSize(ctx, minerAddr)
+	if err != nil {
+		return errors.Wrap(err, "failed to get sector size")
+	}
+	maxUserBytes := libsectorbuilder.GetMaxUserBytesPerStagedSector(sectorSize.Uint64())
+	if pieceSize > maxUserBytes {
+		return fmt.Errorf("piece is %d bytes but sector size is %d bytes", pieceSize, maxUserBytes)
+	}
+
+	pid, err := r.api.MinerGetPeerID(ctx, minerAddr)
+	if err != nil {
+		return errors.Wrap(err, "failed to get peer id")
+	}
+	return r.api.PingMinerWithTimeout(ctx, pid, minerPingTimeout)
+}
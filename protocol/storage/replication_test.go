@@ -0,0 +1,203 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	. "github.com/filecoin-project/go-filecoin/protocol/storage"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestStoreReplicas(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	data := types.SomeCid()
+
+	t.Run("stores with the cheapest distinct miners", func(t *testing.T) {
+		api, miners := newReplicatorTestAPI(5)
+		proposer := newReplicatorTestProposer()
+
+		result, err := NewReplicator(api, proposer).StoreReplicas(ctx, data, 100, 2, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, proposer.prepared)
+		assert.Equal(t, data, result.PieceRef)
+		require.Len(t, result.Deals, 2)
+		assert.Contains(t, result.Deals, miners[0])
+		assert.Contains(t, result.Deals, miners[1])
+		assert.Empty(t, result.Failures)
+
+		// the cheaper of miner 0's asks is used
+		assert.Equal(t, uint64(1), proposer.askIDs[miners[0]])
+	})
+
+	t.Run("skips unreachable miners and miners with small sectors", func(t *testing.T) {
+		api, miners := newReplicatorTestAPI(5)
+		api.sectorSizes[miners[0]] = types.OneKiBSectorSize
+		api.offline[api.peers[miners[1]]] = true
+		proposer := newReplicatorTestProposer()
+
+		result, err := NewReplicator(api, proposer).StoreReplicas(ctx, data, 100, 2, false)
+		require.NoError(t, err)
+
+		require.Len(t, result.Deals, 2)
+		assert.Contains(t, result.Deals, miners[2])
+		assert.Contains(t, result.Deals, miners[3])
+		assert.NotContains(t, proposer.askIDs, miners[0])
+		assert.NotContains(t, proposer.askIDs, miners[1])
+	})
+
+	t.Run("retries with other miners on rejection", func(t *testing.T) {
+		api, miners := newReplicatorTestAPI(4)
+		proposer := newReplicatorTestProposer()
+		proposer.rejects[miners[0]] = true
+		proposer.errors[miners[2]] = errors.New("connection reset")
+
+		result, err := NewReplicator(api, proposer).StoreReplicas(ctx, data, 100, 2, false)
+		require.NoError(t, err)
+
+		require.Len(t, result.Deals, 2)
+		assert.Contains(t, result.Deals, miners[1])
+		assert.Contains(t, result.Deals, miners[3])
+		require.Len(t, result.Failures, 2)
+		assert.Contains(t, result.Failures[miners[0]].Error(), "no thanks")
+		assert.Contains(t, result.Failures[miners[2]].Error(), "connection reset")
+		assert.Equal(t, 1, proposer.prepared)
+	})
+
+	t.Run("reports partial results when too few miners accept", func(t *testing.T) {
+		api, miners := newReplicatorTestAPI(3)
+		proposer := newReplicatorTestProposer()
+		proposer.rejects[miners[1]] = true
+
+		result, err := NewReplicator(api, proposer).StoreReplicas(ctx, data, 100, 3, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "stored 2 of 3 replicas")
+
+		require.NotNil(t, result)
+		assert.Len(t, result.Deals, 2)
+		assert.Len(t, result.Failures, 1)
+	})
+}
+
+type replicatorTestAPI struct {
+	asks        []porcelain.Ask
+	sectorSizes map[address.Address]*types.BytesAmount
+	peers       map[address.Address]peer.ID
+
+	lk      sync.Mutex
+	offline map[peer.ID]bool
+}
+
+// newReplicatorTestAPI creates a test api with the given number of miners.
+// Miners are ordered by ascending ask price, and the first miner has an
+// additional more expensive ask.
+func newReplicatorTestAPI(minerCount int) (*replicatorTestAPI, []address.Address) {
+	addrGetter := address.NewForTestGetter()
+	api := &replicatorTestAPI{
+		sectorSizes: make(map[address.Address]*types.BytesAmount),
+		peers:       make(map[address.Address]peer.ID),
+		offline:     make(map[peer.ID]bool),
+	}
+
+	var miners []address.Address
+	for i := 0; i < minerCount; i++ {
+		minerAddr := addrGetter()
+		miners = append(miners, minerAddr)
+		api.sectorSizes[minerAddr] = types.TwoHundredFiftySixMiBSectorSize
+		api.peers[minerAddr] = peer.ID(minerAddr.String())
+	}
+
+	// list asks in reverse price order to make sure they are sorted
+	for i := minerCount - 1; i >= 0; i-- {
+		api.asks = append(api.asks, porcelain.Ask{
+			Miner:  miners[i],
+			ID:     1,
+			Price:  types.NewAttoFILFromFIL(uint64(10 + i)),
+			Expiry: types.NewBlockHeight(1000),
+		})
+	}
+	api.asks = append(api.asks, porcelain.Ask{
+		Miner:  miners[0],
+		ID:     2,
+		Price:  types.NewAttoFILFromFIL(100),
+		Expiry: types.NewBlockHeight(1000),
+	})
+	return api, miners
+}
+
+func (api *replicatorTestAPI) ChainBlockHeight() (*types.BlockHeight, error) {
+	return types.NewBlockHeight(100), nil
+}
+
+func (api *replicatorTestAPI) ClientListAsks(ctx context.Context) <-chan porcelain.Ask {
+	out := make(chan porcelain.Ask, len(api.asks))
+	for _, ask := range api.asks {
+		out <- ask
+	}
+	close(out)
+	return out
+}
+
+func (api *replicatorTestAPI) MinerGetPeerID(ctx context.Context, minerAddr address.Address) (peer.ID, error) {
+	return api.peers[minerAddr], nil
+}
+
+func (api *replicatorTestAPI) MinerGetSectorSize(ctx context.Context, minerAddr address.Address) (*types.BytesAmount, error) {
+	return api.sectorSizes[minerAddr], nil
+}
+
+func (api *replicatorTestAPI) PingMinerWithTimeout(ctx context.Context, p peer.ID, to time.Duration) error {
+	api.lk.Lock()
+	defer api.lk.Unlock()
+	if api.offline[p] {
+		return errors.New("no response")
+	}
+	return nil
+}
+
+type replicatorTestProposer struct {
+	prepared int
+	askIDs   map[address.Address]uint64
+	rejects  map[address.Address]bool
+	errors   map[address.Address]error
+	newCid   func() cid.Cid
+}
+
+func newReplicatorTestProposer() *replicatorTestProposer {
+	return &replicatorTestProposer{
+		askIDs:  make(map[address.Address]uint64),
+		rejects: make(map[address.Address]bool),
+		errors:  make(map[address.Address]error),
+		newCid:  types.NewCidForTestGetter(),
+	}
+}
+
+func (p *replicatorTestProposer) PreparePiece(ctx context.Context, data cid.Cid) (*Piece, error) {
+	p.prepared++
+	return &Piece{Ref: data, Size: 2000, CommP: types.CommP{1}}, nil
+}
+
+func (p *replicatorTestProposer) ProposePiece(ctx context.Context, miner address.Address, piece *Piece, askID uint64, duration uint64, allowDuplicates bool) (*storagedeal.Response, error) {
+	p.askIDs[miner] = askID
+	if err := p.errors[miner]; err != nil {
+		return nil, err
+	}
+	if p.rejects[miner] {
+		return &storagedeal.Response{State: storagedeal.Rejected, Message: "no thanks"}, nil
+	}
+	return &storagedeal.Response{State: storagedeal.Accepted, ProposalCid: p.newCid()}, nil
+}
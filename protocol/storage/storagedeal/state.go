@@ -55,3 +55,33 @@ func (s State) String() string {
 		return fmt.Sprintf("<unrecognized %d>", s)
 	}
 }
+
+// Verification records the outcome of a client's on-chain check of a deal the
+// miner has reported complete.
+type Verification int
+
+const (
+	// Unverified means the client has not (yet) checked the deal on chain.
+	Unverified = Verification(iota)
+
+	// Verified means the sector commitment for the deal was found on chain and
+	// the miner's piece inclusion proof is valid.
+	Verified
+
+	// Suspicious means the miner reported the deal complete, but its sector
+	// commitment or piece inclusion proof could not be verified on chain.
+	Suspicious
+)
+
+func (v Verification) String() string {
+	switch v {
+	case Unverified:
+		return "unverified"
+	case Verified:
+		return "verified"
+	case Suspicious:
+		return "suspicious"
+	default:
+		return fmt.Sprintf("<unrecognized %d>", v)
+	}
+}
@@ -121,6 +121,24 @@ type Deal struct {
 	Miner    address.Address
 	Proposal *Proposal
 	Response *Response
+
+	// CommP is the piece commitment computed by the client when proposing the
+	// deal. It is only recorded by the client, which needs it to verify the
+	// miner's piece inclusion proof.
+	CommP types.CommP
+
+	// StartHeight is the chain height at which the client proposed the deal.
+	// Storage and payments for the deal's duration are counted from it.
+	StartHeight *types.BlockHeight
+
+	// RenewedAs is the proposal cid of the deal that renewed or repaired this one.
+	RenewedAs *cid.Cid
+
+	// Verification is the outcome of the client's on-chain verification of the deal.
+	Verification Verification
+
+	// VerificationMessage explains why the deal failed verification, if it did.
+	VerificationMessage string
 }
 
 // ProofInfo contains the details about a seal proof, that the client needs to know to verify that his deal was posted on chain.
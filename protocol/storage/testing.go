@@ -7,11 +7,25 @@ import (
 )
 
 // FakeProver provides fake PoSt proofs for a miner.
-type FakeProver struct{}
+type FakeProver struct {
+	// FaultySectors are the sectors the prover reports as faults when they
+	// are among its inputs.
+	FaultySectors []uint64
+}
 
-// CalculatePoSt returns a fixed fake proof.
+// CalculatePoSt returns a fixed fake proof, and the faulty sectors among the
+// inputs.
 func (p *FakeProver) CalculatePoSt(ctx context.Context, start, end *types.BlockHeight, inputs []PoStInputs) (*PoStSubmission, error) {
+	faulty := types.NewIntSet(p.FaultySectors...)
+	var faults []uint64
+	for _, input := range inputs {
+		if faulty.Has(input.SectorID) {
+			faults = append(faults, input.SectorID)
+		}
+	}
+
 	return &PoStSubmission{
 		Proofs: []types.PoStProof{[]byte("test proof")},
+		Faults: types.NewFaultSet(faults),
 	}, nil
 }
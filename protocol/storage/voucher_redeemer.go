@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	"github.com/filecoin-project/go-filecoin/types"
+	vmErrors "github.com/filecoin-project/go-filecoin/vm/errors"
+)
+
+const (
+	// redeemEolMargin is the number of blocks before a channel's eol within
+	// which its best voucher is redeemed without waiting for the next batch.
+	redeemEolMargin = 10
+
+	// TODO: replace this with a query to pick a reasonable gas price.
+	redeemGasPrice = 1
+)
+
+// voucherRedeemerPorcelain is the subset of the porcelain API that VoucherRedeemer needs.
+type voucherRedeemerPorcelain interface {
+	ConfigGet(dottedPath string) (interface{}, error)
+	DealRedeem(ctx context.Context, fromAddr address.Address, dealCid cid.Cid, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error)
+	DealRedeemPreview(ctx context.Context, fromAddr address.Address, dealCid cid.Cid) (types.GasUnits, error)
+	DealsLs(ctx context.Context) (<-chan *porcelain.StorageDealLsResult, error)
+	MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error)
+	MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error
+}
+
+// channelRedemption is the best voucher that can be redeemed from a lane of a
+// payment channel, along with the deal it was received in.
+type channelRedemption struct {
+	payer   address.Address
+	channel types.ChannelID
+	lane    uint64
+	amount  types.AttoFIL
+	dealCid cid.Cid
+}
+
+// VoucherRedeemer implements the optional voucher redemption policy of a
+// storage miner. When mining.autoRedeemVouchers is set, it redeems the best
+// valid voucher of each payment channel lane of the miner's deals, batching
+// redemptions over mining.voucherRedeemBatchBlocks blocks. Channels that
+// would reach their eol before the next batch are redeemed right away.
+type VoucherRedeemer struct {
+	minerAddr address.Address
+	ownerAddr address.Address
+	api       voucherRedeemerPorcelain
+
+	// lk guards inProgress and lastBatch.
+	lk         sync.Mutex
+	inProgress bool
+	lastBatch  *types.BlockHeight
+}
+
+// NewVoucherRedeemer creates a new voucher redeemer for the given miner. The
+// owner is the target of the payment channels and sends the redeem messages.
+func NewVoucherRedeemer(minerAddr, ownerAddr address.Address, api voucherRedeemerPorcelain) *VoucherRedeemer {
+	return &VoucherRedeemer{
+		minerAddr: minerAddr,
+		ownerAddr: ownerAddr,
+		api:       api,
+	}
+}
+
+// OnNewHeaviestTipSet is a callback called by node, every time the latest head
+// is updated. If the redemption policy is enabled and no redemption is in
+// progress, it starts redeeming vouchers in the background.
+func (vr *VoucherRedeemer) OnNewHeaviestTipSet(ts types.TipSet) error {
+	enabled, err := vr.api.ConfigGet("mining.autoRedeemVouchers")
+	if err != nil {
+		return err
+	}
+	if on, ok := enabled.(bool); !ok || !on {
+		return nil
+	}
+
+	height, err := ts.Height()
+	if err != nil {
+		return err
+	}
+
+	vr.lk.Lock()
+	defer vr.lk.Unlock()
+	if vr.inProgress {
+		return nil
+	}
+	vr.inProgress = true
+
+	go func() {
+		defer func() {
+			vr.lk.Lock()
+			vr.inProgress = false
+			vr.lk.Unlock()
+		}()
+
+		if err := vr.RedeemVouchers(context.Background(), types.NewBlockHeight(height)); err != nil {
+			log.Errorf("failed to redeem vouchers: %s", err)
+		}
+	}()
+	return nil
+}
+
+// RedeemVouchers redeems the best voucher valid at the given height for each
+// payment channel lane of the miner's deals, if it is worth more than the
+// amount already redeemed from the lane. Outside of batch heights only channels close to their eol
+// are redeemed. Gas limits are set from a preview of each redemption, and all
+// redeem messages are sent before waiting for them to be mined.
+func (vr *VoucherRedeemer) RedeemVouchers(ctx context.Context, height *types.BlockHeight) error {
+	batchBlocks, err := vr.batchBlocks()
+	if err != nil {
+		return err
+	}
+
+	vr.lk.Lock()
+	batchDue := vr.lastBatch == nil || height.GreaterEqual(vr.lastBatch.Add(types.NewBlockHeight(batchBlocks)))
+	vr.lk.Unlock()
+
+	redemptions, err := vr.bestVouchers(ctx, height)
+	if err != nil {
+		return err
+	}
+
+	// redemptions must be mined before the eol of their channel
+	urgentBefore := height.Add(types.NewBlockHeight(batchBlocks + redeemEolMargin))
+	channels := make(map[address.Address]map[string]*paymentbroker.PaymentChannel)
+	var msgCids []cid.Cid
+	var attempts, failures int
+	for _, redemption := range redemptions {
+		if _, ok := channels[redemption.payer]; !ok {
+			payerChannels, err := vr.payerChannels(ctx, redemption.payer)
+			if err != nil {
+				return err
+			}
+			channels[redemption.payer] = payerChannels
+		}
+
+		channel, ok := channels[redemption.payer][redemption.channel.KeyString()]
+		if !ok || height.GreaterEqual(channel.Eol) {
+			// the channel has been closed, reclaimed or has expired
+			continue
+		}
+		if redemption.amount.LessEqual(channel.LaneRedeemed(redemption.lane)) {
+			continue
+		}
+		if !batchDue && channel.Eol.GreaterThan(urgentBefore) {
+			continue
+		}
+
+		attempts++
+		msgCid, err := vr.redeem(ctx, redemption.dealCid)
+		if err != nil {
+			log.Errorf("failed to redeem voucher for lane %d of channel %s of payer %s: %s", redemption.lane, redemption.channel.String(), redemption.payer, err)
+			failures++
+			continue
+		}
+		log.Infof("redeeming %s from lane %d of channel %s of payer %s", redemption.amount, redemption.lane, redemption.channel.String(), redemption.payer)
+		msgCids = append(msgCids, msgCid)
+	}
+
+	if batchDue {
+		vr.lk.Lock()
+		vr.lastBatch = height
+		vr.lk.Unlock()
+	}
+
+	for _, msgCid := range msgCids {
+		err := vr.api.MessageWait(ctx, msgCid, func(blk *types.Block, smsg *types.SignedMessage, receipt *types.MessageReceipt) error {
+			if receipt.ExitCode != uint8(0) {
+				return vmErrors.VMExitCodeToError(receipt.ExitCode, paymentbroker.Errors)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf("redeem message %s failed: %s", msgCid, err)
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d voucher redemptions failed", failures, attempts)
+	}
+	return nil
+}
+
+// bestVouchers returns, for each payment channel lane of the miner's deals,
+// the highest voucher that can be redeemed at the given height.
+func (vr *VoucherRedeemer) bestVouchers(ctx context.Context, height *types.BlockHeight) ([]*channelRedemption, error) {
+	dealCh, err := vr.api.DealsLs(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list deals")
+	}
+
+	best := make(map[string]*channelRedemption)
+	var order []string
+	for result := range dealCh {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+
+		deal := result.Deal
+		if deal.Miner != vr.minerAddr || deal.Proposal == nil || deal.Response == nil {
+			continue
+		}
+		if deal.Response.State == storagedeal.Rejected || deal.Response.State == storagedeal.Failed {
+			continue
+		}
+
+		voucher := redeemableVoucher(&deal, height)
+		if voucher == nil {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%d", voucher.Payer, voucher.Channel.KeyString(), voucher.Lane)
+		current, ok := best[key]
+		if !ok {
+			order = append(order, key)
+		} else if !voucher.Amount.GreaterThan(current.amount) {
+			continue
+		}
+		best[key] = &channelRedemption{
+			payer:   voucher.Payer,
+			channel: voucher.Channel,
+			lane:    voucher.Lane,
+			amount:  voucher.Amount,
+			dealCid: deal.Response.ProposalCid,
+		}
+	}
+
+	redemptions := make([]*channelRedemption, 0, len(order))
+	for _, key := range order {
+		redemptions = append(redemptions, best[key])
+	}
+	return redemptions, nil
+}
+
+// redeemableVoucher returns the highest voucher of the deal that is valid at
+// the given height, the one DealRedeem would use, or nil if there is none or
+// it is conditional and the piece inclusion proof is not known yet.
+func redeemableVoucher(deal *storagedeal.Deal, height *types.BlockHeight) *types.PaymentVoucher {
+	var voucher *types.PaymentVoucher
+	for _, v := range deal.Proposal.Payment.Vouchers {
+		if height.LessThan(&v.ValidAt) {
+			continue
+		}
+		if voucher != nil && v.Amount.LessThan(voucher.Amount) {
+			continue
+		}
+		voucher = v
+	}
+	if voucher == nil {
+		return nil
+	}
+
+	if voucher.Condition != nil {
+		proofInfo := deal.Response.ProofInfo
+		if proofInfo == nil || len(proofInfo.PieceInclusionProof) == 0 {
+			return nil
+		}
+	}
+	return voucher
+}
+
+func (vr *VoucherRedeemer) redeem(ctx context.Context, dealCid cid.Cid) (cid.Cid, error) {
+	gasLimit, err := vr.api.DealRedeemPreview(ctx, vr.ownerAddr, dealCid)
+	if err != nil {
+		return cid.Undef, errors.Wrap(err, "failed to preview redemption")
+	}
+	return vr.api.DealRedeem(ctx, vr.ownerAddr, dealCid, types.NewGasPrice(redeemGasPrice), gasLimit)
+}
+
+func (vr *VoucherRedeemer) payerChannels(ctx context.Context, payer address.Address) (map[string]*paymentbroker.PaymentChannel, error) {
+	ret, err := vr.api.MessageQuery(ctx, address.Undef, address.PaymentBrokerAddress, "ls", payer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get payment channels of payer %s", payer)
+	}
+
+	var channels map[string]*paymentbroker.PaymentChannel
+	if err := cbor.DecodeInto(ret[0], &channels); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode payment channels of payer %s", payer)
+	}
+	return channels, nil
+}
+
+func (vr *VoucherRedeemer) batchBlocks() (uint64, error) {
+	batchVal, err := vr.api.ConfigGet("mining.voucherRedeemBatchBlocks")
+	if err != nil {
+		return 0, err
+	}
+	batchBlocks, ok := batchVal.(uint64)
+	if !ok {
+		return 0, errors.New("could not retrieve voucherRedeemBatchBlocks from config")
+	}
+	if batchBlocks == 0 {
+		batchBlocks = 1
+	}
+	return batchBlocks, nil
+}
@@ -0,0 +1,249 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/porcelain"
+	. "github.com/filecoin-project/go-filecoin/protocol/storage"
+	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func TestVoucherRedeemer(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	height := types.NewBlockHeight(100)
+
+	t.Run("redeems the best valid voucher of each channel", func(t *testing.T) {
+		api := newVoucherRedeemerTestAPI(t)
+		api.addChannel(1, 500)
+		api.addChannel(2, 500)
+		first := api.addDeal(1, 10, 50, false)
+		second := api.addDeal(1, 20, 90, false)
+		api.addDeal(1, 30, 150, false)
+		third := api.addDeal(2, 5, 50, false)
+		redeemer := NewVoucherRedeemer(api.minerAddr, api.ownerAddr, api)
+
+		require.NoError(t, redeemer.RedeemVouchers(ctx, height))
+
+		assert.NotContains(t, api.redeemed, first)
+		assert.Equal(t, []cid.Cid{second, third}, api.redeemed)
+		assert.Equal(t, types.NewGasUnits(300), api.gasLimit)
+	})
+
+	t.Run("redeems the best valid voucher of each lane of a shared channel", func(t *testing.T) {
+		api := newVoucherRedeemerTestAPI(t)
+		api.addChannel(1, 500)
+		first := api.addDeal(1, 10, 50, false)
+		second := api.addDeal(1, 20, 50, false)
+		api.deals[1].Proposal.Payment.Vouchers[0].Lane = 1
+		redeemer := NewVoucherRedeemer(api.minerAddr, api.ownerAddr, api)
+
+		require.NoError(t, redeemer.RedeemVouchers(ctx, height))
+		assert.Equal(t, []cid.Cid{first, second}, api.redeemed)
+	})
+
+	t.Run("skips vouchers already redeemed or from expired channels", func(t *testing.T) {
+		api := newVoucherRedeemerTestAPI(t)
+		api.addChannel(1, 500)
+		api.channels["1"].Lanes = []*paymentbroker.LaneState{{ID: 0, AmountRedeemed: types.NewAttoFILFromFIL(20)}}
+		api.addChannel(2, 100)
+		api.addDeal(1, 20, 50, false)
+		api.addDeal(2, 10, 50, false)
+		redeemer := NewVoucherRedeemer(api.minerAddr, api.ownerAddr, api)
+
+		require.NoError(t, redeemer.RedeemVouchers(ctx, height))
+		assert.Len(t, api.redeemed, 0)
+	})
+
+	t.Run("waits for the piece inclusion proof of conditional vouchers", func(t *testing.T) {
+		api := newVoucherRedeemerTestAPI(t)
+		api.addChannel(1, 500)
+		dealCid := api.addDeal(1, 10, 50, true)
+		redeemer := NewVoucherRedeemer(api.minerAddr, api.ownerAddr, api)
+
+		require.NoError(t, redeemer.RedeemVouchers(ctx, height))
+		assert.Len(t, api.redeemed, 0)
+
+		api.deals[0].Response.ProofInfo = &storagedeal.ProofInfo{
+			SectorID:            3,
+			PieceInclusionProof: []byte{1, 2, 3},
+		}
+		require.NoError(t, redeemer.RedeemVouchers(ctx, height))
+		assert.Equal(t, []cid.Cid{dealCid}, api.redeemed)
+	})
+
+	t.Run("batches redemptions unless a channel is about to expire", func(t *testing.T) {
+		api := newVoucherRedeemerTestAPI(t)
+		api.batchBlocks = 10
+		api.addChannel(1, 500)
+		api.addChannel(2, 125)
+		api.addDeal(1, 10, 50, false)
+		redeemer := NewVoucherRedeemer(api.minerAddr, api.ownerAddr, api)
+
+		require.NoError(t, redeemer.RedeemVouchers(ctx, height))
+		require.Len(t, api.redeemed, 1)
+
+		// new vouchers are not redeemed before the next batch...
+		api.addDeal(1, 20, 50, false)
+		nearEol := api.addDeal(2, 10, 50, false)
+		require.NoError(t, redeemer.RedeemVouchers(ctx, types.NewBlockHeight(105)))
+
+		// ...except for channels that would expire before it
+		assert.Len(t, api.redeemed, 2)
+		assert.Equal(t, nearEol, api.redeemed[1])
+
+		require.NoError(t, redeemer.RedeemVouchers(ctx, types.NewBlockHeight(110)))
+		assert.Len(t, api.redeemed, 3)
+	})
+
+	t.Run("does nothing when disabled", func(t *testing.T) {
+		api := newVoucherRedeemerTestAPI(t)
+		api.enabled = false
+		api.addChannel(1, 500)
+		api.addDeal(1, 10, 50, false)
+		redeemer := NewVoucherRedeemer(api.minerAddr, api.ownerAddr, api)
+
+		require.NoError(t, redeemer.OnNewHeaviestTipSet(types.RequireNewTipSet(t, &types.Block{Height: 100})))
+		assert.Len(t, api.redeemed, 0)
+	})
+
+	t.Run("reports failed redemptions", func(t *testing.T) {
+		api := newVoucherRedeemerTestAPI(t)
+		api.exitCode = paymentbroker.ErrInvalidSignature
+		api.addChannel(1, 500)
+		api.addDeal(1, 10, 50, false)
+		redeemer := NewVoucherRedeemer(api.minerAddr, api.ownerAddr, api)
+
+		err := redeemer.RedeemVouchers(ctx, height)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "1 of 1 voucher redemptions failed")
+	})
+}
+
+type voucherRedeemerTestAPI struct {
+	t           *testing.T
+	minerAddr   address.Address
+	ownerAddr   address.Address
+	payerAddr   address.Address
+	enabled     bool
+	batchBlocks uint64
+	channels    map[string]*paymentbroker.PaymentChannel
+	deals       []*storagedeal.Deal
+	redeemed    []cid.Cid
+	gasLimit    types.GasUnits
+	exitCode    uint8
+	cidGetter   func() cid.Cid
+}
+
+func newVoucherRedeemerTestAPI(t *testing.T) *voucherRedeemerTestAPI {
+	addrGetter := address.NewForTestGetter()
+	return &voucherRedeemerTestAPI{
+		t:           t,
+		minerAddr:   addrGetter(),
+		ownerAddr:   addrGetter(),
+		payerAddr:   addrGetter(),
+		enabled:     true,
+		batchBlocks: 1,
+		channels:    make(map[string]*paymentbroker.PaymentChannel),
+		cidGetter:   types.NewCidForTestGetter(),
+	}
+}
+
+func (api *voucherRedeemerTestAPI) addChannel(id uint64, eol uint64) {
+	api.channels[types.NewChannelID(id).KeyString()] = &paymentbroker.PaymentChannel{
+		Target:         api.ownerAddr,
+		Amount:         types.NewAttoFILFromFIL(100),
+		AmountRedeemed: types.ZeroAttoFIL,
+		Eol:            types.NewBlockHeight(eol),
+	}
+}
+
+func (api *voucherRedeemerTestAPI) addDeal(channel uint64, amount uint64, validAt uint64, conditional bool) cid.Cid {
+	voucher := &types.PaymentVoucher{
+		Channel: *types.NewChannelID(channel),
+		Payer:   api.payerAddr,
+		Target:  api.ownerAddr,
+		Amount:  types.NewAttoFILFromFIL(amount),
+		ValidAt: *types.NewBlockHeight(validAt),
+	}
+	if conditional {
+		voucher.Condition = &types.Predicate{To: api.minerAddr, Method: "verifyPieceCommitment"}
+	}
+
+	dealCid := api.cidGetter()
+	api.deals = append(api.deals, &storagedeal.Deal{
+		Miner: api.minerAddr,
+		Proposal: &storagedeal.Proposal{
+			Payment: storagedeal.PaymentInfo{Vouchers: []*types.PaymentVoucher{voucher}},
+		},
+		Response: &storagedeal.Response{
+			State:       storagedeal.Complete,
+			ProposalCid: dealCid,
+		},
+	})
+	return dealCid
+}
+
+func (api *voucherRedeemerTestAPI) ConfigGet(dottedPath string) (interface{}, error) {
+	switch dottedPath {
+	case "mining.autoRedeemVouchers":
+		return api.enabled, nil
+	case "mining.voucherRedeemBatchBlocks":
+		return api.batchBlocks, nil
+	}
+	return nil, nil
+}
+
+func (api *voucherRedeemerTestAPI) DealRedeem(ctx context.Context, fromAddr address.Address, dealCid cid.Cid, gasPrice types.AttoFIL, gasLimit types.GasUnits) (cid.Cid, error) {
+	require.Equal(api.t, api.ownerAddr, fromAddr)
+	api.redeemed = append(api.redeemed, dealCid)
+	api.gasLimit = gasLimit
+
+	for _, deal := range api.deals {
+		if deal.Response.ProposalCid.Equals(dealCid) {
+			voucher := deal.Proposal.Payment.Vouchers[0]
+			channel := api.channels[voucher.Channel.KeyString()]
+			if lane := channel.Lane(voucher.Lane); lane != nil {
+				lane.AmountRedeemed = voucher.Amount
+			} else {
+				channel.Lanes = append(channel.Lanes, &paymentbroker.LaneState{ID: voucher.Lane, AmountRedeemed: voucher.Amount})
+			}
+		}
+	}
+	return types.SomeCid(), nil
+}
+
+func (api *voucherRedeemerTestAPI) DealRedeemPreview(ctx context.Context, fromAddr address.Address, dealCid cid.Cid) (types.GasUnits, error) {
+	return types.NewGasUnits(300), nil
+}
+
+func (api *voucherRedeemerTestAPI) DealsLs(ctx context.Context) (<-chan *porcelain.StorageDealLsResult, error) {
+	out := make(chan *porcelain.StorageDealLsResult, len(api.deals))
+	for _, deal := range api.deals {
+		out <- &porcelain.StorageDealLsResult{Deal: *deal}
+	}
+	close(out)
+	return out, nil
+}
+
+func (api *voucherRedeemerTestAPI) MessageQuery(ctx context.Context, optFrom, to address.Address, method string, params ...interface{}) ([][]byte, error) {
+	require.Equal(api.t, "ls", method)
+	channels, err := cbor.DumpObject(api.channels)
+	require.NoError(api.t, err)
+	return [][]byte{channels}, nil
+}
+
+func (api *voucherRedeemerTestAPI) MessageWait(ctx context.Context, msgCid cid.Cid, cb func(*types.Block, *types.SignedMessage, *types.MessageReceipt) error) error {
+	return cb(&types.Block{}, &types.SignedMessage{}, &types.MessageReceipt{ExitCode: api.exitCode})
+}
@@ -53,7 +53,12 @@ const (
 	"mining": {
 		"minerAddress": "empty",
 		"autoSealIntervalSeconds": 120,
-		"storagePrice": "0"
+		"storagePrice": "0",
+		"autoRepriceAsks": false,
+		"askDurationBlocks": 1000,
+		"autoRedeemVouchers": false,
+		"voucherRedeemBatchBlocks": 1,
+		"reportConsensusFaults": false
 	},
 	"mpool": {
 		"maxPoolSize": 10000,
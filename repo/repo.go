@@ -8,7 +8,7 @@ import (
 )
 
 // Version is the version of repo schema that this code understands.
-const Version uint = 2
+const Version uint = 3
 
 // Datastore is the datastore interface provided by the repo
 type Datastore interface {
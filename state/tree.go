@@ -12,6 +12,7 @@ import (
 	"github.com/polydawn/refmt/shared"
 
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/exec"
 )
@@ -135,10 +136,14 @@ func (e actorNotFoundError) ActorNotFound() bool {
 }
 
 func (t *tree) GetBuiltinActorCode(codePointer cid.Cid) (exec.ExecutableActor, error) {
+	return getBuiltinActorCode(t.builtinActors, codePointer)
+}
+
+func getBuiltinActorCode(builtinActors map[cid.Cid]exec.ExecutableActor, codePointer cid.Cid) (exec.ExecutableActor, error) {
 	if !codePointer.Defined() {
 		return nil, fmt.Errorf("missing code")
 	}
-	actor, ok := t.builtinActors[codePointer]
+	actor, ok := builtinActors[codePointer]
 	if !ok {
 		return nil, fmt.Errorf("unknown code: %s", codePointer.String())
 	}
@@ -146,10 +151,36 @@ func (t *tree) GetBuiltinActorCode(codePointer cid.Cid) (exec.ExecutableActor, e
 	return actor, nil
 }
 
-// GetActor retrieves an actor by their address. If no actor
-// exists at the given address then an error will be returned
-// for which IsActorNotFoundError(err) is true.
+// treeWithActors is a tree whose actors execute with other builtin actor
+// implementations.
+type treeWithActors struct {
+	Tree
+	builtinActors map[cid.Cid]exec.ExecutableActor
+}
+
+// NewTreeWithActors returns a view of the given tree whose actors execute
+// with the given builtin actor implementations, e.g. those of a protocol
+// version. Changes made through the view are made to the given tree.
+func NewTreeWithActors(st Tree, builtinActors map[cid.Cid]exec.ExecutableActor) Tree {
+	if wrapped, ok := st.(*treeWithActors); ok {
+		st = wrapped.Tree
+	}
+	return &treeWithActors{Tree: st, builtinActors: builtinActors}
+}
+
+func (t *treeWithActors) GetBuiltinActorCode(codePointer cid.Cid) (exec.ExecutableActor, error) {
+	return getBuiltinActorCode(t.builtinActors, codePointer)
+}
+
+// GetActor retrieves an actor by their address, or by the ID address the
+// init actor assigned it. If no actor exists at the given address then an
+// error will be returned for which IsActorNotFoundError(err) is true.
 func (t *tree) GetActor(ctx context.Context, a address.Address) (*actor.Actor, error) {
+	a, err := t.resolveAddress(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+
 	data, err := t.root.Find(ctx, a.String())
 	if err == hamt.ErrNotFound {
 		return nil, &actorNotFoundError{}
@@ -193,14 +224,67 @@ func (t *tree) GetOrCreateActor(ctx context.Context, address address.Address, cr
 }
 
 // SetActor sets the memory slot at address 'a' to the given actor.
-// This operation can overwrite existing actors at that address.
+// This operation can overwrite existing actors at that address. Actors set
+// at an ID address the init actor assigned are set at the address it was
+// assigned to.
 func (t *tree) SetActor(ctx context.Context, a address.Address, act *actor.Actor) error {
+	a, err := t.resolveAddress(ctx, a)
+	if err != nil {
+		return err
+	}
+
 	if err := t.root.Set(ctx, a.String(), act); err != nil {
 		return errors.Wrap(err, "setting actor in state tree failed")
 	}
 	return nil
 }
 
+// resolveAddress returns the address the actor at a is stored under: the
+// address its ID address was assigned to, for ID addresses the init actor
+// assigned. It reads the flushed storage of the init actor, so it does not
+// resolve the ID addresses assigned while processing messages, which
+// vm.ResolveAddress resolves.
+func (t *tree) resolveAddress(ctx context.Context, a address.Address) (address.Address, error) {
+	if !initactor.IsAssignable(a) {
+		return a, nil
+	}
+
+	data, err := t.root.Find(ctx, address.InitAddress.String())
+	if err == hamt.ErrNotFound {
+		return a, nil
+	} else if err != nil {
+		return address.Undef, err
+	}
+	var initActor actor.Actor
+	if err := hackTransferObject(data, &initActor); err != nil {
+		return address.Undef, err
+	}
+
+	var initState initactor.State
+	if err := t.store.Get(ctx, initActor.Head, &initState); err != nil {
+		return address.Undef, errors.Wrap(err, "failed to load init actor state")
+	}
+	if !initState.IDMap.Defined() {
+		return a, nil
+	}
+	ids, err := hamt.LoadNode(ctx, t.store, initState.IDMap)
+	if err != nil {
+		return address.Undef, errors.Wrap(err, "failed to load ID addresses of init actor")
+	}
+
+	value, err := ids.Find(ctx, a.String())
+	if err == hamt.ErrNotFound {
+		return a, nil
+	} else if err != nil {
+		return address.Undef, err
+	}
+	raw, ok := value.([]byte)
+	if !ok {
+		return address.Undef, errors.Errorf("expected address bytes for %s, got %T", a, value)
+	}
+	return address.NewFromBytes(raw)
+}
+
 // ForEachActor calls walkFn for each actor in the state tree
 func (t *tree) ForEachActor(ctx context.Context, walkFn ActorWalkFn) error {
 	return forEachActor(ctx, t.store, t.root, walkFn)
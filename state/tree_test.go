@@ -12,7 +12,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
 )
@@ -79,6 +81,40 @@ func TestStateErrors(t *testing.T) {
 	assert.Nil(t, tr2)
 }
 
+type versionedActor struct {
+	actor.FakeActor
+	version int
+}
+
+func TestTreeWithActors(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	oldCode, newCode := &versionedActor{version: 0}, &versionedActor{version: 1}
+	tree := NewEmptyStateTreeWithActors(cst, map[cid.Cid]exec.ExecutableActor{types.AccountActorCodeCid: oldCode})
+
+	view := NewTreeWithActors(tree, map[cid.Cid]exec.ExecutableActor{types.AccountActorCodeCid: newCode})
+	code, err := view.GetBuiltinActorCode(types.AccountActorCodeCid)
+	require.NoError(t, err)
+	assert.Equal(t, newCode, code)
+
+	code, err = tree.GetBuiltinActorCode(types.AccountActorCodeCid)
+	require.NoError(t, err)
+	assert.Equal(t, oldCode, code)
+
+	// changes through the view are made to the tree
+	addr := address.NewForTestGetter()()
+	require.NoError(t, view.SetActor(ctx, addr, actor.NewActor(types.AccountActorCodeCid, types.ZeroAttoFIL)))
+	_, err = tree.GetActor(ctx, addr)
+	assert.NoError(t, err)
+
+	// views of views replace the actors of the view
+	view = NewTreeWithActors(view, map[cid.Cid]exec.ExecutableActor{})
+	_, err = view.GetBuiltinActorCode(types.AccountActorCodeCid)
+	assert.Error(t, err)
+}
+
 func TestStateGetOrCreate(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -117,6 +153,54 @@ func TestStateGetOrCreate(t *testing.T) {
 	})
 }
 
+func TestStateIDAddresses(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+	tree := NewEmptyStateTree(cst)
+
+	addr := address.NewForTestGetter()()
+	id, err := address.NewIDAddress(initactor.FirstActorID)
+	require.NoError(t, err)
+	unassigned, err := address.NewIDAddress(initactor.FirstActorID + 1)
+	require.NoError(t, err)
+
+	// the init actor assigned id to addr
+	ids := hamt.NewNode(cst)
+	require.NoError(t, ids.Set(ctx, id.String(), addr.Bytes()))
+	require.NoError(t, ids.Flush(ctx))
+	idsCid, err := cst.Put(ctx, ids)
+	require.NoError(t, err)
+	initActor := initactor.NewActor()
+	initActor.Head, err = cst.Put(ctx, &initactor.State{NextID: initactor.FirstActorID + 1, IDMap: idsCid})
+	require.NoError(t, err)
+	require.NoError(t, tree.SetActor(ctx, address.InitAddress, initActor))
+
+	act := actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(10))
+	require.NoError(t, tree.SetActor(ctx, addr, act))
+
+	t.Run("actors are found by their ID address", func(t *testing.T) {
+		actorBack, err := tree.GetActor(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, act, actorBack)
+	})
+
+	t.Run("actors set at their ID address are set at their address", func(t *testing.T) {
+		updated := actor.NewActor(types.AccountActorCodeCid, types.NewAttoFILFromFIL(20))
+		require.NoError(t, tree.SetActor(ctx, id, updated))
+
+		actorBack, err := tree.GetActor(ctx, addr)
+		require.NoError(t, err)
+		assert.Equal(t, updated, actorBack)
+	})
+
+	t.Run("unassigned ID addresses have no actor", func(t *testing.T) {
+		_, err := tree.GetActor(ctx, unassigned)
+		assert.True(t, IsActorNotFoundError(err))
+	})
+}
+
 func TestGetAllActors(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -71,7 +71,7 @@ func MkFakeChild(params FakeChildParams) (*types.Block, error) {
 // MkFakeChildWithCon creates a chain with the given consensus weight function.
 func MkFakeChildWithCon(params FakeChildParams) (*types.Block, error) {
 	wFun := func(ts types.TipSet) (uint64, error) {
-		return params.Consensus.Weight(context.Background(), params.Parent, nil)
+		return params.Consensus.Weight(context.Background(), params.Parent, cid.Undef)
 	}
 	return MkFakeChildCore(params.Parent,
 		params.StateRoot,
@@ -873,6 +873,9 @@ func (td *TestDaemon) createNewProcess() {
 	td.process = exec.Command(td.daemonArgs[0], td.daemonArgs[1:]...)
 	// disable REUSEPORT, it creates problems in tests
 	td.process.Env = append(os.Environ(), "IPFS_REUSEPORT=false")
+	// test repos are initialized without a wallet passphrase, unlock the
+	// wallet on start
+	td.process.Env = append(td.process.Env, "FIL_WALLET_PASSPHRASE=")
 
 	// setup process pipes
 	var err error
@@ -2,10 +2,12 @@ package testhelpers
 
 import (
 	"context"
+	"os"
 	"testing"
 
 	cid "github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-blockstore"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/actor"
@@ -24,17 +26,17 @@ type TestView struct{}
 var _ consensus.PowerTableView = &TestView{}
 
 // Total always returns 1.
-func (tv *TestView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+func (tv *TestView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
 	return types.NewBytesAmount(1), nil
 }
 
 // Miner always returns 1.
-func (tv *TestView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+func (tv *TestView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
 	return types.NewBytesAmount(1), nil
 }
 
 // HasPower always returns true.
-func (tv *TestView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
+func (tv *TestView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
 
@@ -56,17 +58,17 @@ func NewTestPowerTableView(minerPower *types.BytesAmount, totalPower *types.Byte
 }
 
 // Total always returns value that was supplied to NewTestPowerTableView.
-func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
+func (tv *TestPowerTableView) Total(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore) (*types.BytesAmount, error) {
 	return tv.totalPower, nil
 }
 
 // Miner always returns value that was supplied to NewTestPowerTableView.
-func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
+func (tv *TestPowerTableView) Miner(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) (*types.BytesAmount, error) {
 	return tv.minerPower, nil
 }
 
 // HasPower always returns true.
-func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, bstore blockstore.Blockstore, mAddr address.Address) bool {
+func (tv *TestPowerTableView) HasPower(ctx context.Context, st state.Tree, stateID cid.Cid, bstore blockstore.Blockstore, mAddr address.Address) bool {
 	return true
 }
 
@@ -76,7 +78,7 @@ func NewValidTestBlockFromTipSet(baseTipSet types.TipSet, stateRootCid cid.Cid,
 	poStProof := MakeRandomPoStProofForTest()
 	ticket, _ := consensus.CreateTicket(poStProof, minerWorker, signer)
 
-	return &types.Block{
+	blk := &types.Block{
 		Miner:        minerAddr,
 		Ticket:       ticket,
 		Parents:      baseTipSet.Key(),
@@ -86,6 +88,8 @@ func NewValidTestBlockFromTipSet(baseTipSet types.TipSet, stateRootCid cid.Cid,
 		StateRoot:    stateRootCid,
 		Proof:        poStProof,
 	}
+	blk.BlockSig, _ = signer.SignBytes(blk.SignatureData(), minerWorker)
+	return blk
 }
 
 // MakeRandomPoStProofForTest creates a random proof.
@@ -136,7 +140,7 @@ func NewFakeBlockValidator() *FakeBlockValidator {
 }
 
 // ValidateSemantic does nothing.
-func (fbv *FakeBlockValidator) ValidateSemantic(ctx context.Context, child *types.Block, parents *types.TipSet) error {
+func (fbv *FakeBlockValidator) ValidateSemantic(ctx context.Context, child *types.Block, parents *types.TipSet, worker address.Address) error {
 	return nil
 }
 
@@ -170,13 +174,34 @@ func ApplyTestMessageWithGas(st state.Tree, store vm.StorageMap, msg *types.Mess
 	if err != nil {
 		panic(err)
 	}
-	applier := consensus.NewConfiguredProcessor(consensus.NewDefaultMessageValidator(), consensus.NewDefaultBlockRewarder())
-	return newMessageApplier(smsg, applier, st, store, bh, minerOwner, nil)
+	return newMessageApplier(smsg, DefaultVectorProcessor, st, store, bh, minerOwner, nil)
 }
 
-func newMessageApplier(smsg *types.SignedMessage, processor *consensus.DefaultProcessor, st state.Tree, storageMap vm.StorageMap,
+// newMessageApplier applies a message with the named vector processor. It
+// records the vector of the state transition if RecordVectorsEnv is set
+// and the message does not depend on ancestors.
+func newMessageApplier(smsg *types.SignedMessage, processor string, st state.Tree, storageMap vm.StorageMap,
 	bh *types.BlockHeight, minerOwner address.Address, ancestors []types.TipSet) (*consensus.ApplicationResult, error) {
-	amr, err := processor.ApplyMessagesAndPayRewards(context.Background(), st, storageMap, []*types.SignedMessage{smsg}, minerOwner, bh, ancestors)
+	ctx := context.Background()
+	messages := []*types.SignedMessage{smsg}
+
+	var amr consensus.ApplyMessagesResponse
+	var err error
+	if dir := os.Getenv(RecordVectorsEnv); dir != "" && len(ancestors) == 0 {
+		var v *Vector
+		v, amr, err = RecordVector(ctx, st, storageMap, processor, messages, minerOwner, bh)
+		if v != nil {
+			if _, werr := WriteVector(dir, v); werr != nil {
+				return nil, errors.Wrap(werr, "failed to write vector")
+			}
+		}
+	} else {
+		p, perr := vectorProcessor(processor)
+		if perr != nil {
+			return nil, perr
+		}
+		amr, err = p.ApplyMessagesAndPayRewards(ctx, st, storageMap, messages, minerOwner, bh, ancestors)
+	}
 
 	if len(amr.Results) > 0 {
 		return amr.Results[0], err
@@ -206,8 +231,7 @@ func applyTestMessageWithAncestors(st state.Tree, store vm.StorageMap, msg *type
 		panic(err)
 	}
 
-	ta := newTestApplier()
-	return newMessageApplier(smsg, ta, st, store, bh, address.Undef, ancestors)
+	return newMessageApplier(smsg, TestVectorProcessor, st, store, bh, address.Undef, ancestors)
 }
 
 func newTestApplier() *consensus.DefaultProcessor {
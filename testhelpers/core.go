@@ -223,6 +223,7 @@ type FakeVMContext struct {
 	BalanceValue            types.AttoFIL
 	BlockHeightValue        *types.BlockHeight
 	VerifierValue           verification.Verifier
+	GasScheduleValue        *types.GasSchedule
 	RandomnessValue         []byte
 	IsFromAccountActorValue bool
 	Sender                  func(to address.Address, method string, value types.AttoFIL, params []interface{}) ([][]byte, uint8, error)
@@ -245,6 +246,7 @@ func NewFakeVMContext(message *types.Message, state interface{}) *FakeVMContext
 		StorageValue:            &testStorage{state: state},
 		BlockHeightValue:        types.NewBlockHeight(0),
 		BalanceValue:            types.ZeroAttoFIL,
+		GasScheduleValue:        types.FlatGasSchedule,
 		RandomnessValue:         randomness,
 		IsFromAccountActorValue: true,
 		Charger: func(cost types.GasUnits) error {
@@ -312,6 +314,11 @@ func (tc *FakeVMContext) Charge(cost types.GasUnits) error {
 	return tc.Charger(cost)
 }
 
+// GasSchedule returns the schedule of the gas charged for operations
+func (tc *FakeVMContext) GasSchedule() *types.GasSchedule {
+	return tc.GasScheduleValue
+}
+
 // SampleChainRandomness provides random bytes used in verification challenges
 func (tc *FakeVMContext) SampleChainRandomness(sampleHeight *types.BlockHeight) ([]byte, error) {
 	return tc.Sampler(sampleHeight)
@@ -0,0 +1,313 @@
+package testhelpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	blocks "github.com/ipfs/go-block-format"
+	bserv "github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-car"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-hamt-ipld"
+	"github.com/ipfs/go-ipfs-blockstore"
+	"github.com/ipfs/go-ipfs-exchange-offline"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	ipld "github.com/ipfs/go-ipld-format"
+	dag "github.com/ipfs/go-merkledag"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/actor/builtin"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/consensus"
+	"github.com/filecoin-project/go-filecoin/state"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/vm"
+)
+
+// RecordVectorsEnv is the environment variable naming the directory the
+// message application helpers record conformance vectors to. Vectors are
+// not recorded if it is unset.
+const RecordVectorsEnv = "FIL_RECORD_VECTORS"
+
+const (
+	// TestVectorProcessor applies the messages of a vector without
+	// validating them or paying rewards.
+	TestVectorProcessor = "test"
+
+	// DefaultVectorProcessor applies the messages of a vector with the
+	// default message validator and block rewarder.
+	DefaultVectorProcessor = "default"
+)
+
+// Vector is a state transition conformance test vector. It records the
+// receipts and post-state root a pre-state and the messages of a block at a
+// height must produce, so changes to message processing or the builtin
+// actors cannot silently change them.
+type Vector struct {
+	// Processor names the processor the messages are applied with.
+	Processor string `json:"processor"`
+
+	// Height is the height of the block of the messages.
+	Height uint64 `json:"height"`
+
+	// MinerOwner, if set, is rewarded for the block.
+	MinerOwner *address.Address `json:"minerOwner,omitempty"`
+
+	// PreState is a CAR of the state tree and actor storage of the
+	// pre-state, rooted at PreStateRoot.
+	PreState     []byte  `json:"preState"`
+	PreStateRoot cid.Cid `json:"preStateRoot"`
+
+	Messages []*types.SignedMessage `json:"messages"`
+
+	Receipts      []*types.MessageReceipt `json:"receipts"`
+	PostStateRoot cid.Cid                 `json:"postStateRoot"`
+}
+
+// builtinActorCodes are the code objects of the builtin actors, which
+// vectors embed in their pre-state.
+var builtinActorCodes = map[cid.Cid]ipld.Node{
+	types.AccountActorCodeCid:         types.AccountActorCodeObj,
+	types.StorageMarketActorCodeCid:   types.StorageMarketActorCodeObj,
+	types.PaymentBrokerActorCodeCid:   types.PaymentBrokerActorCodeObj,
+	types.MinerActorCodeCid:           types.MinerActorCodeObj,
+	types.BootstrapMinerActorCodeCid:  types.BootstrapMinerActorCodeObj,
+	types.MultisigActorCodeCid:        types.MultisigActorCodeObj,
+	types.MultisigFactoryActorCodeCid: types.MultisigFactoryActorCodeObj,
+	types.InitActorCodeCid:            types.InitActorCodeObj,
+}
+
+// errNotRecordable is returned for states vectors cannot record.
+var errNotRecordable = errors.New("state cannot be recorded")
+
+func vectorProcessor(name string) (*consensus.DefaultProcessor, error) {
+	switch name {
+	case TestVectorProcessor:
+		return newTestApplier(), nil
+	case DefaultVectorProcessor:
+		return consensus.NewConfiguredProcessor(consensus.NewDefaultMessageValidator(), consensus.NewDefaultBlockRewarder()), nil
+	default:
+		return nil, errors.Errorf("unknown vector processor %q", name)
+	}
+}
+
+// RecordVector applies messages to a state as the messages of a block at
+// the given height, and returns the vector of the state transition along
+// with the response of the processor. The vector is nil if the state holds
+// actors that are not builtin, or a message was not applied, since vectors
+// only record the builtin actors and applied messages.
+func RecordVector(ctx context.Context, st state.Tree, vms vm.StorageMap, processor string, messages []*types.SignedMessage, minerOwner address.Address, bh *types.BlockHeight) (*Vector, consensus.ApplyMessagesResponse, error) {
+	p, err := vectorProcessor(processor)
+	if err != nil {
+		return nil, consensus.ApplyMessagesResponse{}, err
+	}
+
+	preStateRoot, preState, err := snapshotState(ctx, st, vms)
+	if err != nil && err != errNotRecordable {
+		return nil, consensus.ApplyMessagesResponse{}, err
+	}
+	recordable := err == nil
+
+	amr, err := p.ApplyMessagesAndPayRewards(ctx, st, vms, messages, minerOwner, bh, nil)
+	if err != nil || !recordable || len(amr.Results) != len(messages) {
+		return nil, amr, err
+	}
+
+	postStateRoot, err := st.Flush(ctx)
+	if err != nil {
+		return nil, amr, errors.Wrap(err, "failed to flush post-state")
+	}
+
+	v := &Vector{
+		Processor:     processor,
+		Height:        bh.AsBigInt().Uint64(),
+		PreState:      preState,
+		PreStateRoot:  preStateRoot,
+		Messages:      messages,
+		PostStateRoot: postStateRoot,
+	}
+	if !minerOwner.Empty() {
+		v.MinerOwner = &minerOwner
+	}
+	for _, result := range amr.Results {
+		v.Receipts = append(v.Receipts, result.Receipt)
+	}
+	return v, amr, nil
+}
+
+// RunVector applies the messages of a vector to its pre-state in an
+// in-memory blockstore, and returns an error if the receipts or the
+// post-state root differ from those of the vector.
+func RunVector(ctx context.Context, v *Vector) error {
+	p, err := vectorProcessor(v.Processor)
+	if err != nil {
+		return err
+	}
+
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	header, err := car.LoadCar(bs, bytes.NewReader(v.PreState))
+	if err != nil {
+		return errors.Wrap(err, "failed to load pre-state")
+	}
+	if len(header.Roots) != 1 || !header.Roots[0].Equals(v.PreStateRoot) {
+		return errors.Errorf("pre-state is not rooted at %s", v.PreStateRoot)
+	}
+
+	cst := &hamt.CborIpldStore{Blocks: bserv.New(bs, offline.Exchange(bs))}
+	st, err := state.LoadStateTree(ctx, cst, v.PreStateRoot, builtin.Actors)
+	if err != nil {
+		return errors.Wrap(err, "failed to load pre-state tree")
+	}
+	vms := vm.NewStorageMap(bs)
+
+	minerOwner := address.Undef
+	if v.MinerOwner != nil {
+		minerOwner = *v.MinerOwner
+	}
+	amr, err := p.ApplyMessagesAndPayRewards(ctx, st, vms, v.Messages, minerOwner, types.NewBlockHeight(v.Height), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply messages")
+	}
+	if len(amr.Results) != len(v.Receipts) {
+		return errors.Errorf("expected %d receipts, got %d", len(v.Receipts), len(amr.Results))
+	}
+	for i, result := range amr.Results {
+		expected, err := cbor.DumpObject(v.Receipts[i])
+		if err != nil {
+			return err
+		}
+		actual, err := cbor.DumpObject(result.Receipt)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(expected, actual) {
+			return errors.Errorf("receipt %d: expected %+v, got %+v", i, v.Receipts[i], result.Receipt)
+		}
+	}
+
+	if err := vms.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush actor storage")
+	}
+	root, err := st.Flush(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to flush post-state")
+	}
+	if !root.Equals(v.PostStateRoot) {
+		return errors.Errorf("expected post-state root %s, got %s", v.PostStateRoot, root)
+	}
+	return nil
+}
+
+// LoadVector reads a vector from a JSON file.
+func LoadVector(path string) (*Vector, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var v Vector
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode vector %s", path)
+	}
+	return &v, nil
+}
+
+// WriteVector writes a vector to a JSON file in a directory, named after a
+// hash of its content, and returns the path of the file.
+func WriteVector(dir string, v *Vector) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	path := filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+	return path, ioutil.WriteFile(path, data, 0644)
+}
+
+// snapshotState returns the root of a state and a CAR of its state tree and
+// actor storage. It returns errNotRecordable if the state holds actors that
+// are not builtin.
+func snapshotState(ctx context.Context, st state.Tree, vms vm.StorageMap) (cid.Cid, []byte, error) {
+	root, err := st.Flush(ctx)
+	if err != nil {
+		return cid.Undef, nil, errors.Wrap(err, "failed to flush state")
+	}
+
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	blkserv := bserv.New(bs, offline.Exchange(bs))
+	snapshot := state.NewEmptyStateTree(&hamt.CborIpldStore{Blocks: blkserv})
+	err = st.ForEachActor(ctx, func(addr address.Address, act *actor.Actor) error {
+		if act.Code.Defined() {
+			code, ok := builtinActorCodes[act.Code]
+			if !ok {
+				return errNotRecordable
+			}
+			if err := bs.Put(code); err != nil {
+				return err
+			}
+		}
+		if err := copyActorStorage(vms.NewStorage(addr, act), bs, act.Head); err != nil {
+			return errors.Wrapf(err, "failed to copy storage of actor %s", addr)
+		}
+		return snapshot.SetActor(ctx, addr, act)
+	})
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+
+	// the state tree is rebuilt from its actors, which yields the same root
+	// since the tree is canonical
+	snapshotRoot, err := snapshot.Flush(ctx)
+	if err != nil {
+		return cid.Undef, nil, errors.Wrap(err, "failed to flush snapshot")
+	}
+	if !snapshotRoot.Equals(root) {
+		return cid.Undef, nil, errors.Errorf("snapshot root %s differs from state root %s", snapshotRoot, root)
+	}
+
+	var buf bytes.Buffer
+	if err := car.WriteCar(ctx, dag.NewDAGService(blkserv), []cid.Cid{root}, &buf); err != nil {
+		return cid.Undef, nil, errors.Wrap(err, "failed to write snapshot")
+	}
+	return root, buf.Bytes(), nil
+}
+
+// copyActorStorage copies the chunks of actor storage reachable from c to a
+// blockstore.
+func copyActorStorage(storage vm.Storage, bs blockstore.Blockstore, c cid.Cid) error {
+	if !c.Defined() {
+		return nil
+	}
+	if has, err := bs.Has(c); err != nil || has {
+		return err
+	}
+
+	raw, err := storage.Get(c)
+	if err != nil {
+		return err
+	}
+	blk, err := blocks.NewBlockWithCid(raw, c)
+	if err != nil {
+		return err
+	}
+	nd, err := cbor.DecodeBlock(blk)
+	if err != nil {
+		return err
+	}
+	if err := bs.Put(blk); err != nil {
+		return err
+	}
+
+	for _, link := range nd.Links() {
+		if err := copyActorStorage(storage, bs, link.Cid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
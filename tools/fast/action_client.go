@@ -7,6 +7,7 @@ import (
 	"io"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/commands"
 	"github.com/filecoin-project/go-filecoin/protocol/storage/storagedeal"
 
 	"github.com/ipfs/go-cid"
@@ -70,3 +71,43 @@ func (f *Filecoin) ClientQueryStorageDeal(ctx context.Context, prop cid.Cid) (*s
 func (f *Filecoin) ClientListAsks(ctx context.Context) (*json.Decoder, error) {
 	return f.RunCmdLDJSONWithStdin(ctx, nil, "go-filecoin", "client", "list-asks")
 }
+
+// ClientRenewDeals runs the client renew-deals command against the filecoin process.
+// A json decoder is returned that renewal results may be decoded from.
+func (f *Filecoin) ClientRenewDeals(ctx context.Context, within uint64, options ...ActionOption) (*json.Decoder, error) {
+	args := []string{"go-filecoin", "client", "renew-deals", fmt.Sprintf("%d", within)}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	return f.RunCmdLDJSONWithStdin(ctx, nil, args...)
+}
+
+// ClientStore runs the client store command against the filecoin process.
+func (f *Filecoin) ClientStore(ctx context.Context, data cid.Cid, duration uint64, options ...ActionOption) (*commands.ClientStoreResult, error) {
+	var out commands.ClientStoreResult
+	args := []string{"go-filecoin", "client", "store", data.String(), fmt.Sprintf("%d", duration)}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// ClientFindAsks runs the client find-asks command against the filecoin process.
+// A json decoder is returned that asks may be decoded from.
+func (f *Filecoin) ClientFindAsks(ctx context.Context, options ...ActionOption) (*json.Decoder, error) {
+	args := []string{"go-filecoin", "client", "find-asks"}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	return f.RunCmdLDJSONWithStdin(ctx, nil, args...)
+}
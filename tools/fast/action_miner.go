@@ -2,6 +2,8 @@ package fast
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"math/big"
 
 	"github.com/ipfs/go-cid"
@@ -110,3 +112,28 @@ func (f *Filecoin) MinerProvingPeriod(ctx context.Context, miner address.Address
 
 	return out, nil
 }
+
+// MinerAsksLs runs the `miner asks ls` command against the filecoin process.
+// A json decoder is returned that asks may be decoded from.
+func (f *Filecoin) MinerAsksLs(ctx context.Context, minerAddr address.Address) (*json.Decoder, error) {
+	return f.RunCmdLDJSONWithStdin(ctx, nil, "go-filecoin", "miner", "asks", "ls", minerAddr.String())
+}
+
+// MinerAsksCancel runs the `miner asks cancel` command against the filecoin process.
+func (f *Filecoin) MinerAsksCancel(ctx context.Context, askID uint64, options ...ActionOption) (cid.Cid, error) {
+	var out commands.MinerAsksCancelResult
+
+	args := []string{"go-filecoin", "miner", "asks", "cancel"}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	args = append(args, fmt.Sprintf("%d", askID))
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return cid.Undef, err
+	}
+
+	return out.Cid, nil
+}
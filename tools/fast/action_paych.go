@@ -8,9 +8,26 @@ import (
 	"github.com/filecoin-project/go-filecoin/actor/builtin/paymentbroker"
 	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/commands"
+	"github.com/filecoin-project/go-filecoin/plumbing/paych"
 	"github.com/filecoin-project/go-filecoin/types"
 )
 
+// PaychAddFunds runs the `paych add-funds` command against the filecoin process.
+func (f *Filecoin) PaychAddFunds(ctx context.Context, channel *types.ChannelID, amount types.AttoFIL, options ...ActionOption) (cid.Cid, error) {
+	var out commands.AddFundsResult
+	args := []string{"go-filecoin", "paych", "add-funds", channel.String(), amount.String()}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return cid.Undef, err
+	}
+
+	return out.Cid, nil
+}
+
 // PaychCreate runs the `paych create` command against the filecoin process.
 func (f *Filecoin) PaychCreate(ctx context.Context,
 	target address.Address, amount types.AttoFIL, eol *types.BlockHeight,
@@ -132,6 +149,22 @@ func (f *Filecoin) PaychRedeem(ctx context.Context, voucher string, options ...A
 	return out.Cid, nil
 }
 
+// PaychStatus runs the `paych status` command against the filecoin process.
+func (f *Filecoin) PaychStatus(ctx context.Context, channel *types.ChannelID, options ...ActionOption) (*paych.ChannelInfo, error) {
+	var out paych.ChannelInfo
+	args := []string{"go-filecoin", "paych", "status", channel.String()}
+
+	for _, option := range options {
+		args = append(args, option()...)
+	}
+
+	if err := f.RunCmdJSONWithStdin(ctx, nil, &out, args...); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
 // PaychVoucher runs the `paych voucher` command against the filecoin process.
 func (f *Filecoin) PaychVoucher(ctx context.Context, channel *types.ChannelID, amount types.AttoFIL, options ...ActionOption) (string, error) {
 	var out string
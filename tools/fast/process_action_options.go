@@ -3,6 +3,7 @@ package fast
 import (
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/libp2p/go-libp2p-core/peer"
 
@@ -116,6 +117,34 @@ func AOValidAt(bh *types.BlockHeight) ActionOption {
 	}
 }
 
+// AOLane provides the `--lane=<uint64>` option to actions
+func AOLane(lane uint64) ActionOption {
+	sLane := fmt.Sprintf("%d", lane)
+	return func() []string {
+		return []string{"--lane", sLane}
+	}
+}
+
+// AONonce provides the `--nonce=<uint64>` option to actions
+func AONonce(nonce uint64) ActionOption {
+	sNonce := fmt.Sprintf("%d", nonce)
+	return func() []string {
+		return []string{"--nonce", sNonce}
+	}
+}
+
+// AOMerges provides the `--merge=<lane:nonce,...>` option to actions
+func AOMerges(merges ...types.VoucherMerge) ActionOption {
+	sMerges := make([]string, len(merges))
+	for i, merge := range merges {
+		sMerges[i] = fmt.Sprintf("%d:%d", merge.Lane, merge.Nonce)
+	}
+	sMerge := strings.Join(sMerges, ",")
+	return func() []string {
+		return []string{"--merge", sMerge}
+	}
+}
+
 // AOAllowDuplicates provides the --allow-duplicates option to client propose-storage-deal
 func AOAllowDuplicates(allow bool) ActionOption {
 	sAllowDupes := fmt.Sprintf("--allow-duplicates=%t", allow)
@@ -61,6 +61,12 @@ func (l *Localfilecoin) env() ([]string, error) {
 	envs = filecoin.UpdateOrAppendEnv(envs, "GO_FILECOIN_LOG_JSON", l.logJSON)
 	envs = filecoin.UpdateOrAppendEnv(envs, "PATH", newPath)
 
+	// testbed repos are initialized without a wallet passphrase unless one is
+	// given, unlock the wallet on start
+	if _, ok := os.LookupEnv("FIL_WALLET_PASSPHRASE"); !ok {
+		envs = filecoin.UpdateOrAppendEnv(envs, "FIL_WALLET_PASSPHRASE", "")
+	}
+
 	return envs, nil
 }
 
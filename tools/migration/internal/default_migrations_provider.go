@@ -12,6 +12,7 @@ package internal
 
 import (
 	migration12 "github.com/filecoin-project/go-filecoin/tools/migration/migrations/repo-1-2"
+	migration23 "github.com/filecoin-project/go-filecoin/tools/migration/migrations/repo-2-3"
 )
 
 // DefaultMigrationsProvider is the migrations provider dependency used in production.
@@ -19,5 +20,6 @@ import (
 func DefaultMigrationsProvider() []Migration {
 	return []Migration{
 		&migration12.MetadataFormatJSONtoCBOR{},
+		&migration23.EncryptWalletKeys{},
 	}
 }
@@ -0,0 +1,153 @@
+package migration23
+
+import (
+	"os"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	dsq "github.com/ipfs/go-datastore/query"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/wallet"
+)
+
+// EncryptWalletKeys is the migration from version 2 to 3.
+type EncryptWalletKeys struct{}
+
+// Describe describes the steps this migration will take.
+func (m *EncryptWalletKeys) Describe() string {
+	return `EncryptWalletKeys migrates the storage repo from version 2 to 3.
+
+    This migration encrypts the private keys of the wallet datastore with a passphrase,
+    read from the ` + wallet.PassphraseEnvVar + ` environment variable. Each key is read in as
+    plain CBOR and rewritten encrypted. The migration fails if the variable is not
+    set, set it to the empty string to explicitly encrypt the keys with the empty
+    passphrase. No other repo data is changed.
+`
+}
+
+// Migrate performs the migration steps
+func (m *EncryptWalletKeys) Migrate(newRepoPath string) error {
+	oldVer, _ := m.Versions()
+
+	passphrase, err := lookupPassphrase()
+	if err != nil {
+		return err
+	}
+
+	// This call performs some checks on the repo before we start.
+	fsrepo, err := repo.OpenFSRepo(newRepoPath, oldVer)
+	if err != nil {
+		return err
+	}
+	defer mustCloseRepo(fsrepo)
+
+	keys, err := loadPlainKeys(fsrepo.WalletDatastore())
+	if err != nil {
+		return err
+	}
+
+	for key, ki := range keys {
+		data, err := wallet.EncryptKeyInfo(ki, passphrase)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encrypt key %s", key)
+		}
+		if err := fsrepo.WalletDatastore().Put(datastore.NewKey(key), data); err != nil {
+			return errors.Wrapf(err, "failed to write encrypted key %s", key)
+		}
+	}
+	return nil
+}
+
+// Versions returns the old and new versions that are valid for this migration
+func (m *EncryptWalletKeys) Versions() (from, to uint) {
+	return 2, 3
+}
+
+// Validate checks that every key of the old wallet datastore decrypts in the
+// new one, with the passphrase, to the same key.
+func (m *EncryptWalletKeys) Validate(oldRepoPath, newRepoPath string) error {
+	oldVer, _ := m.Versions()
+
+	passphrase, err := lookupPassphrase()
+	if err != nil {
+		return err
+	}
+
+	oldFsRepo, err := repo.OpenFSRepo(oldRepoPath, oldVer)
+	if err != nil {
+		return err
+	}
+	defer mustCloseRepo(oldFsRepo)
+
+	// Version hasn't been updated yet.
+	newFsRepo, err := repo.OpenFSRepo(newRepoPath, oldVer)
+	if err != nil {
+		return err
+	}
+	defer mustCloseRepo(newFsRepo)
+
+	oldKeys, err := loadPlainKeys(oldFsRepo.WalletDatastore())
+	if err != nil {
+		return err
+	}
+
+	for key, oldKi := range oldKeys {
+		data, err := newFsRepo.WalletDatastore().Get(datastore.NewKey(key))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read migrated key %s", key)
+		}
+		newKi, err := wallet.DecryptKeyInfo(data, passphrase)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decrypt migrated key %s", key)
+		}
+		if !oldKi.Equals(newKi) {
+			return errors.Errorf("migrated key %s differs from the original", key)
+		}
+	}
+	return nil
+}
+
+// lookupPassphrase returns the passphrase set in the environment. Not setting
+// it is an error rather than silently encrypting with the empty passphrase.
+func lookupPassphrase() ([]byte, error) {
+	passphrase, ok := os.LookupEnv(wallet.PassphraseEnvVar)
+	if !ok {
+		return nil, errors.Errorf("%s is not set, set it to the wallet passphrase, or to the empty string to encrypt the wallet keys without one", wallet.PassphraseEnvVar)
+	}
+	return []byte(passphrase), nil
+}
+
+// loadPlainKeys reads the unencrypted keys of a version 2 wallet datastore,
+// indexed by their datastore key.
+func loadPlainKeys(ds repo.Datastore) (map[string]*types.KeyInfo, error) {
+	result, err := ds.Query(dsq.Query{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query wallet datastore")
+	}
+
+	entries, err := result.Rest()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read wallet datastore")
+	}
+
+	keys := make(map[string]*types.KeyInfo, len(entries))
+	for _, entry := range entries {
+		ki := &types.KeyInfo{}
+		if err := cbor.DecodeInto(entry.Value, ki); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode key %s", strings.Trim(entry.Key, "/"))
+		}
+		keys[entry.Key] = ki
+	}
+	return keys, nil
+}
+
+func mustCloseRepo(fsRepo *repo.FSRepo) {
+	err := fsRepo.Close()
+	if err != nil {
+		panic(err)
+	}
+}
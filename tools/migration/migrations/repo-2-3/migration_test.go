@@ -0,0 +1,100 @@
+package migration23_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/repo"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/tools/migration/internal"
+	. "github.com/filecoin-project/go-filecoin/tools/migration/migrations/repo-2-3"
+	"github.com/filecoin-project/go-filecoin/types"
+	"github.com/filecoin-project/go-filecoin/wallet"
+)
+
+func TestEncryptWalletKeys(t *testing.T) {
+	tf.UnitTest(t)
+
+	require.NoError(t, os.Setenv(wallet.PassphraseEnvVar, "correct horse"))
+	defer os.Unsetenv(wallet.PassphraseEnvVar) // nolint: errcheck
+
+	ki := types.MustGenerateKeyInfo(1, 42)[0]
+	addr, err := ki.Address()
+	require.NoError(t, err)
+
+	oldContainer, oldRepo := internal.RequireInitRepo(t, 2)
+	defer repo.RequireRemoveAll(t, oldContainer)
+	requirePutPlainKey(t, oldRepo, addr.String(), &ki)
+
+	newContainer, newRepo := internal.RequireInitRepo(t, 2)
+	defer repo.RequireRemoveAll(t, newContainer)
+	requirePutPlainKey(t, newRepo, addr.String(), &ki)
+
+	mig := &EncryptWalletKeys{}
+	require.NoError(t, mig.Migrate(newRepo))
+	require.NoError(t, mig.Validate(oldRepo, newRepo))
+
+	fsrepo, err := repo.OpenFSRepo(newRepo, 2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, fsrepo.Close())
+	}()
+
+	data, err := fsrepo.WalletDatastore().Get(datastore.NewKey(addr.String()))
+	require.NoError(t, err)
+
+	_, err = wallet.DecryptKeyInfo(data, []byte("wrong"))
+	assert.Equal(t, wallet.ErrBadPassphrase, err)
+
+	decrypted, err := wallet.DecryptKeyInfo(data, []byte("correct horse"))
+	require.NoError(t, err)
+	assert.True(t, ki.Equals(decrypted))
+}
+
+func TestEncryptWalletKeysRequiresPassphrase(t *testing.T) {
+	tf.UnitTest(t)
+
+	require.NoError(t, os.Unsetenv(wallet.PassphraseEnvVar))
+
+	ki := types.MustGenerateKeyInfo(1, 42)[0]
+	addr, err := ki.Address()
+	require.NoError(t, err)
+
+	container, repoPath := internal.RequireInitRepo(t, 2)
+	defer repo.RequireRemoveAll(t, container)
+	requirePutPlainKey(t, repoPath, addr.String(), &ki)
+
+	mig := &EncryptWalletKeys{}
+	err = mig.Migrate(repoPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), wallet.PassphraseEnvVar)
+
+	// the keys are left untouched
+	fsrepo, err := repo.OpenFSRepo(repoPath, 2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, fsrepo.Close())
+	}()
+
+	data, err := fsrepo.WalletDatastore().Get(datastore.NewKey(addr.String()))
+	require.NoError(t, err)
+	plain, err := ki.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, plain, data)
+}
+
+func requirePutPlainKey(t *testing.T, repoPath string, key string, ki *types.KeyInfo) {
+	fsrepo, err := repo.OpenFSRepo(repoPath, 2)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, fsrepo.Close())
+	}()
+
+	data, err := ki.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, fsrepo.WalletDatastore().Put(datastore.NewKey(key), data))
+}
@@ -0,0 +1,124 @@
+// remote-signer is a reference signer for the remote wallet backend. It serves
+// the keys of the wallet of a go-filecoin repo, which must not be the repo of
+// a running daemon, over a Unix socket only its owner may connect to. The
+// signer does not authenticate requests, so it is never served over TCP.
+// Point a node at it by setting its wallet.remoteSigner config to the same
+// endpoint.
+//
+// The repo is created and given keys like any other, for example with
+// `go-filecoin init --repodir=<dir> --encrypt-wallet`. The passphrase its keys
+// are encrypted with is read from FIL_WALLET_PASSPHRASE.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+
+	logging "github.com/ipfs/go-log"
+
+	"github.com/filecoin-project/go-filecoin/paths"
+	"github.com/filecoin-project/go-filecoin/repo"
+	"github.com/filecoin-project/go-filecoin/wallet"
+)
+
+var log = logging.Logger("remote-signer")
+
+func init() {
+	// Info level
+	logging.SetAllLoggers(4)
+}
+
+func main() {
+	repoDir := flag.String("repodir", "", "set the go-filecoin repo holding the signer keys")
+	listen := flag.String("listen", "", "(required) set the endpoint to serve on, unix:///path/to/socket")
+	flag.Parse()
+
+	if *listen == "" {
+		fmt.Fprintln(os.Stderr, "ERROR: must provide an endpoint to listen on")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(*repoDir, *listen); err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+}
+
+func run(repoDir, endpoint string) error {
+	repoPath, err := paths.GetRepoPath(repoDir)
+	if err != nil {
+		return err
+	}
+
+	fsrepo, err := repo.OpenFSRepo(repoPath, repo.Version)
+	if err != nil {
+		return err
+	}
+	defer fsrepo.Close() // nolint: errcheck
+
+	backend, err := wallet.NewDSBackend(fsrepo.WalletDatastore())
+	if err != nil {
+		return err
+	}
+	if err := backend.Unlock([]byte(os.Getenv(wallet.PassphraseEnvVar)), 0); err != nil {
+		return err
+	}
+
+	listener, err := listen(endpoint)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: wallet.NewRemoteSignerHandler(backend)}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		server.Close() // nolint: errcheck
+	}()
+
+	log.Infof("serving %d addresses on %s", len(backend.Addresses()), endpoint)
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// listen opens a listener for a unix:///path/to/socket endpoint. The socket
+// is created with 0600 permissions, so only the owner of the signer may
+// connect to it.
+func listen(endpoint string) (net.Listener, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "unix" {
+		return nil, fmt.Errorf("unsupported endpoint scheme %q, the signer only serves unix sockets", u.Scheme)
+	}
+
+	socket := u.Path
+	if socket == "" {
+		socket = u.Opaque
+	}
+	if socket == "" {
+		return nil, fmt.Errorf("endpoint %s has no socket path", endpoint)
+	}
+
+	// create the socket without group or other permissions rather than
+	// restricting them once others could have connected
+	oldMask := syscall.Umask(0177)
+	listener, err := net.Listen("unix", socket)
+	syscall.Umask(oldMask)
+	if err != nil {
+		return nil, err
+	}
+	return listener, nil
+}
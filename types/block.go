@@ -55,6 +55,10 @@ type Block struct {
 	// The timestamp, in seconds since the Unix epoch, at which this block was created.
 	Timestamp Uint64 `json:"timestamp"`
 
+	// BlockSig is the signature of the block by the worker of its miner. It
+	// signs the block without the signature, see SignatureData.
+	BlockSig Signature `json:"blockSig"`
+
 	cachedCid cid.Cid
 
 	cachedBytes []byte
@@ -130,6 +134,36 @@ func DecodeBlock(b []byte) (*Block, error) {
 	return &out, nil
 }
 
+// SignatureData returns the bytes the worker of the block's miner signs,
+// which are the bytes of the block without its signature.
+func (b *Block) SignatureData() []byte {
+	unsigned := *b
+	unsigned.BlockSig = nil
+	unsigned.cachedCid = cid.Undef
+	unsigned.cachedBytes = nil
+
+	bytes, err := cbor.DumpObject(&unsigned)
+	if err != nil {
+		panic(err)
+	}
+	return bytes
+}
+
+// VerifySignature returns true if the block is signed by the given worker.
+func (b *Block) VerifySignature(worker address.Address) bool {
+	return IsValidSignature(b.SignatureData(), worker, b.BlockSig)
+}
+
+// ConflictsWith returns true if the blocks are different blocks of the same
+// miner at the same height or with the same parents. A miner signing
+// conflicting blocks commits a consensus fault.
+func (b *Block) ConflictsWith(other *Block) bool {
+	if b.Miner != other.Miner || b.Cid().Equals(other.Cid()) {
+		return false
+	}
+	return b.Height == other.Height || b.Parents.Equals(other.Parents)
+}
+
 // Score returns the score of this block. Naively this will just return the
 // height. But in the future this will return a more sophisticated metric to be
 // used in the fork choice rule
@@ -72,11 +72,12 @@ func TestTriangleEncoding(t *testing.T) {
 			Proof:           NewTestPoSt(),
 			StateRoot:       SomeCid(),
 			Timestamp:       Uint64(1),
+			BlockSig:        []byte{0x04, 0x05, 0x06},
 		}
 		s := reflect.TypeOf(*b)
 		// This check is here to request that you add a non-zero value for new fields
 		// to the above (and update the field count below).
-		require.Equal(t, 13, s.NumField()) // Note: this also counts private fields
+		require.Equal(t, 14, s.NumField()) // Note: this also counts private fields
 		testRoundTrip(t, b)
 	})
 }
@@ -219,3 +220,26 @@ func TestBlockJsonMarshal(t *testing.T) {
 	AssertHaveSameCid(t, &child, &unmarshalled)
 	assert.True(t, child.Equals(&unmarshalled))
 }
+
+func TestBlockSignature(t *testing.T) {
+	tf.UnitTest(t)
+
+	signer, _ := NewMockSignersAndKeyInfo(2)
+	worker := signer.Addresses[0]
+
+	blk := &Block{
+		Miner:  address.NewForTestGetter()(),
+		Height: Uint64(2),
+	}
+	sig, err := signer.SignBytes(blk.SignatureData(), worker)
+	require.NoError(t, err)
+	blk.BlockSig = sig
+
+	// the signature does not sign itself
+	assert.True(t, blk.VerifySignature(worker))
+	assert.False(t, blk.VerifySignature(signer.Addresses[1]))
+
+	// the signature signs every other field
+	blk.Height = Uint64(3)
+	assert.False(t, blk.VerifySignature(worker))
+}
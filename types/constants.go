@@ -40,6 +40,24 @@ var BootstrapMinerActorCodeObj ipld.Node
 // BootstrapMinerActorCodeCid is the cid of the above object
 var BootstrapMinerActorCodeCid cid.Cid
 
+// MultisigActorCodeObj is the code representation of the builtin multisig actor.
+var MultisigActorCodeObj ipld.Node
+
+// MultisigActorCodeCid is the cid of the above object
+var MultisigActorCodeCid cid.Cid
+
+// MultisigFactoryActorCodeObj is the code representation of the builtin actor creating multisig actors.
+var MultisigFactoryActorCodeObj ipld.Node
+
+// MultisigFactoryActorCodeCid is the cid of the above object
+var MultisigFactoryActorCodeCid cid.Cid
+
+// InitActorCodeObj is the code representation of the builtin actor assigning ID addresses to actors.
+var InitActorCodeObj ipld.Node
+
+// InitActorCodeCid is the cid of the above object
+var InitActorCodeCid cid.Cid
+
 // ActorCodeCidTypeNames maps Actor codeCid's to the name of the associated Actor type.
 var ActorCodeCidTypeNames = make(map[cid.Cid]string)
 
@@ -54,6 +72,12 @@ func init() {
 	MinerActorCodeCid = MinerActorCodeObj.Cid()
 	BootstrapMinerActorCodeObj = dag.NewRawNode([]byte("bootstrapmineractor"))
 	BootstrapMinerActorCodeCid = BootstrapMinerActorCodeObj.Cid()
+	MultisigActorCodeObj = dag.NewRawNode([]byte("multisigactor"))
+	MultisigActorCodeCid = MultisigActorCodeObj.Cid()
+	MultisigFactoryActorCodeObj = dag.NewRawNode([]byte("multisigfactory"))
+	MultisigFactoryActorCodeCid = MultisigFactoryActorCodeObj.Cid()
+	InitActorCodeObj = dag.NewRawNode([]byte("initactor"))
+	InitActorCodeCid = InitActorCodeObj.Cid()
 
 	// New Actors need to be added here.
 	// TODO: Make this work with reflection -- but note that nasty import cycles lie on that path.
@@ -63,6 +87,9 @@ func init() {
 	ActorCodeCidTypeNames[PaymentBrokerActorCodeCid] = "PaymentBrokerActor"
 	ActorCodeCidTypeNames[MinerActorCodeCid] = "MinerActor"
 	ActorCodeCidTypeNames[BootstrapMinerActorCodeCid] = "MinerActor"
+	ActorCodeCidTypeNames[MultisigActorCodeCid] = "MultisigActor"
+	ActorCodeCidTypeNames[MultisigFactoryActorCodeCid] = "MultisigFactoryActor"
+	ActorCodeCidTypeNames[InitActorCodeCid] = "InitActor"
 }
 
 // ActorCodeTypeName returns the (string) name of the Go type of the actor with cid, code.
@@ -0,0 +1,101 @@
+package types
+
+import (
+	"fmt"
+)
+
+// GasSchedule is the gas charged for the operations of message execution, on
+// top of the gas builtin actor methods charge for being called. Networks
+// select the schedule they use at genesis by its version.
+type GasSchedule struct {
+	// Version identifies the schedule.
+	Version uint64
+
+	// MessageBase and MessageByte are charged for the inclusion of a message
+	// in a block, the latter per byte of the serialized message.
+	MessageBase GasUnits
+	MessageByte GasUnits
+
+	// StorageRead and StorageReadByte are charged per actor storage read,
+	// the latter per byte read.
+	StorageRead     GasUnits
+	StorageReadByte GasUnits
+
+	// StorageWrite and StorageWriteByte are charged per actor storage write,
+	// the latter per byte written.
+	StorageWrite     GasUnits
+	StorageWriteByte GasUnits
+
+	// Send is charged per message an actor sends.
+	Send GasUnits
+
+	// CreateActor is charged per actor created.
+	CreateActor GasUnits
+
+	// VerifySignature is charged per signature an actor verifies.
+	VerifySignature GasUnits
+
+	// VerifySeal and VerifyPoSt are charged per proof an actor verifies.
+	VerifySeal GasUnits
+	VerifyPoSt GasUnits
+}
+
+const (
+	// FlatGasScheduleVersion is the version of the schedule charging nothing
+	// but the flat cost of actor methods.
+	FlatGasScheduleVersion = 0
+
+	// OperationGasScheduleVersion is the version of the schedule charging
+	// message size, storage access, sends, actor creation and verification.
+	OperationGasScheduleVersion = 1
+)
+
+var gasSchedules = map[uint64]*GasSchedule{
+	FlatGasScheduleVersion: {
+		Version: FlatGasScheduleVersion,
+	},
+	OperationGasScheduleVersion: {
+		Version:          OperationGasScheduleVersion,
+		MessageBase:      NewGasUnits(100),
+		MessageByte:      NewGasUnits(1),
+		StorageRead:      NewGasUnits(10),
+		StorageReadByte:  NewGasUnits(1),
+		StorageWrite:     NewGasUnits(20),
+		StorageWriteByte: NewGasUnits(2),
+		Send:             NewGasUnits(50),
+		CreateActor:      NewGasUnits(500),
+		VerifySignature:  NewGasUnits(200),
+		VerifySeal:       NewGasUnits(5000),
+		VerifyPoSt:       NewGasUnits(10000),
+	},
+}
+
+// FlatGasSchedule is the schedule of networks that did not select one.
+var FlatGasSchedule = gasSchedules[FlatGasScheduleVersion]
+
+// GasScheduleVersion returns the gas schedule of the given version.
+func GasScheduleVersion(version uint64) (*GasSchedule, error) {
+	schedule, ok := gasSchedules[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown gas schedule version %d", version)
+	}
+	return schedule, nil
+}
+
+// MessageInclusion returns the gas charged for including a message of the
+// given serialized size in a block.
+func (gs *GasSchedule) MessageInclusion(size int) GasUnits {
+	return gs.MessageBase + gs.MessageByte*GasUnits(size)
+}
+
+// StorageReadCost returns the gas charged for reading size bytes from actor
+// storage.
+func (gs *GasSchedule) StorageReadCost(size int) GasUnits {
+	return gs.StorageRead + gs.StorageReadByte*GasUnits(size)
+}
+
+// StorageWriteCost returns the gas charged for writing size bytes to actor
+// storage.
+func (gs *GasSchedule) StorageWriteCost(size int) GasUnits {
+	return gs.StorageWrite + gs.StorageWriteByte*GasUnits(size)
+}
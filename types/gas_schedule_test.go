@@ -0,0 +1,49 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+)
+
+func TestGasScheduleVersion(t *testing.T) {
+	tf.UnitTest(t)
+
+	schedule, err := GasScheduleVersion(FlatGasScheduleVersion)
+	require.NoError(t, err)
+	assert.Equal(t, FlatGasSchedule, schedule)
+
+	schedule, err = GasScheduleVersion(OperationGasScheduleVersion)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(OperationGasScheduleVersion), schedule.Version)
+
+	_, err = GasScheduleVersion(42)
+	assert.Error(t, err)
+}
+
+func TestGasScheduleCosts(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("the flat schedule charges nothing", func(t *testing.T) {
+		assert.Equal(t, NewGasUnits(0), FlatGasSchedule.MessageInclusion(100))
+		assert.Equal(t, NewGasUnits(0), FlatGasSchedule.StorageReadCost(100))
+		assert.Equal(t, NewGasUnits(0), FlatGasSchedule.StorageWriteCost(100))
+	})
+
+	t.Run("costs scale with size", func(t *testing.T) {
+		schedule := &GasSchedule{
+			MessageBase:      NewGasUnits(100),
+			MessageByte:      NewGasUnits(1),
+			StorageRead:      NewGasUnits(10),
+			StorageReadByte:  NewGasUnits(2),
+			StorageWrite:     NewGasUnits(20),
+			StorageWriteByte: NewGasUnits(3),
+		}
+		assert.Equal(t, NewGasUnits(150), schedule.MessageInclusion(50))
+		assert.Equal(t, NewGasUnits(110), schedule.StorageReadCost(50))
+		assert.Equal(t, NewGasUnits(170), schedule.StorageWriteCost(50))
+	})
+}
@@ -6,6 +6,7 @@ import (
 	cbor "github.com/ipfs/go-ipld-cbor"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/crypto"
 )
 
@@ -56,13 +57,23 @@ func (ki *KeyInfo) Equals(other *KeyInfo) bool {
 	return bytes.Equal(ki.PrivateKey, other.PrivateKey)
 }
 
-// Address returns the address for this keyinfo
+// Address returns the address for this keyinfo. BLS keys have BLS addresses,
+// all other keys secp256k1 addresses.
 func (ki *KeyInfo) Address() (address.Address, error) {
-	// TODO: Use the address type we are running on from the config.
+	if ki.Curve == BLS {
+		return address.NewBLSAddress(ki.PublicKey())
+	}
 	return address.NewSecp256k1Address(ki.PublicKey())
 }
 
-// PublicKey returns the public key part as uncompressed bytes.
+// PublicKey returns the public key part as uncompressed bytes, or as
+// compressed bytes for BLS keys.
 func (ki *KeyInfo) PublicKey() []byte {
+	if ki.Curve == BLS {
+		var privateKey bls.PrivateKey
+		copy(privateKey[:], ki.PrivateKey)
+		publicKey := bls.PrivateKeyPublicKey(privateKey)
+		return publicKey[:]
+	}
 	return crypto.PublicKey(ki.PrivateKey)
 }
@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	"github.com/filecoin-project/go-filecoin/crypto"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 )
@@ -31,3 +33,18 @@ func TestKeyInfoMarshal(t *testing.T) {
 	assert.Equal(t, ki.Type(), kiBack.Type())
 	assert.True(t, ki.Equals(kiBack))
 }
+
+func TestKeyInfoAddress(t *testing.T) {
+	tf.UnitTest(t)
+
+	secpKey := MustGenerateKeyInfo(1, 42)[0]
+	addr, err := secpKey.Address()
+	require.NoError(t, err)
+	assert.Equal(t, address.SECP256K1, addr.Protocol())
+
+	blsKey := MustGenerateBLSKeyInfo(1)[0]
+	addr, err = blsKey.Address()
+	require.NoError(t, err)
+	assert.Equal(t, address.BLS, addr.Protocol())
+	assert.Equal(t, blsKey.PublicKey(), addr.Payload())
+}
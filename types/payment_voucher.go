@@ -13,6 +13,7 @@ import (
 func init() {
 	cbor.RegisterCborType(Predicate{})
 	cbor.RegisterCborType(PaymentVoucher{})
+	cbor.RegisterCborType(VoucherMerge{})
 }
 
 // Predicate is an optional message that is sent to another actor and must return true for the voucher to be valid.
@@ -28,6 +29,17 @@ type Predicate struct {
 	Params []interface{} `json:"params"`
 }
 
+// VoucherMerge merges a lane into the lane of a voucher, closing the merged
+// lane to vouchers up to Nonce.
+type VoucherMerge struct {
+	// Lane is the lane being merged.
+	Lane uint64 `json:"lane"`
+
+	// Nonce is the highest nonce of the vouchers of the merged lane that the
+	// merge supersedes.
+	Nonce uint64 `json:"nonce"`
+}
+
 // PaymentVoucher is a voucher for a payment channel that can be transferred off-chain but guarantees a future payment.
 type PaymentVoucher struct {
 	// Channel is the id of this voucher's payment channel.
@@ -45,6 +57,17 @@ type PaymentVoucher struct {
 	// ValidAt is the earliest block height at which this voucher is valid.
 	ValidAt BlockHeight `json:"valid_at"`
 
+	// Lane is the lane of the channel this voucher pays on. Amounts of
+	// vouchers on different lanes of a channel add up.
+	Lane uint64 `json:"lane"`
+
+	// Nonce orders the vouchers of a lane. A voucher can not be redeemed once
+	// a voucher with a higher nonce has been redeemed on its lane.
+	Nonce uint64 `json:"nonce"`
+
+	// Merges are other lanes whose redeemed funds are included in Amount.
+	Merges []VoucherMerge `json:"merges"`
+
 	// Condition defines a optional message that will be called and must return true before this voucher can be redeemed.
 	Condition *Predicate `json:"condition"`
 
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/crypto"
 	wutil "github.com/filecoin-project/go-filecoin/wallet/util"
 )
@@ -48,7 +49,7 @@ func NewMockSigner(kis []KeyInfo) MockSigner {
 	for _, k := range kis {
 		// extract public key
 		pub := k.PublicKey()
-		newAddr, err := address.NewSecp256k1Address(pub)
+		newAddr, err := k.Address()
 		if err != nil {
 			panic(err)
 		}
@@ -88,12 +89,29 @@ func MustGenerateKeyInfo(n int, seed byte) []KeyInfo {
 	return keyinfos
 }
 
+// MustGenerateBLSKeyInfo generates `n` distinct BLS keyinfos. Unlike
+// MustGenerateKeyInfo, the keys are random.
+func MustGenerateBLSKeyInfo(n int) []KeyInfo {
+	var keyinfos []KeyInfo
+	for i := 0; i < n; i++ {
+		prv := bls.PrivateKeyGenerate()
+		keyinfos = append(keyinfos, KeyInfo{
+			PrivateKey: prv[:],
+			Curve:      BLS,
+		})
+	}
+	return keyinfos
+}
+
 // SignBytes cryptographically signs `data` using the Address `addr`.
 func (ms MockSigner) SignBytes(data []byte, addr address.Address) (Signature, error) {
 	ki, ok := ms.AddrKeyInfo[addr]
 	if !ok {
 		panic("unknown address")
 	}
+	if ki.Curve == BLS {
+		return wutil.SignBLS(ki.Key(), data)
+	}
 
 	hash := blake2b.Sum256(data)
 	return crypto.Sign(ki.Key(), hash[:])
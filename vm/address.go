@@ -0,0 +1,56 @@
+package vm
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-filecoin/actor/builtin/initactor"
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/exec"
+	"github.com/filecoin-project/go-filecoin/state"
+)
+
+// ResolveAddress returns the address the actor at addr is stored under in
+// the state tree. Actors are stored under the address they were created at,
+// so the ID addresses the init actor assigned resolve to it, through the
+// staged storage of the init actor. Other addresses, and all addresses of
+// states without an init actor, are returned unchanged. Resolving an
+// assignable ID address that was not assigned is a revert error.
+func ResolveAddress(ctx context.Context, st *state.CachedTree, vms StorageMap, addr address.Address) (address.Address, error) {
+	if !initactor.IsAssignable(addr) {
+		return addr, nil
+	}
+
+	storage, err := initActorStorage(ctx, st, vms)
+	if err != nil || storage == nil {
+		return addr, err
+	}
+	return initactor.LookupRobustAddress(ctx, storage, addr)
+}
+
+// RegisterActorAddress assigns an ID address to the actor created at addr,
+// if the state has an init actor. Actors created at ID addresses are not
+// assigned another one, and registering an address twice has no effect.
+func RegisterActorAddress(ctx context.Context, st *state.CachedTree, vms StorageMap, addr address.Address) error {
+	if addr.Empty() || addr.Protocol() == address.ID {
+		return nil
+	}
+
+	storage, err := initActorStorage(ctx, st, vms)
+	if err != nil || storage == nil {
+		return err
+	}
+	_, err = initactor.RegisterAddress(ctx, storage, addr)
+	return err
+}
+
+// initActorStorage returns the storage of the init actor of the state, or
+// nil if it has none.
+func initActorStorage(ctx context.Context, st *state.CachedTree, vms StorageMap) (exec.Storage, error) {
+	initActor, err := st.GetActor(ctx, address.InitAddress)
+	if state.IsActorNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return vms.NewStorage(address.InitAddress, initActor), nil
+}
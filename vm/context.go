@@ -29,6 +29,10 @@ type Context struct {
 	gasTracker  *GasTracker
 	blockHeight *types.BlockHeight
 	ancestors   []types.TipSet
+	trace       *Trace
+	// goCtx is the context the message is applied in, for the state
+	// lookups the VM makes on behalf of actors.
+	goCtx context.Context
 
 	deps *deps // Inject external dependencies so we can unit test robustly.
 }
@@ -45,10 +49,19 @@ type NewContextParams struct {
 	GasTracker  *GasTracker
 	BlockHeight *types.BlockHeight
 	Ancestors   []types.TipSet
+	// Trace, if set, records the execution of the message.
+	Trace *Trace
+	// Ctx is the context the message is applied in, context.Background()
+	// if unset.
+	Ctx context.Context
 }
 
 // NewVMContext returns an initialized context.
 func NewVMContext(params NewContextParams) *Context {
+	goCtx := params.Ctx
+	if goCtx == nil {
+		goCtx = context.Background()
+	}
 	return &Context{
 		from:        params.From,
 		to:          params.To,
@@ -58,6 +71,8 @@ func NewVMContext(params NewContextParams) *Context {
 		gasTracker:  params.GasTracker,
 		blockHeight: params.BlockHeight,
 		ancestors:   params.Ancestors,
+		trace:       params.Trace,
+		goCtx:       goCtx,
 		deps:        makeDeps(params.State),
 	}
 }
@@ -66,7 +81,7 @@ var _ exec.VMContext = (*Context)(nil)
 
 // Storage returns an implementation of the storage module for this context.
 func (ctx *Context) Storage() exec.Storage {
-	return ctx.storageMap.NewStorage(ctx.message.To, ctx.to)
+	return ctx.storageMap.NewStorage(ctx.message.To, ctx.to).WithGasTracker(ctx.gasTracker)
 }
 
 // Message retrieves the message associated with this context.
@@ -79,6 +94,11 @@ func (ctx *Context) Charge(cost types.GasUnits) error {
 	return ctx.gasTracker.Charge(cost)
 }
 
+// GasSchedule returns the schedule of the gas charged for operations.
+func (ctx *Context) GasSchedule() *types.GasSchedule {
+	return ctx.gasTracker.Schedule
+}
+
 // GasUnits retrieves the gas cost so far
 func (ctx *Context) GasUnits() types.GasUnits {
 	return ctx.gasTracker.gasConsumedByMessage
@@ -104,6 +124,10 @@ func (ctx *Context) IsFromAccountActor() bool {
 func (ctx *Context) Send(to address.Address, method string, value types.AttoFIL, params []interface{}) ([][]byte, uint8, error) {
 	deps := ctx.deps
 
+	if err := ctx.Charge(ctx.gasTracker.Schedule.Send); err != nil {
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
+
 	// the message sender is the `to` actor, so this is what we set as `from` in the new message
 	from := ctx.Message().To
 	fromActor := ctx.to
@@ -118,18 +142,33 @@ func (ctx *Context) Send(to address.Address, method string, value types.AttoFIL,
 		return nil, 1, errors.RevertErrorWrap(err, "encoding params failed")
 	}
 
-	msg := types.NewMessage(from, to, 0, value, method, paramData)
+	resolvedTo, err := ResolveAddress(ctx.goCtx, ctx.state, ctx.storageMap, to)
+	if err != nil {
+		if errors.ShouldRevert(err) {
+			return nil, errors.CodeError(err), err
+		}
+		return nil, 1, errors.FaultErrorWrapf(err, "failed to resolve To address %s", to)
+	}
+
+	msg := types.NewMessage(from, resolvedTo, 0, value, method, paramData)
 	if msg.From == msg.To {
 		// TODO: handle this
 		return nil, 1, errors.NewFaultErrorf("unhandled: sending to self (%s)", msg.From)
 	}
 
-	toActor, err := deps.GetOrCreateActor(context.TODO(), msg.To, func() (*actor.Actor, error) {
+	created := false
+	toActor, err := deps.GetOrCreateActor(ctx.goCtx, msg.To, func() (*actor.Actor, error) {
+		created = true
 		return &actor.Actor{}, nil
 	})
 	if err != nil {
 		return nil, 1, errors.FaultErrorWrapf(err, "failed to get or create To actor %s", msg.To)
 	}
+	if created {
+		if err := RegisterActorAddress(ctx.goCtx, ctx.state, ctx.storageMap, msg.To); err != nil {
+			return nil, 1, errors.FaultErrorWrapf(err, "failed to register address of To actor %s", msg.To)
+		}
+	}
 	// TODO(fritz) de-dup some of the logic between here and core.Send
 	innerParams := NewContextParams{
 		From:        fromActor,
@@ -140,10 +179,14 @@ func (ctx *Context) Send(to address.Address, method string, value types.AttoFIL,
 		GasTracker:  ctx.gasTracker,
 		BlockHeight: ctx.blockHeight,
 		Ancestors:   ctx.ancestors,
+		Ctx:         ctx.goCtx,
+	}
+	if ctx.trace != nil {
+		innerParams.Trace = ctx.trace.subcall()
 	}
 	innerCtx := NewVMContext(innerParams)
 
-	out, ret, err := deps.Send(context.Background(), innerCtx)
+	out, ret, err := deps.Send(ctx.goCtx, innerCtx)
 	if err != nil {
 		return nil, ret, err
 	}
@@ -176,8 +219,12 @@ func computeActorAddress(creator address.Address, nonce uint64) (address.Address
 // CreateNewActor creates and initializes an actor at the given address.
 // If the address is occupied by a non-empty actor, this method will fail.
 func (ctx *Context) CreateNewActor(addr address.Address, code cid.Cid, initializerData interface{}) error {
+	if err := ctx.Charge(ctx.gasTracker.Schedule.CreateActor); err != nil {
+		return err
+	}
+
 	// Check existing address. If nothing there, create empty actor.
-	newActor, err := ctx.state.GetOrCreateActor(context.TODO(), addr, func() (*actor.Actor, error) {
+	newActor, err := ctx.state.GetOrCreateActor(ctx.goCtx, addr, func() (*actor.Actor, error) {
 		return &actor.Actor{}, nil
 	})
 
@@ -192,7 +239,11 @@ func (ctx *Context) CreateNewActor(addr address.Address, code cid.Cid, initializ
 	// make this the right 'type' of actor
 	newActor.Code = code
 
-	childStorage := ctx.storageMap.NewStorage(addr, newActor)
+	if err := RegisterActorAddress(ctx.goCtx, ctx.state, ctx.storageMap, addr); err != nil {
+		return errors.FaultErrorWrap(err, "could not register address of new actor")
+	}
+
+	childStorage := ctx.storageMap.NewStorage(addr, newActor).WithGasTracker(ctx.gasTracker)
 	execActor, err := ctx.state.GetBuiltinActorCode(code)
 	if err != nil {
 		return errors.NewRevertErrorf("attempt to create executable actor from non-existent code %s", code.String())
@@ -12,6 +12,7 @@ import (
 	xerrors "github.com/pkg/errors"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-filecoin/abi"
@@ -89,6 +90,8 @@ func TestVMContextSendFailures(t *testing.T) {
 	}
 	fakeActorCid := types.NewCidForTestGetter()()
 	mockStateTree.BuiltinActors[fakeActorCid] = &actor.FakeActor{}
+	// the state has no init actor, so new actors are not assigned ID addresses
+	mockStateTree.On("GetActor", mock.Anything, address.InitAddress).Return(nil, actorNotFoundError{})
 	tree := state.NewCachedStateTree(&mockStateTree)
 	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
 	vms := NewStorageMap(bs)
@@ -286,3 +289,15 @@ func TestVMContextIsAccountActor(t *testing.T) {
 	ctx = NewVMContext(vmCtxParams)
 	assert.False(t, ctx.IsFromAccountActor())
 }
+
+// actorNotFoundError is the error of a state tree without the actor at an
+// address.
+type actorNotFoundError struct{}
+
+func (e actorNotFoundError) Error() string {
+	return "actor not found"
+}
+
+func (e actorNotFoundError) ActorNotFound() bool {
+	return true
+}
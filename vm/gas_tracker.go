@@ -7,15 +7,20 @@ import (
 
 // GasTracker maintains the state of gas usage throughout the execution of a block and a message
 type GasTracker struct {
-	MsgGasLimit          types.GasUnits
+	MsgGasLimit types.GasUnits
+	// Schedule is the gas charged for the operations of message execution.
+	Schedule             *types.GasSchedule
 	gasConsumedByBlock   types.GasUnits
 	gasConsumedByMessage types.GasUnits
+	outOfGas             bool
 }
 
-// NewGasTracker initializes a new empty gas tracker
+// NewGasTracker initializes a new empty gas tracker charging the flat gas
+// schedule.
 func NewGasTracker() *GasTracker {
 	return &GasTracker{
 		MsgGasLimit:          types.NewGasUnits(0),
+		Schedule:             types.FlatGasSchedule,
 		gasConsumedByBlock:   types.NewGasUnits(0),
 		gasConsumedByMessage: types.NewGasUnits(0),
 	}
@@ -25,6 +30,7 @@ func NewGasTracker() *GasTracker {
 func (gasTracker *GasTracker) ResetForNewMessage(message types.MeteredMessage) {
 	gasTracker.MsgGasLimit = message.GasLimit
 	gasTracker.gasConsumedByMessage = types.NewGasUnits(0)
+	gasTracker.outOfGas = false
 }
 
 // Charge will add the gas charge to the current method gas context.
@@ -32,6 +38,7 @@ func (gasTracker *GasTracker) Charge(cost types.GasUnits) error {
 	if gasTracker.gasConsumedByMessage+cost > gasTracker.MsgGasLimit {
 		gasTracker.gasConsumedByMessage = gasTracker.MsgGasLimit
 		gasTracker.gasConsumedByBlock += gasTracker.MsgGasLimit
+		gasTracker.outOfGas = true
 		return errors.NewRevertError("gas cost exceeds gas limit")
 	}
 
@@ -40,6 +47,12 @@ func (gasTracker *GasTracker) Charge(cost types.GasUnits) error {
 	return nil
 }
 
+// OutOfGas returns true if a charge exceeded the gas limit of the current
+// message.
+func (gasTracker *GasTracker) OutOfGas() bool {
+	return gasTracker.outOfGas
+}
+
 // GasAboveBlockLimit will return true if the MsgGasLimit of the current message is greater than the block gas limit.
 func (gasTracker *GasTracker) GasAboveBlockLimit() bool {
 	return gasTracker.MsgGasLimit > types.BlockGasLimit
@@ -89,6 +89,8 @@ type Storage struct {
 	actor      *actor.Actor
 	chunks     map[cid.Cid]ipld.Node
 	blockstore blockstore.Blockstore
+	// gasTracker, if set, is charged for reads and writes.
+	gasTracker *GasTracker
 }
 
 var _ exec.Storage = (*Storage)(nil)
@@ -118,6 +120,10 @@ func (s Storage) Put(v interface{}) (cid.Cid, error) {
 		return cid.Undef, exec.Errors[exec.ErrDecode]
 	}
 
+	if err := s.chargeWrite(len(nd.RawData())); err != nil {
+		return cid.Undef, err
+	}
+
 	c := nd.Cid()
 	s.chunks[c] = nd
 
@@ -126,10 +132,15 @@ func (s Storage) Put(v interface{}) (cid.Cid, error) {
 
 // Get retrieves a chunk from either temporary storage or its backing store.
 // If the chunk is not found in storage, a vm.ErrNotFound error is returned.
+// Reads are charged before anything is read, then per byte read.
 func (s Storage) Get(cid cid.Cid) ([]byte, error) {
+	if err := s.chargeRead(); err != nil {
+		return []byte{}, err
+	}
+
 	n, ok := s.chunks[cid]
 	if ok {
-		return n.RawData(), nil
+		return n.RawData(), s.chargeReadBytes(len(n.RawData()))
 	}
 
 	blk, err := s.blockstore.Get(cid)
@@ -140,7 +151,51 @@ func (s Storage) Get(cid cid.Cid) ([]byte, error) {
 		return []byte{}, err
 	}
 
-	return blk.RawData(), nil
+	return blk.RawData(), s.chargeReadBytes(len(blk.RawData()))
+}
+
+// WithGasTracker returns the storage charging its reads and writes to the
+// gas tracker, according to its schedule.
+func (s Storage) WithGasTracker(gasTracker *GasTracker) Storage {
+	s.gasTracker = gasTracker
+	return s
+}
+
+// chargeRead charges the gas tracker of the storage, if it has one, for a
+// read.
+func (s Storage) chargeRead() error {
+	if s.gasTracker == nil {
+		return nil
+	}
+	return s.charge(s.gasTracker.Schedule.StorageRead)
+}
+
+// chargeReadBytes charges the gas tracker of the storage, if it has one, for
+// the size bytes read.
+func (s Storage) chargeReadBytes(size int) error {
+	if s.gasTracker == nil {
+		return nil
+	}
+	return s.charge(s.gasTracker.Schedule.StorageReadCost(size) - s.gasTracker.Schedule.StorageRead)
+}
+
+// chargeWrite charges the gas tracker of the storage, if it has one, for
+// writing size bytes.
+func (s Storage) chargeWrite(size int) error {
+	if s.gasTracker == nil {
+		return nil
+	}
+	return s.charge(s.gasTracker.Schedule.StorageWriteCost(size))
+}
+
+// charge charges the gas tracker of the storage. Running out of gas is a
+// revert error, so actors reading their storage revert the message rather
+// than fault the block.
+func (s Storage) charge(cost types.GasUnits) error {
+	if err := s.gasTracker.Charge(cost); err != nil {
+		return vmerrors.NewCodedRevertErrorf(exec.ErrInsufficientGas, "Insufficient gas: %s", err)
+	}
+	return nil
 }
 
 // Commit updates the head of the current actor to the given cid.
@@ -12,6 +12,7 @@ import (
 	"github.com/filecoin-project/go-filecoin/exec"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 	"github.com/filecoin-project/go-filecoin/types"
+	vmerrors "github.com/filecoin-project/go-filecoin/vm/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -368,3 +369,68 @@ func TestValidationAndPruning(t *testing.T) {
 		assert.Equal(t, memory3.RawData(), chunk)
 	})
 }
+
+func TestStorageChargesGas(t *testing.T) {
+	tf.UnitTest(t)
+
+	bs := blockstore.NewBlockstore(datastore.NewMapDatastore())
+	testActor := actor.NewActor(types.AccountActorCodeCid, types.ZeroAttoFIL)
+
+	schedule, err := types.GasScheduleVersion(types.OperationGasScheduleVersion)
+	require.NoError(t, err)
+
+	data, err := cbor.DumpObject("some data an actor might store")
+	require.NoError(t, err)
+
+	t.Run("Put and Get are charged by size", func(t *testing.T) {
+		gasTracker := NewGasTracker()
+		gasTracker.MsgGasLimit = types.BlockGasLimit
+		gasTracker.Schedule = schedule
+		stage := NewStorageMap(bs).NewStorage(address.TestAddress, testActor).WithGasTracker(gasTracker)
+
+		id, err := stage.Put(data)
+		require.NoError(t, err)
+		writeCost := schedule.StorageWriteCost(len(data))
+		assert.Equal(t, writeCost, gasTracker.gasConsumedByMessage)
+
+		_, err = stage.Get(id)
+		require.NoError(t, err)
+		assert.Equal(t, writeCost+schedule.StorageReadCost(len(data)), gasTracker.gasConsumedByMessage)
+	})
+
+	t.Run("Put fails without enough gas", func(t *testing.T) {
+		gasTracker := NewGasTracker()
+		gasTracker.MsgGasLimit = schedule.StorageWriteCost(len(data)) - 1
+		gasTracker.Schedule = schedule
+		stage := NewStorageMap(bs).NewStorage(address.TestAddress, testActor).WithGasTracker(gasTracker)
+
+		_, err := stage.Put(data)
+		assert.Error(t, err)
+	})
+
+	t.Run("Get reverts without enough gas", func(t *testing.T) {
+		gasTracker := NewGasTracker()
+		gasTracker.MsgGasLimit = types.BlockGasLimit
+		gasTracker.Schedule = schedule
+		stage := NewStorageMap(bs).NewStorage(address.TestAddress, testActor).WithGasTracker(gasTracker)
+
+		id, err := stage.Put(data)
+		require.NoError(t, err)
+
+		gasTracker.MsgGasLimit = gasTracker.gasConsumedByMessage + schedule.StorageRead - 1
+		_, err = stage.Get(id)
+		require.Error(t, err)
+		assert.True(t, vmerrors.ShouldRevert(err))
+		assert.Equal(t, uint8(exec.ErrInsufficientGas), vmerrors.CodeError(err))
+		assert.True(t, gasTracker.OutOfGas())
+	})
+
+	t.Run("Storage without a gas tracker is free", func(t *testing.T) {
+		stage := NewStorageMap(bs).NewStorage(address.TestAddress, testActor)
+
+		id, err := stage.Put(data)
+		require.NoError(t, err)
+		_, err = stage.Get(id)
+		require.NoError(t, err)
+	})
+}
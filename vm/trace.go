@@ -0,0 +1,51 @@
+package vm
+
+import (
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// Trace records the execution of a message in the VM: its outcome, the gas it
+// was charged and, in order, the traces of the messages it sent to other
+// actors. Contexts only record traces when given one, so execution without
+// tracing does no extra work.
+type Trace struct {
+	From   address.Address `json:"from"`
+	To     address.Address `json:"to"`
+	Value  types.AttoFIL   `json:"value"`
+	Method string          `json:"method"`
+	Params []byte          `json:"params"`
+
+	Return   [][]byte `json:"return"`
+	ExitCode uint8    `json:"exitCode"`
+	Error    string   `json:"error,omitempty"`
+
+	// GasCharged is the gas charged while executing the message, including
+	// the gas charged by the messages it sent.
+	GasCharged types.GasUnits `json:"gasCharged"`
+
+	Subcalls []*Trace `json:"subcalls"`
+}
+
+// subcall returns a new trace recorded as the next message sent by the
+// message of this one.
+func (t *Trace) subcall() *Trace {
+	sub := &Trace{}
+	t.Subcalls = append(t.Subcalls, sub)
+	return sub
+}
+
+// record sets the message and the outcome of its execution.
+func (t *Trace) record(msg *types.Message, ret [][]byte, exitCode uint8, err error, gas types.GasUnits) {
+	t.From = msg.From
+	t.To = msg.To
+	t.Value = msg.Value
+	t.Method = msg.Method
+	t.Params = msg.Params
+	t.Return = ret
+	t.ExitCode = exitCode
+	if err != nil {
+		t.Error = err.Error()
+	}
+	t.GasCharged = gas
+}
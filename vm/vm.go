@@ -8,17 +8,26 @@ import (
 	cbor "github.com/ipfs/go-ipld-cbor"
 
 	"github.com/filecoin-project/go-filecoin/actor"
+	"github.com/filecoin-project/go-filecoin/exec"
 	"github.com/filecoin-project/go-filecoin/types"
 	"github.com/filecoin-project/go-filecoin/vm/errors"
 )
 
 // Send executes a message pass inside the VM. If error is set it
-// will always satisfy either ShouldRevert() or IsFault().
+// will always satisfy either ShouldRevert() or IsFault(). The execution is
+// recorded in the trace of the context, if it has one.
 func Send(ctx context.Context, vmCtx *Context) ([][]byte, uint8, error) {
 	deps := sendDeps{
 		transfer: Transfer,
 	}
-	return send(ctx, deps, vmCtx)
+	if vmCtx.trace == nil {
+		return send(ctx, deps, vmCtx)
+	}
+
+	gasBefore := vmCtx.GasUnits()
+	out, code, err := send(ctx, deps, vmCtx)
+	vmCtx.trace.record(vmCtx.message, out, code, err, vmCtx.GasUnits()-gasBefore)
+	return out, code, err
 }
 
 type sendDeps struct {
@@ -52,6 +61,11 @@ func send(ctx context.Context, deps sendDeps, vmCtx *Context) ([][]byte, uint8,
 	}
 
 	r, code, err := actor.MakeTypedExport(toExecutable, vmCtx.message.Method)(vmCtx)
+	if errors.IsFault(err) && vmCtx.gasTracker != nil && vmCtx.gasTracker.OutOfGas() {
+		// Actors may report a failed storage access as a fault, but a message running out of gas must only
+		// revert, or anyone could fault the blocks including it.
+		return nil, exec.ErrInsufficientGas, errors.RevertErrorWrap(err, "Insufficient gas")
+	}
 	if r != nil {
 		var rv [][]byte
 		err = cbor.DecodeInto(r, &rv)
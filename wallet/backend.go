@@ -34,3 +34,11 @@ type Importer interface {
 	// into the backend
 	ImportKey(ki *types.KeyInfo) error
 }
+
+// PublicKeyer is a specialization of a wallet backend that provides the
+// public keys of its addresses without their private keys. Remote signers
+// do this.
+type PublicKeyer interface {
+	// PublicKey returns the public key of the address `addr`
+	PublicKey(addr address.Address) ([]byte, error)
+}
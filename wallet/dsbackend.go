@@ -4,12 +4,14 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	ds "github.com/ipfs/go-datastore"
 	dsq "github.com/ipfs/go-datastore/query"
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/crypto"
 	"github.com/filecoin-project/go-filecoin/repo"
 	"github.com/filecoin-project/go-filecoin/types"
@@ -19,25 +21,41 @@ import (
 const (
 	// SECP256K1 is a curve used to computer private keys
 	SECP256K1 = "secp256k1"
+
+	// BLS is the curve of BLS private keys
+	BLS = "bls"
 )
 
 // DSBackendType is the reflect type of the DSBackend.
 var DSBackendType = reflect.TypeOf(&DSBackend{})
 
 // DSBackend is a wallet backend implementation for storing addresses in a datastore.
+// Private keys are stored encrypted with the wallet passphrase, and can only be
+// used while the backend is unlocked.
 type DSBackend struct {
 	lk sync.RWMutex
 
-	// TODO: use a better interface that supports time locks, encryption, etc.
 	ds repo.Datastore
 
 	// TODO: proper cache
 	cache map[address.Address]struct{}
+
+	// passphrase is nil while the backend is locked.
+	passphrase []byte
+	// keys are the decrypted keys of the unlocked backend.
+	keys map[address.Address]*types.KeyInfo
+	// hd is the decrypted mnemonic addresses are derived from, if any.
+	hd *hdSeed
+	// unlockedUntil is when the unlock timeout expires, zero for no timeout.
+	unlockedUntil time.Time
+	// lockTimer locks the backend when the unlock timeout expires.
+	lockTimer *time.Timer
 }
 
 var _ Backend = (*DSBackend)(nil)
 
-// NewDSBackend constructs a new backend using the passed in datastore.
+// NewDSBackend constructs a new backend using the passed in datastore. The
+// backend starts locked, its keys can only be used after calling Unlock.
 func NewDSBackend(ds repo.Datastore) (*DSBackend, error) {
 	result, err := ds.Query(dsq.Query{
 		KeysOnly: true,
@@ -53,6 +71,10 @@ func NewDSBackend(ds repo.Datastore) (*DSBackend, error) {
 
 	cache := make(map[address.Address]struct{})
 	for _, el := range list {
+		if el.Key == hdSeedKey.String() {
+			continue
+		}
+
 		parsedAddr, err := address.NewFromString(strings.Trim(el.Key, "/"))
 		if err != nil {
 			return nil, errors.Wrapf(err, "trying to restore invalid address: %s", el.Key)
@@ -66,7 +88,89 @@ func NewDSBackend(ds repo.Datastore) (*DSBackend, error) {
 	}, nil
 }
 
-// ImportKey loads the address in `ai` and KeyInfo `ki` into the backend
+// Unlock decrypts the private keys of the backend with the passphrase, making
+// them usable for timeout, or until Lock is called if timeout is zero. The
+// passphrase of a backend without keys is the one it is first unlocked with.
+func (backend *DSBackend) Unlock(passphrase []byte, timeout time.Duration) error {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	keys := make(map[address.Address]*types.KeyInfo, len(backend.cache))
+	for addr := range backend.cache {
+		data, err := backend.ds.Get(ds.NewKey(addr.String()))
+		if err != nil {
+			return errors.Wrap(err, "failed to fetch private key from backend")
+		}
+
+		ki, err := DecryptKeyInfo(data, passphrase)
+		if err != nil {
+			return err
+		}
+		keys[addr] = ki
+	}
+
+	hd, err := backend.loadHDSeed(passphrase)
+	if err != nil {
+		return err
+	}
+
+	backend.stopLockTimer()
+	backend.passphrase = append([]byte{}, passphrase...)
+	backend.keys = keys
+	backend.hd = hd
+	backend.unlockedUntil = time.Time{}
+	if timeout > 0 {
+		backend.unlockedUntil = time.Now().Add(timeout)
+		backend.lockTimer = time.AfterFunc(timeout, backend.lockIfExpired)
+	}
+	return nil
+}
+
+// Lock forgets the passphrase and the decrypted private keys of the backend.
+func (backend *DSBackend) Lock() {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	backend.lock()
+}
+
+// lockIfExpired locks the backend if its unlock timeout has expired, and not
+// been replaced by a later unlock.
+func (backend *DSBackend) lockIfExpired() {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	if !backend.unlockedUntil.IsZero() && !time.Now().Before(backend.unlockedUntil) {
+		backend.lock()
+	}
+}
+
+func (backend *DSBackend) lock() {
+	backend.stopLockTimer()
+	backend.passphrase = nil
+	backend.keys = nil
+	backend.hd = nil
+	backend.unlockedUntil = time.Time{}
+}
+
+// IsLocked returns whether the private keys of the backend are unusable until
+// it is unlocked.
+func (backend *DSBackend) IsLocked() bool {
+	backend.lk.RLock()
+	defer backend.lk.RUnlock()
+
+	return backend.passphrase == nil
+}
+
+func (backend *DSBackend) stopLockTimer() {
+	if backend.lockTimer != nil {
+		backend.lockTimer.Stop()
+		backend.lockTimer = nil
+	}
+}
+
+// ImportKey loads the address in `ai` and KeyInfo `ki` into the backend.
+// The backend must be unlocked to encrypt the key.
 func (backend *DSBackend) ImportKey(ki *types.KeyInfo) error {
 	return backend.putKeyInfo(ki)
 }
@@ -93,18 +197,32 @@ func (backend *DSBackend) HasAddress(addr address.Address) bool {
 	return ok
 }
 
-// NewAddress creates a new address and stores it.
+// NewAddress creates a new address using the given protocol and stores it.
+// Only the SECP256K1 and BLS protocols are supported, and the backend must be
+// unlocked.
 // Safe for concurrent access.
-func (backend *DSBackend) NewAddress() (address.Address, error) {
-	prv, err := crypto.GenerateKey()
-	if err != nil {
-		return address.Undef, err
-	}
+func (backend *DSBackend) NewAddress(protocol address.Protocol) (address.Address, error) {
+	var ki *types.KeyInfo
+	switch protocol {
+	case address.SECP256K1:
+		prv, err := crypto.GenerateKey()
+		if err != nil {
+			return address.Undef, err
+		}
 
-	// TODO: maybe the above call should just return a keyinfo?
-	ki := &types.KeyInfo{
-		PrivateKey: prv,
-		Curve:      SECP256K1,
+		// TODO: maybe the above call should just return a keyinfo?
+		ki = &types.KeyInfo{
+			PrivateKey: prv,
+			Curve:      SECP256K1,
+		}
+	case address.BLS:
+		prv := bls.PrivateKeyGenerate()
+		ki = &types.KeyInfo{
+			PrivateKey: prv[:],
+			Curve:      BLS,
+		}
+	default:
+		return address.Undef, errors.Errorf("unsupported address protocol %d", protocol)
 	}
 
 	if err := backend.putKeyInfo(ki); err != nil {
@@ -123,7 +241,16 @@ func (backend *DSBackend) putKeyInfo(ki *types.KeyInfo) error {
 	backend.lk.Lock()
 	defer backend.lk.Unlock()
 
-	kib, err := ki.Marshal()
+	if backend.passphrase == nil {
+		return ErrLocked
+	}
+	return backend.storeKeyInfo(a, ki)
+}
+
+// storeKeyInfo encrypts and stores the key of the address. The caller must
+// hold the write lock of the unlocked backend.
+func (backend *DSBackend) storeKeyInfo(a address.Address, ki *types.KeyInfo) error {
+	kib, err := EncryptKeyInfo(ki, backend.passphrase)
 	if err != nil {
 		return err
 	}
@@ -133,6 +260,7 @@ func (backend *DSBackend) putKeyInfo(ki *types.KeyInfo) error {
 	}
 
 	backend.cache[a] = struct{}{}
+	backend.keys[a] = ki
 	return nil
 }
 
@@ -143,6 +271,9 @@ func (backend *DSBackend) SignBytes(data []byte, addr address.Address) (types.Si
 		return nil, err
 	}
 
+	if ki.Type() == BLS {
+		return wutil.SignBLS(ki.Key(), data)
+	}
 	return wutil.Sign(ki.Key(), data)
 }
 
@@ -153,22 +284,23 @@ func (backend *DSBackend) Verify(data, pk []byte, sig types.Signature) bool {
 }
 
 // GetKeyInfo will return the private & public keys associated with address `addr`
-// iff backend contains the addr.
+// iff backend contains the addr. It fails with ErrLocked while the backend is locked.
 func (backend *DSBackend) GetKeyInfo(addr address.Address) (*types.KeyInfo, error) {
 	if !backend.HasAddress(addr) {
 		return nil, errors.New("backend does not contain address")
 	}
 
-	// kib is a cbor of types.KeyInfo
-	kib, err := backend.ds.Get(ds.NewKey(addr.String()))
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to fetch private key from backend")
-	}
+	backend.lk.RLock()
+	defer backend.lk.RUnlock()
 
-	ki := &types.KeyInfo{}
-	if err := ki.Unmarshal(kib); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal keyinfo from backend")
+	if backend.passphrase == nil {
+		return nil, ErrLocked
 	}
 
-	return ki, nil
+	ki, ok := backend.keys[addr]
+	if !ok {
+		return nil, errors.New("backend does not contain address")
+	}
+	cpy := *ki
+	return &cpy, nil
 }
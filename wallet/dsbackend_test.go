@@ -3,11 +3,13 @@ package wallet
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/filecoin-project/go-filecoin/address"
 	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
 )
 
@@ -21,12 +23,13 @@ func TestDSBackendSimple(t *testing.T) {
 
 	fs, err := NewDSBackend(ds)
 	assert.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
 
 	t.Log("empty address list on empty datastore")
 	assert.Len(t, fs.Addresses(), 0)
 
 	t.Log("can create new address")
-	addr, err := fs.NewAddress()
+	addr, err := fs.NewAddress(address.SECP256K1)
 	assert.NoError(t, err)
 
 	t.Log("address is stored")
@@ -49,9 +52,10 @@ func TestDSBackendKeyPairMatchAddress(t *testing.T) {
 
 	fs, err := NewDSBackend(ds)
 	assert.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
 
 	t.Log("can create new address")
-	addr, err := fs.NewAddress()
+	addr, err := fs.NewAddress(address.SECP256K1)
 	assert.NoError(t, err)
 
 	t.Log("address is stored")
@@ -68,6 +72,29 @@ func TestDSBackendKeyPairMatchAddress(t *testing.T) {
 	assert.Equal(t, addr, dAddr)
 }
 
+func TestDSBackendBLSAddress(t *testing.T) {
+	tf.UnitTest(t)
+
+	fs, err := NewDSBackend(datastore.NewMapDatastore())
+	require.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
+
+	addr, err := fs.NewAddress(address.BLS)
+	require.NoError(t, err)
+	assert.Equal(t, address.BLS, addr.Protocol())
+
+	ki, err := fs.GetKeyInfo(addr)
+	require.NoError(t, err)
+	assert.Equal(t, BLS, ki.Type())
+
+	dAddr, err := ki.Address()
+	require.NoError(t, err)
+	assert.Equal(t, addr, dAddr)
+
+	_, err = fs.NewAddress(address.Actor)
+	assert.Error(t, err)
+}
+
 func TestDSBackendErrorsForUnknownAddress(t *testing.T) {
 	tf.UnitTest(t)
 
@@ -78,6 +105,7 @@ func TestDSBackendErrorsForUnknownAddress(t *testing.T) {
 	}()
 	fs1, err := NewDSBackend(ds1)
 	assert.NoError(t, err)
+	require.NoError(t, fs1.Unlock(nil, 0))
 
 	ds2 := datastore.NewMapDatastore()
 	defer func() {
@@ -85,9 +113,10 @@ func TestDSBackendErrorsForUnknownAddress(t *testing.T) {
 	}()
 	fs2, err := NewDSBackend(ds2)
 	assert.NoError(t, err)
+	require.NoError(t, fs2.Unlock(nil, 0))
 
 	t.Log("can create new address in fs1")
-	addr, err := fs1.NewAddress()
+	addr, err := fs1.NewAddress(address.SECP256K1)
 	assert.NoError(t, err)
 
 	t.Log("address is stored fs1")
@@ -117,13 +146,14 @@ func TestDSBackendParallel(t *testing.T) {
 
 	fs, err := NewDSBackend(ds)
 	assert.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
 
 	var wg sync.WaitGroup
 	count := 10
 	wg.Add(count)
 	for i := 0; i < count; i++ {
 		go func() {
-			_, err := fs.NewAddress()
+			_, err := fs.NewAddress(address.SECP256K1)
 			assert.NoError(t, err)
 			wg.Done()
 		}()
@@ -132,3 +162,109 @@ func TestDSBackendParallel(t *testing.T) {
 	wg.Wait()
 	assert.Len(t, fs.Addresses(), 10)
 }
+
+func TestDSBackendLocking(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	defer func() {
+		require.NoError(t, ds.Close())
+	}()
+
+	fs, err := NewDSBackend(ds)
+	require.NoError(t, err)
+	require.NoError(t, fs.Unlock([]byte("passphrase"), 0))
+
+	addr, err := fs.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+
+	t.Run("key is encrypted at rest", func(t *testing.T) {
+		data, err := ds.Get(datastore.NewKey(addr.String()))
+		require.NoError(t, err)
+
+		ki, err := DecryptKeyInfo(data, []byte("passphrase"))
+		require.NoError(t, err)
+		kiAddr, err := ki.Address()
+		require.NoError(t, err)
+		assert.Equal(t, addr, kiAddr)
+	})
+
+	t.Run("reopened backend starts locked", func(t *testing.T) {
+		fs2, err := NewDSBackend(ds)
+		require.NoError(t, err)
+
+		assert.True(t, fs2.IsLocked())
+		assert.True(t, fs2.HasAddress(addr))
+
+		_, err = fs2.SignBytes([]byte("data"), addr)
+		assert.Equal(t, ErrLocked, err)
+		_, err = fs2.GetKeyInfo(addr)
+		assert.Equal(t, ErrLocked, err)
+		_, err = fs2.NewAddress(address.SECP256K1)
+		assert.Equal(t, ErrLocked, err)
+	})
+
+	t.Run("wrong passphrase is rejected", func(t *testing.T) {
+		fs2, err := NewDSBackend(ds)
+		require.NoError(t, err)
+
+		assert.Equal(t, ErrBadPassphrase, fs2.Unlock([]byte("wrong"), 0))
+		assert.True(t, fs2.IsLocked())
+	})
+
+	t.Run("unlock and lock", func(t *testing.T) {
+		fs2, err := NewDSBackend(ds)
+		require.NoError(t, err)
+
+		require.NoError(t, fs2.Unlock([]byte("passphrase"), 0))
+		assert.False(t, fs2.IsLocked())
+		_, err = fs2.SignBytes([]byte("data"), addr)
+		assert.NoError(t, err)
+
+		fs2.Lock()
+		assert.True(t, fs2.IsLocked())
+		_, err = fs2.SignBytes([]byte("data"), addr)
+		assert.Equal(t, ErrLocked, err)
+	})
+
+	t.Run("unlock timeout locks again", func(t *testing.T) {
+		fs2, err := NewDSBackend(ds)
+		require.NoError(t, err)
+
+		require.NoError(t, fs2.Unlock([]byte("passphrase"), 10*time.Millisecond))
+		assert.False(t, fs2.IsLocked())
+
+		deadline := time.Now().Add(time.Second)
+		for !fs2.IsLocked() && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		assert.True(t, fs2.IsLocked())
+	})
+}
+
+func TestDSBackendStartsLockedWithEmptyPassphrase(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	defer func() {
+		require.NoError(t, ds.Close())
+	}()
+
+	fs, err := NewDSBackend(ds)
+	require.NoError(t, err)
+	assert.True(t, fs.IsLocked())
+	require.NoError(t, fs.Unlock(nil, 0))
+
+	addr, err := fs.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+
+	fs2, err := NewDSBackend(ds)
+	require.NoError(t, err)
+	assert.True(t, fs2.IsLocked())
+	_, err = fs2.SignBytes([]byte("data"), addr)
+	assert.Equal(t, ErrLocked, err)
+
+	require.NoError(t, fs2.Unlock(nil, 0))
+	_, err = fs2.SignBytes([]byte("data"), addr)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,183 @@
+package wallet
+
+import (
+	"fmt"
+
+	ds "github.com/ipfs/go-datastore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+	bip39 "github.com/tyler-smith/go-bip39"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/crypto"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// DefaultHDPath is the BIP-44 derivation path of Filecoin (coin type 461)
+// account addresses, below which the datastore backend derives its keys.
+const DefaultHDPath = "m/44'/461'/0'/0"
+
+// mnemonicEntropyBits is the entropy of generated mnemonics, 24 words.
+const mnemonicEntropyBits = 256
+
+var (
+	// ErrNoMnemonic is returned when the recovery phrase of a wallet that has
+	// none is requested.
+	ErrNoMnemonic = errors.New("wallet has no recovery phrase")
+
+	// ErrInvalidMnemonic is returned when a recovery phrase is not a valid
+	// BIP-39 mnemonic.
+	ErrInvalidMnemonic = errors.New("invalid recovery phrase")
+)
+
+// hdSeedKey is the datastore key of the encrypted hdSeed, next to the keys
+// of the addresses.
+var hdSeedKey = ds.NewKey("/hdseed")
+
+func init() {
+	cbor.RegisterCborType(hdSeed{})
+}
+
+// hdSeed is what the datastore backend derives addresses from: the BIP-39
+// mnemonic, the derivation path of its keys and the index of the next one.
+type hdSeed struct {
+	Mnemonic string
+	Path     string
+	Next     uint64
+}
+
+// NewMnemonic generates a new random BIP-39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// SetMnemonic makes the backend derive its addresses from the mnemonic,
+// starting at the first one. The backend must be unlocked and have no
+// mnemonic yet.
+func (backend *DSBackend) SetMnemonic(mnemonic string) error {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return ErrInvalidMnemonic
+	}
+
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	if backend.passphrase == nil {
+		return ErrLocked
+	}
+	if backend.hd != nil {
+		return errors.New("wallet already has a recovery phrase")
+	}
+	return backend.storeHDSeed(&hdSeed{Mnemonic: mnemonic, Path: DefaultHDPath})
+}
+
+// Mnemonic returns the recovery phrase the addresses of the backend are
+// derived from. The backend must be unlocked.
+func (backend *DSBackend) Mnemonic() (string, error) {
+	backend.lk.RLock()
+	defer backend.lk.RUnlock()
+
+	if backend.passphrase == nil {
+		return "", ErrLocked
+	}
+	if backend.hd == nil {
+		return "", ErrNoMnemonic
+	}
+	return backend.hd.Mnemonic, nil
+}
+
+// DeriveAddress derives the next secp256k1 address from the mnemonic of the
+// backend and stores it. A backend without a mnemonic gets a new one first.
+// The backend must be unlocked.
+func (backend *DSBackend) DeriveAddress() (address.Address, error) {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	if backend.passphrase == nil {
+		return address.Undef, ErrLocked
+	}
+
+	var seed hdSeed
+	if backend.hd != nil {
+		seed = *backend.hd
+	} else {
+		mnemonic, err := NewMnemonic()
+		if err != nil {
+			return address.Undef, errors.Wrap(err, "failed to generate recovery phrase")
+		}
+		seed = hdSeed{Mnemonic: mnemonic, Path: DefaultHDPath}
+	}
+
+	prv, err := crypto.DeriveKey(bip39.NewSeed(seed.Mnemonic, ""), fmt.Sprintf("%s/%d", seed.Path, seed.Next))
+	if err != nil {
+		return address.Undef, errors.Wrapf(err, "failed to derive key %d", seed.Next)
+	}
+
+	ki := &types.KeyInfo{
+		PrivateKey: prv,
+		Curve:      SECP256K1,
+	}
+	addr, err := ki.Address()
+	if err != nil {
+		return address.Undef, err
+	}
+
+	// The key is stored before the index moves past it, so that a failure
+	// in between derives the same key again.
+	if err := backend.storeKeyInfo(addr, ki); err != nil {
+		return address.Undef, err
+	}
+
+	seed.Next++
+	if err := backend.storeHDSeed(&seed); err != nil {
+		return address.Undef, err
+	}
+	return addr, nil
+}
+
+// storeHDSeed encrypts and stores the seed. The caller must hold the write
+// lock of the unlocked backend.
+func (backend *DSBackend) storeHDSeed(seed *hdSeed) error {
+	plaintext, err := cbor.DumpObject(seed)
+	if err != nil {
+		return err
+	}
+
+	data, err := encrypt(plaintext, backend.passphrase)
+	if err != nil {
+		return err
+	}
+
+	if err := backend.ds.Put(hdSeedKey, data); err != nil {
+		return errors.Wrap(err, "failed to store recovery phrase")
+	}
+
+	backend.hd = seed
+	return nil
+}
+
+// loadHDSeed reads and decrypts the seed of the backend, nil if it has none.
+func (backend *DSBackend) loadHDSeed(passphrase []byte) (*hdSeed, error) {
+	data, err := backend.ds.Get(hdSeedKey)
+	if err == ds.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch recovery phrase from backend")
+	}
+
+	plaintext, err := decrypt(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var seed hdSeed
+	if err := cbor.DecodeInto(plaintext, &seed); err != nil {
+		return nil, errors.Wrap(err, "failed to decode recovery phrase")
+	}
+	return &seed, nil
+}
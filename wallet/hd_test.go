@@ -0,0 +1,125 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+var testMnemonic = strings.Repeat("abandon ", 11) + "about"
+
+func TestDSBackendDeriveAddress(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	defer func() {
+		require.NoError(t, ds.Close())
+	}()
+
+	fs, err := NewDSBackend(ds)
+	require.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
+	require.NoError(t, fs.SetMnemonic(testMnemonic))
+
+	first, err := fs.DeriveAddress()
+	require.NoError(t, err)
+	second, err := fs.DeriveAddress()
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.True(t, fs.HasAddress(first))
+	assert.True(t, fs.HasAddress(second))
+
+	t.Run("derives along the Filecoin path", func(t *testing.T) {
+		// m/44'/461'/0'/0/0 of the mnemonic
+		ki, err := fs.GetKeyInfo(first)
+		require.NoError(t, err)
+		assert.Equal(t, "e1808079c6734eff9a187c917455dc1b2c70385e13f1cd6cecc94978e57f7f76", hex.EncodeToString(ki.Key()))
+		assert.Equal(t, types.SECP256K1, ki.Type())
+	})
+
+	t.Run("derived keys sign", func(t *testing.T) {
+		data := []byte("data to sign")
+		sig, err := fs.SignBytes(data, second)
+		require.NoError(t, err)
+		assert.True(t, types.IsValidSignature(data, second, sig))
+	})
+
+	t.Run("reopened backend continues at the next index", func(t *testing.T) {
+		fs2, err := NewDSBackend(ds)
+		require.NoError(t, err)
+		require.NoError(t, fs2.Unlock(nil, 0))
+
+		mnemonic, err := fs2.Mnemonic()
+		require.NoError(t, err)
+		assert.Equal(t, testMnemonic, mnemonic)
+		assert.Len(t, fs2.Addresses(), 2)
+
+		third, err := fs2.DeriveAddress()
+		require.NoError(t, err)
+		assert.NotEqual(t, first, third)
+		assert.NotEqual(t, second, third)
+	})
+
+	t.Run("restores the same addresses from the mnemonic", func(t *testing.T) {
+		restored, err := NewDSBackend(datastore.NewMapDatastore())
+		require.NoError(t, err)
+		require.NoError(t, restored.Unlock(nil, 0))
+		require.NoError(t, restored.SetMnemonic(testMnemonic))
+
+		addr, err := restored.DeriveAddress()
+		require.NoError(t, err)
+		assert.Equal(t, first, addr)
+
+		addr, err = restored.DeriveAddress()
+		require.NoError(t, err)
+		assert.Equal(t, second, addr)
+	})
+
+	t.Run("mnemonic is set only once", func(t *testing.T) {
+		assert.Error(t, fs.SetMnemonic(testMnemonic))
+	})
+}
+
+func TestDSBackendMnemonicErrors(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	defer func() {
+		require.NoError(t, ds.Close())
+	}()
+
+	fs, err := NewDSBackend(ds)
+	require.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
+
+	_, err = fs.Mnemonic()
+	assert.Equal(t, ErrNoMnemonic, err)
+
+	assert.Equal(t, ErrInvalidMnemonic, fs.SetMnemonic("not a mnemonic"))
+
+	t.Run("deriving generates a mnemonic", func(t *testing.T) {
+		_, err := fs.DeriveAddress()
+		require.NoError(t, err)
+
+		mnemonic, err := fs.Mnemonic()
+		require.NoError(t, err)
+		assert.Len(t, strings.Fields(mnemonic), 24)
+	})
+
+	t.Run("locked backend hides the mnemonic", func(t *testing.T) {
+		require.NoError(t, fs.Unlock([]byte{}, 0))
+		fs.Lock()
+
+		_, err := fs.Mnemonic()
+		assert.Equal(t, ErrLocked, err)
+		_, err = fs.DeriveAddress()
+		assert.Equal(t, ErrLocked, err)
+	})
+}
@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// scrypt parameters used to derive key encryption keys from the wallet
+// passphrase.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 32
+)
+
+// PassphraseEnvVar is the environment variable the daemon reads the wallet
+// passphrase from to unlock the wallet on start.
+const PassphraseEnvVar = "FIL_WALLET_PASSPHRASE"
+
+var (
+	// ErrLocked is returned when private keys are needed while the wallet is locked.
+	ErrLocked = errors.New("wallet is locked, run 'go-filecoin wallet unlock' to unlock it")
+
+	// ErrBadPassphrase is returned when a private key cannot be decrypted with
+	// the given passphrase.
+	ErrBadPassphrase = errors.New("incorrect wallet passphrase")
+)
+
+func init() {
+	cbor.RegisterCborType(encryptedKeyInfo{})
+}
+
+// encryptedKeyInfo is a KeyInfo encrypted at rest with AES-GCM, using a key
+// derived from the wallet passphrase and a salt of its own.
+type encryptedKeyInfo struct {
+	Salt       []byte
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+// EncryptKeyInfo encrypts the given KeyInfo with the passphrase, returning the
+// bytes the datastore backend stores it as.
+func EncryptKeyInfo(ki *types.KeyInfo, passphrase []byte) ([]byte, error) {
+	plaintext, err := ki.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return encrypt(plaintext, passphrase)
+}
+
+// DecryptKeyInfo decrypts a KeyInfo encrypted by EncryptKeyInfo. It fails with
+// ErrBadPassphrase if the passphrase is not the one it was encrypted with.
+func DecryptKeyInfo(data []byte, passphrase []byte) (*types.KeyInfo, error) {
+	plaintext, err := decrypt(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	ki := &types.KeyInfo{}
+	if err := ki.Unmarshal(plaintext); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal keyinfo")
+	}
+	return ki, nil
+}
+
+func encrypt(plaintext []byte, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "failed to generate salt")
+	}
+
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+
+	return cbor.DumpObject(&encryptedKeyInfo{
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, nil),
+	})
+}
+
+func decrypt(data []byte, passphrase []byte) ([]byte, error) {
+	var eki encryptedKeyInfo
+	if err := cbor.DecodeInto(data, &eki); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal encrypted keyinfo")
+	}
+
+	aead, err := newAEAD(passphrase, eki.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(eki.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid encrypted keyinfo nonce")
+	}
+
+	plaintext, err := aead.Open(nil, eki.Nonce, eki.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrBadPassphrase
+	}
+	return plaintext, nil
+}
+
+func newAEAD(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive key from passphrase")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
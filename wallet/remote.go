@@ -0,0 +1,296 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	"github.com/filecoin-project/go-filecoin/crypto"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+// The remote signer protocol is HTTP with JSON bodies, spoken over a Unix
+// socket. It is not authenticated, so access to the signer is restricted by
+// the permissions of the socket. Addresses are encoded as strings and byte
+// strings as base64.
+//
+//	GET  /v0/addresses
+//	     200 {"keys": [{"address": "t1...", "publicKey": "<base64>"}]}
+//
+//	POST /v0/sign {"address": "t1...", "data": "<base64>"}
+//	     200 {"signature": "<base64>"}
+//	     404 if the signer does not hold the address
+//
+// Any other status is a failure, with the reason as plain text body.
+const (
+	// remoteSignerURL is the base URL of requests, the host is ignored by
+	// the dialer of the socket.
+	remoteSignerURL = "http://signer"
+
+	remoteAddressesPath = "/v0/addresses"
+	remoteSignPath      = "/v0/sign"
+
+	remoteSignerTimeout = 30 * time.Second
+
+	// remoteKeysTTL is how long the keys listed by a remote signer are
+	// cached for address lookups.
+	remoteKeysTTL = time.Minute
+)
+
+var log = logging.Logger("wallet")
+
+// ErrNoPrivateKey is returned when the private key of an address held by a
+// remote signer is requested.
+var ErrNoPrivateKey = errors.New("private keys of a remote signer are not available")
+
+// RemoteKey is a key held by a remote signer.
+type RemoteKey struct {
+	Address   address.Address `json:"address"`
+	PublicKey []byte          `json:"publicKey"`
+}
+
+type remoteAddressesResponse struct {
+	Keys []RemoteKey `json:"keys"`
+}
+
+type remoteSignRequest struct {
+	Address address.Address `json:"address"`
+	Data    []byte          `json:"data"`
+}
+
+type remoteSignResponse struct {
+	Signature types.Signature `json:"signature"`
+}
+
+// RemoteBackendType is the reflect type of the RemoteBackend.
+var RemoteBackendType = reflect.TypeOf(&RemoteBackend{})
+
+// RemoteBackend is a wallet backend forwarding signing to an external signer
+// process, so that private keys stay out of the node.
+type RemoteBackend struct {
+	client *http.Client
+
+	lk sync.Mutex
+	// keys caches the keys held by the signer, as listed at keysListedAt.
+	keys         []RemoteKey
+	keysListedAt time.Time
+}
+
+var _ Backend = (*RemoteBackend)(nil)
+var _ PublicKeyer = (*RemoteBackend)(nil)
+
+// NewRemoteBackend constructs a backend for the remote signer listening at
+// endpoint, unix:///path/to/socket.
+func NewRemoteBackend(endpoint string) (*RemoteBackend, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid remote signer endpoint %s", endpoint)
+	}
+	if u.Scheme != "unix" {
+		return nil, fmt.Errorf("unsupported remote signer endpoint scheme %q, remote signers are reached over unix sockets", u.Scheme)
+	}
+
+	socket := u.Path
+	if socket == "" {
+		socket = u.Opaque
+	}
+	if socket == "" {
+		return nil, fmt.Errorf("remote signer endpoint %s has no socket path", endpoint)
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}
+	return &RemoteBackend{
+		client: &http.Client{Transport: transport, Timeout: remoteSignerTimeout},
+	}, nil
+}
+
+// Addresses returns the addresses held by the remote signer, or none if it
+// cannot be reached. The addresses are listed anew, and cached for lookups.
+func (backend *RemoteBackend) Addresses() []address.Address {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	keys, err := backend.listKeys()
+	if err != nil {
+		log.Errorf("failed to list remote signer addresses: %s", err)
+		return nil
+	}
+
+	var out []address.Address
+	for _, key := range keys {
+		out = append(out, key.Address)
+	}
+	return out
+}
+
+// HasAddress checks if the remote signer holds the address.
+func (backend *RemoteBackend) HasAddress(addr address.Address) bool {
+	_, err := backend.PublicKey(addr)
+	return err == nil
+}
+
+// PublicKey returns the public key of an address held by the remote signer.
+func (backend *RemoteBackend) PublicKey(addr address.Address) ([]byte, error) {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	keys := backend.keys
+	if time.Since(backend.keysListedAt) > remoteKeysTTL {
+		var err error
+		if keys, err = backend.listKeys(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range keys {
+		if key.Address == addr {
+			return key.PublicKey, nil
+		}
+	}
+	return nil, ErrUnknownAddress
+}
+
+// SignBytes asks the remote signer to sign data with the private key of addr.
+func (backend *RemoteBackend) SignBytes(data []byte, addr address.Address) (types.Signature, error) {
+	body, err := json.Marshal(remoteSignRequest{Address: addr, Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := backend.client.Post(remoteSignerURL+remoteSignPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reach remote signer")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUnknownAddress
+	}
+	if err := checkRemoteStatus(resp); err != nil {
+		return nil, err
+	}
+
+	var out remoteSignResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, errors.Wrap(err, "failed to decode remote signer response")
+	}
+	return out.Signature, nil
+}
+
+// Verify cryptographically verifies that 'sig' is the signed hash of 'data' with
+// the public key `pk`.
+func (backend *RemoteBackend) Verify(data, pk []byte, sig types.Signature) bool {
+	return crypto.Verify(pk, data, sig)
+}
+
+// GetKeyInfo always fails with ErrNoPrivateKey, the private keys never leave
+// the remote signer.
+func (backend *RemoteBackend) GetKeyInfo(addr address.Address) (*types.KeyInfo, error) {
+	return nil, ErrNoPrivateKey
+}
+
+// listKeys lists the keys held by the signer, and caches them. The caller
+// must hold the lock of the backend.
+func (backend *RemoteBackend) listKeys() ([]RemoteKey, error) {
+	resp, err := backend.client.Get(remoteSignerURL + remoteAddressesPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reach remote signer")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if err := checkRemoteStatus(resp); err != nil {
+		return nil, err
+	}
+
+	var out remoteAddressesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, errors.Wrap(err, "failed to decode remote signer response")
+	}
+	backend.keys, backend.keysListedAt = out.Keys, time.Now()
+	return out.Keys, nil
+}
+
+func checkRemoteStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	reason, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("remote signer failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(reason)))
+}
+
+// NewRemoteSignerHandler returns an http.Handler serving the remote signer
+// protocol with the keys of the backend.
+func NewRemoteSignerHandler(backend Backend) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(remoteAddressesPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		keys := []RemoteKey{}
+		for _, addr := range backend.Addresses() {
+			ki, err := backend.GetKeyInfo(addr)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			keys = append(keys, RemoteKey{Address: addr, PublicKey: ki.PublicKey()})
+		}
+		writeRemoteResponse(w, remoteAddressesResponse{Keys: keys})
+	})
+
+	mux.HandleFunc(remoteSignPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req remoteSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid sign request: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		if !backend.HasAddress(req.Address) {
+			http.Error(w, ErrUnknownAddress.Error(), http.StatusNotFound)
+			return
+		}
+
+		sig, err := backend.SignBytes(req.Data, req.Address)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeRemoteResponse(w, remoteSignResponse{Signature: sig})
+	})
+
+	return mux
+}
+
+func writeRemoteResponse(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("failed to write remote signer response: %s", err)
+	}
+}
@@ -0,0 +1,157 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-filecoin/address"
+	tf "github.com/filecoin-project/go-filecoin/testhelpers/testflags"
+	"github.com/filecoin-project/go-filecoin/types"
+)
+
+func newSignerBackend(t *testing.T) (*DSBackend, address.Address) {
+	signerBackend, err := NewDSBackend(datastore.NewMapDatastore())
+	require.NoError(t, err)
+	require.NoError(t, signerBackend.Unlock(nil, 0))
+
+	addr, err := signerBackend.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	return signerBackend, addr
+}
+
+// serveRemoteSigner serves handler on a unix socket, returning its endpoint
+// and a function stopping the server.
+func serveRemoteSigner(t *testing.T, handler http.Handler) (string, func()) {
+	dir, err := ioutil.TempDir("", "remote-signer")
+	require.NoError(t, err)
+
+	socket := filepath.Join(dir, "signer.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener) // nolint: errcheck
+
+	return "unix://" + socket, func() {
+		server.Close()    // nolint: errcheck
+		os.RemoveAll(dir) // nolint: errcheck
+	}
+}
+
+func TestRemoteBackend(t *testing.T) {
+	tf.UnitTest(t)
+
+	signerBackend, addr := newSignerBackend(t)
+
+	var listings int32
+	handler := NewRemoteSignerHandler(signerBackend)
+	endpoint, stop := serveRemoteSigner(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == remoteAddressesPath {
+			atomic.AddInt32(&listings, 1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer stop()
+
+	remote, err := NewRemoteBackend(endpoint)
+	require.NoError(t, err)
+
+	t.Run("lists the signer addresses", func(t *testing.T) {
+		assert.Equal(t, []address.Address{addr}, remote.Addresses())
+		assert.True(t, remote.HasAddress(addr))
+		assert.False(t, remote.HasAddress(address.TestAddress))
+	})
+
+	t.Run("caches the signer addresses for lookups", func(t *testing.T) {
+		atomic.StoreInt32(&listings, 0)
+		remote.Addresses()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&listings))
+
+		for i := 0; i < 3; i++ {
+			assert.True(t, remote.HasAddress(addr))
+			assert.False(t, remote.HasAddress(address.TestAddress))
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&listings))
+	})
+
+	t.Run("provides public keys", func(t *testing.T) {
+		ki, err := signerBackend.GetKeyInfo(addr)
+		require.NoError(t, err)
+
+		pk, err := remote.PublicKey(addr)
+		require.NoError(t, err)
+		assert.Equal(t, ki.PublicKey(), pk)
+	})
+
+	t.Run("signs with the signer keys", func(t *testing.T) {
+		data := []byte("data to sign")
+		sig, err := remote.SignBytes(data, addr)
+		require.NoError(t, err)
+		assert.True(t, types.IsValidSignature(data, addr, sig))
+
+		_, err = remote.SignBytes(data, address.TestAddress)
+		assert.Equal(t, ErrUnknownAddress, err)
+	})
+
+	t.Run("does not expose private keys", func(t *testing.T) {
+		_, err := remote.GetKeyInfo(addr)
+		assert.Equal(t, ErrNoPrivateKey, err)
+	})
+
+	t.Run("reports a locked signer", func(t *testing.T) {
+		signerBackend.Lock()
+		defer func() {
+			require.NoError(t, signerBackend.Unlock(nil, 0))
+		}()
+
+		_, err := remote.SignBytes([]byte("data"), addr)
+		assert.Error(t, err)
+		assert.Empty(t, remote.Addresses())
+	})
+}
+
+func TestRemoteBackendWallet(t *testing.T) {
+	tf.UnitTest(t)
+
+	signerBackend, addr := newSignerBackend(t)
+	endpoint, stop := serveRemoteSigner(t, NewRemoteSignerHandler(signerBackend))
+	defer stop()
+
+	remote, err := NewRemoteBackend(endpoint)
+	require.NoError(t, err)
+
+	w := New(remote)
+	assert.True(t, w.HasAddress(addr))
+
+	pk, err := w.GetPubKeyForAddress(addr)
+	require.NoError(t, err)
+	assert.NotEmpty(t, pk)
+
+	data := []byte("data to sign")
+	sig, err := w.SignBytes(data, addr)
+	require.NoError(t, err)
+	assert.True(t, types.IsValidSignature(data, addr, sig))
+}
+
+func TestNewRemoteBackendRejectsInvalidEndpoints(t *testing.T) {
+	tf.UnitTest(t)
+
+	_, err := NewRemoteBackend("ftp://signer")
+	assert.Error(t, err)
+
+	// the signer is not authenticated, so it is never reached over TCP
+	_, err = NewRemoteBackend("http://127.0.0.1:1234")
+	assert.Error(t, err)
+
+	_, err = NewRemoteBackend("unix://")
+	assert.Error(t, err)
+}
@@ -27,8 +27,9 @@ func requireSignerAddr(t *testing.T) (*DSBackend, address.Address) {
 	ds := datastore.NewMapDatastore()
 	fs, err := NewDSBackend(ds)
 	require.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
 
-	addr, err := fs.NewAddress()
+	addr, err := fs.NewAddress(address.SECP256K1)
 	require.NoError(t, err)
 	return fs, addr
 }
@@ -82,7 +83,7 @@ func TestInvalidAddress(t *testing.T) {
 	sig, err := fs.SignBytes(data, addr)
 	require.NoError(t, err)
 
-	badAddr, err := fs.NewAddress()
+	badAddr, err := fs.NewAddress(address.SECP256K1)
 	require.NoError(t, err)
 
 	assert.False(t, types.IsValidSignature(data, badAddr, sig))
@@ -122,7 +123,7 @@ func TestBadFrom(t *testing.T) {
 	tf.UnitTest(t)
 
 	fs, addr := requireSignerAddr(t)
-	addr2, err := fs.NewAddress()
+	addr2, err := fs.NewAddress(address.SECP256K1)
 	require.NoError(t, err)
 
 	msg := types.NewMessage(addr, addr, 1, types.ZeroAttoFIL, "", nil)
@@ -166,3 +167,53 @@ func TestSignedMessageCorrupted(t *testing.T) {
 	smsg.Message.Nonce = types.Uint64(uint64(42))
 	assert.False(t, smsg.VerifySignature())
 }
+
+/* Test BLS signatures */
+
+func requireBLSSignerAddr(t *testing.T) (*DSBackend, address.Address) {
+	fs, err := NewDSBackend(datastore.NewMapDatastore())
+	require.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
+
+	addr, err := fs.NewAddress(address.BLS)
+	require.NoError(t, err)
+	return fs, addr
+}
+
+func TestBLSSignature(t *testing.T) {
+	tf.UnitTest(t)
+
+	fs, addr := requireBLSSignerAddr(t)
+
+	data := []byte("THESE BYTES WILL BE SIGNED")
+	sig, err := fs.SignBytes(data, addr)
+	require.NoError(t, err)
+
+	assert.True(t, types.IsValidSignature(data, addr, sig))
+	assert.False(t, types.IsValidSignature([]byte("THESE BYTEZ WILL BE SIGNED"), addr, sig))
+	assert.False(t, types.IsValidSignature(data, addr, nil))
+
+	otherAddr, err := fs.NewAddress(address.BLS)
+	require.NoError(t, err)
+	assert.False(t, types.IsValidSignature(data, otherAddr, sig))
+}
+
+func TestBLSSignedMessage(t *testing.T) {
+	tf.UnitTest(t)
+
+	fs, addr := requireBLSSignerAddr(t)
+	secpAddr, err := fs.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+
+	msg := types.NewMessage(addr, secpAddr, 1, types.ZeroAttoFIL, "", nil)
+	smsg, err := types.NewSignedMessage(*msg, fs, types.NewGasPrice(0), types.NewGasUnits(0))
+	require.NoError(t, err)
+	assert.True(t, smsg.VerifySignature())
+
+	// a secp256k1 signature does not verify for a BLS sender
+	bmsg, err := smsg.MeteredMessage.Marshal()
+	require.NoError(t, err)
+	smsg.Signature, err = fs.SignBytes(bmsg, secpAddr)
+	require.NoError(t, err)
+	assert.False(t, smsg.VerifySignature())
+}
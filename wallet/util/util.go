@@ -4,6 +4,7 @@ import (
 	"github.com/minio/blake2b-simd"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/go-filecoin/bls-signatures"
 	"github.com/filecoin-project/go-filecoin/crypto"
 )
 
@@ -36,3 +37,29 @@ func Ecrecover(data, signature []byte) ([]byte, error) {
 	hash := blake2b.Sum256(data)
 	return crypto.EcRecover(hash[:], signature)
 }
+
+// SignBLS cryptographically signs `data` using the BLS private key `priv`.
+func SignBLS(priv, data []byte) ([]byte, error) {
+	if len(priv) != bls.PrivateKeyBytes {
+		return nil, errors.Errorf("invalid BLS private key length %d", len(priv))
+	}
+
+	var privateKey bls.PrivateKey
+	copy(privateKey[:], priv)
+	sig := bls.PrivateKeySign(privateKey, data)
+	return sig[:], nil
+}
+
+// VerifyBLS cryptographically verifies that 'signature' is the BLS signature of
+// 'data' with the public key `pk`.
+func VerifyBLS(pk []byte, data, signature []byte) bool {
+	if len(pk) != bls.PublicKeyBytes || len(signature) != bls.SignatureBytes {
+		return false
+	}
+
+	var publicKey bls.PublicKey
+	copy(publicKey[:], pk)
+	var sig bls.Signature
+	copy(sig[:], signature)
+	return bls.Verify(sig, []bls.Digest{bls.Hash(data)}, []bls.PublicKey{publicKey})
+}
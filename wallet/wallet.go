@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -107,6 +108,24 @@ func (w *Wallet) SignBytes(data []byte, addr address.Address) (types.Signature,
 	return backend.SignBytes(data, addr)
 }
 
+// Unlock unlocks the datastore backends of the wallet with the passphrase, for
+// timeout or until Lock is called if timeout is zero.
+func (w *Wallet) Unlock(passphrase []byte, timeout time.Duration) error {
+	for _, backend := range w.Backends(DSBackendType) {
+		if err := backend.(*DSBackend).Unlock(passphrase, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lock locks the datastore backends of the wallet.
+func (w *Wallet) Lock() {
+	for _, backend := range w.Backends(DSBackendType) {
+		backend.(*DSBackend).Lock()
+	}
+}
+
 // GetAddressForPubKey looks up a KeyInfo address associated with a given PublicKey
 func (w *Wallet) GetAddressForPubKey(pk []byte) (address.Address, error) {
 	var addr address.Address
@@ -138,20 +157,54 @@ func (w *Wallet) Ecrecover(data []byte, sig types.Signature) ([]byte, error) {
 	return wutil.Ecrecover(data, sig)
 }
 
-// NewAddress creates a new account address on the default wallet backend.
-func NewAddress(w *Wallet) (address.Address, error) {
+// NewAddress creates a new account address using the given protocol on the
+// default wallet backend.
+func NewAddress(w *Wallet, protocol address.Protocol) (address.Address, error) {
+	backend, err := defaultBackend(w)
+	if err != nil {
+		return address.Undef, err
+	}
+	return backend.NewAddress(protocol)
+}
+
+// DeriveAddress derives the next account address from the recovery phrase of
+// the default wallet backend.
+func DeriveAddress(w *Wallet) (address.Address, error) {
+	backend, err := defaultBackend(w)
+	if err != nil {
+		return address.Undef, err
+	}
+	return backend.DeriveAddress()
+}
+
+// Mnemonic returns the recovery phrase of the default wallet backend.
+func Mnemonic(w *Wallet) (string, error) {
+	backend, err := defaultBackend(w)
+	if err != nil {
+		return "", err
+	}
+	return backend.Mnemonic()
+}
+
+func defaultBackend(w *Wallet) (*DSBackend, error) {
 	backends := w.Backends(DSBackendType)
 	if len(backends) == 0 {
-		return address.Undef, fmt.Errorf("missing default ds backend")
+		return nil, fmt.Errorf("missing default ds backend")
 	}
-
-	backend := (backends[0]).(*DSBackend)
-	return backend.NewAddress()
+	return (backends[0]).(*DSBackend), nil
 }
 
 // GetPubKeyForAddress returns the public key in the keystore associated with
 // the given address.
 func (w *Wallet) GetPubKeyForAddress(addr address.Address) ([]byte, error) {
+	backend, err := w.Find(addr)
+	if err != nil {
+		return nil, err
+	}
+	if pker, ok := backend.(PublicKeyer); ok {
+		return pker.PublicKey(addr)
+	}
+
 	info, err := w.keyInfoForAddr(addr)
 	if err != nil {
 		return nil, err
@@ -162,7 +215,7 @@ func (w *Wallet) GetPubKeyForAddress(addr address.Address) ([]byte, error) {
 
 // NewKeyInfo creates a new KeyInfo struct in the wallet backend and returns it
 func (w *Wallet) NewKeyInfo() (*types.KeyInfo, error) {
-	newAddr, err := NewAddress(w)
+	newAddr, err := NewAddress(w, address.SECP256K1)
 	if err != nil {
 		return &types.KeyInfo{}, err
 	}
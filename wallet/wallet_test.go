@@ -22,6 +22,7 @@ func TestWalletSimple(t *testing.T) {
 	ds := datastore.NewMapDatastore()
 	fs, err := wallet.NewDSBackend(ds)
 	assert.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
 
 	t.Log("create a wallet with a single backend")
 	w := wallet.New(fs)
@@ -30,7 +31,7 @@ func TestWalletSimple(t *testing.T) {
 	assert.Len(t, w.Backends(wallet.DSBackendType), 1)
 
 	t.Log("create a new address in the backend")
-	addr, err := fs.NewAddress()
+	addr, err := fs.NewAddress(address.SECP256K1)
 	assert.NoError(t, err)
 
 	t.Log("test HasAddress")
@@ -52,7 +53,7 @@ func TestWalletSimple(t *testing.T) {
 	assert.Equal(t, list[0], addr)
 
 	t.Log("addresses are sorted")
-	addr2, err := fs.NewAddress()
+	addr2, err := fs.NewAddress(address.SECP256K1)
 	assert.NoError(t, err)
 
 	if bytes.Compare(addr2.Bytes(), addr.Bytes()) < 0 {
@@ -73,6 +74,7 @@ func TestSimpleSignAndVerify(t *testing.T) {
 	ds := datastore.NewMapDatastore()
 	fs, err := wallet.NewDSBackend(ds)
 	assert.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
 
 	t.Log("create a wallet with a single backend")
 	w := wallet.New(fs)
@@ -81,7 +83,7 @@ func TestSimpleSignAndVerify(t *testing.T) {
 	assert.Len(t, w.Backends(wallet.DSBackendType), 1)
 
 	t.Log("create a new address in the backend")
-	addr, err := fs.NewAddress()
+	addr, err := fs.NewAddress(address.SECP256K1)
 	assert.NoError(t, err)
 
 	t.Log("test HasAddress")
@@ -135,10 +137,12 @@ func TestSignErrorCases(t *testing.T) {
 	ds1 := datastore.NewMapDatastore()
 	fs1, err := wallet.NewDSBackend(ds1)
 	assert.NoError(t, err)
+	require.NoError(t, fs1.Unlock(nil, 0))
 
 	ds2 := datastore.NewMapDatastore()
 	fs2, err := wallet.NewDSBackend(ds2)
 	assert.NoError(t, err)
+	require.NoError(t, fs2.Unlock(nil, 0))
 
 	t.Log("create 2 wallets each with a backend")
 	w1 := wallet.New(fs1)
@@ -149,9 +153,9 @@ func TestSignErrorCases(t *testing.T) {
 	assert.Len(t, w2.Backends(wallet.DSBackendType), 1)
 
 	t.Log("create a new address each backend")
-	addr1, err := fs1.NewAddress()
+	addr1, err := fs1.NewAddress(address.SECP256K1)
 	assert.NoError(t, err)
-	addr2, err := fs2.NewAddress()
+	addr2, err := fs2.NewAddress(address.SECP256K1)
 	assert.NoError(t, err)
 
 	t.Log("test HasAddress")
@@ -182,6 +186,7 @@ func TestGetAddressForPubKeyy(t *testing.T) {
 	ds := datastore.NewMapDatastore()
 	fs, err := wallet.NewDSBackend(ds)
 	assert.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
 	w := wallet.New(fs)
 
 	for range []int{0, 1, 2} {
@@ -205,8 +210,9 @@ func TestWallet_CreateTicket(t *testing.T) {
 	ds := datastore.NewMapDatastore()
 	fs, err := wallet.NewDSBackend(ds)
 	assert.NoError(t, err)
+	require.NoError(t, fs.Unlock(nil, 0))
 	w := wallet.New(fs)
-	addr, err := wallet.NewAddress(w)
+	addr, err := wallet.NewAddress(w, address.SECP256K1)
 	require.NoError(t, err)
 
 	t.Run("Returns real ticket and nil error with good params", func(t *testing.T) {